@@ -7,9 +7,14 @@ import (
 
 	"ai-career-buddy/internal/config"
 	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/enrich"
+	"ai-career-buddy/internal/fulltext"
+	"ai-career-buddy/internal/handlers"
 	"ai-career-buddy/internal/logger"
 	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/monitor"
 	"ai-career-buddy/internal/router"
+	"ai-career-buddy/internal/summary"
 )
 
 func main() {
@@ -56,12 +61,44 @@ func main() {
 		&models.CompanyMonitor{},
 		&models.PersonalMetrics{},
 		&models.UserDocument{},
+		&models.Goal{},
+		&models.PlanTask{},
+		&models.CareerStageDefinition{},
+		&models.MonitorAlert{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.NotificationDelivery{},
+		&models.Company{},
+		&models.CompanyAlias{},
+		&models.EmbeddingChunk{},
+		&models.PromptTemplate{},
+		&models.Experiment{},
+		&models.MessageFeedback{},
+		&models.ThreadSummary{},
+		&models.CareerHistoryTag{},
+		&models.ResumeTailoring{},
+		&models.ResumeSuggestion{},
+		&models.Draft{},
 	); err != nil {
 		logger.Fatal("自动迁移失败: %v", err)
 	}
 	logger.Info("数据库表迁移完成")
 	fmt.Println("✅ 数据库表迁移完成")
 
+	// 建立全文索引
+	fulltext.Setup()
+	enrich.Setup()
+	summary.Setup()
+	if err := handlers.SeedCareerStageDefinitions(); err != nil {
+		logger.Error("写入默认职业阶段配置失败: %v", err)
+	}
+
+	// 启动企业监控引擎
+	if config.C.MonitorEnabled {
+		monitor.Default().Start()
+		fmt.Println("✅ 企业监控引擎已启动")
+	}
+
 	// 设置路由
 	fmt.Println("🌐 设置路由...")
 	r := router.Setup()
@@ -73,38 +73,78 @@ func NewBailianClient() *BailianClient {
 	}
 }
 
-// ChatMessage 聊天消息结构
+// ChatMessage 聊天消息结构。Parts 不为空时按多模态格式发送，content 为文本和图片组成的数组
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string        `json:"role"`
+	Content string        `json:"content"`
+	Parts   []ContentPart `json:"-"`
+}
+
+// ContentPart 多模态消息中的一段内容
+type ContentPart struct {
+	Type     string    `json:"type"` // text, image_url
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL 图片地址，可以是 http(s) 链接或 data:image/...;base64 数据
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON 有多模态内容时以数组形式输出 content
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		type plain ChatMessage
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{m.Role, m.Parts})
 }
 
 // ChatRequest 聊天请求结构
 type ChatRequest struct {
-	Model    string        `json:"model"`
-	Stream   bool          `json:"stream"`
-	Messages []ChatMessage `json:"messages"`
+	Model         string         `json:"model"`
+	Stream        bool           `json:"stream"`
+	Messages      []ChatMessage  `json:"messages"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions 流式请求选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"` // 在最后一个数据块中返回token用量
+}
+
+// ChatOptions 可选的调用参数
+type ChatOptions struct {
+	Temperature *float64
+}
+
+// Usage token用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // ChatResponse 聊天响应结构
 type ChatResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   Usage        `json:"usage"`
+}
+
+// ChatChoice 非流式响应中的一条回复
+type ChatChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
 }
 
 // StreamChunk 流式响应块
@@ -121,33 +161,54 @@ type StreamChunk struct {
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
 }
 
-// SendMessage 发送消息到百炼API
-func (c *BailianClient) SendMessage(modelID, userMessage string, attachments []string) (*ChatResponse, error) {
-	// 构建消息内容
-	content := userMessage
-	if len(attachments) > 0 {
-		content += "\n\n[附件信息]:\n"
-		for i, attachment := range attachments {
-			if strings.HasPrefix(attachment, "data:image/") {
-				content += fmt.Sprintf("图片附件 %d: [已上传]\n", i+1)
-			} else if strings.HasPrefix(attachment, "data:application/pdf") {
-				content += fmt.Sprintf("PDF附件 %d: [已上传]\n", i+1)
+// buildUserMessage 构建用户消息。支持图片的模型直接接收图片附件，其他模型只在文本中注明附件
+func buildUserMessage(modelID, userMessage string, attachments []string) ChatMessage {
+	msg := ChatMessage{Role: "user", Content: userMessage}
+	if len(attachments) == 0 {
+		return msg
+	}
+
+	if IsVisionModel(modelID) {
+		var images []ContentPart
+		for _, attachment := range attachments {
+			if strings.HasPrefix(attachment, "data:image/") || strings.HasPrefix(attachment, "http") {
+				images = append(images, ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: attachment}})
 			}
 		}
+		if len(images) > 0 {
+			msg.Parts = append([]ContentPart{{Type: "text", Text: userMessage}}, images...)
+			return msg
+		}
+	}
+
+	msg.Content += "\n\n[附件信息]:\n"
+	for i, attachment := range attachments {
+		if strings.HasPrefix(attachment, "data:image/") {
+			msg.Content += fmt.Sprintf("图片附件 %d: [已上传，当前模型无法查看图片]\n", i+1)
+		} else if strings.HasPrefix(attachment, "data:application/pdf") {
+			msg.Content += fmt.Sprintf("PDF附件 %d: [已上传]\n", i+1)
+		}
 	}
+	return msg
+}
+
+// SendMessage 发送消息到百炼API
+func (c *BailianClient) SendMessage(modelID, userMessage string, attachments []string) (*ChatResponse, error) {
+	return c.SendMessageWithOptions(modelID, userMessage, attachments, ChatOptions{})
+}
+
+// SendMessageWithOptions 按指定参数发送消息到百炼API
+func (c *BailianClient) SendMessageWithOptions(modelID, userMessage string, attachments []string, opts ChatOptions) (*ChatResponse, error) {
 
 	// 构建请求
 	request := ChatRequest{
-		Model:  modelID,
-		Stream: false,
-		Messages: []ChatMessage{
-			{
-				Role:    "user",
-				Content: content,
-			},
-		},
+		Model:       modelID,
+		Stream:      false,
+		Messages:    []ChatMessage{buildUserMessage(modelID, userMessage, attachments)},
+		Temperature: opts.Temperature,
 	}
 
 	// 序列化请求
@@ -172,7 +233,7 @@ func (c *BailianClient) SendMessage(modelID, userMessage string, attachments []s
 
 	// 记录请求信息用于调试
 	logger.Info("发送API请求: URL=%s, ModelID=%s, CleanModelID=%s", c.apiURL, modelID, cleanModelID)
-	logger.Info("请求体: %s", string(requestBody))
+	logger.Info("请求体: %s", truncateForLog(requestBody))
 
 	// 发送请求
 	resp, err := c.client.Do(req)
@@ -220,41 +281,32 @@ func (c *BailianClient) SendMessage(modelID, userMessage string, attachments []s
 
 // SendStreamMessage 发送流式消息到百炼API
 func (c *BailianClient) SendStreamMessage(modelID, userMessage string, attachments []string, writer io.Writer) error {
-	// 构建消息内容
-	content := userMessage
-	if len(attachments) > 0 {
-		content += "\n\n[附件信息]:\n"
-		for i, attachment := range attachments {
-			if strings.HasPrefix(attachment, "data:image/") {
-				content += fmt.Sprintf("图片附件 %d: [已上传]\n", i+1)
-			} else if strings.HasPrefix(attachment, "data:application/pdf") {
-				content += fmt.Sprintf("PDF附件 %d: [已上传]\n", i+1)
-			}
-		}
-	}
+	_, err := c.SendStreamMessageWithOptions(modelID, userMessage, attachments, writer, ChatOptions{})
+	return err
+}
+
+// SendStreamMessageWithOptions 按指定参数发送流式消息，返回服务端提供的token用量（未提供时为nil）
+func (c *BailianClient) SendStreamMessageWithOptions(modelID, userMessage string, attachments []string, writer io.Writer, opts ChatOptions) (*Usage, error) {
 
 	// 构建请求
 	request := ChatRequest{
-		Model:  modelID,
-		Stream: true,
-		Messages: []ChatMessage{
-			{
-				Role:    "user",
-				Content: content,
-			},
-		},
+		Model:         modelID,
+		Stream:        true,
+		Messages:      []ChatMessage{buildUserMessage(modelID, userMessage, attachments)},
+		Temperature:   opts.Temperature,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
 	}
 
 	// 序列化请求
 	requestBody, err := json.Marshal(request)
 	if err != nil {
-		return fmt.Errorf("序列化请求失败: %v", err)
+		return nil, fmt.Errorf("序列化请求失败: %v", err)
 	}
 
 	// 创建HTTP请求
 	req, err := http.NewRequest("POST", c.apiURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %v", err)
+		return nil, fmt.Errorf("创建请求失败: %v", err)
 	}
 
 	// 设置请求头
@@ -268,17 +320,18 @@ func (c *BailianClient) SendStreamMessage(modelID, userMessage string, attachmen
 	// 发送请求
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("发送请求失败: %v", err)
+		return nil, fmt.Errorf("发送请求失败: %v", err)
 	}
 	defer resp.Body.Close()
 
 	// 检查响应状态
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API请求失败 (状态码: %d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API请求失败 (状态码: %d): %s", resp.StatusCode, string(body))
 	}
 
 	// 处理SSE流式响应
+	var usage *Usage
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -301,29 +354,28 @@ func (c *BailianClient) SendStreamMessage(modelID, userMessage string, attachmen
 		if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
 			continue // 跳过解析错误的数据块
 		}
+		// token用量在最后一个数据块中返回（choices为空），因此收到结束原因后仍读取到[DONE]
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
 
 		// 提取内容并写入
 		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
 			if _, err := writer.Write([]byte(chunk.Choices[0].Delta.Content)); err != nil {
-				return fmt.Errorf("写入流式内容失败: %v", err)
+				return nil, fmt.Errorf("写入流式内容失败: %v", err)
 			}
 			// 立即刷新输出
 			if flusher, ok := writer.(http.Flusher); ok {
 				flusher.Flush()
 			}
 		}
-
-		// 检查是否结束
-		if len(chunk.Choices) > 0 && chunk.Choices[0].FinishReason != "" {
-			break
-		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取流式响应失败: %v", err)
+		return nil, fmt.Errorf("读取流式响应失败: %v", err)
 	}
 
-	return nil
+	return usage, nil
 }
 
 // GetModelList 获取可用模型列表
@@ -346,3 +398,12 @@ func (c *BailianClient) GetModelList() ([]string, error) {
 		"bailian/qwen-vl-plus",
 	}, nil
 }
+
+// truncateForLog 截断过长的请求体，避免图片数据写满日志
+func truncateForLog(body []byte) string {
+	const limit = 4096
+	if len(body) <= limit {
+		return string(body)
+	}
+	return fmt.Sprintf("%s...(共%d字节)", body[:limit], len(body))
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"ai-career-buddy/internal/logger"
+)
+
+// FakeLabel 模拟模型回复的开头，提示用户这不是真实模型的回答
+const FakeLabel = "【模拟回复】"
+
+const (
+	defaultChunkRunes = 8
+	questionMarker    = "用户问题: "
+)
+
+// 没有脚本命中的对话问题的回复
+const fallbackResponse = "这是离线模拟模型（{{.Model}}）生成的回复，没有调用真实的大模型，仅用于开发和测试。\n\n" +
+	"您的问题：{{.Question}}\n\n{{if .Images}}收到图片 {{.Images}} 张。\n\n{{end}}如需获得真实的建议，请切换到百炼或Azure模型。"
+
+// FakeRule 模拟模型的一条脚本
+type FakeRule struct {
+	Name            string   `json:"name"`
+	Models          []string `json:"models,omitempty"`          // 只对这些模型生效，为空时对所有模型生效
+	Match           []string `json:"match,omitempty"`           // 用户问题包含任一关键词（不区分大小写）时命中，为空时总是命中
+	Response        string   `json:"response"`                  // 回复内容，可使用 {{.Question}}、{{.Model}} 和 {{.Images}}
+	LatencyMs       int      `json:"latencyMs,omitempty"`       // 开始返回前的等待时间
+	ChunkRunes      int      `json:"chunkRunes,omitempty"`      // 流式输出时每段的字数，默认8
+	ChunkDelayMs    *int     `json:"chunkDelayMs,omitempty"`    // 流式输出每段之间的间隔，默认 FAKE_MODEL_STREAM_DELAY
+	Error           string   `json:"error,omitempty"`           // 模拟调用失败
+	FailAfterChunks int      `json:"failAfterChunks,omitempty"` // 流式输出若干段后再失败，需同时设置 error
+	Usage           *Usage   `json:"usage,omitempty"`           // 未设置时按字数估算
+
+	tmpl *template.Template
+}
+
+// FakeProvider 离线模拟模型。回复由脚本目录中的规则决定，相同输入总是得到相同的回复，
+// 可模拟流式输出、延迟、调用失败和token用量。
+//
+// 脚本为 JSON 文件，按文件名顺序加载，按顺序匹配第一条命中的规则：
+//
+//	{"rules": [
+//	  {"name": "salary", "match": ["薪资", "谈薪"], "response": "关于{{.Question}}……", "latencyMs": 200},
+//	  {"name": "outage", "match": ["[fake:error]"], "error": "模拟网关超时"}
+//	]}
+type FakeProvider struct {
+	rules       []*FakeRule
+	fallback    *FakeRule
+	unmatched   *FakeRule // 没有脚本命中的内部提示词（文档提取、意图识别等）直接失败，不回显提示词
+	streamDelay time.Duration
+}
+
+type fakeVars struct {
+	Question string
+	Model    string
+	Images   int // 收到的图片数
+}
+
+// NewFakeProvider 加载脚本目录，目录不存在时只使用默认回复
+func NewFakeProvider(dir string, streamDelay time.Duration) *FakeProvider {
+	p := &FakeProvider{streamDelay: streamDelay}
+	p.fallback = &FakeRule{Name: "fallback", tmpl: template.Must(template.New("fallback").Parse(fallbackResponse))}
+	p.unmatched = &FakeRule{Name: "unmatched", Error: "没有匹配的模拟脚本"}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取模拟模型脚本目录失败: Dir=%s, 错误=%v", dir, err)
+		}
+		return p
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".json" {
+			continue
+		}
+		rules, err := loadFakeRules(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			logger.Warn("加载模拟模型脚本 %s 失败: %v", entry.Name(), err)
+			continue
+		}
+		p.rules = append(p.rules, rules...)
+	}
+	logger.Info("模拟模型已加载: Dir=%s, 规则数=%d", dir, len(p.rules))
+	return p
+}
+
+func loadFakeRules(path string) ([]*FakeRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Rules []*FakeRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, r := range file.Rules {
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return file.Rules, nil
+}
+
+func (r *FakeRule) compile() error {
+	t, err := template.New(r.Name).Parse(r.Response)
+	if err != nil {
+		return fmt.Errorf("规则 %s 的回复模板无效: %v", r.Name, err)
+	}
+	r.tmpl = t
+	return nil
+}
+
+// SendMessage 使用默认参数发送消息
+func (p *FakeProvider) SendMessage(modelID, userMessage string, attachments []string) (*ChatResponse, error) {
+	return p.SendMessageWithOptions(modelID, userMessage, attachments, ChatOptions{})
+}
+
+// SendMessageWithOptions 按脚本返回完整回复
+func (p *FakeProvider) SendMessageWithOptions(modelID, userMessage string, attachments []string, opts ChatOptions) (*ChatResponse, error) {
+	rule, question := p.match(modelID, userMessage)
+	logger.Info("模拟模型调用: ModelID=%s, 规则=%s", modelID, rule.Name)
+	time.Sleep(time.Duration(rule.LatencyMs) * time.Millisecond)
+	if rule.Error != "" {
+		return nil, fmt.Errorf("模拟模型调用失败: %s", rule.Error)
+	}
+
+	content, err := rule.render(question, modelID, attachments)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResponse{
+		Object:  "chat.completion",
+		Model:   modelID,
+		Created: time.Now().Unix(),
+		Choices: []ChatChoice{{Message: ChatMessage{Role: "assistant", Content: content}, FinishReason: "stop"}},
+		Usage:   rule.usage(userMessage, content),
+	}, nil
+}
+
+// SendStreamMessageWithOptions 按脚本分段写入回复
+func (p *FakeProvider) SendStreamMessageWithOptions(modelID, userMessage string, attachments []string, writer io.Writer, opts ChatOptions) (*Usage, error) {
+	rule, question := p.match(modelID, userMessage)
+	logger.Info("模拟模型流式调用: ModelID=%s, 规则=%s", modelID, rule.Name)
+	time.Sleep(time.Duration(rule.LatencyMs) * time.Millisecond)
+	if rule.Error != "" && rule.FailAfterChunks <= 0 {
+		return nil, fmt.Errorf("模拟模型调用失败: %s", rule.Error)
+	}
+
+	content, err := rule.render(question, modelID, attachments)
+	if err != nil {
+		return nil, err
+	}
+	size := rule.ChunkRunes
+	if size <= 0 {
+		size = defaultChunkRunes
+	}
+	delay := p.streamDelay
+	if rule.ChunkDelayMs != nil {
+		delay = time.Duration(*rule.ChunkDelayMs) * time.Millisecond
+	}
+
+	runes := []rune(content)
+	for i, n := 0, 0; i < len(runes); i, n = i+size, n+1 {
+		if rule.Error != "" && n == rule.FailAfterChunks {
+			return nil, fmt.Errorf("模拟模型调用失败: %s", rule.Error)
+		}
+		if n > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+		if _, err := io.WriteString(writer, string(runes[i:min(i+size, len(runes))])); err != nil {
+			return nil, fmt.Errorf("写入流式内容失败: %v", err)
+		}
+		if flusher, ok := writer.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	usage := rule.usage(userMessage, content)
+	return &usage, nil
+}
+
+// match 返回第一条命中的规则和从输入中提取的用户问题。
+// 输入中没有“用户问题: ”标记的是内部提示词，没有规则命中时返回失败的规则，
+// 否则回显的提示词（含JSON示例）会被当作提取结果保存
+func (p *FakeProvider) match(modelID, userMessage string) (*FakeRule, string) {
+	question := userMessage
+	chat := false
+	if i := strings.LastIndex(userMessage, questionMarker); i >= 0 {
+		question = userMessage[i+len(questionMarker):]
+		chat = true
+	}
+	question = strings.TrimSpace(question)
+	lower := strings.ToLower(question)
+
+	for _, r := range p.rules {
+		if len(r.Models) > 0 && !containsString(r.Models, modelID) {
+			continue
+		}
+		if len(r.Match) == 0 {
+			return r, question
+		}
+		for _, keyword := range r.Match {
+			if strings.Contains(lower, strings.ToLower(keyword)) {
+				return r, question
+			}
+		}
+	}
+	if !chat {
+		return p.unmatched, question
+	}
+	return p.fallback, question
+}
+
+func (r *FakeRule) render(question, modelID string, attachments []string) (string, error) {
+	vars := fakeVars{Question: question, Model: modelID}
+	for _, attachment := range attachments {
+		if strings.HasPrefix(attachment, "data:image/") {
+			vars.Images++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(FakeLabel + "\n\n")
+	if err := r.tmpl.Execute(&sb, vars); err != nil {
+		return "", fmt.Errorf("渲染模拟回复失败: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// usage 返回脚本中设置的用量，未设置时按每2个字1个token估算
+func (r *FakeRule) usage(input, output string) Usage {
+	if r.Usage != nil {
+		u := *r.Usage
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+		return u
+	}
+	prompt := (utf8.RuneCountInString(input) + 1) / 2
+	completion := (utf8.RuneCountInString(output) + 1) / 2
+	return Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFakeProviderMatching(t *testing.T) {
+	p := NewFakeProvider(filepath.Join("..", "..", "fixtures", "fake"), 0)
+
+	resp, err := p.SendMessage("fake/default", "系统提示\n\n用户问题: 想了解竞业限制", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := resp.Choices[0].Message.Content; !strings.HasPrefix(content, FakeLabel) || !strings.Contains(content, "竞业限制的范围") {
+		t.Errorf("chat rule not matched: %q", content)
+	}
+
+	// 没有规则的对话问题回显问题本身
+	resp, err = p.SendMessage("fake/default", "用户问题: 周末适合读什么书", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := resp.Choices[0].Message.Content; !strings.Contains(content, "您的问题：周末适合读什么书") {
+		t.Errorf("unexpected fallback: %q", content)
+	}
+
+	// 文档提取等内部提示词不能回显，否则提示词里的JSON示例会被当作提取结果
+	if _, err := p.SendMessage("bailian/qwen-flash", "你是一位专业的招聘顾问，请从以下简历内容中提取结构化信息，并以JSON格式返回。\n{\"name\": \"姓名\"}", nil); err == nil {
+		t.Error("extract prompt should fail")
+	}
+	if _, err := p.SendMessage("bailian/qwen-flash", "请把下面的内容翻译成英文：{\"a\": 1}", nil); err == nil {
+		t.Error("unmatched internal prompt should fail")
+	}
+}
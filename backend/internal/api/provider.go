@@ -0,0 +1,57 @@
+package api
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"ai-career-buddy/internal/config"
+)
+
+// FakeModelPrefix 离线模拟模型的模型ID前缀，如 fake/default
+const FakeModelPrefix = "fake/"
+
+// ChatProvider 对话模型的调用方
+type ChatProvider interface {
+	// SendMessage 使用默认参数发送消息
+	SendMessage(modelID, userMessage string, attachments []string) (*ChatResponse, error)
+	// SendMessageWithOptions 发送消息并返回完整回复
+	SendMessageWithOptions(modelID, userMessage string, attachments []string, opts ChatOptions) (*ChatResponse, error)
+	// SendStreamMessageWithOptions 把回复逐段写入 writer，返回token用量（未提供时为nil）
+	SendStreamMessageWithOptions(modelID, userMessage string, attachments []string, writer io.Writer, opts ChatOptions) (*Usage, error)
+}
+
+var (
+	fakeOnce     sync.Once
+	fakeProvider *FakeProvider
+)
+
+// ProviderFor 按模型ID选择调用方：百炼和Azure模型调用模型网关，fake/ 前缀及其他未接入的模型
+// 使用离线模拟模型；CHAT_PROVIDER=fake 时所有模型都使用离线模拟模型，便于离线开发和测试
+func ProviderFor(modelID string) ChatProvider {
+	if config.C.ChatProvider != "fake" && IsGatewayModel(modelID) {
+		return NewBailianClient()
+	}
+	fakeOnce.Do(func() {
+		fakeProvider = NewFakeProvider(config.C.FakeModelDir, config.C.FakeModelStreamDelay)
+	})
+	return fakeProvider
+}
+
+// IsGatewayModel 判断模型是否通过百炼/Azure模型网关调用
+func IsGatewayModel(modelID string) bool {
+	return strings.HasPrefix(modelID, "bailian/") || modelID == "nbg-v3-33b" || strings.HasPrefix(modelID, "azure/")
+}
+
+// IsVisionModel 判断模型是否支持图片输入，模拟模型总是支持，便于离线测试
+func IsVisionModel(modelID string) bool {
+	if strings.HasPrefix(modelID, FakeModelPrefix) {
+		return true
+	}
+	for _, id := range strings.Split(config.C.VisionModels, ",") {
+		if strings.TrimSpace(id) == modelID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package company
+
+import (
+	"strings"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+)
+
+// BackfillResult 回填结果
+type BackfillResult struct {
+	Monitors  int `json:"monitors"`
+	Risks     int `json:"risks"`
+	Documents int `json:"documents"`
+}
+
+// LinkMonitor 为企业监控关联企业，并用监控上的代码和行业补全企业信息
+func LinkMonitor(m *models.CompanyMonitor) {
+	if m.CompanyID != nil {
+		return
+	}
+
+	var c *models.Company
+	var err error
+	if code := strings.TrimSpace(m.CompanyCode); code != "" {
+		if match, _ := Best(code); match != nil {
+			c = &match.Company
+		}
+	}
+	if c == nil {
+		c, err = ResolveOrCreate(m.CompanyName, SourceMonitor)
+		if err != nil {
+			logger.Warn("企业监控关联企业失败: Company=%s, 错误=%v", m.CompanyName, err)
+			return
+		}
+	}
+	if c == nil {
+		return
+	}
+
+	m.CompanyID = &c.ID
+	enrichCompany(c, m.CompanyCode, m.Industry)
+}
+
+// LinkRisk 为合同风险点关联企业
+func LinkRisk(r *models.ContractRisk) {
+	if r.CompanyID != nil {
+		return
+	}
+	c, err := ResolveOrCreate(r.CompanyName, SourceRisk)
+	if err != nil {
+		logger.Warn("合同风险点关联企业失败: Company=%s, 错误=%v", r.CompanyName, err)
+		return
+	}
+	if c != nil {
+		r.CompanyID = &c.ID
+	}
+}
+
+// LinkDocument 根据AI提取结果为合同、Offer、在职文档关联企业
+func LinkDocument(doc *models.UserDocument) {
+	if doc.CompanyID != nil {
+		return
+	}
+	info, err := doc.GetExtractedInfo()
+	if err != nil || info == nil {
+		return
+	}
+	name := DocumentCompanyName(doc.DocumentType, info)
+	if name == "" {
+		return
+	}
+
+	c, err := ResolveOrCreate(name, SourceExtraction)
+	if err != nil {
+		logger.Warn("文档关联企业失败: DocumentID=%d, Company=%s, 错误=%v", doc.ID, name, err)
+		return
+	}
+	if c != nil {
+		doc.CompanyID = &c.ID
+	}
+}
+
+// DocumentCompanyName 取文档对应的企业名称，简历涉及多家企业，不做关联
+func DocumentCompanyName(documentType string, info *models.DocumentExtractedInfo) string {
+	switch documentType {
+	case "contract":
+		return strings.TrimSpace(info.ContractInfo.CompanyName)
+	case "offer":
+		return strings.TrimSpace(info.OfferInfo.CompanyName)
+	case "employment":
+		return strings.TrimSpace(info.EmploymentInfo.CompanyName)
+	case "resume":
+		return ""
+	}
+	for _, name := range []string{info.ContractInfo.CompanyName, info.OfferInfo.CompanyName, info.EmploymentInfo.CompanyName} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Backfill 为尚未关联企业的监控、风险点和文档补充关联
+func Backfill() (*BackfillResult, error) {
+	result := &BackfillResult{}
+
+	var monitors []models.CompanyMonitor
+	if err := db.Conn.Where("company_id IS NULL AND company_name <> ''").Find(&monitors).Error; err != nil {
+		return nil, err
+	}
+	for i := range monitors {
+		LinkMonitor(&monitors[i])
+		if monitors[i].CompanyID != nil {
+			db.Conn.Model(&models.CompanyMonitor{}).Where("id = ?", monitors[i].ID).Update("company_id", *monitors[i].CompanyID)
+			result.Monitors++
+		}
+	}
+
+	var risks []models.ContractRisk
+	if err := db.Conn.Where("company_id IS NULL AND company_name <> ''").Find(&risks).Error; err != nil {
+		return nil, err
+	}
+	for i := range risks {
+		LinkRisk(&risks[i])
+		if risks[i].CompanyID != nil {
+			db.Conn.Model(&models.ContractRisk{}).Where("id = ?", risks[i].ID).Update("company_id", *risks[i].CompanyID)
+			result.Risks++
+		}
+	}
+
+	var docs []models.UserDocument
+	if err := db.Conn.Where("company_id IS NULL AND document_type <> ? AND extracted_info <> ''", "resume").Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		LinkDocument(&docs[i])
+		if docs[i].CompanyID != nil {
+			db.Conn.Model(&models.UserDocument{}).Where("id = ?", docs[i].ID).Update("company_id", *docs[i].CompanyID)
+			result.Documents++
+		}
+	}
+
+	logger.Info("企业关联回填完成: 监控=%d, 风险点=%d, 文档=%d", result.Monitors, result.Risks, result.Documents)
+	return result, nil
+}
+
+// enrichCompany 企业信息缺失时用关联数据补全
+func enrichCompany(c *models.Company, code, industry string) {
+	updates := map[string]interface{}{}
+	if industry = strings.TrimSpace(industry); industry != "" && c.Industry == "" {
+		updates["industry"] = industry
+	}
+	if code = strings.TrimSpace(code); code != "" {
+		if ValidCreditCode(code) {
+			if c.CreditCode == "" {
+				updates["credit_code"] = strings.ToUpper(code)
+			}
+		} else if ticker := NormalizeTicker(code); isTickerLike(ticker) {
+			tickers := c.GetTickers()
+			found := false
+			for _, t := range tickers {
+				if t == ticker || tickerBase(t) == tickerBase(ticker) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				c.SetTickers(append(tickers, ticker))
+				updates["tickers"] = c.Tickers
+			}
+		}
+	}
+	if len(updates) == 0 {
+		return
+	}
+	if err := db.Conn.Model(&models.Company{}).Where("id = ?", c.ID).Updates(updates).Error; err != nil {
+		logger.Warn("补全企业信息失败: CompanyID=%d, 错误=%v", c.ID, err)
+	}
+}
@@ -0,0 +1,166 @@
+package company
+
+import (
+	"strings"
+	"unicode"
+)
+
+// legalSuffixes 企业组织形式后缀，按长度从长到短排列
+var legalSuffixes = []string{"股份有限公司", "有限责任公司", "集团有限公司", "有限公司", "分公司", "集团", "公司", "控股"}
+
+// genericSuffixes 行业通用词后缀，去掉后通常仍能唯一指代企业
+var genericSuffixes = []string{"电子商务", "文化传媒", "信息技术", "网络技术", "科技", "技术", "网络", "信息", "软件", "数字", "智能"}
+
+// regionPrefixes 注册地前缀
+var regionPrefixes = []string{
+	"北京", "上海", "天津", "重庆", "深圳", "广州", "杭州", "南京", "苏州", "成都", "武汉", "西安", "厦门", "长沙",
+	"青岛", "宁波", "合肥", "郑州", "济南", "福州", "珠海", "东莞", "佛山", "无锡", "大连", "沈阳", "香港",
+	"广东", "浙江", "江苏", "山东", "福建", "湖北", "湖南", "四川", "河南", "河北", "安徽", "陕西", "辽宁",
+}
+
+// englishSuffixWords 英文名称末尾可去掉的单词，按整词匹配
+var englishSuffixWords = map[string]bool{
+	"co": true, "ltd": true, "limited": true, "inc": true, "corp": true, "corporation": true, "company": true,
+	"group": true, "holdings": true, "holding": true, "llc": true, "plc": true, "gmbh": true,
+	"technology": true, "technologies": true, "tech": true, "network": true, "software": true,
+}
+
+// englishRegionWords 英文名称开头可去掉的注册地
+var englishRegionWords = map[string]bool{
+	"beijing": true, "shanghai": true, "shenzhen": true, "guangzhou": true, "hangzhou": true, "hongkong": true, "china": true,
+}
+
+// Normalize 归一化企业名称，用于匹配：
+// 全角转半角、转小写、去掉括号内容和标点空白，再反复剥离组织形式后缀、
+// 行业通用词和注册地前缀。例如"北京字节跳动科技有限公司"归一化为"字节跳动"，
+// "Beijing ByteDance Technology Co., Ltd." 归一化为"bytedance"。
+func Normalize(name string) string {
+	s := strings.ToLower(toHalfWidth(strings.TrimSpace(name)))
+	s = stripParentheses(s)
+
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for len(words) > 1 && englishSuffixWords[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+	for len(words) > 1 && englishRegionWords[words[0]] {
+		words = words[1:]
+	}
+	s = strings.Join(words, "")
+
+	for {
+		before := s
+		s = trimSuffixes(s, legalSuffixes)
+		s = trimSuffixes(s, genericSuffixes)
+		s = trimPrefixes(s, regionPrefixes)
+		if s == before {
+			break
+		}
+	}
+	return s
+}
+
+// minCoreLength 剥离后至少保留的字符数，避免"北京科技有限公司"被剥成空串
+const minCoreLength = 2
+
+func trimSuffixes(s string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) && runeLen(s)-runeLen(suffix) >= minCoreLength {
+			return strings.TrimSuffix(s, suffix)
+		}
+	}
+	return s
+}
+
+func trimPrefixes(s string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(s, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(s, prefix)
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "市"), "省")
+		if runeLen(rest) >= minCoreLength {
+			return rest
+		}
+	}
+	return s
+}
+
+// stripParentheses 去掉括号及其内容，如"腾讯科技（深圳）有限公司"中的"（深圳）"
+func stripParentheses(s string) string {
+	var sb strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(', '（', '[', '【':
+			depth++
+			continue
+		case ')', '）', ']', '】':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return s
+	}
+	return sb.String()
+}
+
+// toHalfWidth 全角字符转半角
+func toHalfWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0x3000:
+			return ' '
+		case r >= 0xFF01 && r <= 0xFF5E:
+			return r - 0xFEE0
+		}
+		return r
+	}, s)
+}
+
+func runeLen(s string) int {
+	return len([]rune(s))
+}
+
+// creditCodeChars 统一社会信用代码字符集（GB 32100-2015，不含 I O Z S V）
+const creditCodeChars = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+
+var creditCodeWeights = []int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+
+// ValidCreditCode 校验18位统一社会信用代码的字符集和校验位
+func ValidCreditCode(code string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 18 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		v := strings.IndexByte(creditCodeChars, code[i])
+		if v < 0 {
+			return false
+		}
+		sum += v * creditCodeWeights[i]
+	}
+	check := (31 - sum%31) % 31
+	return code[17] == creditCodeChars[check]
+}
+
+// NormalizeTicker 统一股票代码格式：大写，去掉空白
+func NormalizeTicker(ticker string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(ticker), ""))
+}
+
+// tickerBase 去掉交易所后缀，如 "600000.SH" 返回 "600000"
+func tickerBase(ticker string) string {
+	if i := strings.IndexByte(ticker, '.'); i > 0 {
+		return ticker[:i]
+	}
+	return ticker
+}
@@ -0,0 +1,337 @@
+package company
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 匹配方式
+const (
+	MatchCreditCode = "credit_code"
+	MatchTicker     = "ticker"
+	MatchExact      = "exact"
+	MatchFuzzy      = "fuzzy"
+)
+
+// 别名来源
+const (
+	SourceManual     = "manual"
+	SourceExtraction = "extraction"
+	SourceChat       = "chat"
+	SourceMonitor    = "monitor"
+	SourceRisk       = "risk"
+)
+
+// MatchThreshold 模糊匹配达到该分数才视为同一企业
+const MatchThreshold = 0.8
+
+// candidateThreshold 低于该分数的候选不返回
+const candidateThreshold = 0.5
+
+// Match 企业匹配结果
+type Match struct {
+	Company     models.Company `json:"company"`
+	Score       float64        `json:"score"`
+	MatchedBy   string         `json:"matchedBy"`
+	MatchedName string         `json:"matchedName"` // 命中的名称或别名
+}
+
+// createMu 串行化"解析失败则创建"，避免并发请求为同一企业建出多条记录
+var createMu sync.Mutex
+
+// Resolve 解析企业名称、统一社会信用代码或股票代码，返回按分数排序的候选企业
+func Resolve(query string, limit int) ([]Match, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+
+	// 统一社会信用代码
+	if ValidCreditCode(query) {
+		var c models.Company
+		err := db.Conn.Where("credit_code = ?", strings.ToUpper(query)).First(&c).Error
+		if err == nil {
+			return []Match{{Company: c, Score: 1, MatchedBy: MatchCreditCode, MatchedName: c.CreditCode}}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	// 股票代码
+	if c, err := findByTicker(query); err != nil {
+		return nil, err
+	} else if c != nil {
+		return []Match{{Company: *c, Score: 1, MatchedBy: MatchTicker, MatchedName: NormalizeTicker(query)}}, nil
+	}
+
+	normalized := Normalize(query)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	// 归一化名称精确匹配企业名或别名
+	names, err := loadNames()
+	if err != nil {
+		return nil, err
+	}
+
+	best := map[uint]Match{}
+	for _, n := range names {
+		score := Similarity(normalized, n.Normalized)
+		matchedBy := MatchFuzzy
+		if score == 1 {
+			matchedBy = MatchExact
+		}
+		if score < candidateThreshold {
+			continue
+		}
+		if existing, ok := best[n.CompanyID]; ok && existing.Score >= score {
+			continue
+		}
+		best[n.CompanyID] = Match{Score: score, MatchedBy: matchedBy, MatchedName: n.Name}
+	}
+	if len(best) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, 0, len(best))
+	for id := range best {
+		ids = append(ids, id)
+	}
+	var companies []models.Company
+	if err := db.Conn.Where("id IN ?", ids).Find(&companies).Error; err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, 0, len(companies))
+	for _, c := range companies {
+		m := best[c.ID]
+		m.Company = c
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Company.ID < matches[j].Company.ID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Best 返回分数达到阈值的最佳匹配，没有时返回 nil
+func Best(query string) (*Match, error) {
+	matches, err := Resolve(query, 1)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	if matches[0].Score < MatchThreshold {
+		return nil, nil
+	}
+	return &matches[0], nil
+}
+
+// ResolveOrCreate 解析企业名称，匹配不到时新建企业。匹配到时把新的写法记为别名。
+func ResolveOrCreate(name, source string) (*models.Company, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || Normalize(name) == "" {
+		return nil, nil
+	}
+
+	createMu.Lock()
+	defer createMu.Unlock()
+
+	match, err := Best(name)
+	if err != nil {
+		return nil, err
+	}
+	if match != nil {
+		if match.MatchedBy != MatchCreditCode && match.MatchedBy != MatchTicker {
+			if err := AddAlias(match.Company.ID, name, source); err != nil {
+				logger.Warn("记录企业别名失败: CompanyID=%d, Alias=%s, 错误=%v", match.Company.ID, name, err)
+			}
+		}
+		return &match.Company, nil
+	}
+
+	c := models.Company{Name: name, Normalized: Normalize(name)}
+	err = db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&c).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CompanyAlias{
+			CompanyID:  c.ID,
+			Alias:      name,
+			Normalized: c.Normalized,
+			Source:     source,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateNames()
+	logger.Info("新建企业: CompanyID=%d, Name=%s, Source=%s", c.ID, c.Name, source)
+	return &c, nil
+}
+
+// AddAlias 为企业添加别名，已存在相同写法时忽略
+func AddAlias(companyID uint, alias, source string) error {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return nil
+	}
+	var count int64
+	if err := db.Conn.Model(&models.CompanyAlias{}).
+		Where("company_id = ? AND alias = ?", companyID, alias).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := db.Conn.Create(&models.CompanyAlias{
+		CompanyID:  companyID,
+		Alias:      alias,
+		Normalized: Normalize(alias),
+		Source:     source,
+	}).Error; err != nil {
+		return err
+	}
+	InvalidateNames()
+	return nil
+}
+
+// FindMentions 找出文本中提到的已知企业，返回企业ID
+func FindMentions(text string) ([]uint, error) {
+	text = strings.ToLower(toHalfWidth(text))
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	names, err := loadNames()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, n := range names {
+		if seen[n.CompanyID] {
+			continue
+		}
+		// 单个字母或汉字的简称误报太多，至少两个字符才认为是提及
+		for _, candidate := range []string{strings.ToLower(n.Name), n.Normalized} {
+			if runeLen(candidate) >= minCoreLength && strings.Contains(text, candidate) {
+				seen[n.CompanyID] = true
+				ids = append(ids, n.CompanyID)
+				break
+			}
+		}
+	}
+	return ids, nil
+}
+
+// nameEntry 用于匹配的企业名称或别名
+type nameEntry struct {
+	CompanyID  uint
+	Name       string
+	Normalized string
+}
+
+// namesCacheTTL 名称索引的缓存时间，多副本部署时其他实例的修改最迟在该时间后生效
+const namesCacheTTL = time.Minute
+
+// nameIndex 缓存的企业名称和别名，每条对话消息都要做企业识别，不能每次全表扫描
+var nameIndex struct {
+	mu         sync.RWMutex
+	names      []nameEntry
+	loadedAt   time.Time
+	generation int // 每次失效加一，避免加载期间发生的修改被旧数据覆盖
+}
+
+// InvalidateNames 清空名称索引缓存，新建、改名、删除、合并企业或增删别名后调用
+func InvalidateNames() {
+	nameIndex.mu.Lock()
+	nameIndex.names = nil
+	nameIndex.generation++
+	nameIndex.mu.Unlock()
+}
+
+// loadNames 返回所有企业名称和别名，调用方不能修改返回的切片。企业库规模在万级以内时全量比对足够快。
+func loadNames() ([]nameEntry, error) {
+	nameIndex.mu.RLock()
+	if nameIndex.names != nil && time.Since(nameIndex.loadedAt) < namesCacheTTL {
+		defer nameIndex.mu.RUnlock()
+		return nameIndex.names, nil
+	}
+	generation := nameIndex.generation
+	nameIndex.mu.RUnlock()
+
+	names := []nameEntry{}
+	if err := db.Conn.Model(&models.Company{}).
+		Select("id AS company_id, name, normalized").
+		Scan(&names).Error; err != nil {
+		return nil, err
+	}
+	var aliases []nameEntry
+	if err := db.Conn.Model(&models.CompanyAlias{}).
+		Select("company_id, alias AS name, normalized").
+		Scan(&aliases).Error; err != nil {
+		return nil, err
+	}
+	names = append(names, aliases...)
+
+	nameIndex.mu.Lock()
+	if nameIndex.generation == generation {
+		nameIndex.names = names
+		nameIndex.loadedAt = time.Now()
+	}
+	nameIndex.mu.Unlock()
+	return names, nil
+}
+
+// findByTicker 按股票代码查找企业，"600000" 可以匹配 "600000.SH"
+func findByTicker(query string) (*models.Company, error) {
+	ticker := NormalizeTicker(query)
+	if ticker == "" || strings.ContainsAny(ticker, " ") || runeLen(ticker) > 12 || !isTickerLike(ticker) {
+		return nil, nil
+	}
+
+	var companies []models.Company
+	if err := db.Conn.Where("tickers LIKE ?", "%"+tickerBase(ticker)+"%").Find(&companies).Error; err != nil {
+		return nil, err
+	}
+	for i := range companies {
+		for _, t := range companies[i].GetTickers() {
+			if t == ticker || tickerBase(t) == tickerBase(ticker) {
+				return &companies[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isTickerLike 股票代码只包含字母、数字和点
+func isTickerLike(s string) bool {
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') && r != '.' {
+			return false
+		}
+	}
+	return true
+}
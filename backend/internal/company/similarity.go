@@ -0,0 +1,87 @@
+package company
+
+// Similarity 计算两个归一化名称的相似度，范围 0-1
+//
+// 一方包含另一方时（如"字节"与"字节跳动"）按长度比例给出较高分数，
+// 否则取字符二元组 Dice 系数和编辑距离相似度中的较大值。
+func Similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	short, long := ra, rb
+	if len(short) > len(long) {
+		short, long = long, short
+	}
+	if len(short) >= minCoreLength && containsRunes(long, short) {
+		return 0.6 + 0.3*float64(len(short))/float64(len(long))
+	}
+
+	dice := diceCoefficient(ra, rb)
+	lev := 1 - float64(levenshtein(ra, rb))/float64(len(long))
+	if dice > lev {
+		return dice
+	}
+	return lev
+}
+
+func containsRunes(haystack, needle []rune) bool {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// diceCoefficient 字符二元组 Dice 系数
+func diceCoefficient(a, b []rune) float64 {
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	grams := map[[2]rune]int{}
+	for i := 0; i+1 < len(a); i++ {
+		grams[[2]rune{a[i], a[i+1]}]++
+	}
+	overlap := 0
+	for i := 0; i+1 < len(b); i++ {
+		g := [2]rune{b[i], b[i+1]}
+		if grams[g] > 0 {
+			grams[g]--
+			overlap++
+		}
+	}
+	return 2 * float64(overlap) / float64(len(a)-1+len(b)-1)
+}
+
+// levenshtein 编辑距离
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
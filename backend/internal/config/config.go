@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +17,99 @@ type AppConfig struct {
 	BailianAPIURL string
 	BailianAPIKey string
 	LogDir        string
+
+	// 对话模型调用方：auto 按模型ID选择，fake 全部使用离线模拟模型
+	ChatProvider         string
+	FakeModelDir         string
+	FakeModelStreamDelay time.Duration
+
+	// 企业监控
+	MonitorEnabled    bool
+	MonitorInterval   time.Duration
+	MonitorCooldown   time.Duration
+	MonitorSources    string
+	MonitorFixtureDir string
+
+	// 通知投递
+	SMTPHost           string
+	SMTPPort           string
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	NotifyMaxAttempts  int
+	NotifyRetryBackoff time.Duration
+	NotifyAllowPrivate bool // 允许webhook投递到内网地址，仅用于本地调试
+
+	// 企业聚合洞察
+	InsightsKAnonymity int
+
+	// 联网检索
+	SearchProvider  string
+	SearchCorpusDir string
+	SearchAPIURL    string
+	SearchAPIKey    string
+	SearchTopK      int
+	SearchTimeout   time.Duration
+
+	// 用户资料检索
+	RAGEnabled        bool
+	RAGEmbedder       string
+	RAGEmbeddingURL   string
+	RAGEmbeddingKey   string
+	RAGEmbeddingModel string
+	RAGStore          string
+	RAGTopK           int
+	RAGMinScore       float64
+
+	// 提示词模板
+	PromptDir      string
+	PromptCacheTTL time.Duration
+
+	// 模型token单价，用于实验报告估算费用
+	ModelTokenPrices string
+
+	// 长会话滚动摘要
+	ThreadSummaryEnabled bool
+	ThreadSummaryTurns   int // 未摘要的对话超过该轮数时生成摘要
+	ThreadRecentTurns    int // 始终以原文作为上下文的最近轮数
+	ThreadSummaryModel   string
+
+	// 意图识别
+	IntentClassifier    string // llm: 模型分类，失败时退回朴素贝叶斯；bayes: 只用朴素贝叶斯
+	IntentModel         string
+	IntentTimeout       time.Duration
+	IntentMinConfidence float64
+
+	// 咨询记录整理
+	EnrichEnabled bool
+	EnrichModel   string
+
+	// 合同/Offer差异评价
+	DiffCommentaryModel string
+
+	// 对话附件和图片理解
+	VisionModels       string // 支持图片输入的模型ID，逗号分隔
+	AttachmentMaxBytes int    // 对话中单个附件的大小上限
+	ImageMaxSide       int    // 发送给模型的图片最长边，超过时等比缩小
+	ImageMaxBytes      int    // 发送给模型的单张图片大小上限
+	VisionMaxImages    int    // 每条消息最多发送的图片数（含扫描版PDF的页面）
+
+	// 文件存储
+	StorageBackend     string // local 或 s3
+	StorageLocalDir    string
+	StorageS3Endpoint  string
+	StorageS3Region    string
+	StorageS3Bucket    string
+	StorageS3AccessKey string
+	StorageS3SecretKey string
+	StorageS3PathStyle bool
+	StorageSigningKey  string        // 下载链接的签名密钥，多副本部署时必须配置为相同的值
+	StorageURLTTL      time.Duration // 下载链接有效期
+
+	// 简历生成
+	ResumeFontPath    string // 嵌入PDF的中文字体（TrueType .ttf/.ttc），为空时查找系统字体
+	ResumeTailorModel string // 针对目标岗位优化简历的模型
+	DraftModel        string // 撰写还价邮件、辞职信、求职信等文书的模型
 }
 
 var C AppConfig
@@ -30,6 +125,84 @@ func Load() {
 		BailianAPIURL: getEnv("BAILIAN_API_URL", "http://higress-pirate-prod-gao.weizhipin.com/v1/chat/completions"),
 		BailianAPIKey: getEnv("BAILIAN_API_KEY", "sk-84229c5e-18ea-4b6a-a04a-2183688f9373"),
 		LogDir:        getEnv("LOG_DIR", "./logs"),
+
+		ChatProvider:         getEnv("CHAT_PROVIDER", "auto"),
+		FakeModelDir:         getEnv("FAKE_MODEL_DIR", "./fixtures/fake"),
+		FakeModelStreamDelay: getEnvDuration("FAKE_MODEL_STREAM_DELAY", 20*time.Millisecond),
+
+		MonitorEnabled:    getEnvBool("MONITOR_ENABLED", true),
+		MonitorInterval:   getEnvDuration("MONITOR_INTERVAL", time.Hour),
+		MonitorCooldown:   getEnvDuration("MONITOR_ALERT_COOLDOWN", 24*time.Hour),
+		MonitorSources:    getEnv("MONITOR_SOURCES", "file"),
+		MonitorFixtureDir: getEnv("MONITOR_FIXTURE_DIR", "./fixtures/monitor"),
+
+		SMTPHost:           getEnv("SMTP_HOST", ""),
+		SMTPPort:           getEnv("SMTP_PORT", "25"),
+		SMTPUsername:       getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:       getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:           getEnv("SMTP_FROM", "AI职场管家 <noreply@ai-career-buddy.local>"),
+		NotifyMaxAttempts:  getEnvInt("NOTIFY_MAX_ATTEMPTS", 3),
+		NotifyRetryBackoff: getEnvDuration("NOTIFY_RETRY_BACKOFF", 2*time.Second),
+		NotifyAllowPrivate: getEnvBool("NOTIFY_ALLOW_PRIVATE", false),
+
+		InsightsKAnonymity: getEnvInt("INSIGHTS_K_ANONYMITY", 10),
+
+		SearchProvider:  getEnv("SEARCH_PROVIDER", "local"),
+		SearchCorpusDir: getEnv("SEARCH_CORPUS_DIR", "./fixtures/search"),
+		SearchAPIURL:    getEnv("SEARCH_API_URL", ""),
+		SearchAPIKey:    getEnv("SEARCH_API_KEY", ""),
+		SearchTopK:      getEnvInt("SEARCH_TOP_K", 5),
+		SearchTimeout:   getEnvDuration("SEARCH_TIMEOUT", 5*time.Second),
+
+		RAGEnabled:        getEnvBool("RAG_ENABLED", true),
+		RAGEmbedder:       getEnv("RAG_EMBEDDER", "hash"),
+		RAGEmbeddingURL:   getEnv("RAG_EMBEDDING_URL", ""),
+		RAGEmbeddingKey:   getEnv("RAG_EMBEDDING_KEY", ""),
+		RAGEmbeddingModel: getEnv("RAG_EMBEDDING_MODEL", ""),
+		RAGStore:          getEnv("RAG_STORE", "db"),
+		RAGTopK:           getEnvInt("RAG_TOP_K", 4),
+		RAGMinScore:       getEnvFloat("RAG_MIN_SCORE", 0.1),
+
+		PromptDir:      getEnv("PROMPT_DIR", ""),
+		PromptCacheTTL: getEnvDuration("PROMPT_CACHE_TTL", 30*time.Second),
+
+		ModelTokenPrices: getEnv("MODEL_TOKEN_PRICES", ""),
+
+		ThreadSummaryEnabled: getEnvBool("THREAD_SUMMARY_ENABLED", true),
+		ThreadSummaryTurns:   getEnvInt("THREAD_SUMMARY_TURNS", 10),
+		ThreadRecentTurns:    getEnvInt("THREAD_RECENT_TURNS", 4),
+		ThreadSummaryModel:   getEnv("THREAD_SUMMARY_MODEL", "bailian/qwen-flash"),
+
+		IntentClassifier:    getEnv("INTENT_CLASSIFIER", "llm"),
+		IntentModel:         getEnv("INTENT_MODEL", "bailian/qwen-flash"),
+		IntentTimeout:       getEnvDuration("INTENT_TIMEOUT", 3*time.Second),
+		IntentMinConfidence: getEnvFloat("INTENT_MIN_CONFIDENCE", 0.5),
+
+		EnrichEnabled: getEnvBool("ENRICH_ENABLED", true),
+		EnrichModel:   getEnv("ENRICH_MODEL", "bailian/qwen-flash"),
+
+		DiffCommentaryModel: getEnv("DIFF_COMMENTARY_MODEL", "bailian/qwen-plus"),
+
+		VisionModels:       getEnv("VISION_MODELS", "bailian/qwen-vl-max,bailian/qwen-vl-plus,azure/gpt-5,azure/gpt-5-mini,azure/gpt-5-chat"),
+		AttachmentMaxBytes: getEnvInt("ATTACHMENT_MAX_BYTES", 10<<20),
+		ImageMaxSide:       getEnvInt("IMAGE_MAX_SIDE", 1568),
+		ImageMaxBytes:      getEnvInt("IMAGE_MAX_BYTES", 1<<20),
+		VisionMaxImages:    getEnvInt("VISION_MAX_IMAGES", 6),
+
+		StorageBackend:     getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:    getEnv("STORAGE_LOCAL_DIR", "./storage"),
+		StorageS3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", ""),
+		StorageS3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+		StorageS3Bucket:    getEnv("STORAGE_S3_BUCKET", ""),
+		StorageS3AccessKey: getEnv("STORAGE_S3_ACCESS_KEY", ""),
+		StorageS3SecretKey: getEnv("STORAGE_S3_SECRET_KEY", ""),
+		StorageS3PathStyle: getEnvBool("STORAGE_S3_PATH_STYLE", true),
+		StorageSigningKey:  getEnv("STORAGE_SIGNING_KEY", ""),
+		StorageURLTTL:      getEnvDuration("STORAGE_URL_TTL", 15*time.Minute),
+
+		ResumeFontPath:    getEnv("RESUME_FONT_PATH", ""),
+		ResumeTailorModel: getEnv("RESUME_TAILOR_MODEL", "bailian/qwen-plus"),
+		DraftModel:        getEnv("DRAFT_MODEL", "bailian/qwen-plus"),
 	}
 
 	if C.MySQLDSN == "" {
@@ -43,3 +216,43 @@ func getEnv(key string, def string) string {
 	}
 	return def
 }
+
+func getEnvBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+		log.Printf("环境变量 %s=%s 不是有效的布尔值，使用默认值 %t", key, v, def)
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if i, err := strconv.Atoi(v); err == nil {
+			return i
+		}
+		log.Printf("环境变量 %s=%s 不是有效的整数，使用默认值 %d", key, v, def)
+	}
+	return def
+}
+
+func getEnvFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+		log.Printf("环境变量 %s=%s 不是有效的数值，使用默认值 %v", key, v, def)
+	}
+	return def
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		log.Printf("环境变量 %s=%s 不是有效的时长，使用默认值 %v", key, v, def)
+	}
+	return def
+}
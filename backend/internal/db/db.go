@@ -13,6 +13,15 @@ import (
 
 var Conn *gorm.DB
 
+// 数据库类型
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// Driver 当前连接的数据库类型，需要使用数据库特有功能（如全文索引）时据此区分
+var Driver string
+
 func Connect(dsn string) {
 	var err error
 	var dbType string
@@ -26,12 +35,14 @@ func Connect(dsn string) {
 	if strings.HasPrefix(dsn, "file:") {
 		// SQLite
 		dbType = "SQLite"
+		Driver = DriverSQLite
 		Conn, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
 			Logger: gormLogger,
 		})
 	} else {
 		// MySQL
 		dbType = "MySQL"
+		Driver = DriverMySQL
 		Conn, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
 			Logger: gormLogger,
 		})
@@ -0,0 +1,323 @@
+package docdiff
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 条款对齐时认为是同一条款的最低相似度
+const minClauseSimilarity = 0.45
+
+// 逐字比较的规模上限（两段文本的词元数之积），超过时整段标记为替换
+const maxTextDiffCells = 4_000_000
+
+// clauseHeading 条款标题行：Markdown 标题、第X条/章/节、一、（一）、1. 1.2 等编号
+var clauseHeading = regexp.MustCompile(`^\s*(#{1,6}\s*)?(第[一二三四五六七八九十百零〇\d]+[条章节款]|[一二三四五六七八九十]+[、.．]|[（(][一二三四五六七八九十\d]+[)）]|\d+(?:\.\d+)+\.?|\d+[、.．)）])\s*`)
+
+var markdownHeading = regexp.MustCompile(`^\s*#{1,6}\s+`)
+
+// Clause 文档中的一个条款
+type Clause struct {
+	Number string // 条款编号，如 第三条、2.1，前言部分为空
+	Title  string // 标题行去掉编号后的内容
+	Text   string // 去掉编号后的完整条款
+}
+
+// Segment 文本差异片段
+type Segment struct {
+	Op   string `json:"op"` // equal, insert, delete
+	Text string `json:"text"`
+}
+
+// ClauseChange 对齐后的一组条款。编号变化但内容相同的条款视为 renumbered，不作为修改
+type ClauseChange struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"` // unchanged, renumbered, modified, added, removed
+	OldNumber  string    `json:"oldNumber,omitempty"`
+	NewNumber  string    `json:"newNumber,omitempty"`
+	Title      string    `json:"title,omitempty"`
+	Old        string    `json:"old,omitempty"`
+	New        string    `json:"new,omitempty"`
+	Similarity float64   `json:"similarity,omitempty"`
+	Segments   []Segment `json:"segments,omitempty"`
+	Assessment string    `json:"assessment,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+}
+
+// SplitClauses 按条款编号和 Markdown 标题把文本切分为条款，第一个标题之前的内容作为前言
+func SplitClauses(text string) []Clause {
+	var clauses []Clause
+	var current *Clause
+	var body []string
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Text = strings.TrimSpace(strings.Join(body, "\n"))
+		if current.Text != "" || current.Number != "" {
+			clauses = append(clauses, *current)
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if m := clauseHeading.FindStringSubmatchIndex(line); m != nil {
+			flush()
+			rest := strings.TrimSpace(line[m[1]:])
+			current = &Clause{Number: strings.TrimSpace(line[m[4]:m[5]]), Title: rest}
+			body = []string{rest}
+			continue
+		}
+		if markdownHeading.MatchString(line) {
+			flush()
+			title := strings.TrimSpace(markdownHeading.ReplaceAllString(line, ""))
+			current = &Clause{Title: title}
+			body = []string{title}
+			continue
+		}
+		if current == nil {
+			current = &Clause{}
+		}
+		body = append(body, line)
+	}
+	flush()
+	return clauses
+}
+
+// AlignClauses 按内容相似度对齐新旧文本的条款，不依赖编号，因此能识别重新编号和调整顺序的条款。
+// 结果按新文本的顺序排列，删除的条款放在其在旧文本中前一个条款之后
+func AlignClauses(oldText, newText string) []ClauseChange {
+	oldClauses, newClauses := SplitClauses(oldText), SplitClauses(newText)
+	oldGrams := make([]map[string]int, len(oldClauses))
+	for i, c := range oldClauses {
+		oldGrams[i] = bigrams(c.Text)
+	}
+	newGrams := make([]map[string]int, len(newClauses))
+	for j, c := range newClauses {
+		newGrams[j] = bigrams(c.Text)
+	}
+
+	type pair struct {
+		i, j  int
+		sim   float64
+		score float64
+	}
+	var pairs []pair
+	for i := range oldClauses {
+		for j := range newClauses {
+			sim := dice(oldGrams[i], newGrams[j])
+			if sim < minClauseSimilarity {
+				continue
+			}
+			score := sim
+			if oldClauses[i].Title != "" && oldClauses[i].Title == newClauses[j].Title {
+				score += 0.1
+			}
+			pairs = append(pairs, pair{i, j, sim, score})
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		if pairs[a].score != pairs[b].score {
+			return pairs[a].score > pairs[b].score
+		}
+		return abs(pairs[a].i-pairs[a].j) < abs(pairs[b].i-pairs[b].j)
+	})
+	oldMatch := make([]int, len(oldClauses))
+	newMatch := make([]int, len(newClauses))
+	similarity := make([]float64, len(newClauses))
+	for i := range oldMatch {
+		oldMatch[i] = -1
+	}
+	for j := range newMatch {
+		newMatch[j] = -1
+	}
+	for _, p := range pairs {
+		if oldMatch[p.i] >= 0 || newMatch[p.j] >= 0 {
+			continue
+		}
+		oldMatch[p.i], newMatch[p.j], similarity[p.j] = p.j, p.i, p.sim
+	}
+
+	// 删除的条款挂在旧文本中前一个已匹配条款对应的新条款之后
+	removedAfter := map[int][]int{}
+	lastMatched := -1
+	for i := range oldClauses {
+		if oldMatch[i] >= 0 {
+			lastMatched = oldMatch[i]
+			continue
+		}
+		removedAfter[lastMatched] = append(removedAfter[lastMatched], i)
+	}
+
+	var changes []ClauseChange
+	appendRemoved := func(after int) {
+		for _, i := range removedAfter[after] {
+			c := oldClauses[i]
+			changes = append(changes, ClauseChange{Status: "removed", OldNumber: c.Number, Title: c.Title, Old: c.Text})
+		}
+	}
+	appendRemoved(-1)
+	for j, c := range newClauses {
+		change := ClauseChange{NewNumber: c.Number, Title: c.Title, New: c.Text}
+		if i := newMatch[j]; i < 0 {
+			change.Status = "added"
+		} else {
+			o := oldClauses[i]
+			change.OldNumber = o.Number
+			switch {
+			case normalizeText(o.Text) != normalizeText(c.Text):
+				change.Status = "modified"
+				change.Old = o.Text
+				change.Similarity = float64(int(similarity[j]*100)) / 100
+				change.Segments = DiffText(o.Text, c.Text)
+			case o.Number != c.Number:
+				change.Status = "renumbered"
+			default:
+				change.Status = "unchanged"
+			}
+		}
+		changes = append(changes, change)
+		appendRemoved(j)
+	}
+	for i := range changes {
+		changes[i].ID = "C" + strconv.Itoa(i+1)
+	}
+	return changes
+}
+
+// DiffText 逐词比较两段文本，中文按字、英文和数字按词
+func DiffText(oldText, newText string) []Segment {
+	a, b := tokenize(oldText), tokenize(newText)
+	if len(a)*len(b) > maxTextDiffCells {
+		return []Segment{{Op: "delete", Text: oldText}, {Op: "insert", Text: newText}}
+	}
+
+	// 最长公共子序列，lcs[i][j] 为 a[i:] 和 b[j:] 的长度
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var segments []Segment
+	emit := func(op, text string) {
+		if n := len(segments); n > 0 && segments[n-1].Op == op {
+			segments[n-1].Text += text
+			return
+		}
+		segments = append(segments, Segment{Op: op, Text: text})
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			emit("equal", a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			emit("delete", a[i])
+			i++
+		default:
+			emit("insert", b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		emit("delete", a[i])
+	}
+	for ; j < len(b); j++ {
+		emit("insert", b[j])
+	}
+	return segments
+}
+
+// tokenize 中文每个字一个词元，连续的字母数字、空白和其他符号各为一个词元
+func tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		j := i + 1
+		switch {
+		case isWordRune(r):
+			for j < len(runes) && (isWordRune(runes[j]) || (runes[j] == '.' && j+1 < len(runes) && unicode.IsDigit(runes[j+1]))) {
+				j++
+			}
+		case unicode.IsSpace(r):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsDigit(r) || (unicode.IsLetter(r) && r <= unicode.MaxLatin1)
+}
+
+// normalizeText 忽略空白和标点差异。数字之间的小数点、千分位等符号保留，
+// 否则“1.5倍”和“15倍”、“3,000”和“30,00”会被当作相同
+func normalizeText(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) {
+			if !unicode.IsPunct(r) || i == 0 || i+1 == len(runes) || !unicode.IsDigit(runes[i-1]) || !unicode.IsDigit(runes[i+1]) {
+				continue
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+func bigrams(s string) map[string]int {
+	runes := []rune(normalizeText(s))
+	grams := map[string]int{}
+	if len(runes) == 1 {
+		grams[string(runes)]++
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])]++
+	}
+	return grams
+}
+
+// dice 两组二元词的 Dice 系数
+func dice(a, b map[string]int) float64 {
+	total := 0
+	for _, n := range a {
+		total += n
+	}
+	for _, n := range b {
+		total += n
+	}
+	if total == 0 {
+		return 1
+	}
+	common := 0
+	for g, n := range a {
+		common += min(n, b[g])
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,296 @@
+// Package docdiff 比较两份文档AI提取的结构化信息
+//
+// 用于同一文档不同版本之间的变化：新增/删除的技能、工作经历中职位的变化、
+// Offer 或合同修订前后的薪资变化，以及其他字段和列表的增删改。
+//
+// 合同和Offer的修订对比（Redline）另外按内容相似度对齐条款原文，重新编号的条款不视为修改，
+// 并由模型逐条评价变化对员工是否有利。
+package docdiff
+
+import (
+	"math"
+	"strings"
+
+	"ai-career-buddy/internal/company"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/utils"
+)
+
+// FieldChange 单个字段的变化，Old 为空表示新增，New 为空表示删除
+type FieldChange struct {
+	Field string `json:"field"` // 如 offerInfo.position
+	Label string `json:"label"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ListChange 列表字段（福利、职责等）的增删
+type ListChange struct {
+	Field   string   `json:"field"`
+	Label   string   `json:"label"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// PositionChange 工作经历的变化，按公司匹配
+type PositionChange struct {
+	Company     string `json:"company"`
+	Change      string `json:"change"` // added, removed, changed
+	OldPosition string `json:"oldPosition,omitempty"`
+	NewPosition string `json:"newPosition,omitempty"`
+	OldDuration string `json:"oldDuration,omitempty"`
+	NewDuration string `json:"newDuration,omitempty"`
+}
+
+// SalaryChange 薪资变化，两边都能解析时给出年薪中值的变化
+type SalaryChange struct {
+	Field       string  `json:"field"`
+	Label       string  `json:"label"`
+	Old         string  `json:"old"`
+	New         string  `json:"new"`
+	OldAnnual   float64 `json:"oldAnnual,omitempty"`
+	NewAnnual   float64 `json:"newAnnual,omitempty"`
+	AnnualDelta float64 `json:"annualDelta,omitempty"`
+	Percent     float64 `json:"percent,omitempty"` // 年薪变化百分比，保留一位小数
+}
+
+// Diff 两份提取信息之间的差异
+type Diff struct {
+	SkillsAdded   []string         `json:"skillsAdded"`
+	SkillsRemoved []string         `json:"skillsRemoved"`
+	Positions     []PositionChange `json:"positions"`
+	Salary        []SalaryChange   `json:"salary"`
+	Fields        []FieldChange    `json:"fields"`
+	Lists         []ListChange     `json:"lists"`
+}
+
+// Empty 没有任何变化
+func (d *Diff) Empty() bool {
+	return len(d.SkillsAdded) == 0 && len(d.SkillsRemoved) == 0 && len(d.Positions) == 0 &&
+		len(d.Salary) == 0 && len(d.Fields) == 0 && len(d.Lists) == 0
+}
+
+type info = models.DocumentExtractedInfo
+
+type scalarField struct {
+	name  string
+	label string
+	get   func(*info) string
+}
+
+type listField struct {
+	name  string
+	label string
+	get   func(*info) []string
+}
+
+var scalarFields = []scalarField{
+	{"personalInfo.name", "姓名", func(i *info) string { return i.PersonalInfo.Name }},
+	{"personalInfo.email", "邮箱", func(i *info) string { return i.PersonalInfo.Email }},
+	{"personalInfo.phone", "电话", func(i *info) string { return i.PersonalInfo.Phone }},
+	{"personalInfo.location", "所在地", func(i *info) string { return i.PersonalInfo.Location }},
+	{"personalInfo.linkedin", "LinkedIn", func(i *info) string { return i.PersonalInfo.LinkedIn }},
+	{"personalInfo.github", "GitHub", func(i *info) string { return i.PersonalInfo.GitHub }},
+
+	{"contractInfo.companyName", "合同公司", func(i *info) string { return i.ContractInfo.CompanyName }},
+	{"contractInfo.position", "合同职位", func(i *info) string { return i.ContractInfo.Position }},
+	{"contractInfo.startDate", "合同开始日期", func(i *info) string { return i.ContractInfo.StartDate }},
+	{"contractInfo.contractType", "合同类型", func(i *info) string { return i.ContractInfo.ContractType }},
+	{"contractInfo.workLocation", "合同工作地点", func(i *info) string { return i.ContractInfo.WorkLocation }},
+	{"contractInfo.workingHours", "合同工作时间", func(i *info) string { return i.ContractInfo.WorkingHours }},
+	{"contractInfo.noticePeriod", "通知期", func(i *info) string { return i.ContractInfo.NoticePeriod }},
+	{"contractInfo.nonCompete", "竞业限制", func(i *info) string { return i.ContractInfo.NonCompete }},
+	{"contractInfo.confidentiality", "保密条款", func(i *info) string { return i.ContractInfo.Confidentiality }},
+
+	{"offerInfo.companyName", "Offer公司", func(i *info) string { return i.OfferInfo.CompanyName }},
+	{"offerInfo.position", "Offer职位", func(i *info) string { return i.OfferInfo.Position }},
+	{"offerInfo.bonus", "奖金", func(i *info) string { return i.OfferInfo.Bonus }},
+	{"offerInfo.equity", "股权", func(i *info) string { return i.OfferInfo.Equity }},
+	{"offerInfo.startDate", "入职日期", func(i *info) string { return i.OfferInfo.StartDate }},
+	{"offerInfo.workLocation", "Offer工作地点", func(i *info) string { return i.OfferInfo.WorkLocation }},
+	{"offerInfo.workingHours", "Offer工作时间", func(i *info) string { return i.OfferInfo.WorkingHours }},
+	{"offerInfo.reportingTo", "汇报对象", func(i *info) string { return i.OfferInfo.ReportingTo }},
+	{"offerInfo.teamSize", "Offer团队规模", func(i *info) string { return i.OfferInfo.TeamSize }},
+
+	{"employmentInfo.companyName", "在职公司", func(i *info) string { return i.EmploymentInfo.CompanyName }},
+	{"employmentInfo.position", "在职职位", func(i *info) string { return i.EmploymentInfo.Position }},
+	{"employmentInfo.department", "部门", func(i *info) string { return i.EmploymentInfo.Department }},
+	{"employmentInfo.manager", "直属上级", func(i *info) string { return i.EmploymentInfo.Manager }},
+	{"employmentInfo.teamSize", "在职团队规模", func(i *info) string { return i.EmploymentInfo.TeamSize }},
+}
+
+var salaryFields = []scalarField{
+	{"contractInfo.salary", "合同薪资", func(i *info) string { return i.ContractInfo.Salary }},
+	{"offerInfo.salary", "Offer薪资", func(i *info) string { return i.OfferInfo.Salary }},
+}
+
+var listFields = []listField{
+	{"education", "教育背景", educationEntries},
+	{"contractInfo.benefits", "合同福利", func(i *info) []string { return i.ContractInfo.Benefits }},
+	{"offerInfo.benefits", "Offer福利", func(i *info) []string { return i.OfferInfo.Benefits }},
+	{"employmentInfo.responsibilities", "工作职责", func(i *info) []string { return i.EmploymentInfo.Responsibilities }},
+	{"employmentInfo.achievements", "工作成果", func(i *info) []string { return i.EmploymentInfo.Achievements }},
+	{"employmentInfo.skillsUsed", "使用技能", func(i *info) []string { return i.EmploymentInfo.SkillsUsed }},
+	{"employmentInfo.projects", "项目", func(i *info) []string { return i.EmploymentInfo.Projects }},
+}
+
+// Compare 比较旧版本和新版本的提取信息，nil 视为空信息
+func Compare(oldInfo, newInfo *models.DocumentExtractedInfo) *Diff {
+	if oldInfo == nil {
+		oldInfo = &models.DocumentExtractedInfo{}
+	}
+	if newInfo == nil {
+		newInfo = &models.DocumentExtractedInfo{}
+	}
+
+	d := &Diff{
+		Positions: comparePositions(oldInfo, newInfo),
+		Fields:    []FieldChange{},
+		Salary:    []SalaryChange{},
+		Lists:     []ListChange{},
+	}
+	d.SkillsAdded, d.SkillsRemoved = compareLists(allSkills(oldInfo), allSkills(newInfo))
+
+	for _, f := range scalarFields {
+		o, n := strings.TrimSpace(f.get(oldInfo)), strings.TrimSpace(f.get(newInfo))
+		if o != n {
+			d.Fields = append(d.Fields, FieldChange{Field: f.name, Label: f.label, Old: o, New: n})
+		}
+	}
+	for _, f := range salaryFields {
+		o, n := strings.TrimSpace(f.get(oldInfo)), strings.TrimSpace(f.get(newInfo))
+		if o != n {
+			d.Salary = append(d.Salary, compareSalary(f, o, n))
+		}
+	}
+	for _, f := range listFields {
+		added, removed := compareLists(f.get(oldInfo), f.get(newInfo))
+		if len(added) > 0 || len(removed) > 0 {
+			d.Lists = append(d.Lists, ListChange{Field: f.name, Label: f.label, Added: added, Removed: removed})
+		}
+	}
+	return d
+}
+
+func compareSalary(f scalarField, o, n string) SalaryChange {
+	change := SalaryChange{Field: f.name, Label: f.label, Old: o, New: n}
+	oldRange, newRange := utils.ParseSalary(o), utils.ParseSalary(n)
+	if oldRange == nil || newRange == nil {
+		return change
+	}
+	change.OldAnnual = oldRange.AnnualMid()
+	change.NewAnnual = newRange.AnnualMid()
+	change.AnnualDelta = change.NewAnnual - change.OldAnnual
+	if change.OldAnnual > 0 {
+		change.Percent = math.Round(change.AnnualDelta/change.OldAnnual*1000) / 10
+	}
+	return change
+}
+
+// comparePositions 按公司名（规范化后）对齐工作经历，同一公司出现多次时按顺序配对
+func comparePositions(oldInfo, newInfo *models.DocumentExtractedInfo) []PositionChange {
+	type entry struct{ company, position, duration string }
+	collect := func(i *info) ([]string, map[string][]entry) {
+		var keys []string
+		byKey := map[string][]entry{}
+		for _, w := range i.WorkExperience {
+			key := company.Normalize(w.Company)
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(w.Position))
+			}
+			if _, ok := byKey[key]; !ok {
+				keys = append(keys, key)
+			}
+			byKey[key] = append(byKey[key], entry{strings.TrimSpace(w.Company), strings.TrimSpace(w.Position), strings.TrimSpace(w.Duration)})
+		}
+		return keys, byKey
+	}
+	oldKeys, oldByKey := collect(oldInfo)
+	newKeys, newByKey := collect(newInfo)
+
+	changes := []PositionChange{}
+	for _, key := range newKeys {
+		olds := oldByKey[key]
+		for i, n := range newByKey[key] {
+			if i >= len(olds) {
+				changes = append(changes, PositionChange{Company: n.company, Change: "added", NewPosition: n.position, NewDuration: n.duration})
+				continue
+			}
+			o := olds[i]
+			if o.position != n.position || o.duration != n.duration {
+				changes = append(changes, PositionChange{Company: n.company, Change: "changed",
+					OldPosition: o.position, NewPosition: n.position, OldDuration: o.duration, NewDuration: n.duration})
+			}
+		}
+	}
+	for _, key := range oldKeys {
+		olds := oldByKey[key]
+		for i := len(newByKey[key]); i < len(olds); i++ {
+			o := olds[i]
+			changes = append(changes, PositionChange{Company: o.company, Change: "removed", OldPosition: o.position, OldDuration: o.duration})
+		}
+	}
+	return changes
+}
+
+// allSkills 技能栏和各段工作经历中的技能合并去重
+func allSkills(i *info) []string {
+	var skills []string
+	skills = append(skills, i.Skills.Technical...)
+	skills = append(skills, i.Skills.Soft...)
+	skills = append(skills, i.Skills.Languages...)
+	skills = append(skills, i.Skills.Certifications...)
+	for _, w := range i.WorkExperience {
+		skills = append(skills, w.Skills...)
+	}
+	return skills
+}
+
+func educationEntries(i *info) []string {
+	var entries []string
+	for _, e := range i.Education {
+		parts := []string{}
+		for _, p := range []string{e.School, e.Degree, e.Major} {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		if len(parts) > 0 {
+			entries = append(entries, strings.Join(parts, " "))
+		}
+	}
+	return entries
+}
+
+// compareLists 忽略大小写和首尾空白比较两个列表，返回新增和删除的项
+func compareLists(oldItems, newItems []string) ([]string, []string) {
+	normalize := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	index := func(items []string) ([]string, map[string]bool) {
+		var unique []string
+		seen := map[string]bool{}
+		for _, item := range items {
+			key := normalize(item)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			unique = append(unique, strings.TrimSpace(item))
+		}
+		return unique, seen
+	}
+	oldUnique, oldSeen := index(oldItems)
+	newUnique, newSeen := index(newItems)
+
+	added, removed := []string{}, []string{}
+	for _, item := range newUnique {
+		if !oldSeen[normalize(item)] {
+			added = append(added, item)
+		}
+	}
+	for _, item := range oldUnique {
+		if !newSeen[normalize(item)] {
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
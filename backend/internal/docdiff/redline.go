@@ -0,0 +1,220 @@
+package docdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/utils"
+)
+
+const (
+	maxCommentaryItems = 40  // 提交给模型评价的变化数
+	maxCommentaryRunes = 300 // 每段条款原文截断长度
+)
+
+// 对员工是否有利
+const (
+	Favourable   = "favourable"
+	Unfavourable = "unfavourable"
+	Neutral      = "neutral"
+	Unknown      = "unknown"
+)
+
+// Redline 两份合同或Offer之间的差异
+type Redline struct {
+	Clauses []ClauseChange `json:"clauses"`
+	Terms   []TermChange   `json:"terms"`
+	Stats   map[string]int `json:"stats"`             // 各状态的条款数
+	Summary string         `json:"summary,omitempty"` // 模型对整体变化的评价
+}
+
+// NewRedline 对齐两份文档的条款并比较提取的条款字段，文档未处理时只比较原文
+func NewRedline(oldDoc, newDoc *models.UserDocument) (*Redline, error) {
+	r := &Redline{
+		Clauses: AlignClauses(oldDoc.FileContent, newDoc.FileContent),
+		Terms:   []TermChange{},
+		Stats:   map[string]int{},
+	}
+	for _, c := range r.Clauses {
+		r.Stats[c.Status]++
+	}
+	if oldDoc.IsProcessed && newDoc.IsProcessed {
+		oldInfo, err := oldDoc.GetExtractedInfo()
+		if err != nil {
+			return nil, err
+		}
+		newInfo, err := newDoc.GetExtractedInfo()
+		if err != nil {
+			return nil, err
+		}
+		r.Terms = CompareTerms(oldDoc.DocumentType, oldInfo, newDoc.DocumentType, newInfo)
+	}
+	r.assessByRule()
+	return r, nil
+}
+
+// Changed 是否有需要评价的变化
+func (r *Redline) Changed() bool {
+	return len(r.Terms) > 0 || r.Stats["modified"]+r.Stats["added"]+r.Stats["removed"] > 0
+}
+
+// assessByRule 按规则给出初步评价：涨薪、增加福利有利，降薪、减少福利不利，其他待模型判断
+func (r *Redline) assessByRule() {
+	for i := range r.Terms {
+		t := &r.Terms[i]
+		t.Assessment = Unknown
+		switch {
+		case t.Percent > 0:
+			t.Assessment = Favourable
+		case t.Percent < 0:
+			t.Assessment = Unfavourable
+		case len(t.Added) > 0 && len(t.Removed) == 0:
+			t.Assessment = Favourable
+		case len(t.Removed) > 0 && len(t.Added) == 0:
+			t.Assessment = Unfavourable
+		}
+	}
+	for i := range r.Clauses {
+		switch r.Clauses[i].Status {
+		case "modified", "added", "removed":
+			r.Clauses[i].Assessment = Unknown
+		}
+	}
+}
+
+type commentaryOutput struct {
+	Items []struct {
+		ID         string `json:"id"`
+		Assessment string `json:"assessment"`
+		Comment    string `json:"comment"`
+	} `json:"items"`
+	Summary string `json:"summary"`
+}
+
+// Annotate 由模型逐条评价变化对员工是否有利，失败时保留规则评价
+func (r *Redline) Annotate(documentType, modelID string) error {
+	changes := r.describeChanges()
+	if changes == "" {
+		return nil
+	}
+	vars := prompts.DiffCommentaryVars{DocumentType: documentType, Changes: changes}
+	prompt, ref, err := prompts.Render(prompts.KeyDiffCommentary, prompts.Selector{Category: documentType, ModelID: modelID}, vars)
+	if err != nil {
+		return err
+	}
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, nil)
+	if err != nil {
+		return err
+	}
+	if len(response.Choices) == 0 {
+		return fmt.Errorf("模型未返回结果")
+	}
+	content := response.Choices[0].Message.Content
+	var out commentaryOutput
+	if err := json.Unmarshal([]byte(utils.CleanJSONContent(content)), &out); err != nil {
+		return fmt.Errorf("无法解析模型输出: %v", err)
+	}
+
+	applied := 0
+	for _, item := range out.Items {
+		assessment := strings.ToLower(strings.TrimSpace(item.Assessment))
+		switch assessment {
+		case Favourable, Unfavourable, Neutral:
+		default:
+			assessment = ""
+		}
+		if r.annotate(strings.TrimSpace(item.ID), assessment, strings.TrimSpace(item.Comment)) {
+			applied++
+		}
+	}
+	r.Summary = strings.TrimSpace(out.Summary)
+	logger.Info("差异评价完成: 模板=%s, 评价=%d/%d", ref, applied, len(out.Items))
+	return nil
+}
+
+func (r *Redline) annotate(id, assessment, comment string) bool {
+	for i := range r.Terms {
+		if r.Terms[i].ID == id {
+			if assessment != "" {
+				r.Terms[i].Assessment = assessment
+			}
+			r.Terms[i].Comment = comment
+			return true
+		}
+	}
+	for i := range r.Clauses {
+		if r.Clauses[i].ID == id {
+			if assessment != "" {
+				r.Clauses[i].Assessment = assessment
+			}
+			r.Clauses[i].Comment = comment
+			return true
+		}
+	}
+	return false
+}
+
+// describeChanges 把需要评价的变化整理为带编号的列表，字段变化在前，条款变化在后
+func (r *Redline) describeChanges() string {
+	var lines []string
+	for _, t := range r.Terms {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %s：", t.ID, t.Label)
+		if len(t.Added) > 0 || len(t.Removed) > 0 {
+			if len(t.Added) > 0 {
+				fmt.Fprintf(&sb, "新增 %s；", strings.Join(t.Added, "、"))
+			}
+			if len(t.Removed) > 0 {
+				fmt.Fprintf(&sb, "删除 %s；", strings.Join(t.Removed, "、"))
+			}
+		} else {
+			fmt.Fprintf(&sb, "%s → %s", valueOrNone(t.Old), valueOrNone(t.New))
+			if t.Percent != 0 {
+				fmt.Fprintf(&sb, "（年薪中值 %+.1f%%）", t.Percent)
+			}
+		}
+		lines = append(lines, sb.String())
+	}
+	for _, c := range r.Clauses {
+		number := c.NewNumber
+		if number == "" {
+			number = c.OldNumber
+		}
+		switch c.Status {
+		case "modified":
+			lines = append(lines, fmt.Sprintf("%s [修改] %s %s\n  原文：%s\n  新文：%s", c.ID, number, c.Title,
+				truncate(oneLine(c.Old), maxCommentaryRunes), truncate(oneLine(c.New), maxCommentaryRunes)))
+		case "added":
+			lines = append(lines, fmt.Sprintf("%s [新增] %s %s\n  新文：%s", c.ID, number, c.Title, truncate(oneLine(c.New), maxCommentaryRunes)))
+		case "removed":
+			lines = append(lines, fmt.Sprintf("%s [删除] %s %s\n  原文：%s", c.ID, number, c.Title, truncate(oneLine(c.Old), maxCommentaryRunes)))
+		}
+	}
+	if len(lines) > maxCommentaryItems {
+		lines = lines[:maxCommentaryItems]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func valueOrNone(s string) string {
+	if s == "" {
+		return "（无）"
+	}
+	return s
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
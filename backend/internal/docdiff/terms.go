@@ -0,0 +1,76 @@
+package docdiff
+
+import (
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/models"
+)
+
+// TermChange 合同或Offer条款字段的变化，列表字段（福利）用 Added/Removed 表示
+type TermChange struct {
+	ID          string   `json:"id"`
+	Field       string   `json:"field"`
+	Label       string   `json:"label"`
+	Old         string   `json:"old,omitempty"`
+	New         string   `json:"new,omitempty"`
+	Added       []string `json:"added,omitempty"`
+	Removed     []string `json:"removed,omitempty"`
+	AnnualDelta float64  `json:"annualDelta,omitempty"`
+	Percent     float64  `json:"percent,omitempty"`
+	Assessment  string   `json:"assessment,omitempty"`
+	Comment     string   `json:"comment,omitempty"`
+}
+
+// CompareTerms 比较两份合同或Offer的 ContractInfo/OfferInfo 字段。
+// 一份是Offer、一份是合同时（如Offer与最终合同对比），按两者共有的字段对齐
+func CompareTerms(oldType string, oldInfo *models.DocumentExtractedInfo, newType string, newInfo *models.DocumentExtractedInfo) []TermChange {
+	switch {
+	case oldType == "offer" && newType == "contract":
+		oldInfo = offerAsContract(oldInfo)
+	case oldType == "contract" && newType == "offer":
+		newInfo = offerAsContract(newInfo)
+	}
+	d := Compare(oldInfo, newInfo)
+
+	changes := []TermChange{}
+	for _, s := range d.Salary {
+		changes = append(changes, TermChange{Field: s.Field, Label: s.Label, Old: s.Old, New: s.New, AnnualDelta: s.AnnualDelta, Percent: s.Percent})
+	}
+	for _, f := range d.Fields {
+		if isTermField(f.Field) {
+			changes = append(changes, TermChange{Field: f.Field, Label: f.Label, Old: f.Old, New: f.New})
+		}
+	}
+	for _, l := range d.Lists {
+		if isTermField(l.Field) {
+			changes = append(changes, TermChange{Field: l.Field, Label: l.Label, Added: l.Added, Removed: l.Removed})
+		}
+	}
+	for i := range changes {
+		changes[i].ID = "T" + strconv.Itoa(i+1)
+	}
+	return changes
+}
+
+func isTermField(field string) bool {
+	return strings.HasPrefix(field, "contractInfo.") || strings.HasPrefix(field, "offerInfo.")
+}
+
+// offerAsContract 把Offer信息放到合同信息的对应字段，便于与合同比较
+func offerAsContract(info *models.DocumentExtractedInfo) *models.DocumentExtractedInfo {
+	if info == nil {
+		return nil
+	}
+	converted := *info
+	converted.ContractInfo = models.DocumentExtractedInfo{}.ContractInfo
+	converted.ContractInfo.CompanyName = info.OfferInfo.CompanyName
+	converted.ContractInfo.Position = info.OfferInfo.Position
+	converted.ContractInfo.Salary = info.OfferInfo.Salary
+	converted.ContractInfo.StartDate = info.OfferInfo.StartDate
+	converted.ContractInfo.WorkLocation = info.OfferInfo.WorkLocation
+	converted.ContractInfo.WorkingHours = info.OfferInfo.WorkingHours
+	converted.ContractInfo.Benefits = info.OfferInfo.Benefits
+	converted.OfferInfo = models.DocumentExtractedInfo{}.OfferInfo
+	return &converted
+}
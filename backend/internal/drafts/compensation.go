@@ -0,0 +1,84 @@
+package drafts
+
+import (
+	"fmt"
+	"math"
+
+	"ai-career-buddy/internal/insights"
+	"ai-career-buddy/internal/utils"
+)
+
+// 还价建议的规则参数
+const (
+	counterMinRaise    = 0.10 // 至少在Offer基础上提高10%
+	counterMaxRaise    = 0.25 // 最多提高25%，避免要价过高
+	switchExpectedRise = 0.20 // 跳槽常见涨幅，相对当前薪资
+	counterRoundStep   = 500  // 建议月薪取整到500元
+)
+
+// Compensation Offer薪酬分析：与当前薪资、平台同岗位薪资带比较，并给出还价建议
+type Compensation struct {
+	Offered          *utils.SalaryRange   `json:"offered,omitempty"`
+	Current          *utils.SalaryRange   `json:"current,omitempty"`
+	RaisePercent     *float64             `json:"raisePercent,omitempty"`     // Offer年薪相对当前年薪的涨幅(%)
+	Market           *insights.SalaryBand `json:"market,omitempty"`           // 平台同企业同职位的匿名薪资带，不含用户本人
+	MarketPosition   string               `json:"marketPosition,omitempty"`   // Offer月薪在薪资带中的位置: below_p25, p25_p50, p50_p75, above_p75
+	SuggestedMonthly float64              `json:"suggestedMonthly,omitempty"` // 建议的还价月薪
+	Rationale        []string             `json:"rationale"`
+}
+
+// Analyze 分析Offer薪资。offerSalary 无法解析时返回 nil
+func Analyze(offerSalary, currentSalary string, market *insights.SalaryBand) *Compensation {
+	offered := utils.ParseSalary(offerSalary)
+	if offered == nil {
+		return nil
+	}
+	c := &Compensation{Offered: offered, Current: utils.ParseSalary(currentSalary), Market: market, Rationale: []string{}}
+	base := offered.MonthlyMid()
+	target := base * (1 + counterMinRaise)
+	c.Rationale = append(c.Rationale, fmt.Sprintf("Offer月薪约 %s，还价通常在此基础上提高 10%%-20%%", formatMoney(base)))
+
+	if c.Current != nil && c.Current.AnnualMid() > 0 {
+		raise := math.Round((offered.AnnualMid()/c.Current.AnnualMid()-1)*1000) / 10
+		c.RaisePercent = &raise
+		c.Rationale = append(c.Rationale, fmt.Sprintf("Offer年薪相对当前年薪涨幅 %.1f%%", raise))
+		if expected := c.Current.MonthlyMid() * (1 + switchExpectedRise); expected > target {
+			target = expected
+			c.Rationale = append(c.Rationale, "跳槽涨幅通常在20%以上，按当前月薪上浮20%计算")
+		}
+	}
+
+	if market != nil {
+		m := market.Monthly
+		switch {
+		case base < m.P25:
+			c.MarketPosition = "below_p25"
+		case base < m.P50:
+			c.MarketPosition = "p25_p50"
+		case base < m.P75:
+			c.MarketPosition = "p50_p75"
+		default:
+			c.MarketPosition = "above_p75"
+		}
+		c.Rationale = append(c.Rationale, fmt.Sprintf("平台 %d 名用户的同岗位月薪中位数为 %s", market.Users, formatMoney(m.P50)))
+		if m.P50 > target {
+			target = m.P50
+			c.Rationale = append(c.Rationale, "Offer低于同岗位中位数，可按中位数还价")
+		}
+	}
+
+	if limit := base * (1 + counterMaxRaise); target > limit {
+		target = limit
+	}
+	c.SuggestedMonthly = math.Ceil(target/counterRoundStep) * counterRoundStep
+	return c
+}
+
+// formatMoney 金额按千元显示，如 25.5k
+func formatMoney(v float64) string {
+	k := math.Round(v/100) / 10
+	if k == math.Trunc(k) {
+		return fmt.Sprintf("%.0fk", k)
+	}
+	return fmt.Sprintf("%.1fk", k)
+}
@@ -0,0 +1,367 @@
+// Package drafts 生成求职沟通文书草稿
+//
+// 每种文书（还价邮件、接受/婉拒Offer、辞职信、求职信）都有内置模板，用Offer提取信息、
+// 用户资料和薪酬分析填写；使用模型时由模型按语气和篇幅撰写，模型不可用时回退到模板。
+package drafts
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/utils"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var bodyTemplates = template.Must(template.New("drafts").Funcs(template.FuncMap{
+	"join":    strings.Join,
+	"percent": func(v *float64) string { return fmt.Sprintf("%.1f%%", *v) },
+}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// 文书类型
+const (
+	KindCounterOffer    = "counter_offer"
+	KindOfferAcceptance = "offer_acceptance"
+	KindOfferRejection  = "offer_rejection"
+	KindResignation     = "resignation"
+	KindCoverLetter     = "cover_letter"
+)
+
+var (
+	// ErrUnknownKind 文书类型不存在
+	ErrUnknownKind = errors.New("不支持的文书类型")
+	// ErrUnknownTone 语气不存在
+	ErrUnknownTone = errors.New("不支持的语气")
+	// ErrUnknownLength 篇幅不存在
+	ErrUnknownLength = errors.New("不支持的篇幅")
+)
+
+// Kind 文书类型
+type Kind struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	NeedsOffer  bool   `json:"needsOffer"` // 需要依据一份Offer
+}
+
+// Tone 语气
+type Tone struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	greeting    string // 称呼格式，%s 为收件人
+	closing     string
+}
+
+// Length 篇幅
+type Length struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var kinds = []Kind{
+	{Name: KindCounterOffer, Title: "薪资还价", Description: "收到Offer后就薪资和待遇进行协商", NeedsOffer: true},
+	{Name: KindOfferAcceptance, Title: "接受Offer", Description: "确认接受Offer并沟通入职安排", NeedsOffer: true},
+	{Name: KindOfferRejection, Title: "婉拒Offer", Description: "礼貌地拒绝Offer并保持联系", NeedsOffer: true},
+	{Name: KindResignation, Title: "辞职信", Description: "向现任公司正式提出离职"},
+	{Name: KindCoverLetter, Title: "求职信", Description: "投递简历时附上的自我推荐信"},
+}
+
+var tones = []Tone{
+	{Name: "formal", Title: "正式", Description: "措辞规范、礼貌克制，适合首次沟通和正式场合", greeting: "尊敬的%s：", closing: "此致\n敬礼"},
+	{Name: "friendly", Title: "亲切", Description: "语气温和自然，适合已经熟悉的HR或上级", greeting: "%s，您好！", closing: "祝好"},
+	{Name: "assertive", Title: "坚定", Description: "表达明确、有理有据，适合薪资谈判", greeting: "%s，您好：", closing: "期待您的回复"},
+}
+
+var lengths = []Length{
+	{Name: "concise", Title: "简短", Description: "一到两段，只说关键信息"},
+	{Name: "standard", Title: "适中", Description: "三到四段，说明理由和后续安排"},
+	{Name: "detailed", Title: "详细", Description: "完整说明背景、理由和具体诉求"},
+}
+
+// Kinds 支持的文书类型
+func Kinds() []Kind { return kinds }
+
+// Tones 支持的语气
+func Tones() []Tone { return tones }
+
+// Lengths 支持的篇幅
+func Lengths() []Length { return lengths }
+
+// LookupKind 按名称查找文书类型
+func LookupKind(name string) (Kind, error) {
+	for _, k := range kinds {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+	return Kind{}, ErrUnknownKind
+}
+
+// LookupTone 按名称查找语气，为空时使用正式语气
+func LookupTone(name string) (Tone, error) {
+	if name == "" {
+		return tones[0], nil
+	}
+	for _, t := range tones {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Tone{}, ErrUnknownTone
+}
+
+// LookupLength 按名称查找篇幅，为空时使用适中篇幅
+func LookupLength(name string) (Length, error) {
+	if name == "" {
+		return lengths[1], nil
+	}
+	for _, l := range lengths {
+		if l.Name == name {
+			return l, nil
+		}
+	}
+	return Length{}, ErrUnknownLength
+}
+
+// OfferTerms Offer中与文书相关的条款
+type OfferTerms struct {
+	Salary       string   `json:"salary,omitempty"`
+	Bonus        string   `json:"bonus,omitempty"`
+	Equity       string   `json:"equity,omitempty"`
+	StartDate    string   `json:"startDate,omitempty"`
+	WorkLocation string   `json:"workLocation,omitempty"`
+	Benefits     []string `json:"benefits,omitempty"`
+}
+
+// Context 填写文书使用的信息
+type Context struct {
+	SenderName      string        `json:"senderName,omitempty"`
+	SenderEmail     string        `json:"senderEmail,omitempty"`
+	SenderPhone     string        `json:"senderPhone,omitempty"`
+	CurrentCompany  string        `json:"currentCompany,omitempty"`
+	CurrentPosition string        `json:"currentPosition,omitempty"`
+	Experience      int           `json:"experience,omitempty"` // 工作年限
+	Company         string        `json:"company,omitempty"`    // Offer或求职的公司
+	Position        string        `json:"position,omitempty"`   // Offer或求职的职位
+	RecipientName   string        `json:"recipientName,omitempty"`
+	Offer           *OfferTerms   `json:"offer,omitempty"`
+	Compensation    *Compensation `json:"compensation,omitempty"`
+	TargetSalary    string        `json:"targetSalary,omitempty"` // 用户的期望薪资，还价时优先于建议值
+	Highlights      []string      `json:"highlights,omitempty"`   // 简历中的代表性经历
+	Skills          []string      `json:"skills,omitempty"`
+	LastDay         string        `json:"lastDay,omitempty"` // 辞职信中的最后工作日
+	Notes           string        `json:"notes,omitempty"`   // 用户补充的说明
+}
+
+// Result 生成的文书
+type Result struct {
+	Subject       string
+	Body          string
+	PromptVersion string
+}
+
+// Fill 用内置模板填写文书，不调用模型
+func Fill(kind Kind, c *Context, toneName, lengthName string) (*Result, error) {
+	tone, err := LookupTone(toneName)
+	if err != nil {
+		return nil, err
+	}
+	length, err := LookupLength(lengthName)
+	if err != nil {
+		return nil, err
+	}
+	recipient := c.RecipientName
+	if recipient == "" {
+		recipient = defaultRecipient(kind.Name)
+	}
+	data := struct {
+		*Context
+		Greeting string
+		Closing  string
+		Concise  bool
+		Detailed bool
+	}{c, fmt.Sprintf(tone.greeting, recipient), tone.closing, length.Name == "concise", length.Name == "detailed"}
+
+	var buf bytes.Buffer
+	if err := bodyTemplates.ExecuteTemplate(&buf, kind.Name+".tmpl", data); err != nil {
+		return nil, err
+	}
+	return &Result{Subject: Subject(kind, c), Body: normalizeBody(buf.String())}, nil
+}
+
+// Compose 由模型按语气和篇幅撰写文书
+func Compose(kind Kind, c *Context, toneName, lengthName, modelID string) (*Result, error) {
+	tone, err := LookupTone(toneName)
+	if err != nil {
+		return nil, err
+	}
+	length, err := LookupLength(lengthName)
+	if err != nil {
+		return nil, err
+	}
+	vars := prompts.DraftComposeVars{
+		Kind:      kind.Name,
+		KindTitle: kind.Title,
+		Tone:      tone.Title + "：" + tone.Description,
+		Length:    length.Title + "：" + length.Description,
+		Facts:     c.Facts(),
+		Notes:     c.Notes,
+	}
+	prompt, ref, err := prompts.Render(prompts.KeyDraftCompose, prompts.Selector{Category: "offer", ModelID: modelID}, vars)
+	if err != nil {
+		return nil, err
+	}
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("模型未返回结果")
+	}
+	content := response.Choices[0].Message.Content
+	var out struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	if err := json.Unmarshal([]byte(utils.CleanJSONContent(content)), &out); err != nil {
+		return nil, fmt.Errorf("无法解析模型输出: %v", err)
+	}
+	body := normalizeBody(out.Body)
+	if body == "" {
+		return nil, fmt.Errorf("模型未生成正文")
+	}
+	subject := strings.TrimSpace(out.Subject)
+	if subject == "" {
+		subject = Subject(kind, c)
+	}
+	logger.Info("文书草稿生成: 类型=%s, 模板=%s, 字数=%d", kind.Name, ref, len([]rune(body)))
+	return &Result{Subject: subject, Body: body, PromptVersion: ref.String()}, nil
+}
+
+// Subject 文书的默认邮件主题
+func Subject(kind Kind, c *Context) string {
+	name := c.SenderName
+	target := strings.TrimSpace(c.Company + " " + c.Position)
+	switch kind.Name {
+	case KindCounterOffer:
+		return joinSubject("关于"+target+"Offer薪资待遇的沟通", name)
+	case KindOfferAcceptance:
+		return joinSubject("确认接受"+target+"Offer", name)
+	case KindOfferRejection:
+		return joinSubject("关于"+target+"Offer的回复", name)
+	case KindResignation:
+		return joinSubject("辞职申请", name)
+	default:
+		return joinSubject("应聘"+target, name)
+	}
+}
+
+func joinSubject(subject, name string) string {
+	if name == "" {
+		return subject
+	}
+	return subject + " - " + name
+}
+
+func defaultRecipient(kind string) string {
+	switch kind {
+	case KindResignation:
+		return "领导"
+	case KindCoverLetter:
+		return "招聘负责人"
+	default:
+		return "HR"
+	}
+}
+
+// Facts 整理为提示词中的事实列表，只包含已知的信息
+func (c *Context) Facts() string {
+	var lines []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, "- "+label+"："+value)
+		}
+	}
+	add("写信人", c.SenderName)
+	add("联系方式", strings.TrimSpace(c.SenderPhone+" "+c.SenderEmail))
+	add("当前公司", c.CurrentCompany)
+	add("当前职位", c.CurrentPosition)
+	if c.Experience > 0 {
+		add("工作年限", fmt.Sprintf("%d年", c.Experience))
+	}
+	add("目标公司", c.Company)
+	add("目标职位", c.Position)
+	add("收件人", c.RecipientName)
+	if o := c.Offer; o != nil {
+		add("Offer薪资", o.Salary)
+		add("奖金", o.Bonus)
+		add("股权/期权", o.Equity)
+		add("入职日期", o.StartDate)
+		add("工作地点", o.WorkLocation)
+		add("福利", strings.Join(o.Benefits, "、"))
+	}
+	if comp := c.Compensation; comp != nil {
+		if comp.RaisePercent != nil {
+			add("相对当前年薪涨幅", fmt.Sprintf("%.1f%%", *comp.RaisePercent))
+		}
+		if comp.Market != nil {
+			add("平台同岗位月薪", fmt.Sprintf("P25 %s / 中位数 %s / P75 %s", formatMoney(comp.Market.Monthly.P25),
+				formatMoney(comp.Market.Monthly.P50), formatMoney(comp.Market.Monthly.P75)))
+		}
+	}
+	add("期望薪资", c.CounterSalary())
+	add("代表性经历", strings.Join(c.Highlights, "；"))
+	add("技能", strings.Join(c.Skills, "、"))
+	add("最后工作日", c.LastDay)
+	return strings.Join(lines, "\n")
+}
+
+// CounterSalary 还价的期望薪资：用户填写的优先，否则使用薪酬分析的建议值
+func (c *Context) CounterSalary() string {
+	if c.TargetSalary != "" {
+		return c.TargetSalary
+	}
+	if c.Compensation != nil && c.Compensation.SuggestedMonthly > 0 {
+		return "月薪" + formatMoney(c.Compensation.SuggestedMonthly)
+	}
+	return ""
+}
+
+// normalizeBody 去掉多余的空行和行尾空白
+func normalizeBody(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	var out []string
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,89 @@
+package drafts
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message 导出的邮件
+type Message struct {
+	From    *mail.Address
+	To      *mail.Address
+	Subject string
+	Body    string // Markdown
+	Date    time.Time
+}
+
+// Markdown 导出为 Markdown 文件，主题作为标题
+func Markdown(m *Message) []byte {
+	var b strings.Builder
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "# %s\n\n", m.Subject)
+	}
+	b.WriteString(strings.TrimSpace(m.Body))
+	b.WriteString("\n")
+	return []byte(b.String())
+}
+
+// EML 导出为 RFC 5322 邮件文件，可在邮件客户端中打开后修改发送。
+// 带 X-Unsent 头，Outlook 等客户端会作为未发送的草稿打开
+func EML(m *Message) []byte {
+	var b bytes.Buffer
+	header := func(k, v string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	if m.From != nil {
+		header("From", m.From.String())
+	}
+	if m.To != nil {
+		header("To", m.To.String())
+	}
+	header("Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	header("Message-ID", messageID(m.From))
+	header("MIME-Version", "1.0")
+	header("X-Unsent", "1")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	b.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(strings.ReplaceAll(PlainText(m.Body), "\n", "\r\n")))
+	qp.Close()
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+var (
+	markdownHeading  = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownEmphasis = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// PlainText 去掉正文中的 Markdown 标记，列表符号保留为“-”
+func PlainText(body string) string {
+	s := markdownHeading.ReplaceAllString(body, "")
+	s = markdownEmphasis.ReplaceAllString(s, "$1$2")
+	s = markdownLink.ReplaceAllString(s, "$1 ($2)")
+	return strings.TrimSpace(s) + "\n"
+}
+
+func messageID(from *mail.Address) string {
+	domain := "ai-career-buddy.local"
+	if from != nil {
+		if i := strings.LastIndex(from.Address, "@"); i >= 0 {
+			domain = from.Address[i+1:]
+		}
+	}
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(buf), domain)
+}
@@ -0,0 +1,270 @@
+// Package enrich 在每轮对话保存后由模型为咨询记录生成标题、标签、情绪和关键实体
+//
+// 标签同时写入 CareerHistory.Tags（JSON数组，兼容原有接口）和 career_history_tags 表，
+// 后者用于标签统计和按标签筛选。模型调用失败时保留保存时按关键词提取的标签和截断的标题。
+package enrich
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxTags        = 5
+	maxTagRunes    = 20
+	maxTitleRunes  = 50
+	maxEntities    = 10
+	maxInputRunes  = 1500
+	existingTagCap = 30 // 提示词中列出的已有标签数
+	concurrency    = 4  // 同时进行的整理任务数
+)
+
+var sem = make(chan struct{}, concurrency)
+
+// Output 模型生成的整理结果
+type Output struct {
+	Title     string                 `json:"title"`
+	Tags      []string               `json:"tags"`
+	Sentiment string                 `json:"sentiment"`
+	Entities  models.HistoryEntities `json:"entities"`
+}
+
+// HistoryAsync 后台整理咨询记录
+func HistoryAsync(historyID uint) {
+	if !config.C.EnrichEnabled {
+		return
+	}
+	go func() {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		if err := History(historyID); err != nil {
+			logger.Warn("整理咨询记录失败: HistoryID=%d, 错误=%v", historyID, err)
+		}
+	}()
+}
+
+// History 调用模型整理咨询记录，并更新标题、标签、情绪和关键实体
+func History(historyID uint) error {
+	var h models.CareerHistory
+	if err := db.Conn.First(&h, historyID).Error; err != nil {
+		return err
+	}
+
+	existing, err := TopTags(h.UserID, "", existingTagCap)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(existing))
+	for i, t := range existing {
+		names[i] = t.Tag
+	}
+
+	modelID := config.C.EnrichModel
+	vars := prompts.EnrichVars{
+		Category:     h.Category,
+		Question:     truncate(h.Content, maxInputRunes),
+		Answer:       truncate(stripModelNote(h.AIResponse), maxInputRunes),
+		ExistingTags: strings.Join(names, "、"),
+	}
+	prompt, _, err := prompts.Render(prompts.KeyHistoryEnrich, prompts.Selector{Category: h.Category, ModelID: modelID}, vars)
+	if err != nil {
+		return err
+	}
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, nil)
+	if err != nil {
+		return err
+	}
+	if len(response.Choices) == 0 {
+		return fmt.Errorf("模型未返回结果")
+	}
+	out, err := parseOutput(response.Choices[0].Message.Content)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	h.Sentiment = out.Sentiment
+	h.Entities = out.Entities
+	h.EnrichedAt = &now
+	if out.Title != "" {
+		h.Title = out.Title
+	}
+	tags := out.Tags
+	if len(tags) == 0 {
+		tags = NormalizeTags(decodeTags(h.Tags))
+	} else {
+		data, _ := json.Marshal(tags)
+		h.Tags = string(data)
+	}
+
+	err = db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&h).Select("title", "tags", "sentiment", "entities", "enriched_at").Updates(&h).Error; err != nil {
+			return err
+		}
+		return replaceTags(tx, h.ID, h.UserID, tags)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("咨询记录整理完成: HistoryID=%d, 标签=%v, 情绪=%s", h.ID, tags, out.Sentiment)
+	return nil
+}
+
+// SyncTags 按 CareerHistory.Tags 写入标签表，保存记录时调用
+func SyncTags(h *models.CareerHistory) error {
+	return db.Conn.Transaction(func(tx *gorm.DB) error {
+		return replaceTags(tx, h.ID, h.UserID, NormalizeTags(decodeTags(h.Tags)))
+	})
+}
+
+// Setup 为标签表上线前保存的咨询记录补全标签
+func Setup() {
+	var count int64
+	if err := db.Conn.Model(&models.CareerHistoryTag{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+	var histories []models.CareerHistory
+	if err := db.Conn.Select("id", "user_id", "tags").Where("tags <> '' AND tags <> '[]'").Find(&histories).Error; err != nil {
+		logger.Warn("补全咨询记录标签失败: %v", err)
+		return
+	}
+	for i := range histories {
+		if err := SyncTags(&histories[i]); err != nil {
+			logger.Warn("补全咨询记录标签失败: HistoryID=%d, 错误=%v", histories[i].ID, err)
+			return
+		}
+	}
+	if len(histories) > 0 {
+		logger.Info("已补全咨询记录标签: 记录数=%d", len(histories))
+	}
+}
+
+// TagCount 标签及使用次数
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+// TopTags 返回用户最常用的标签，category 非空时只统计该类别的记录
+func TopTags(userID, category string, limit int) ([]TagCount, error) {
+	q := db.Conn.Table("career_history_tags AS t").
+		Select("t.tag AS tag, COUNT(*) AS count").
+		Where("t.user_id = ?", userID)
+	if category != "" {
+		q = q.Joins("JOIN career_histories h ON h.id = t.history_id").Where("h.category = ?", category)
+	}
+	tags := []TagCount{}
+	err := q.Group("t.tag").Order("count DESC").Order("t.tag ASC").Limit(limit).Scan(&tags).Error
+	return tags, err
+}
+
+// NormalizeTag 规范化标签：去掉#号和空白，英文转小写，过长截断
+func NormalizeTag(tag string) string {
+	tag = strings.Trim(strings.TrimSpace(tag), "#＃")
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	return truncate(tag, maxTagRunes)
+}
+
+// NormalizeTags 规范化并去重，最多保留5个
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+		if len(result) == maxTags {
+			break
+		}
+	}
+	return result
+}
+
+func replaceTags(tx *gorm.DB, historyID uint, userID string, tags []string) error {
+	if err := tx.Where("history_id = ?", historyID).Delete(&models.CareerHistoryTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]models.CareerHistoryTag, len(tags))
+	for i, t := range tags {
+		rows[i] = models.CareerHistoryTag{HistoryID: historyID, UserID: userID, Tag: t}
+	}
+	return tx.Create(&rows).Error
+}
+
+// parseOutput 解析并校验模型输出
+func parseOutput(content string) (*Output, error) {
+	var out Output
+	if err := json.Unmarshal([]byte(utils.CleanJSONContent(content)), &out); err != nil {
+		return nil, fmt.Errorf("无法解析模型输出: %v", err)
+	}
+
+	out.Title = truncate(strings.TrimSpace(out.Title), maxTitleRunes)
+	out.Tags = NormalizeTags(out.Tags)
+	switch out.Sentiment = strings.ToLower(strings.TrimSpace(out.Sentiment)); out.Sentiment {
+	case "positive", "neutral", "negative":
+	default:
+		out.Sentiment = ""
+	}
+	out.Entities.Companies = cleanList(out.Entities.Companies)
+	out.Entities.Positions = cleanList(out.Entities.Positions)
+	out.Entities.Amounts = cleanList(out.Entities.Amounts)
+	return &out, nil
+}
+
+func cleanList(items []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, s := range items {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		result = append(result, s)
+		if len(result) == maxEntities {
+			break
+		}
+	}
+	return result
+}
+
+func decodeTags(raw string) []string {
+	var tags []string
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &tags)
+	}
+	return tags
+}
+
+// stripModelNote 去掉回复末尾的模型标注
+func stripModelNote(content string) string {
+	if i := strings.LastIndex(content, "\n\n[使用模型:"); i >= 0 {
+		return content[:i]
+	}
+	return content
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+// Package experiment 把对话请求按用户或会话确定性地分到实验分组，
+// 并结合用户评分、收藏、耗时和token用量统计各分组的效果
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+)
+
+// 实验状态
+const (
+	StatusDraft   = "draft"
+	StatusRunning = "running"
+	StatusStopped = "stopped"
+)
+
+// 分组单位
+const (
+	BucketUser   = "user"
+	BucketThread = "thread"
+)
+
+// Assignment 一次请求命中的实验分组
+type Assignment struct {
+	Experiment string
+	Variant    models.ExperimentVariant
+}
+
+// Assign 返回请求命中的实验分组，未命中时返回 nil；
+// 同时运行多个实验时按创建顺序取第一个命中的实验，一次请求只参与一个实验
+func Assign(userID, threadID, category string) *Assignment {
+	var running []models.Experiment
+	if err := db.Conn.Where("status = ?", StatusRunning).Order("id ASC").Find(&running).Error; err != nil {
+		logger.Error("加载运行中的实验失败: %v", err)
+		return nil
+	}
+	for i := range running {
+		e := &running[i]
+		if e.Category != "" && e.Category != category {
+			continue
+		}
+		if v, ok := Bucket(e, userID, threadID); ok {
+			return &Assignment{Experiment: e.Key, Variant: v}
+		}
+	}
+	return nil
+}
+
+// Bucket 计算分组，同一分组单位在实验配置不变时总是得到相同结果
+func Bucket(e *models.Experiment, userID, threadID string) (models.ExperimentVariant, bool) {
+	unit := userID
+	if e.BucketBy == BucketThread {
+		unit = threadID
+	}
+	if unit == "" || len(e.Variants) == 0 {
+		return models.ExperimentVariant{}, false
+	}
+
+	// 流量和分组使用不同的哈希，调整流量比例时已进入实验的单位不会换组
+	if hash(e.Key+":traffic:"+unit)%100 >= uint64(e.Traffic) {
+		return models.ExperimentVariant{}, false
+	}
+
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return models.ExperimentVariant{}, false
+	}
+	point := int(hash(e.Key+":variant:"+unit) % uint64(total))
+	for _, v := range e.Variants {
+		if point < v.Weight {
+			return v, true
+		}
+		point -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1], true
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Validate 校验实验配置并补全默认值
+func Validate(e *models.Experiment) error {
+	e.Key = strings.TrimSpace(e.Key)
+	if e.Key == "" {
+		return errors.New("实验标识不能为空")
+	}
+	if e.Status == "" {
+		e.Status = StatusDraft
+	}
+	if e.Status != StatusDraft && e.Status != StatusRunning && e.Status != StatusStopped {
+		return fmt.Errorf("无效的实验状态: %s", e.Status)
+	}
+	if e.BucketBy == "" {
+		e.BucketBy = BucketUser
+	}
+	if e.BucketBy != BucketUser && e.BucketBy != BucketThread {
+		return fmt.Errorf("无效的分组单位: %s", e.BucketBy)
+	}
+	if e.Traffic == 0 {
+		e.Traffic = 100
+	}
+	if e.Traffic < 0 || e.Traffic > 100 {
+		return errors.New("流量百分比需在1-100之间")
+	}
+
+	if len(e.Variants) < 2 {
+		return errors.New("实验至少需要两个分组")
+	}
+	names := map[string]bool{}
+	for i := range e.Variants {
+		v := &e.Variants[i]
+		v.Name = strings.TrimSpace(v.Name)
+		if v.Name == "" {
+			return fmt.Errorf("第%d个分组缺少名称", i+1)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("分组名称重复: %s", v.Name)
+		}
+		names[v.Name] = true
+		if v.Weight <= 0 {
+			return fmt.Errorf("分组 %s 的权重需大于0", v.Name)
+		}
+		if v.Temperature != nil && (*v.Temperature < 0 || *v.Temperature > 2) {
+			return fmt.Errorf("分组 %s 的温度需在0-2之间", v.Name)
+		}
+		for key, id := range v.Prompts {
+			if _, ok := prompts.Lookup(key); !ok {
+				return fmt.Errorf("分组 %s 引用了未知的提示词模板: %s", v.Name, key)
+			}
+			var count int64
+			db.Conn.Model(&models.PromptTemplate{}).Where("id = ? AND template_key = ?", id, key).Count(&count)
+			if count == 0 {
+				return fmt.Errorf("分组 %s 引用的提示词模板版本不存在: %s#%d", v.Name, key, id)
+			}
+		}
+	}
+	return nil
+}
+
+// Record 在助手消息上记录实验和分组，a 为 nil 时不做处理
+func (a *Assignment) Record(msg *models.Message) {
+	if a == nil {
+		return
+	}
+	msg.Experiment = a.Experiment
+	msg.Variant = a.Variant.Name
+}
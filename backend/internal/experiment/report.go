@@ -0,0 +1,196 @@
+package experiment
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+)
+
+// VariantReport 一个分组的效果统计
+type VariantReport struct {
+	Variant  string `json:"variant"`
+	Units    int    `json:"units"`    // 分到该组的用户或会话数
+	Messages int    `json:"messages"` // 助手回复数
+
+	AvgLatencyMs float64 `json:"avgLatencyMs"`
+	P50LatencyMs int64   `json:"p50LatencyMs"`
+	P95LatencyMs int64   `json:"p95LatencyMs"`
+
+	// token用量只统计模型返回了用量的回复
+	TokenSamples        int     `json:"tokenSamples"`
+	AvgPromptTokens     float64 `json:"avgPromptTokens"`
+	AvgCompletionTokens float64 `json:"avgCompletionTokens"`
+	EstimatedCost       float64 `json:"estimatedCost"` // 按 MODEL_TOKEN_PRICES 估算的费用（元），未配置单价的模型不计入
+
+	Rated        int     `json:"rated"`        // 有评分的回复数
+	AvgRating    float64 `json:"avgRating"`    // 平均评分 1-5
+	Satisfaction float64 `json:"satisfaction"` // 评分4分及以上的占比
+	Bookmarked   int     `json:"bookmarked"`
+	BookmarkRate float64 `json:"bookmarkRate"`
+}
+
+// Report 实验报告
+type Report struct {
+	Experiment  models.Experiment `json:"experiment"`
+	Variants    []VariantReport   `json:"variants"`
+	GeneratedAt time.Time         `json:"generatedAt"`
+}
+
+// outcome 一条助手回复及其用户反馈
+type outcome struct {
+	Variant          string
+	UserID           string
+	ThreadID         string
+	ModelID          string
+	LatencyMs        int64
+	PromptTokens     int
+	CompletionTokens int
+	Rating           int
+	IsBookmarked     bool
+}
+
+// BuildReport 汇总实验各分组的满意度、耗时和token成本，评分和收藏来自回复对应的咨询记录
+func BuildReport(e *models.Experiment) (*Report, error) {
+	var rows []outcome
+	err := db.Conn.Table("messages m").
+		Select("m.variant, m.user_id, m.thread_id, m.model_id, m.latency_ms, m.prompt_tokens, m.completion_tokens, "+
+			"COALESCE(h.rating, 0) AS rating, COALESCE(h.is_bookmarked, false) AS is_bookmarked").
+		Joins("LEFT JOIN career_histories h ON h.message_id = m.id").
+		Where("m.experiment = ? AND m.role = ?", e.Key, "assistant").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	grouped := map[string][]outcome{}
+	for _, row := range rows {
+		grouped[row.Variant] = append(grouped[row.Variant], row)
+	}
+
+	// 按配置顺序输出分组，已从配置中删除但有数据的分组排在最后
+	var names []string
+	seen := map[string]bool{}
+	for _, v := range e.Variants {
+		names = append(names, v.Name)
+		seen[v.Name] = true
+	}
+	var extra []string
+	for name := range grouped {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+
+	prices := parsePrices(config.C.ModelTokenPrices)
+	report := &Report{Experiment: *e, GeneratedAt: time.Now()}
+	for _, name := range names {
+		report.Variants = append(report.Variants, summarize(name, e.BucketBy, grouped[name], prices))
+	}
+	return report, nil
+}
+
+func summarize(name, bucketBy string, rows []outcome, prices map[string]price) VariantReport {
+	r := VariantReport{Variant: name, Messages: len(rows)}
+	if len(rows) == 0 {
+		return r
+	}
+
+	units := map[string]bool{}
+	latencies := make([]int64, 0, len(rows))
+	var latencySum int64
+	var promptSum, completionSum, ratingSum, satisfied int
+	for _, row := range rows {
+		if bucketBy == BucketThread {
+			units[row.ThreadID] = true
+		} else {
+			units[row.UserID] = true
+		}
+		latencies = append(latencies, row.LatencyMs)
+		latencySum += row.LatencyMs
+
+		if row.PromptTokens > 0 || row.CompletionTokens > 0 {
+			r.TokenSamples++
+			promptSum += row.PromptTokens
+			completionSum += row.CompletionTokens
+			if p, ok := prices[row.ModelID]; ok {
+				r.EstimatedCost += (float64(row.PromptTokens)*p.input + float64(row.CompletionTokens)*p.output) / 1000
+			}
+		}
+		if row.Rating > 0 {
+			r.Rated++
+			ratingSum += row.Rating
+			if row.Rating >= 4 {
+				satisfied++
+			}
+		}
+		if row.IsBookmarked {
+			r.Bookmarked++
+		}
+	}
+
+	r.Units = len(units)
+	r.AvgLatencyMs = round(float64(latencySum) / float64(len(rows)))
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	r.P50LatencyMs = percentile(latencies, 0.5)
+	r.P95LatencyMs = percentile(latencies, 0.95)
+	if r.TokenSamples > 0 {
+		r.AvgPromptTokens = round(float64(promptSum) / float64(r.TokenSamples))
+		r.AvgCompletionTokens = round(float64(completionSum) / float64(r.TokenSamples))
+	}
+	r.EstimatedCost = math.Round(r.EstimatedCost*10000) / 10000
+	if r.Rated > 0 {
+		r.AvgRating = round(float64(ratingSum) / float64(r.Rated))
+		r.Satisfaction = round(float64(satisfied) / float64(r.Rated))
+	}
+	r.BookmarkRate = round(float64(r.Bookmarked) / float64(len(rows)))
+	return r
+}
+
+// percentile 取已排序数据的近似分位数
+func percentile(sorted []int64, q float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(0, min(idx, len(sorted)-1))]
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// price 每千token单价（元）
+type price struct {
+	input  float64
+	output float64
+}
+
+// parsePrices 解析 "模型=输入单价/输出单价" 的逗号分隔列表，格式错误的项忽略
+func parsePrices(spec string) map[string]price {
+	prices := map[string]price{}
+	for _, item := range strings.Split(spec, ",") {
+		model, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		in, out, _ := strings.Cut(value, "/")
+		inPrice, err1 := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		outPrice, err2 := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err1 != nil {
+			continue
+		}
+		if err2 != nil {
+			outPrice = inPrice
+		}
+		prices[strings.TrimSpace(model)] = price{input: inPrice, output: outPrice}
+	}
+	return prices
+}
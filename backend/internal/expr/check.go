@@ -0,0 +1,171 @@
+package expr
+
+// Type 表达式值类型
+type Type int
+
+const (
+	TypeAny Type = iota // 类型未知，推迟到求值时检查
+	TypeNumber
+	TypeBool
+	TypeString
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeNumber:
+		return "number"
+	case TypeBool:
+		return "bool"
+	case TypeString:
+		return "string"
+	}
+	return "any"
+}
+
+// TypeOf 返回运行时值对应的类型
+func TypeOf(v interface{}) Type {
+	switch normalize(v).(type) {
+	case float64:
+		return TypeNumber
+	case bool:
+		return TypeBool
+	case string:
+		return TypeString
+	}
+	return TypeAny
+}
+
+// Schema 变量名到类型的映射；为 nil 时不检查变量是否存在
+type Schema map[string]Type
+
+// SchemaOf 根据变量取值推断 Schema
+func SchemaOf(vars map[string]interface{}) Schema {
+	schema := make(Schema, len(vars))
+	for name, v := range vars {
+		schema[name] = TypeOf(v)
+	}
+	return schema
+}
+
+// funcSig 内置函数签名
+type funcSig struct {
+	params []Type
+	result Type
+}
+
+var builtinSigs = map[string]funcSig{
+	"abs":        {params: []Type{TypeNumber}, result: TypeNumber},
+	"min":        {params: []Type{TypeNumber, TypeNumber}, result: TypeNumber},
+	"max":        {params: []Type{TypeNumber, TypeNumber}, result: TypeNumber},
+	"contains":   {params: []Type{TypeString, TypeString}, result: TypeBool},
+	"startsWith": {params: []Type{TypeString, TypeString}, result: TypeBool},
+	"lower":      {params: []Type{TypeString}, result: TypeString},
+	"len":        {params: []Type{TypeString}, result: TypeNumber},
+}
+
+// Check 对语法树做类型检查，返回表达式的结果类型和所有类型错误
+func Check(node Node, schema Schema) (Type, []*Error) {
+	c := &checker{schema: schema}
+	t := c.check(node)
+	return t, c.errs
+}
+
+type checker struct {
+	schema Schema
+	errs   []*Error
+}
+
+func (c *checker) fail(pos int, format string, args ...interface{}) {
+	c.errs = append(c.errs, errorf(pos, format, args...))
+}
+
+// expect 检查类型是否匹配，TypeAny 视为匹配
+func (c *checker) expect(node Node, got, want Type, what string) {
+	if got != TypeAny && got != want {
+		c.fail(node.Pos(), "%s需要 %s 类型，实际为 %s", what, want, got)
+	}
+}
+
+func (c *checker) check(node Node) Type {
+	switch n := node.(type) {
+	case *Literal:
+		return TypeOf(n.Value)
+
+	case *Ident:
+		if c.schema == nil {
+			return TypeAny
+		}
+		t, ok := c.schema[n.Name]
+		if !ok {
+			c.fail(n.pos, "未知的信号 %s", n.Name)
+			return TypeAny
+		}
+		return t
+
+	case *Unary:
+		t := c.check(n.Operand)
+		if n.Op == "!" {
+			c.expect(n.Operand, t, TypeBool, "运算符 ! ")
+			return TypeBool
+		}
+		c.expect(n.Operand, t, TypeNumber, "负号")
+		return TypeNumber
+
+	case *Binary:
+		lt := c.check(n.Left)
+		rt := c.check(n.Right)
+		switch n.Op {
+		case "&&", "||":
+			c.expect(n.Left, lt, TypeBool, "运算符 "+n.Op+" ")
+			c.expect(n.Right, rt, TypeBool, "运算符 "+n.Op+" ")
+			return TypeBool
+		case "==", "!=":
+			if lt != TypeAny && rt != TypeAny && lt != rt {
+				c.fail(n.pos, "无法比较 %s 和 %s", lt, rt)
+			}
+			return TypeBool
+		case "<", "<=", ">", ">=":
+			c.expect(n.Left, lt, TypeNumber, "运算符 "+n.Op+" ")
+			c.expect(n.Right, rt, TypeNumber, "运算符 "+n.Op+" ")
+			return TypeBool
+		case "+":
+			// + 同时支持数字相加和字符串拼接
+			if lt == TypeString || rt == TypeString {
+				c.expect(n.Left, lt, TypeString, "字符串拼接")
+				c.expect(n.Right, rt, TypeString, "字符串拼接")
+				return TypeString
+			}
+			c.expect(n.Left, lt, TypeNumber, "运算符 + ")
+			c.expect(n.Right, rt, TypeNumber, "运算符 + ")
+			if lt == TypeAny && rt == TypeAny {
+				return TypeAny
+			}
+			return TypeNumber
+		default:
+			c.expect(n.Left, lt, TypeNumber, "运算符 "+n.Op+" ")
+			c.expect(n.Right, rt, TypeNumber, "运算符 "+n.Op+" ")
+			return TypeNumber
+		}
+
+	case *Call:
+		sig, ok := builtinSigs[n.Func]
+		if !ok {
+			c.fail(n.pos, "未知的函数 %s", n.Func)
+			for _, arg := range n.Args {
+				c.check(arg)
+			}
+			return TypeAny
+		}
+		if len(n.Args) != len(sig.params) {
+			c.fail(n.pos, "函数 %s 需要 %d 个参数，实际为 %d 个", n.Func, len(sig.params), len(n.Args))
+		}
+		for i, arg := range n.Args {
+			t := c.check(arg)
+			if i < len(sig.params) {
+				c.expect(arg, t, sig.params[i], "函数 "+n.Func+" 的参数")
+			}
+		}
+		return sig.result
+	}
+	return TypeAny
+}
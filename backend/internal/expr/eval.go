@@ -0,0 +1,205 @@
+package expr
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+// Eval 使用变量取值对语法树求值
+func Eval(node Node, vars map[string]interface{}) (interface{}, error) {
+	switch n := node.(type) {
+	case *Literal:
+		return n.Value, nil
+
+	case *Ident:
+		v, ok := vars[n.Name]
+		if !ok {
+			return nil, errorf(n.pos, "快照中没有信号 %s", n.Name)
+		}
+		v = normalize(v)
+		if TypeOf(v) == TypeAny {
+			return nil, errorf(n.pos, "信号 %s 的类型不受支持", n.Name)
+		}
+		return v, nil
+
+	case *Unary:
+		v, err := Eval(n.Operand, vars)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == "!" {
+			b, err := asBool(n.Operand, v)
+			if err != nil {
+				return nil, err
+			}
+			return !b, nil
+		}
+		f, err := asNumber(n.Operand, v)
+		if err != nil {
+			return nil, err
+		}
+		return -f, nil
+
+	case *Binary:
+		return evalBinary(n, vars)
+
+	case *Call:
+		args := make([]interface{}, len(n.Args))
+		for i, arg := range n.Args {
+			v, err := Eval(arg, vars)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return callBuiltin(n, args)
+	}
+	return nil, errorf(node.Pos(), "无法求值的节点")
+}
+
+func evalBinary(n *Binary, vars map[string]interface{}) (interface{}, error) {
+	left, err := Eval(n.Left, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// 逻辑运算短路求值
+	if n.Op == "&&" || n.Op == "||" {
+		lb, err := asBool(n.Left, left)
+		if err != nil {
+			return nil, err
+		}
+		if (n.Op == "&&" && !lb) || (n.Op == "||" && lb) {
+			return lb, nil
+		}
+		right, err := Eval(n.Right, vars)
+		if err != nil {
+			return nil, err
+		}
+		return asBool(n.Right, right)
+	}
+
+	right, err := Eval(n.Right, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case "==", "!=":
+		if TypeOf(left) != TypeOf(right) {
+			return nil, errorf(n.pos, "无法比较 %s 和 %s", TypeOf(left), TypeOf(right))
+		}
+		return (left == right) == (n.Op == "=="), nil
+	case "+":
+		if ls, ok := left.(string); ok {
+			rs, ok := right.(string)
+			if !ok {
+				return nil, errorf(n.Right.Pos(), "字符串拼接需要 string 类型，实际为 %s", TypeOf(right))
+			}
+			return ls + rs, nil
+		}
+	}
+
+	lf, err := asNumber(n.Left, left)
+	if err != nil {
+		return nil, err
+	}
+	rf, err := asNumber(n.Right, right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.Op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, errorf(n.pos, "除数为零")
+		}
+		return lf / rf, nil
+	}
+	return nil, errorf(n.pos, "不支持的运算符 %s", n.Op)
+}
+
+func callBuiltin(n *Call, args []interface{}) (interface{}, error) {
+	sig, ok := builtinSigs[n.Func]
+	if !ok {
+		return nil, errorf(n.pos, "未知的函数 %s", n.Func)
+	}
+	if len(args) != len(sig.params) {
+		return nil, errorf(n.pos, "函数 %s 需要 %d 个参数，实际为 %d 个", n.Func, len(sig.params), len(args))
+	}
+	for i, want := range sig.params {
+		if TypeOf(args[i]) != want {
+			return nil, errorf(n.Args[i].Pos(), "函数 %s 的参数需要 %s 类型，实际为 %s", n.Func, want, TypeOf(args[i]))
+		}
+	}
+
+	switch n.Func {
+	case "abs":
+		return math.Abs(args[0].(float64)), nil
+	case "min":
+		return math.Min(args[0].(float64), args[1].(float64)), nil
+	case "max":
+		return math.Max(args[0].(float64), args[1].(float64)), nil
+	case "contains":
+		return strings.Contains(args[0].(string), args[1].(string)), nil
+	case "startsWith":
+		return strings.HasPrefix(args[0].(string), args[1].(string)), nil
+	case "lower":
+		return strings.ToLower(args[0].(string)), nil
+	case "len":
+		return float64(len([]rune(args[0].(string)))), nil
+	}
+	return nil, errorf(n.pos, "未实现的函数 %s", n.Func)
+}
+
+func asBool(node Node, v interface{}) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, errorf(node.Pos(), "需要 bool 类型，实际为 %s", TypeOf(v))
+	}
+	return b, nil
+}
+
+func asNumber(node Node, v interface{}) (float64, error) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, errorf(node.Pos(), "需要 number 类型，实际为 %s", TypeOf(v))
+	}
+	return f, nil
+}
+
+// normalize 将各种数字类型统一为 float64
+func normalize(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case uint:
+		return float64(n)
+	case json.Number:
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,95 @@
+// Package expr 实现告警规则使用的小型表达式语言
+//
+// 支持数字（含百分数 10%）、字符串、布尔字面量，信号变量引用，
+// 运算符 ! - * / + < <= > >= == != && ||（以及 and/or/not），括号，
+// 和内置函数 abs、min、max、contains、startsWith、lower、len。
+// 表达式只能读取传入的变量，没有赋值和循环，可安全执行用户编写的规则。
+//
+// 示例: revenue_yoy < -10% && layoffs_reported
+package expr
+
+import (
+	"errors"
+	"sort"
+)
+
+// Program 编译后的表达式
+type Program struct {
+	Source string
+	root   Node
+	vars   []string
+}
+
+// Compile 解析并检查表达式，结果必须是布尔值。schema 为 nil 时不检查变量是否存在。
+// 返回的错误均带有位置信息。
+func Compile(src string, schema Schema) (*Program, []*Error) {
+	root, err := Parse(src)
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			return nil, []*Error{e}
+		}
+		return nil, []*Error{{Pos: 1, Message: err.Error()}}
+	}
+
+	t, errs := Check(root, schema)
+	if len(errs) == 0 && t != TypeBool && t != TypeAny {
+		errs = append(errs, errorf(1, "规则表达式的结果必须是 bool 类型，实际为 %s", t))
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return &Program{Source: src, root: root, vars: collectIdents(root)}, nil
+}
+
+// Run 对变量求值，返回表达式是否成立
+func (p *Program) Run(vars map[string]interface{}) (bool, error) {
+	v, err := Eval(p.root, vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, errorf(1, "规则表达式的结果必须是 bool 类型，实际为 %s", TypeOf(v))
+	}
+	return b, nil
+}
+
+// Variables 表达式引用的变量名，按字母排序
+func (p *Program) Variables() []string {
+	return p.vars
+}
+
+// String 返回带完整括号的规范化表达式
+func (p *Program) String() string {
+	return p.root.String()
+}
+
+func collectIdents(root Node) []string {
+	seen := map[string]bool{}
+	var walk func(Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case *Ident:
+			seen[n.Name] = true
+		case *Unary:
+			walk(n.Operand)
+		case *Binary:
+			walk(n.Left)
+			walk(n.Right)
+		case *Call:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+	walk(root)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,229 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"10 - 4 - 3", "((10 - 4) - 3)"},
+		{"8 / 4 / 2", "((8 / 4) / 2)"},
+		{"-x * 2", "(-x * 2)"},
+		{"1 + 2 < 4 == true", "(((1 + 2) < 4) == true)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"!a && b", "(!a && b)"},
+		{"a and not b or c", "((a && !b) || c)"},
+		{"x > 1 && y <= 2 || z", "(((x > 1) && (y <= 2)) || z)"},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.src, err)
+			continue
+		}
+		if got := node.String(); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalNumbers(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"8 / 4 / 2", 1},
+		{"-2 * 3 + 1", -5},
+		// 百分数字面量按 1/100 换算
+		{"10%", 0.1},
+		{"12.5%", 0.125},
+		{"-10%", -0.1},
+		{"200% * 3", 6},
+		{".5%", 0.005},
+	}
+	for _, tt := range tests {
+		node, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.src, err)
+			continue
+		}
+		v, err := Eval(node, nil)
+		if err != nil {
+			t.Errorf("Eval(%q) error: %v", tt.src, err)
+			continue
+		}
+		if f, ok := v.(float64); !ok || math.Abs(f-tt.want) > 1e-12 {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, v, tt.want)
+		}
+	}
+}
+
+func TestRunPercentCondition(t *testing.T) {
+	program, errs := Compile("revenue_yoy < -10% && layoffs_reported", Schema{"revenue_yoy": TypeNumber, "layoffs_reported": TypeBool})
+	if len(errs) > 0 {
+		t.Fatalf("Compile errors: %v", errs)
+	}
+	tests := []struct {
+		yoy  interface{}
+		want bool
+	}{
+		{-0.15, true},
+		{-0.1, false},
+		{0.05, false},
+		{-1, true}, // 整数取值按 float64 比较
+	}
+	for _, tt := range tests {
+		got, err := program.Run(map[string]interface{}{"revenue_yoy": tt.yoy, "layoffs_reported": true})
+		if err != nil {
+			t.Errorf("Run(revenue_yoy=%v) error: %v", tt.yoy, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Run(revenue_yoy=%v) = %v, want %v", tt.yoy, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	schema := Schema{"revenue": TypeNumber, "flag": TypeBool, "name": TypeString}
+	tests := []struct {
+		src     string
+		pos     int
+		message string
+	}{
+		// 类型错误
+		{`revenue > "a"`, 11, "运算符 > 需要 number 类型，实际为 string"},
+		{"!revenue", 2, "运算符 ! 需要 bool 类型，实际为 number"},
+		{"revenue && flag", 1, "运算符 && 需要 bool 类型，实际为 number"},
+		{"flag || name", 9, "运算符 || 需要 bool 类型，实际为 string"},
+		{"flag == 1", 6, "无法比较 bool 和 number"},
+		{"-name == 1", 2, "负号需要 number 类型，实际为 string"},
+		{`abs("x") > 1`, 5, "函数 abs 的参数需要 number 类型，实际为 string"},
+		{"min(revenue) > 1", 1, "函数 min 需要 2 个参数，实际为 1 个"},
+		{"revenue + 1", 1, "规则表达式的结果必须是 bool 类型，实际为 number"},
+		// 未知的标识符
+		{"unknown_signal > 0", 1, "未知的信号 unknown_signal"},
+		{"flag && revenue_qoq < 0", 9, "未知的信号 revenue_qoq"},
+		{"foo(revenue) > 0", 1, "未知的函数 foo"},
+		// 语法错误
+		{"revenue >", 10, "表达式不完整"},
+		{"(revenue > 1", 13, "缺少右括号"},
+		{"revenue = 1", 9, "无效的运算符 '='"},
+		{"revenue > 1 2", 13, "多余的内容"},
+		{`name == "abc`, 9, "字符串缺少结束引号"},
+		{"   ", 1, "表达式为空"},
+	}
+	for _, tt := range tests {
+		_, errs := Compile(tt.src, schema)
+		if len(errs) != 1 {
+			t.Errorf("Compile(%q) errors = %v, want exactly one", tt.src, errs)
+			continue
+		}
+		if errs[0].Pos != tt.pos || !strings.Contains(errs[0].Message, tt.message) {
+			t.Errorf("Compile(%q) error = %v, want 位置 %d: %s", tt.src, errs[0], tt.pos, tt.message)
+		}
+	}
+}
+
+func TestCompileCollectsAllTypeErrors(t *testing.T) {
+	_, errs := Compile(`missing > 0 && revenue > "a"`, Schema{"revenue": TypeNumber})
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want 2", errs)
+	}
+	if errs[0].Pos != 1 || errs[1].Pos != 26 {
+		t.Errorf("positions = %d, %d, want 1, 26", errs[0].Pos, errs[1].Pos)
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	tests := []struct {
+		src     string
+		want    bool
+		wantErr string
+	}{
+		// 右侧不会被求值，缺失的信号和除零都不报错
+		{"false && missing", false, ""},
+		{"true || missing", true, ""},
+		{"x > 5 && 1 / zero > 0", false, ""},
+		{"x < 5 || 1 / zero > 0", true, ""},
+		{"false and missing", false, ""},
+		// 左侧不能决定结果时才求值右侧
+		{"true && missing", false, "位置 9: 快照中没有信号 missing"},
+		{"false || missing", false, "位置 10: 快照中没有信号 missing"},
+		{"x < 5 && 1 / zero > 0", false, "位置 12: 除数为零"},
+	}
+	vars := map[string]interface{}{"x": 1, "zero": 0}
+	for _, tt := range tests {
+		program, errs := Compile(tt.src, nil)
+		if len(errs) > 0 {
+			t.Errorf("Compile(%q) errors: %v", tt.src, errs)
+			continue
+		}
+		got, err := program.Run(vars)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Run(%q) error = %v, want %s", tt.src, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Run(%q) = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []struct {
+		src  string
+		vars map[string]interface{}
+		pos  int
+	}{
+		{"x / 0 > 1", map[string]interface{}{"x": 1}, 3},
+		{"x / y > 1", map[string]interface{}{"x": 1.5, "y": 0}, 3},
+		{"1 + x / (y - y) > 1", map[string]interface{}{"x": 1, "y": 2}, 7},
+		{"0 / 0 == 0", nil, 3},
+	}
+	for _, tt := range tests {
+		program, errs := Compile(tt.src, nil)
+		if len(errs) > 0 {
+			t.Errorf("Compile(%q) errors: %v", tt.src, errs)
+			continue
+		}
+		_, err := program.Run(tt.vars)
+		e, ok := err.(*Error)
+		if !ok || e.Pos != tt.pos || e.Message != "除数为零" {
+			t.Errorf("Run(%q) error = %v, want 位置 %d: 除数为零", tt.src, err, tt.pos)
+		}
+	}
+}
+
+func TestRunUnknownIdentifier(t *testing.T) {
+	// schema 为 nil 时编译不检查变量，缺失的信号在求值时报错
+	program, errs := Compile("revenue > 0 && headcount_yoy < -5%", nil)
+	if len(errs) > 0 {
+		t.Fatalf("Compile errors: %v", errs)
+	}
+	if got := strings.Join(program.Variables(), ","); got != "headcount_yoy,revenue" {
+		t.Errorf("Variables() = %s", got)
+	}
+
+	_, err := program.Run(map[string]interface{}{"revenue": 10})
+	if e, ok := err.(*Error); !ok || e.Pos != 16 || e.Message != "快照中没有信号 headcount_yoy" {
+		t.Errorf("Run error = %v, want 位置 16: 快照中没有信号 headcount_yoy", err)
+	}
+
+	_, err = program.Run(map[string]interface{}{"revenue": []int{1}, "headcount_yoy": 0})
+	if e, ok := err.(*Error); !ok || e.Pos != 1 || e.Message != "信号 revenue 的类型不受支持" {
+		t.Errorf("Run error = %v, want 位置 1: 信号 revenue 的类型不受支持", err)
+	}
+}
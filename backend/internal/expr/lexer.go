@@ -0,0 +1,166 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokTrue
+	tokFalse
+	tokOp // 运算符
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+// token 词法单元，Pos 为从1开始的字符位置
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Error 表达式错误，Pos 为从1开始的字符位置，便于前端标注
+type Error struct {
+	Pos     int    `json:"pos"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("位置 %d: %s", e.Pos, e.Message)
+}
+
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// twoCharOps 双字符运算符，需优先于单字符匹配
+var twoCharOps = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+const singleCharOps = "!<>+-*/"
+
+// lex 将表达式切分为词法单元
+func lex(src string) ([]token, error) {
+	runes := []rune(src)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			seenDot := false
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || (runes[i] == '.' && !seenDot)) {
+				if runes[i] == '.' {
+					seenDot = true
+				}
+				i++
+			}
+			// 百分数写法: 10% 等价于 0.1
+			if i < len(runes) && runes[i] == '%' {
+				tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]) + "%", pos: pos})
+				i++
+				continue
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: pos})
+
+		case r == '"' || r == '\'':
+			quote := r
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == quote {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, errorf(pos, "字符串缺少结束引号")
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: pos})
+
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '.' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			text := string(runes[start:i])
+			switch text {
+			case "true":
+				tokens = append(tokens, token{kind: tokTrue, text: text, pos: pos})
+			case "false":
+				tokens = append(tokens, token{kind: tokFalse, text: text, pos: pos})
+			case "and":
+				tokens = append(tokens, token{kind: tokOp, text: "&&", pos: pos})
+			case "or":
+				tokens = append(tokens, token{kind: tokOp, text: "||", pos: pos})
+			case "not":
+				tokens = append(tokens, token{kind: tokOp, text: "!", pos: pos})
+			default:
+				tokens = append(tokens, token{kind: tokIdent, text: text, pos: pos})
+			}
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: pos})
+			i++
+
+		default:
+			matched := false
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				for _, op := range twoCharOps {
+					if pair == op {
+						tokens = append(tokens, token{kind: tokOp, text: op, pos: pos})
+						i += 2
+						matched = true
+						break
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			if strings.ContainsRune(singleCharOps, r) {
+				tokens = append(tokens, token{kind: tokOp, text: string(r), pos: pos})
+				i++
+				continue
+			}
+			if r == '=' {
+				return nil, errorf(pos, "无效的运算符 '='，比较相等请使用 '=='")
+			}
+			return nil, errorf(pos, "无法识别的字符 %q", r)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
@@ -0,0 +1,236 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Node 语法树节点
+type Node interface {
+	Pos() int
+	String() string
+}
+
+// Literal 字面量：数字、字符串或布尔值
+type Literal struct {
+	Value interface{}
+	pos   int
+}
+
+// Ident 变量引用，对应快照中的信号名称
+type Ident struct {
+	Name string
+	pos  int
+}
+
+// Unary 一元运算: !x, -x
+type Unary struct {
+	Op      string
+	Operand Node
+	pos     int
+}
+
+// Binary 二元运算
+type Binary struct {
+	Op          string
+	Left, Right Node
+	pos         int
+}
+
+// Call 函数调用
+type Call struct {
+	Func string
+	Args []Node
+	pos  int
+}
+
+func (n *Literal) Pos() int { return n.pos }
+func (n *Ident) Pos() int   { return n.pos }
+func (n *Unary) Pos() int   { return n.pos }
+func (n *Binary) Pos() int  { return n.pos }
+func (n *Call) Pos() int    { return n.pos }
+
+func (n *Literal) String() string {
+	switch v := n.Value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return "?"
+}
+
+func (n *Ident) String() string { return n.Name }
+func (n *Unary) String() string { return n.Op + n.Operand.String() }
+func (n *Binary) String() string {
+	return "(" + n.Left.String() + " " + n.Op + " " + n.Right.String() + ")"
+}
+func (n *Call) String() string {
+	args := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		args[i] = a.String()
+	}
+	return n.Func + "(" + strings.Join(args, ", ") + ")"
+}
+
+// binaryPrecedence 二元运算符优先级，数字越大结合越紧
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+// maxDepth 限制嵌套深度，防止恶意表达式耗尽栈空间
+const maxDepth = 64
+
+type parser struct {
+	tokens []token
+	cur    int
+	depth  int
+}
+
+// Parse 解析表达式为语法树
+func Parse(src string) (Node, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, errorf(1, "表达式为空")
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseExpr(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, errorf(tok.pos, "多余的内容 %q", tok.text)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.cur] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.cur]
+	if tok.kind != tokEOF {
+		p.cur++
+	}
+	return tok
+}
+
+// parseExpr 优先级爬升解析二元表达式
+func (p *parser) parseExpr(minPrec int) (Node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, errorf(p.peek().pos, "表达式嵌套过深")
+	}
+
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := binaryPrecedence[tok.text]
+		if tok.kind != tokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseExpr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Op: tok.text, Left: left, Right: right, pos: tok.pos}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxDepth {
+			return nil, errorf(tok.pos, "表达式嵌套过深")
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: tok.text, Operand: operand, pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		text := tok.text
+		scale := 1.0
+		if strings.HasSuffix(text, "%") {
+			text = strings.TrimSuffix(text, "%")
+			scale = 0.01
+		}
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errorf(tok.pos, "无效的数字 %q", tok.text)
+		}
+		return &Literal{Value: v * scale, pos: tok.pos}, nil
+	case tokString:
+		return &Literal{Value: tok.text, pos: tok.pos}, nil
+	case tokTrue:
+		return &Literal{Value: true, pos: tok.pos}, nil
+	case tokFalse:
+		return &Literal{Value: false, pos: tok.pos}, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		return &Ident{Name: tok.text, pos: tok.pos}, nil
+	case tokLParen:
+		node, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "缺少右括号")
+		}
+		return node, nil
+	case tokEOF:
+		return nil, errorf(tok.pos, "表达式不完整")
+	}
+	return nil, errorf(tok.pos, "意外的 %q", tok.text)
+}
+
+func (p *parser) parseCall(name token) (Node, error) {
+	p.next() // (
+	call := &Call{Func: name.text, pos: name.pos}
+	if p.peek().kind == tokRParen {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+
+		tok := p.next()
+		switch tok.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return call, nil
+		}
+		return nil, errorf(tok.pos, "函数 %s 的参数列表缺少右括号", name.text)
+	}
+}
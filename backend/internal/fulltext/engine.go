@@ -0,0 +1,171 @@
+package fulltext
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+)
+
+// engine 数据库相关的全文检索实现
+type engine interface {
+	name() string
+	// setup 建立索引，返回错误时退回到 LIKE 检索
+	setup() error
+	// minTermLength 全文索引能匹配的最短检索词（字符数），更短的检索词使用 LIKE
+	minTermLength() int
+	// clause 生成全文匹配条件，ok 为 false 表示该类对象无法使用全文索引
+	clause(src *source, terms []string) (c fullTextClause, ok bool)
+}
+
+// fullTextClause 全文匹配的 SQL 片段，主表别名为 t
+type fullTextClause struct {
+	join      string
+	where     string
+	whereArgs []interface{}
+	score     string
+	scoreArgs []interface{}
+}
+
+// likeEngine 不依赖全文索引的兜底实现
+type likeEngine struct{}
+
+func (likeEngine) name() string                                    { return "like" }
+func (likeEngine) setup() error                                    { return nil }
+func (likeEngine) minTermLength() int                              { return 0 }
+func (likeEngine) clause(*source, []string) (fullTextClause, bool) { return fullTextClause{}, false }
+
+// sqliteEngine 基于 FTS5 外部内容表，trigram 分词
+type sqliteEngine struct{}
+
+func (sqliteEngine) name() string       { return "sqlite-fts5" }
+func (sqliteEngine) minTermLength() int { return 3 }
+
+func ftsTable(src *source) string { return "fts_" + src.Table }
+
+func (sqliteEngine) setup() error {
+	var enabled int
+	if err := db.Conn.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error; err != nil {
+		return err
+	}
+	if enabled == 0 {
+		// 未启用 FTS5 时删除之前建立的触发器，否则写入主表会因缺少模块而失败
+		for _, src := range sources {
+			for _, suffix := range []string{"ai", "ad", "au"} {
+				db.Conn.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %s_%s", ftsTable(src), suffix))
+			}
+		}
+		return fmt.Errorf("SQLite 未启用 FTS5，请使用 -tags sqlite_fts5 编译")
+	}
+
+	for _, src := range sources {
+		if err := setupFTSTable(src); err != nil {
+			return fmt.Errorf("%s: %w", src.Table, err)
+		}
+	}
+	return nil
+}
+
+func setupFTSTable(src *source) error {
+	fts := ftsTable(src)
+	cols := strings.Join(src.Columns, ", ")
+	newCols := "new." + strings.Join(src.Columns, ", new.")
+	oldCols := "old." + strings.Join(src.Columns, ", old.")
+
+	if err := db.Conn.Exec(fmt.Sprintf(
+		"CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content='%s', content_rowid='id', tokenize='trigram')",
+		fts, cols, src.Table)).Error; err != nil {
+		return err
+	}
+
+	// 触发器缺失说明索引是新建的或在未启用 FTS5 时被删除过，需要重建索引
+	var triggers int64
+	db.Conn.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?", fts+"_au").Scan(&triggers)
+	if triggers > 0 {
+		return nil
+	}
+
+	statements := []string{
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_ai AFTER INSERT ON %[2]s BEGIN INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[4]s); END",
+			fts, src.Table, cols, newCols),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_ad AFTER DELETE ON %[2]s BEGIN INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s); END",
+			fts, src.Table, cols, oldCols),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %[1]s_au AFTER UPDATE ON %[2]s BEGIN INSERT INTO %[1]s(%[1]s, rowid, %[3]s) VALUES ('delete', old.id, %[4]s); INSERT INTO %[1]s(rowid, %[3]s) VALUES (new.id, %[5]s); END",
+			fts, src.Table, cols, oldCols, newCols),
+		fmt.Sprintf("INSERT INTO %[1]s(%[1]s) VALUES ('rebuild')", fts),
+	}
+	for _, stmt := range statements {
+		if err := db.Conn.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	logger.Info("已建立全文索引: 表=%s", fts)
+	return nil
+}
+
+func (sqliteEngine) clause(src *source, terms []string) (fullTextClause, bool) {
+	fts := ftsTable(src)
+	phrases := make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = `"` + term + `"`
+	}
+	return fullTextClause{
+		join:      fmt.Sprintf(" JOIN %[1]s ON %[1]s.rowid = t.id", fts),
+		where:     fts + " MATCH ?",
+		whereArgs: []interface{}{strings.Join(phrases, " ")},
+		score:     fmt.Sprintf("-bm25(%s)", fts),
+	}, true
+}
+
+// mysqlEngine 基于 ngram 分词的 FULLTEXT 索引
+type mysqlEngine struct {
+	ready map[string]bool
+}
+
+func newMySQLEngine() *mysqlEngine {
+	return &mysqlEngine{ready: map[string]bool{}}
+}
+
+func (*mysqlEngine) name() string       { return "mysql-ngram" }
+func (*mysqlEngine) minTermLength() int { return 2 }
+
+func ftIndex(src *source) string { return "ft_" + src.Table }
+
+func (e *mysqlEngine) setup() error {
+	for _, src := range sources {
+		index := ftIndex(src)
+		var exists int64
+		db.Conn.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?",
+			src.Table, index).Scan(&exists)
+		if exists == 0 {
+			stmt := fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s) WITH PARSER ngram", src.Table, index, strings.Join(src.Columns, ", "))
+			if err := db.Conn.Exec(stmt).Error; err != nil {
+				// 单张表建索引失败不影响其他表，该表退回到 LIKE 检索
+				logger.Warn("建立全文索引失败: 表=%s, 错误=%v", src.Table, err)
+				continue
+			}
+			logger.Info("已建立全文索引: 表=%s, 索引=%s", src.Table, index)
+		}
+		e.ready[src.Table] = true
+	}
+	return nil
+}
+
+func (e *mysqlEngine) clause(src *source, terms []string) (fullTextClause, bool) {
+	if !e.ready[src.Table] {
+		return fullTextClause{}, false
+	}
+	words := make([]string, len(terms))
+	for i, term := range terms {
+		words[i] = `+"` + term + `"`
+	}
+	against := strings.Join(words, " ")
+	match := fmt.Sprintf("MATCH(t.%s) AGAINST(? IN BOOLEAN MODE)", strings.Join(src.Columns, ", t."))
+	return fullTextClause{
+		where:     match,
+		whereArgs: []interface{}{against},
+		score:     match,
+		scoreArgs: []interface{}{against},
+	}, true
+}
@@ -0,0 +1,225 @@
+// Package fulltext 提供跨消息、笔记、职业咨询记录和文档的全文检索
+//
+// MySQL 使用 ngram 分词的 FULLTEXT 索引，SQLite 使用 trigram 分词的 FTS5 虚拟表，
+// 两者都按字符 n-gram 切分中文，无需额外的分词词典。短于 n-gram 长度的检索词、
+// 以及数据库不支持全文索引时，退回到 LIKE 匹配。
+package fulltext
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+)
+
+// 检索对象类型
+const (
+	TypeMessage       = "message"
+	TypeNote          = "note"
+	TypeCareerHistory = "career_history"
+	TypeDocument      = "document"
+)
+
+// 检索限制
+const (
+	maxTerms      = 8
+	maxCandidates = 1000 // 每类对象参与排序的最大候选数
+	maxPageSize   = 50
+)
+
+// ErrEmptyQuery 检索词为空
+var ErrEmptyQuery = errors.New("检索内容不能为空")
+
+// source 一类可检索的对象
+type source struct {
+	Type    string
+	Label   string
+	Table   string
+	Columns []string // 参与检索的列，按展示摘要的优先级排列
+	// userClause 用户过滤条件，t 为表别名
+	userClause string
+}
+
+var sources = []*source{
+	{Type: TypeMessage, Label: "对话消息", Table: "messages", Columns: []string{"content"}, userClause: "t.user_id = ?"},
+	{Type: TypeNote, Label: "笔记", Table: "notes", Columns: []string{"title", "content"}, userClause: "t.user_id = ?"},
+	{Type: TypeCareerHistory, Label: "咨询记录", Table: "career_histories", Columns: []string{"title", "content", "ai_response"}, userClause: "t.user_id = ?"},
+	{Type: TypeDocument, Label: "文档", Table: "user_documents", Columns: []string{"file_name", "file_content"}, userClause: "t.user_id = ?"},
+}
+
+// ValidType 是否为支持的检索对象类型
+func ValidType(t string) bool {
+	for _, src := range sources {
+		if src.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Query 检索请求
+type Query struct {
+	Text     string
+	Types    []string // 为空时检索全部类型
+	Page     int
+	PageSize int
+}
+
+// Hit 一条检索结果
+type Hit struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Field     string    `json:"field"`   // 命中的字段
+	Snippet   string    `json:"snippet"` // 命中片段，检索词用 <mark> 标注，其余内容已做 HTML 转义
+	ThreadID  string    `json:"threadId,omitempty"`
+	Score     float64   `json:"score"` // 同类对象内归一化后的相关度，0-1
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Facet 按类型统计的命中数
+type Facet struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+// Result 检索结果
+type Result struct {
+	Query    string   `json:"query"`
+	Terms    []string `json:"terms"`
+	Engine   string   `json:"engine"`
+	Total    int64    `json:"total"`
+	Page     int      `json:"page"`
+	PageSize int      `json:"pageSize"`
+	Facets   []Facet  `json:"facets"`
+	Hits     []Hit    `json:"hits"`
+}
+
+var (
+	current engine = likeEngine{}
+	setupMu sync.Mutex
+)
+
+// Setup 根据数据库类型建立全文索引，需在表迁移完成后调用
+func Setup() {
+	setupMu.Lock()
+	defer setupMu.Unlock()
+
+	var e engine
+	switch db.Driver {
+	case db.DriverMySQL:
+		e = newMySQLEngine()
+	case db.DriverSQLite:
+		e = sqliteEngine{}
+	default:
+		e = likeEngine{}
+	}
+	if err := e.setup(); err != nil {
+		logger.Warn("建立全文索引失败，退回到LIKE检索: 引擎=%s, 错误=%v", e.name(), err)
+		e = likeEngine{}
+	}
+	current = e
+	logger.Info("全文检索已就绪: 引擎=%s", e.name())
+}
+
+// Search 检索用户的消息、笔记、咨询记录和文档
+func Search(userID string, q Query) (*Result, error) {
+	terms := ParseTerms(q.Text)
+	if len(terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 20
+	}
+	q.PageSize = min(q.PageSize, maxPageSize)
+	limit := min(q.Page*q.PageSize, maxCandidates)
+
+	result := &Result{
+		Query:    q.Text,
+		Terms:    terms,
+		Engine:   current.name(),
+		Page:     q.Page,
+		PageSize: q.PageSize,
+		Facets:   []Facet{},
+		Hits:     []Hit{},
+	}
+
+	var candidates []candidate
+	for _, src := range sources {
+		if len(q.Types) > 0 && !containsString(q.Types, src.Type) {
+			continue
+		}
+		found, count, err := match(current, src, userID, terms, limit)
+		if err != nil {
+			return nil, err
+		}
+		result.Facets = append(result.Facets, Facet{Type: src.Type, Label: src.Label, Count: count})
+		result.Total += count
+		candidates = append(candidates, found...)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].UpdatedAt.After(candidates[j].UpdatedAt)
+	})
+	offset := (q.Page - 1) * q.PageSize
+	if offset >= len(candidates) {
+		return result, nil
+	}
+	page := candidates[offset:min(offset+q.PageSize, len(candidates))]
+
+	hits, err := loadHits(page, terms)
+	if err != nil {
+		return nil, err
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+// ParseTerms 将检索内容按空白切分为检索词，去掉会破坏短语匹配的引号等字符
+func ParseTerms(text string) []string {
+	var terms []string
+	seen := map[string]bool{}
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		term := strings.Map(func(r rune) rune {
+			switch r {
+			case '"', '*', '(', ')':
+				return -1
+			}
+			return r
+		}, field)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) >= maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,221 @@
+package fulltext
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+)
+
+// 摘要长度（字符数）
+const (
+	snippetLength  = 120
+	snippetLeading = 30 // 命中位置之前保留的字符数
+	titleLength    = 40
+)
+
+// field 记录中可用于生成摘要的字段
+type field struct {
+	name string
+	text string
+}
+
+// record 命中记录的展示信息
+type record struct {
+	title     string
+	threadID  string
+	updatedAt time.Time
+	fields    []field
+}
+
+// loadHits 读取命中记录并生成高亮摘要，保持候选顺序
+func loadHits(page []candidate, terms []string) ([]Hit, error) {
+	ids := map[string][]uint{}
+	for _, c := range page {
+		ids[c.Type] = append(ids[c.Type], c.ID)
+	}
+	records := map[string]map[uint]record{}
+	for t, list := range ids {
+		loaded, err := loadRecords(t, list)
+		if err != nil {
+			return nil, err
+		}
+		records[t] = loaded
+	}
+
+	hits := make([]Hit, 0, len(page))
+	for _, c := range page {
+		rec, ok := records[c.Type][c.ID]
+		if !ok {
+			continue // 检索后被删除
+		}
+		name, snippet := bestField(rec.fields, terms)
+		hits = append(hits, Hit{
+			Type:      c.Type,
+			ID:        c.ID,
+			Title:     rec.title,
+			Field:     name,
+			Snippet:   snippet,
+			ThreadID:  rec.threadID,
+			Score:     c.Score,
+			UpdatedAt: rec.updatedAt,
+		})
+	}
+	return hits, nil
+}
+
+func loadRecords(t string, ids []uint) (map[uint]record, error) {
+	result := map[uint]record{}
+	switch t {
+	case TypeMessage:
+		var rows []models.Message
+		if err := db.Conn.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, m := range rows {
+			result[m.ID] = record{title: truncate(m.Content, titleLength), threadID: m.ThreadID, updatedAt: m.UpdatedAt,
+				fields: []field{{"content", m.Content}}}
+		}
+	case TypeNote:
+		var rows []models.Note
+		if err := db.Conn.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, n := range rows {
+			result[n.ID] = record{title: n.Title, updatedAt: n.UpdatedAt,
+				fields: []field{{"title", n.Title}, {"content", n.Content}}}
+		}
+	case TypeCareerHistory:
+		var rows []models.CareerHistory
+		if err := db.Conn.Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, h := range rows {
+			result[h.ID] = record{title: h.Title, threadID: h.ThreadID, updatedAt: h.UpdatedAt,
+				fields: []field{{"title", h.Title}, {"content", h.Content}, {"aiResponse", h.AIResponse}}}
+		}
+	case TypeDocument:
+		var rows []models.UserDocument
+		if err := db.Conn.Select("id", "file_name", "file_content", "updated_at").Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, d := range rows {
+			result[d.ID] = record{title: d.FileName, updatedAt: d.UpdatedAt,
+				fields: []field{{"fileName", d.FileName}, {"fileContent", d.FileContent}}}
+		}
+	}
+	return result, nil
+}
+
+// bestField 选出命中检索词最多的字段并生成摘要，都未命中时使用第一个非空字段
+func bestField(fields []field, terms []string) (string, string) {
+	best, bestCount := -1, 0
+	for i, f := range fields {
+		count := 0
+		lower := strings.ToLower(f.text)
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	if best < 0 {
+		for i, f := range fields {
+			if strings.TrimSpace(f.text) != "" {
+				best = i
+				break
+			}
+		}
+	}
+	if best < 0 {
+		return "", ""
+	}
+	return fields[best].name, Highlight(fields[best].text, terms, snippetLength)
+}
+
+// Highlight 截取首个命中位置附近的片段，HTML 转义后用 <mark> 标注检索词
+func Highlight(text string, terms []string, length int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	// 标记每个字符是否属于某个检索词
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		tr := []rune(term)
+		if len(tr) == 0 {
+			continue
+		}
+		for i := 0; i+len(tr) <= len(lower); i++ {
+			if !runesEqual(lower[i:i+len(tr)], tr) {
+				continue
+			}
+			for j := i; j < i+len(tr); j++ {
+				marked[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+			i += len(tr) - 1
+		}
+	}
+
+	start := 0
+	if first > snippetLeading {
+		start = first - snippetLeading
+	}
+	end := min(start+length, len(runes))
+	if end-start < length {
+		start = max(0, end-length)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	inMark := false
+	for i := start; i < end; i++ {
+		if marked[i] != inMark {
+			if marked[i] {
+				b.WriteString("<mark>")
+			} else {
+				b.WriteString("</mark>")
+			}
+			inMark = marked[i]
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+	}
+	if inMark {
+		b.WriteString("</mark>")
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,93 @@
+package fulltext
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/db"
+)
+
+// candidate 参与排序的命中记录
+type candidate struct {
+	Type      string
+	ID        uint
+	Score     float64
+	UpdatedAt time.Time
+}
+
+// match 在一类对象中检索，返回按相关度排序的前 limit 条候选及命中总数
+func match(e engine, src *source, userID string, terms []string, limit int) ([]candidate, int64, error) {
+	var long, short []string
+	for _, term := range terms {
+		if runeLen(term) >= e.minTermLength() {
+			long = append(long, term)
+		} else {
+			short = append(short, term)
+		}
+	}
+
+	from := src.Table + " t"
+	where := []string{src.userClause}
+	whereArgs := []interface{}{userID}
+	score := "1"
+	var scoreArgs []interface{}
+
+	if len(long) > 0 {
+		if c, ok := e.clause(src, long); ok {
+			from += c.join
+			where = append(where, c.where)
+			whereArgs = append(whereArgs, c.whereArgs...)
+			score = c.score
+			scoreArgs = c.scoreArgs
+		} else {
+			short = terms
+		}
+	}
+	for _, term := range short {
+		pattern := "%" + escapeLike(term) + "%"
+		likes := make([]string, len(src.Columns))
+		for i, col := range src.Columns {
+			likes[i] = fmt.Sprintf("t.%s LIKE ? ESCAPE '!'", col)
+			whereArgs = append(whereArgs, pattern)
+		}
+		where = append(where, "("+strings.Join(likes, " OR ")+")")
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int64
+	if err := db.Conn.Raw("SELECT COUNT(*) FROM "+from+" WHERE "+whereSQL, whereArgs...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	var rows []candidate
+	args := append(append(append([]interface{}{}, scoreArgs...), whereArgs...), limit)
+	err := db.Conn.Raw("SELECT t.id AS id, t.updated_at AS updated_at, "+score+" AS score FROM "+from+
+		" WHERE "+whereSQL+" ORDER BY score DESC, t.updated_at DESC LIMIT ?", args...).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 不同数据库、不同表的原始分值不可比，按本类最高分归一化
+	maxScore := 0.0
+	for _, row := range rows {
+		maxScore = max(maxScore, row.Score)
+	}
+	for i := range rows {
+		rows[i].Type = src.Type
+		if maxScore > 0 {
+			rows[i].Score = float64(int(rows[i].Score/maxScore*1000+0.5)) / 1000
+		} else {
+			rows[i].Score = 1
+		}
+	}
+	return rows, total, nil
+}
+
+// escapeLike 转义 LIKE 通配符，转义字符为 !
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/rag"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 提取出的文本少于该长度的PDF视为扫描件
+const scannedPDFMinText = 20
+
+// chatAttachmentTypes 对话中可以直接上传的附件类型及保存时的扩展名
+var chatAttachmentTypes = map[string]string{
+	"image/png":       "png",
+	"image/jpeg":      "jpg",
+	"image/gif":       "gif",
+	"image/webp":      "webp",
+	"application/pdf": "pdf",
+}
+
+var (
+	errUnsupportedAttachment = errors.New("不支持的附件类型，仅支持图片（PNG、JPEG、GIF、WebP）和PDF")
+	errAttachmentTooLarge    = errors.New("附件过大")
+)
+
+// processAttachments 处理消息附件：图片和PDF保存到文档库并改为 document:ID 引用，
+// 返回附带文档内容的消息正文、附件JSON和引用的文档ID
+func processAttachments(userID string, attachments []string, question string) (string, string, []uint, error) {
+	if len(attachments) == 0 {
+		return question, "", nil, nil
+	}
+
+	enhancedContent := question
+	refs := make([]string, 0, len(attachments))
+	var attachedDocuments []uint
+	var documentTexts []string
+	for i, attachment := range attachments {
+		// 图片和PDF保存到文档库，PDF中的文本直接附在消息中
+		if strings.HasPrefix(attachment, "data:") {
+			document, err := storeAttachment(userID, attachment, i)
+			if err != nil {
+				return "", "", nil, err
+			}
+			refs = append(refs, fmt.Sprintf("document:%d", document.ID))
+			attachedDocuments = append(attachedDocuments, document.ID)
+			if document.FileType == "pdf" && !isScannedPDF(document) {
+				documentTexts = append(documentTexts, "[PDF文档内容]:\n"+document.FileContent)
+			}
+			continue
+		}
+
+		// 检查是否为文档引用（document:格式），只注入与问题相关的片段
+		refs = append(refs, attachment)
+		if strings.HasPrefix(attachment, "document:") {
+			documentID := strings.TrimPrefix(attachment, "document:")
+			var document models.UserDocument
+			if err := db.Conn.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err == nil {
+				attachedDocuments = append(attachedDocuments, document.ID)
+				if text := documentAttachmentText(&document, question); text != "" {
+					documentTexts = append(documentTexts, text)
+				}
+			}
+		}
+	}
+
+	// 将文档内容添加到消息内容中
+	if len(documentTexts) > 0 {
+		enhancedContent += "\n\n" + strings.Join(documentTexts, "\n\n")
+	}
+
+	attachmentsBytes, _ := json.Marshal(refs)
+	return enhancedContent, string(attachmentsBytes), attachedDocuments, nil
+}
+
+// respondAttachmentError 附件无效时返回400
+func respondAttachmentError(c *gin.Context, err error) {
+	if errors.Is(err, errUnsupportedAttachment) || errors.Is(err, errAttachmentTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Error("保存附件失败: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "保存附件失败"})
+}
+
+// storeAttachment 把 data URL 形式的附件保存到文件存储并创建用户文档，PDF同时提取文本并建立检索索引
+func storeAttachment(userID, dataURL string, index int) (*models.UserDocument, error) {
+	header, payload, _ := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	mimeType, isBase64 := strings.CutSuffix(header, ";base64")
+	ext, ok := chatAttachmentTypes[mimeType]
+	if !ok || !isBase64 {
+		return nil, errUnsupportedAttachment
+	}
+	if base64.StdEncoding.DecodedLen(len(payload)) > config.C.AttachmentMaxBytes+3 {
+		return nil, fmt.Errorf("%w: 不能超过 %dMB", errAttachmentTooLarge, config.C.AttachmentMaxBytes>>20)
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 附件数据无效", errUnsupportedAttachment)
+	}
+
+	blob, release, err := saveDocumentBlob(bytes.NewReader(data), mimeType)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// 同一文件在对话中多次发送时复用已保存的文档
+	if existing := findDuplicateDocument(userID, "other", blob.SHA256); existing != nil {
+		logger.Info("对话附件已存在: UserID=%s, DocumentID=%d", userID, existing.ID)
+		return existing, nil
+	}
+	fileName := fmt.Sprintf("对话附件_%s_%d.%s", time.Now().Format("20060102150405"), index+1, ext)
+
+	document := models.UserDocument{
+		UserID:           userID,
+		DocumentType:     "other",
+		FileName:         fileName,
+		FileSize:         blob.Size,
+		FileType:         ext,
+		BlobKey:          blob.Key,
+		ContentType:      mimeType,
+		ContentHash:      blob.SHA256,
+		Version:          1,
+		UploadSource:     "chat",
+		ProcessingStatus: "completed",
+	}
+	if ext == "pdf" {
+		text, err := utils.NewSimplePDFExtractor().ExtractTextFromBase64PDF(dataURL)
+		if err != nil {
+			logger.Warn("提取PDF附件文本失败: FileName=%s, 错误=%v", fileName, err)
+		}
+		document.FileContent = utils.CleanDocumentContent(text)
+	}
+	if err := createVersioned(&document, nil); err != nil {
+		return nil, err
+	}
+	logger.Info("对话附件已保存: UserID=%s, DocumentID=%d, FileType=%s, 大小=%d", userID, document.ID, ext, len(data))
+
+	if document.FileContent != "" {
+		if ix := rag.Default(); ix != nil {
+			ix.IndexDocumentAsync(document)
+		}
+	}
+	return &document, nil
+}
+
+// isScannedPDF 提取不到文本的PDF视为扫描件
+func isScannedPDF(document *models.UserDocument) bool {
+	return document.FileType == "pdf" && len([]rune(strings.TrimSpace(document.FileContent))) < scannedPDFMinText
+}
+
+func isImageFile(fileType string) bool {
+	switch fileType {
+	case "png", "jpg", "jpeg", "gif", "webp":
+		return true
+	}
+	return false
+}
+
+// attachmentImages 为支持图片的模型准备附件中的图片和扫描版PDF页面（data URL），
+// 不支持图片的模型返回提示说明，由模型告知用户切换模型。只读取 userID 本人的文档
+func attachmentImages(userID, modelID string, documentIDs []uint) ([]string, string) {
+	if len(documentIDs) == 0 {
+		return nil, ""
+	}
+	var documents []models.UserDocument
+	if err := db.Conn.Where("id IN ? AND user_id = ?", documentIDs, userID).Order("id").Find(&documents).Error; err != nil {
+		logger.Warn("读取附件失败: %v", err)
+		return nil, ""
+	}
+
+	vision := api.IsVisionModel(modelID)
+	limit := config.C.VisionMaxImages
+	var images []string
+	visual, dropped := 0, 0
+	for i := range documents {
+		document := &documents[i]
+		if !isImageFile(document.FileType) && !isScannedPDF(document) {
+			continue
+		}
+		visual++
+		if !vision {
+			continue
+		}
+		if len(images) >= limit {
+			dropped++
+			continue
+		}
+
+		data, err := readDocumentFile(document)
+		if err != nil {
+			logger.Warn("读取附件失败: DocumentID=%d, 错误=%v", document.ID, err)
+			continue
+		}
+		raw, fileType := [][]byte{data}, document.FileType
+		if fileType == "pdf" {
+			if raw, err = utils.ExtractPDFPageImages(data, limit-len(images)); err != nil {
+				logger.Warn("扫描版PDF取页面图片失败: DocumentID=%d, 错误=%v", document.ID, err)
+				continue
+			}
+			fileType = "jpg"
+		}
+		for _, page := range raw {
+			url, err := imageDataURL(page, fileType)
+			if err != nil {
+				logger.Warn("图片无法压缩，已跳过: DocumentID=%d, 错误=%v", document.ID, err)
+				continue
+			}
+			images = append(images, url)
+		}
+	}
+
+	switch {
+	case visual > 0 && !vision:
+		return nil, fmt.Sprintf("\n\n[用户附带了%d个图片或扫描件，当前模型无法查看，请提醒用户切换到支持图片的模型后重新提问]", visual)
+	case dropped > 0:
+		return images, fmt.Sprintf("\n\n[附件较多，仅提供了前%d张图片]", len(images))
+	}
+	return images, ""
+}
+
+// imageDataURL 缩小图片后编码为 data URL，无法解析的图片（如WebP）在大小允许时原样发送
+func imageDataURL(data []byte, fileType string) (string, error) {
+	prepared, mimeType, err := utils.PrepareImage(data, config.C.ImageMaxSide, config.C.ImageMaxBytes)
+	if err != nil {
+		if len(data) > config.C.ImageMaxBytes {
+			return "", err
+		}
+		prepared, mimeType = data, "image/"+strings.Replace(fileType, "jpg", "jpeg", 1)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(prepared), nil
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errInvalidParent 指定的父消息不存在或不属于当前会话
+var errInvalidParent = errors.New("父消息不存在或不属于当前会话")
+
+// RegenerateMessageRequest 重新生成回复请求
+type RegenerateMessageRequest struct {
+	UserID        string `json:"userId"`            // 也可以通过查询参数 userId 传入
+	ModelID       string `json:"modelId,omitempty"` // 为空时沿用该问题最近一次回复所用的模型
+	DeepThinking  bool   `json:"deepThinking,omitempty"`
+	NetworkSearch bool   `json:"networkSearch,omitempty"`
+}
+
+// EditMessageRequest 编辑用户消息请求
+type EditMessageRequest struct {
+	UserID        string `json:"userId"`
+	Content       string `json:"content" binding:"required"`
+	ModelID       string `json:"modelId,omitempty"`
+	DeepThinking  bool   `json:"deepThinking,omitempty"`
+	NetworkSearch bool   `json:"networkSearch,omitempty"`
+}
+
+// messageNode 对话树节点
+type messageNode struct {
+	models.Message
+	Children []*messageNode `json:"children"`
+}
+
+// branchMessage 当前分支上的消息，附带同一父消息下的候选消息，便于前端切换
+type branchMessage struct {
+	models.Message
+	SiblingIDs []uint `json:"siblingIds,omitempty"` // 含自身，按创建顺序排列
+}
+
+// RegenerateMessage 为同一个问题重新生成回复，原有回复作为候选保留
+func RegenerateMessage(c *gin.Context) {
+	var in RegenerateMessageRequest
+	if err := c.ShouldBindJSON(&in); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if in.UserID == "" {
+		in.UserID = c.Query("userId")
+	}
+	target, ok := loadBranchMessage(c, in.UserID)
+	if !ok {
+		return
+	}
+
+	// 针对助手回复重新生成时，新回复与其同属一个用户消息
+	userMsg := target
+	if target.Role == "assistant" {
+		if target.ParentID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "找不到该回复对应的问题"})
+			return
+		}
+		var parent models.Message
+		if err := db.Conn.Where("id = ? AND user_id = ?", *target.ParentID, target.UserID).First(&parent).Error; err != nil || parent.Role != "user" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "找不到该回复对应的问题"})
+			return
+		}
+		userMsg = &parent
+	} else if target.Role != "user" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持对该消息重新生成"})
+		return
+	}
+
+	if in.ModelID == "" {
+		in.ModelID = latestReplyModel(userMsg.ID)
+	}
+	attachments := decodeAttachments(userMsg.Attachments)
+	logger.Info("重新生成回复: MessageID=%d, ThreadID=%s, ModelID=%s", userMsg.ID, userMsg.ThreadID, in.ModelID)
+
+	aiReply, err := createAssistantReply(userMsg, originalQuestion(userMsg), replyOptions{
+		modelID:           in.ModelID,
+		deepThinking:      in.DeepThinking,
+		networkSearch:     in.NetworkSearch,
+		attachments:       attachments,
+		attachedDocuments: attachmentDocumentIDs(attachments),
+	})
+	if err != nil {
+		logger.Error("保存AI回复失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, aiReply)
+}
+
+// EditMessage 以修改后的内容创建新的用户消息并生成回复，从原消息处分叉出新分支
+func EditMessage(c *gin.Context) {
+	var in EditMessageRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if in.UserID == "" {
+		in.UserID = c.Query("userId")
+	}
+	original, ok := loadBranchMessage(c, in.UserID)
+	if !ok {
+		return
+	}
+	if original.Role != "user" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能编辑用户消息"})
+		return
+	}
+
+	if in.ModelID == "" {
+		in.ModelID = latestReplyModel(original.ID)
+	}
+
+	// 沿用原消息的附件，按新的问题重新提取文档片段
+	attachments := decodeAttachments(original.Attachments)
+	enhancedContent, attachmentsJSON, attachedDocuments, err := processAttachments(original.UserID, attachments, in.Content)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
+	}
+
+	userMsg := models.Message{
+		UserID:      original.UserID,
+		Role:        "user",
+		Content:     utils.SanitizeForDatabase(enhancedContent),
+		ThreadID:    original.ThreadID,
+		Attachments: utils.SanitizeForDatabase(attachmentsJSON),
+		ParentID:    original.ParentID,
+		Question:    utils.SanitizeForDatabase(attachedQuestion(in.Content, enhancedContent)),
+	}
+	if err := db.Conn.Create(&userMsg).Error; err != nil {
+		logger.Error("保存用户消息失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Info("编辑消息: OriginalID=%d, NewID=%d, ThreadID=%s", original.ID, userMsg.ID, userMsg.ThreadID)
+
+	aiReply, err := createAssistantReply(&userMsg, in.Content, replyOptions{
+		modelID:           in.ModelID,
+		deepThinking:      in.DeepThinking,
+		networkSearch:     in.NetworkSearch,
+		attachments:       decodeAttachments(attachmentsJSON),
+		attachedDocuments: attachedDocuments,
+	})
+	if err != nil {
+		logger.Error("保存AI回复失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 返回格式与SendMessage保持一致
+	c.JSON(http.StatusOK, []models.Message{userMsg, aiReply})
+}
+
+// loadBranchMessage 按路由参数加载该用户的消息，早期会话会先补全父消息关系，失败时已写入错误响应
+func loadBranchMessage(c *gin.Context, userID string) (*models.Message, bool) {
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId 不能为空"})
+		return nil, false
+	}
+	var msg models.Message
+	err := db.Conn.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&msg).Error
+	if err == nil && msg.ParentID == nil {
+		if err = linkLegacyMessages(userID, msg.ThreadID); err == nil {
+			err = db.Conn.First(&msg, msg.ID).Error
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "消息不存在"})
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("获取消息失败: ID=%s, 错误=%v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return nil, false
+	}
+	return &msg, true
+}
+
+// resolveParent 确定新用户消息的父消息：指定时校验其属于同一会话，否则接在会话最新消息之后
+func resolveParent(userID, threadID string, requested *uint) (*uint, error) {
+	if requested != nil {
+		var parent models.Message
+		err := db.Conn.Where("id = ? AND thread_id = ? AND user_id = ?", *requested, threadID, userID).First(&parent).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errInvalidParent
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &parent.ID, nil
+	}
+
+	if err := linkLegacyMessages(userID, threadID); err != nil {
+		return nil, err
+	}
+	var latest models.Message
+	err := db.Conn.Where("thread_id = ? AND user_id = ?", threadID, userID).Order("id DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &latest.ID, nil
+}
+
+// respondParentError 写入父消息校验失败的响应
+func respondParentError(c *gin.Context, err error) {
+	if errors.Is(err, errInvalidParent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Error("获取父消息失败: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// linkLegacyMessages 为没有记录父消息的早期会话按时间顺序补全该用户消息的父消息关系，
+// 不同用户可能使用相同的会话名称，不能把他们的消息串到一起
+func linkLegacyMessages(userID, threadID string) error {
+	if threadID == "" {
+		return nil
+	}
+	var linked int64
+	if err := db.Conn.Model(&models.Message{}).Where("thread_id = ? AND user_id = ? AND parent_id IS NOT NULL", threadID, userID).Count(&linked).Error; err != nil {
+		return err
+	}
+	if linked > 0 {
+		return nil
+	}
+
+	var ids []uint
+	if err := db.Conn.Model(&models.Message{}).Where("thread_id = ? AND user_id = ?", threadID, userID).Order("id ASC").Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) < 2 {
+		return nil
+	}
+	return db.Conn.Transaction(func(tx *gorm.DB) error {
+		for i := 1; i < len(ids); i++ {
+			if err := tx.Model(&models.Message{}).Where("id = ?", ids[i]).Update("parent_id", ids[i-1]).Error; err != nil {
+				return err
+			}
+		}
+		logger.Info("补全会话消息父子关系: UserID=%s, ThreadID=%s, 消息数=%d", userID, threadID, len(ids))
+		return nil
+	})
+}
+
+// listThreadMessages 按对话树或当前分支返回该用户的会话消息
+func listThreadMessages(c *gin.Context, userID, threadID, view string) {
+	if threadID == "" || userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "threadId和userId不能为空"})
+		return
+	}
+	if err := linkLegacyMessages(userID, threadID); err != nil {
+		logger.Error("补全会话消息父子关系失败: ThreadID=%s, 错误=%v", threadID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var msgs []models.Message
+	if err := db.Conn.Where("thread_id = ? AND user_id = ?", threadID, userID).Order("id ASC").Find(&msgs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	roots, nodes := buildMessageTree(msgs)
+
+	if view == "tree" {
+		c.JSON(http.StatusOK, roots)
+		return
+	}
+
+	var leaf *messageNode
+	if leafID := c.Query("leafId"); leafID != "" {
+		id, err := strconv.ParseUint(leafID, 10, 64)
+		if err != nil || nodes[uint(id)] == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "leafId不属于当前会话"})
+			return
+		}
+		leaf = nodes[uint(id)]
+	} else if len(msgs) > 0 {
+		leaf = nodes[msgs[len(msgs)-1].ID]
+	}
+	c.JSON(http.StatusOK, activeBranch(leaf, roots, nodes))
+}
+
+// buildMessageTree 按父消息关系构建对话树，子消息按创建顺序排列
+func buildMessageTree(msgs []models.Message) ([]*messageNode, map[uint]*messageNode) {
+	nodes := make(map[uint]*messageNode, len(msgs))
+	for _, m := range msgs {
+		nodes[m.ID] = &messageNode{Message: m, Children: []*messageNode{}}
+	}
+	roots := []*messageNode{}
+	for _, m := range msgs {
+		node := nodes[m.ID]
+		if m.ParentID != nil {
+			if parent, ok := nodes[*m.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots, nodes
+}
+
+// activeBranch 返回经过指定消息的分支：向下沿最新的子消息延伸，再回溯到根消息
+func activeBranch(leaf *messageNode, roots []*messageNode, nodes map[uint]*messageNode) []branchMessage {
+	branch := []branchMessage{}
+	if leaf == nil {
+		return branch
+	}
+	for len(leaf.Children) > 0 {
+		leaf = leaf.Children[len(leaf.Children)-1]
+	}
+
+	for node := leaf; node != nil; {
+		siblings := roots
+		var parent *messageNode
+		if node.ParentID != nil {
+			parent = nodes[*node.ParentID]
+		}
+		if parent != nil {
+			siblings = parent.Children
+		}
+
+		item := branchMessage{Message: node.Message}
+		if len(siblings) > 1 {
+			for _, s := range siblings {
+				item.SiblingIDs = append(item.SiblingIDs, s.ID)
+			}
+		}
+		branch = append(branch, item)
+		node = parent
+	}
+
+	for i, j := 0, len(branch)-1; i < j; i, j = i+1, j-1 {
+		branch[i], branch[j] = branch[j], branch[i]
+	}
+	return branch
+}
+
+// latestReplyModel 返回该用户消息最近一次回复所用的模型
+func latestReplyModel(userMessageID uint) string {
+	var reply models.Message
+	err := db.Conn.Where("parent_id = ? AND role = ?", userMessageID, "assistant").Order("id DESC").First(&reply).Error
+	if err != nil {
+		return ""
+	}
+	return reply.ModelID
+}
+
+// legacyAttachmentText 早期消息中附在问题之后的附件文本的开头
+var legacyAttachmentText = regexp.MustCompile(`\n\n\[(PDF文档内容|[^\]\n]*(相关片段|分析结果|文档摘要))\]:\n`)
+
+// attachedQuestion 附件文本附加到消息内容后返回原始问题，没有附加时返回空字符串
+func attachedQuestion(question, content string) string {
+	if content == question {
+		return ""
+	}
+	return question
+}
+
+// originalQuestion 返回用户消息的原始问题，早期没有保存原始问题的消息去掉附加的附件文本
+func originalQuestion(msg *models.Message) string {
+	if msg.Question != "" {
+		return msg.Question
+	}
+	if msg.Attachments != "" {
+		if loc := legacyAttachmentText.FindStringIndex(msg.Content); loc != nil {
+			return msg.Content[:loc[0]]
+		}
+	}
+	return msg.Content
+}
+
+// decodeAttachments 解析消息中保存的附件列表
+func decodeAttachments(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var attachments []string
+	if err := json.Unmarshal([]byte(raw), &attachments); err != nil {
+		logger.Warn("解析消息附件失败: %v", err)
+		return nil
+	}
+	return attachments
+}
+
+// attachmentDocumentIDs 返回附件中引用的文档ID
+func attachmentDocumentIDs(attachments []string) []uint {
+	var ids []uint
+	for _, attachment := range attachments {
+		if !strings.HasPrefix(attachment, "document:") {
+			continue
+		}
+		if id, err := strconv.ParseUint(strings.TrimPrefix(attachment, "document:"), 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	seedStagesMu   sync.Mutex
+	seedStagesDone bool
+)
+
+// SeedCareerStageDefinitions 阶段配置表为空时写入内置默认配置，需在表迁移完成后调用。
+// 只有写入成功后才标记完成，失败时下次调用会重试
+func SeedCareerStageDefinitions() error {
+	seedStagesMu.Lock()
+	defer seedStagesMu.Unlock()
+	if seedStagesDone {
+		return nil
+	}
+
+	var count int64
+	if err := db.Conn.Model(&models.CareerStageDefinition{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		definitions := utils.DefaultCareerStageDefinitions()
+		if err := db.Conn.Create(&definitions).Error; err != nil {
+			return err
+		}
+		logger.Info("已写入默认职业阶段配置: 数量=%d", len(definitions))
+	}
+	seedStagesDone = true
+	return nil
+}
+
+// careerStagesSeeded 默认配置是否已写入数据库
+func careerStagesSeeded() bool {
+	seedStagesMu.Lock()
+	defer seedStagesMu.Unlock()
+	return seedStagesDone
+}
+
+// defaultCareerStageDefinitions 默认配置尚未写入数据库时，读取内置的指定路线配置
+func defaultCareerStageDefinitions(track string) []models.CareerStageDefinition {
+	var definitions []models.CareerStageDefinition
+	for _, d := range utils.DefaultCareerStageDefinitions() {
+		if d.Track == track {
+			definitions = append(definitions, d)
+		}
+	}
+	return definitions
+}
+
+// loadCareerStageDefinitions 加载指定路线的阶段配置，优先使用行业专属配置，否则回退到通用配置
+func loadCareerStageDefinitions(track, industry string) ([]models.CareerStageDefinition, error) {
+	var definitions []models.CareerStageDefinition
+	if industry != "" {
+		if err := db.Conn.Where("track = ? AND industry = ?", track, industry).
+			Order("stage_order ASC").Find(&definitions).Error; err != nil {
+			return nil, err
+		}
+		if len(definitions) > 0 {
+			return definitions, nil
+		}
+	}
+
+	if err := db.Conn.Where("track = ? AND industry = ?", track, "").
+		Order("stage_order ASC").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 && !careerStagesSeeded() {
+		return defaultCareerStageDefinitions(track), nil
+	}
+	return definitions, nil
+}
+
+// GetCareerStages 获取职业阶段定义，支持按发展路线和行业筛选
+func GetCareerStages(c *gin.Context) {
+	track := c.DefaultQuery("track", utils.TrackGeneral)
+	industry := c.Query("industry")
+
+	definitions, err := loadCareerStageDefinitions(track, industry)
+	if err != nil {
+		logger.Error("获取职业阶段配置失败: Track=%s, Industry=%s, 错误=%v", track, industry, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	stages := make([]models.CareerStage, 0, len(definitions))
+	for _, d := range definitions {
+		stages = append(stages, models.CareerStage{
+			Stage:       d.Stage,
+			Description: d.Description,
+			Skills:      d.Skills,
+			Goals:       d.Goals,
+			Duration:    d.Duration,
+			Track:       d.Track,
+		})
+	}
+
+	logger.Info("获取职业阶段定义: Track=%s, Industry=%s, 数量=%d", track, industry, len(stages))
+	c.JSON(http.StatusOK, gin.H{"stages": stages})
+}
+
+// CareerStageDefinitionsRequest 替换某路线/行业的阶段配置
+type CareerStageDefinitionsRequest struct {
+	Industry string `json:"industry"`
+	Stages   []struct {
+		Stage            string   `json:"stage" binding:"required"`
+		Description      string   `json:"description"`
+		Skills           string   `json:"skills"`
+		Goals            string   `json:"goals"`
+		Duration         string   `json:"duration"`
+		MinExperience    int      `json:"minExperience"`
+		MaxExperience    int      `json:"maxExperience"`
+		PositionKeywords []string `json:"positionKeywords"`
+		RequiredGoals    int      `json:"requiredGoals"`
+	} `json:"stages" binding:"required,min=1,dive"`
+}
+
+// UpdateCareerStageDefinitions 替换指定发展路线（及行业）的阶段配置
+func UpdateCareerStageDefinitions(c *gin.Context) {
+	track := c.Param("track")
+	if track == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "发展路线不能为空"})
+		return
+	}
+
+	var in CareerStageDefinitionsRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error("职业阶段配置更新请求解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	definitions := make([]models.CareerStageDefinition, 0, len(in.Stages))
+	for i, s := range in.Stages {
+		definition := models.CareerStageDefinition{
+			Track:         track,
+			Industry:      in.Industry,
+			StageOrder:    i,
+			Stage:         s.Stage,
+			Description:   s.Description,
+			Skills:        s.Skills,
+			Goals:         s.Goals,
+			Duration:      s.Duration,
+			MinExperience: s.MinExperience,
+			MaxExperience: s.MaxExperience,
+			RequiredGoals: s.RequiredGoals,
+		}
+		definition.SetPositionKeywords(s.PositionKeywords)
+		definitions = append(definitions, definition)
+	}
+
+	// 先写入默认配置，否则替换一条路线后表不再为空，其他路线的默认配置不会再写入
+	if err := SeedCareerStageDefinitions(); err != nil {
+		logger.Error("写入默认职业阶段配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+	err := db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("track = ? AND industry = ?", track, in.Industry).
+			Delete(&models.CareerStageDefinition{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&definitions).Error
+	})
+	if err != nil {
+		logger.Error("更新职业阶段配置失败: Track=%s, Industry=%s, 错误=%v", track, in.Industry, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	logger.Info("职业阶段配置更新成功: Track=%s, Industry=%s, 数量=%d", track, in.Industry, len(definitions))
+	c.JSON(http.StatusOK, gin.H{"definitions": definitions})
+}
+
+// GetUserCareerStage 评估用户当前职业阶段及各阶段进度，并同步到用户档案
+func GetUserCareerStage(c *gin.Context) {
+	userID := c.Param("userId")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户ID不能为空"})
+		return
+	}
+
+	var profile models.UserProfile
+	hasProfile := db.Conn.Where("user_id = ?", userID).First(&profile).Error == nil
+
+	signals := collectCareerStageSignals(userID, &profile)
+
+	track := c.Query("track")
+	if track == "" {
+		track = utils.InferCareerTrack(signals.Position)
+	}
+	industry := c.Query("industry")
+	if industry == "" {
+		industry = profile.Industry
+	}
+
+	definitions, err := loadCareerStageDefinitions(track, industry)
+	if err != nil {
+		logger.Error("获取职业阶段配置失败: Track=%s, Industry=%s, 错误=%v", track, industry, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	if len(definitions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "未找到该发展路线的阶段配置"})
+		return
+	}
+
+	assessment := utils.AssessCareerStage(definitions, signals)
+
+	// 同步到用户档案
+	if hasProfile {
+		if profile.CareerStage != assessment.CurrentStage {
+			if err := db.Conn.Model(&models.UserProfile{}).Where("id = ?", profile.ID).
+				Updates(map[string]interface{}{"career_stage": assessment.CurrentStage, "updated_at": time.Now()}).Error; err != nil {
+				logger.Error("同步职业阶段到用户档案失败: UserID=%s, 错误=%v", userID, err)
+			}
+		}
+	} else {
+		profile = models.UserProfile{UserID: userID, CareerStage: assessment.CurrentStage}
+		if err := db.Conn.Create(&profile).Error; err != nil {
+			logger.Error("创建用户档案失败: UserID=%s, 错误=%v", userID, err)
+		}
+	}
+
+	logger.Info("评估用户职业阶段: UserID=%s, Track=%s, Stage=%s", userID, assessment.Track, assessment.CurrentStage)
+	c.JSON(http.StatusOK, assessment)
+}
+
+// collectCareerStageSignals 汇总档案、简历历史和已完成目标，作为阶段评估的输入
+func collectCareerStageSignals(userID string, profile *models.UserProfile) utils.CareerStageSignals {
+	signals := utils.CareerStageSignals{
+		ExperienceYears:  float64(profile.Experience),
+		ExperienceSource: "profile",
+		Position:         strings.TrimSpace(profile.Position),
+	}
+
+	var resumes []models.UserDocument
+	if err := db.Conn.Where("user_id = ? AND document_type = ? AND is_processed = ?", userID, "resume", true).
+		Order("created_at DESC").Find(&resumes).Error; err != nil {
+		logger.Warn("获取简历历史失败: UserID=%s, 错误=%v", userID, err)
+	}
+	signals.ResumeCount = len(resumes)
+
+	if len(resumes) > 0 {
+		if info, err := resumes[0].GetExtractedInfo(); err == nil {
+			signals.WorkExperienceCount = len(info.WorkExperience)
+
+			if signals.ExperienceYears == 0 {
+				now := time.Now()
+				var years float64
+				for _, exp := range info.WorkExperience {
+					years += utils.ParseDurationYears(exp.Duration, now)
+				}
+				if years > 0 {
+					signals.ExperienceYears = years
+					signals.ExperienceSource = "resume"
+				}
+			}
+			if signals.Position == "" && len(info.WorkExperience) > 0 {
+				signals.Position = info.WorkExperience[0].Position
+			}
+		}
+	}
+
+	var completed int64
+	db.Conn.Model(&models.Goal{}).Where("user_id = ? AND status = ?", userID, models.GoalStatusCompleted).Count(&completed)
+	signals.CompletedGoals = int(completed)
+
+	return signals
+}
@@ -0,0 +1,559 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/company"
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/insights"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CompanyRequest 创建/更新企业请求，字段为空表示不修改
+type CompanyRequest struct {
+	Name         *string  `json:"name"`
+	ShortName    *string  `json:"shortName"`
+	EnglishName  *string  `json:"englishName"`
+	CreditCode   *string  `json:"creditCode"`
+	Tickers      []string `json:"tickers"`
+	Industry     *string  `json:"industry"`
+	IndustryCode *string  `json:"industryCode"`
+	Website      *string  `json:"website"`
+	Aliases      []string `json:"aliases"` // 额外别名
+}
+
+// ResolveCompanyRequest 企业名称解析请求
+type ResolveCompanyRequest struct {
+	Name   string `json:"name"`   // 企业名称、统一社会信用代码或股票代码
+	Text   string `json:"text"`   // 一段文本，识别其中提到的已知企业
+	Create bool   `json:"create"` // 匹配不到时是否新建
+	Limit  int    `json:"limit"`
+}
+
+// MergeCompanyRequest 合并企业请求
+type MergeCompanyRequest struct {
+	SourceID uint `json:"sourceId" binding:"required"` // 被合并的企业，合并后删除
+}
+
+var (
+	errCompanyNameEmpty  = errors.New("企业名称不能为空")
+	errInvalidCreditCode = errors.New("统一社会信用代码无效")
+)
+
+// GetCompanies 获取企业列表，提供 q 时按名称模糊匹配
+func GetCompanies(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		matches, err := company.Resolve(q, limit)
+		if err != nil {
+			logger.Error("企业搜索失败: Query=%s, 错误=%v", q, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
+			return
+		}
+		if matches == nil {
+			matches = []company.Match{}
+		}
+		c.JSON(http.StatusOK, gin.H{"matches": matches})
+		return
+	}
+
+	var companies []models.Company
+	query := db.Conn.Model(&models.Company{})
+	if industry := c.Query("industry"); industry != "" {
+		query = query.Where("industry = ?", industry)
+	}
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&companies).Error; err != nil {
+		logger.Error("获取企业列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"companies": companies})
+}
+
+// CreateCompany 创建企业
+func CreateCompany(c *gin.Context) {
+	var req CompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "企业名称不能为空"})
+		return
+	}
+
+	var item models.Company
+	if err := applyCompanyRequest(&item, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 已存在同名或同信用代码的企业时不重复创建
+	if existing := findDuplicateCompany(&item); existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "企业已存在", "company": existing})
+		return
+	}
+
+	if err := db.Conn.Create(&item).Error; err != nil {
+		logger.Error("创建企业失败: Name=%s, 错误=%v", item.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
+		return
+	}
+	company.InvalidateNames()
+	saveCompanyAliases(&item, req.Aliases)
+
+	logger.Info("创建企业成功: CompanyID=%d, Name=%s", item.ID, item.Name)
+	db.Conn.Preload("Aliases").First(&item, item.ID)
+	c.JSON(http.StatusOK, gin.H{"company": item})
+}
+
+// GetCompany 获取企业详情，包括别名和关联数据统计
+func GetCompany(c *gin.Context) {
+	item, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	var monitors, risks, documents, users int64
+	db.Conn.Model(&models.CompanyMonitor{}).Where("company_id = ?", item.ID).Count(&monitors)
+	db.Conn.Model(&models.ContractRisk{}).Where("company_id = ?", item.ID).Count(&risks)
+	db.Conn.Model(&models.UserDocument{}).Where("company_id = ?", item.ID).Count(&documents)
+	db.Conn.Model(&models.CompanyMonitor{}).Where("company_id = ?", item.ID).Distinct("user_id").Count(&users)
+
+	c.JSON(http.StatusOK, gin.H{
+		"company": item,
+		"stats": gin.H{
+			"monitors":        monitors,
+			"monitoringUsers": users,
+			"risks":           risks,
+			"documents":       documents,
+		},
+	})
+}
+
+// GetCompanyInsights 获取企业聚合洞察（薪资带、竞业条款、常见风险），支持按职位筛选
+func GetCompanyInsights(c *gin.Context) {
+	item, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	position := strings.TrimSpace(c.Query("position"))
+	result, err := insights.Compute(item, position, config.C.InsightsKAnonymity)
+	if err != nil {
+		logger.Error("计算企业洞察失败: CompanyID=%d, 错误=%v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算企业洞察失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"insights":   result,
+		"sufficient": result.HasData(),
+	})
+}
+
+// UpdateCompany 更新企业信息
+func UpdateCompany(c *gin.Context) {
+	item, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	var req CompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	oldName := item.Name
+	if err := applyCompanyRequest(item, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Conn.Omit("Aliases").Save(item).Error; err != nil {
+		logger.Error("更新企业失败: CompanyID=%d, 错误=%v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+	company.InvalidateNames()
+	// 改名后旧名称保留为别名，已关联的写法仍然可以解析
+	aliases := req.Aliases
+	if item.Name != oldName {
+		aliases = append(aliases, oldName)
+	}
+	saveCompanyAliases(item, aliases)
+
+	logger.Info("更新企业成功: CompanyID=%d", item.ID)
+	db.Conn.Preload("Aliases").First(item, item.ID)
+	c.JSON(http.StatusOK, gin.H{"company": item})
+}
+
+// DeleteCompany 删除企业，关联数据保留但解除关联
+func DeleteCompany(c *gin.Context) {
+	item, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	err := db.Conn.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.CompanyMonitor{}, &models.ContractRisk{}, &models.UserDocument{}} {
+			if err := tx.Model(model).Where("company_id = ?", item.ID).Update("company_id", nil).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("company_id = ?", item.ID).Delete(&models.CompanyAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(item).Error
+	})
+	if err != nil {
+		logger.Error("删除企业失败: CompanyID=%d, 错误=%v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	company.InvalidateNames()
+
+	logger.Info("删除企业成功: CompanyID=%d", item.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// AddCompanyAlias 为企业添加别名
+func AddCompanyAlias(c *gin.Context) {
+	item, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Alias string `json:"alias" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if company.Normalize(req.Alias) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的别名"})
+		return
+	}
+
+	if err := company.AddAlias(item.ID, req.Alias, company.SourceManual); err != nil {
+		logger.Error("添加企业别名失败: CompanyID=%d, 错误=%v", item.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加失败"})
+		return
+	}
+
+	db.Conn.Preload("Aliases").First(item, item.ID)
+	c.JSON(http.StatusOK, gin.H{"company": item})
+}
+
+// DeleteCompanyAlias 删除企业别名
+func DeleteCompanyAlias(c *gin.Context) {
+	companyID := c.Param("companyId")
+	aliasID := c.Param("aliasId")
+
+	result := db.Conn.Where("id = ? AND company_id = ?", aliasID, companyID).Delete(&models.CompanyAlias{})
+	if result.Error != nil {
+		logger.Error("删除企业别名失败: AliasID=%s, 错误=%v", aliasID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "别名不存在"})
+		return
+	}
+	company.InvalidateNames()
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// MergeCompany 将重复的企业合并到当前企业：别名和所有关联数据迁移过来，然后删除被合并的企业
+func MergeCompany(c *gin.Context) {
+	target, ok := loadCompany(c)
+	if !ok {
+		return
+	}
+
+	var req MergeCompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SourceID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不能与自身合并"})
+		return
+	}
+
+	var source models.Company
+	if err := db.Conn.First(&source, req.SourceID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "被合并的企业不存在"})
+		return
+	}
+
+	err := db.Conn.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.CompanyMonitor{}, &models.ContractRisk{}, &models.UserDocument{}} {
+			if err := tx.Model(model).Where("company_id = ?", source.ID).Update("company_id", target.ID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.CompanyAlias{}).Where("company_id = ?", source.ID).Update("company_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := mergeCompanyMentions(tx, source.ID, target.ID); err != nil {
+			return err
+		}
+
+		// 补全目标企业缺失的字段
+		if target.CreditCode == "" {
+			target.CreditCode = source.CreditCode
+		}
+		if target.Industry == "" {
+			target.Industry = source.Industry
+		}
+		if target.IndustryCode == "" {
+			target.IndustryCode = source.IndustryCode
+		}
+		if target.EnglishName == "" {
+			target.EnglishName = source.EnglishName
+		}
+		tickers := target.GetTickers()
+		for _, t := range source.GetTickers() {
+			if !contains(tickers, t) {
+				tickers = append(tickers, t)
+			}
+		}
+		if len(tickers) > 0 {
+			target.SetTickers(tickers)
+		}
+		if err := tx.Omit("Aliases").Save(target).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		logger.Error("合并企业失败: Target=%d, Source=%d, 错误=%v", target.ID, source.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "合并失败"})
+		return
+	}
+	company.InvalidateNames()
+	saveCompanyAliases(target, []string{source.Name})
+
+	logger.Info("合并企业成功: Target=%d, Source=%d", target.ID, source.ID)
+	db.Conn.Preload("Aliases").First(target, target.ID)
+	c.JSON(http.StatusOK, gin.H{"company": target})
+}
+
+// ResolveCompany 解析企业名称，或识别文本中提到的企业
+func ResolveCompany(c *gin.Context) {
+	var req ResolveCompanyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" && strings.TrimSpace(req.Text) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name 和 text 不能同时为空"})
+		return
+	}
+
+	resp := gin.H{}
+	if req.Name != "" {
+		matches, err := company.Resolve(req.Name, req.Limit)
+		if err != nil {
+			logger.Error("解析企业名称失败: Name=%s, 错误=%v", req.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "解析失败"})
+			return
+		}
+		if matches == nil {
+			matches = []company.Match{}
+		}
+		resp["normalized"] = company.Normalize(req.Name)
+		resp["matches"] = matches
+
+		if len(matches) > 0 && matches[0].Score >= company.MatchThreshold {
+			resp["company"] = matches[0].Company
+		} else if req.Create {
+			created, err := company.ResolveOrCreate(req.Name, company.SourceManual)
+			if err != nil {
+				logger.Error("创建企业失败: Name=%s, 错误=%v", req.Name, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
+				return
+			}
+			resp["company"] = created
+			resp["created"] = created != nil
+		}
+	}
+
+	if req.Text != "" {
+		ids, err := company.FindMentions(req.Text)
+		if err != nil {
+			logger.Error("识别文本中的企业失败: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "识别失败"})
+			return
+		}
+		mentions := []models.Company{}
+		if len(ids) > 0 {
+			db.Conn.Where("id IN ?", ids).Find(&mentions)
+		}
+		resp["mentions"] = mentions
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// BackfillCompanies 为历史监控、风险点和文档补充企业关联
+func BackfillCompanies(c *gin.Context) {
+	result, err := company.Backfill()
+	if err != nil {
+		logger.Error("企业关联回填失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "回填失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"linked": result})
+}
+
+func loadCompany(c *gin.Context) (*models.Company, bool) {
+	companyID := c.Param("companyId")
+
+	var item models.Company
+	if err := db.Conn.Preload("Aliases").First(&item, "id = ?", companyID).Error; err != nil {
+		logger.Warn("企业不存在: CompanyID=%s", companyID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "企业不存在"})
+		return nil, false
+	}
+	return &item, true
+}
+
+func applyCompanyRequest(item *models.Company, req *CompanyRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return errCompanyNameEmpty
+		}
+		item.Name = name
+		item.Normalized = company.Normalize(name)
+	}
+	if req.ShortName != nil {
+		item.ShortName = strings.TrimSpace(*req.ShortName)
+	}
+	if req.EnglishName != nil {
+		item.EnglishName = strings.TrimSpace(*req.EnglishName)
+	}
+	if req.CreditCode != nil {
+		code := strings.ToUpper(strings.TrimSpace(*req.CreditCode))
+		if code != "" && !company.ValidCreditCode(code) {
+			return errInvalidCreditCode
+		}
+		item.CreditCode = code
+	}
+	if req.Tickers != nil {
+		tickers := make([]string, 0, len(req.Tickers))
+		for _, t := range req.Tickers {
+			if t = company.NormalizeTicker(t); t != "" {
+				tickers = append(tickers, t)
+			}
+		}
+		item.SetTickers(tickers)
+	}
+	if req.Industry != nil {
+		item.Industry = strings.TrimSpace(*req.Industry)
+	}
+	if req.IndustryCode != nil {
+		item.IndustryCode = strings.ToUpper(strings.TrimSpace(*req.IndustryCode))
+	}
+	if req.Website != nil {
+		item.Website = strings.TrimSpace(*req.Website)
+	}
+	return nil
+}
+
+// saveCompanyAliases 保存企业名称、简称、英文名以及额外别名
+func saveCompanyAliases(item *models.Company, extra []string) {
+	aliases := append([]string{item.Name, item.ShortName, item.EnglishName}, extra...)
+	for _, alias := range aliases {
+		if err := company.AddAlias(item.ID, alias, company.SourceManual); err != nil {
+			logger.Warn("保存企业别名失败: CompanyID=%d, Alias=%s, 错误=%v", item.ID, alias, err)
+		}
+	}
+}
+
+// mergeCompanyMentions 把咨询记录元数据 companyIds 中被合并的企业替换为目标企业
+func mergeCompanyMentions(tx *gorm.DB, sourceID, targetID uint) error {
+	id := strconv.FormatUint(uint64(sourceID), 10)
+	var histories []models.CareerHistory
+	if err := tx.Select("id, metadata").
+		Where("metadata LIKE ?", "%\"companyIds\"%").
+		Where("metadata LIKE ? OR metadata LIKE ? OR metadata LIKE ? OR metadata LIKE ?",
+			"%["+id+",%", "%,"+id+",%", "%,"+id+"]%", "%["+id+"]%").
+		Find(&histories).Error; err != nil {
+		return err
+	}
+	for _, h := range histories {
+		var metadata map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(h.Metadata), &metadata); err != nil {
+			continue
+		}
+		var ids []uint
+		if err := json.Unmarshal(metadata["companyIds"], &ids); err != nil {
+			continue
+		}
+		merged := make([]uint, 0, len(ids))
+		changed := false
+		for _, cid := range ids {
+			if cid == sourceID {
+				cid, changed = targetID, true
+			}
+			if !containsUint(merged, cid) {
+				merged = append(merged, cid)
+			}
+		}
+		if !changed {
+			continue
+		}
+		metadata["companyIds"], _ = json.Marshal(merged)
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.CareerHistory{}).Where("id = ?", h.ID).Update("metadata", string(data)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsUint 检查切片是否包含指定ID
+func containsUint(slice []uint, item uint) bool {
+	for _, v := range slice {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
+
+func findDuplicateCompany(item *models.Company) *models.Company {
+	var existing models.Company
+	if item.CreditCode != "" {
+		if err := db.Conn.Where("credit_code = ?", item.CreditCode).First(&existing).Error; err == nil {
+			return &existing
+		}
+	}
+	if match, _ := company.Best(item.Name); match != nil && match.MatchedBy == company.MatchExact {
+		return &match.Company
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/docdiff"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiffDocumentsRequest 文档对比请求
+type DiffDocumentsRequest struct {
+	OldDocumentID uint  `json:"oldDocumentId" binding:"required"`
+	NewDocumentID uint  `json:"newDocumentId" binding:"required"`
+	Commentary    *bool `json:"commentary"` // 是否由模型评价每项变化，默认开启
+}
+
+// DiffUserDocuments 对比两份文档（通常是修订前后的合同或Offer）：按条款对齐的原文差异、
+// 提取字段的差异，以及每项变化对员工是否有利的评价
+func DiffUserDocuments(c *gin.Context) {
+	userID := c.Param("userId")
+	var req DiffDocumentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供 oldDocumentId 和 newDocumentId"})
+		return
+	}
+	if req.OldDocumentID == req.NewDocumentID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择两份不同的文档"})
+		return
+	}
+
+	var oldDoc, newDoc models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", req.OldDocumentID, userID).First(&oldDoc).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	if err := db.Conn.Where("id = ? AND user_id = ?", req.NewDocumentID, userID).First(&newDoc).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	if oldDoc.FileContent == "" || newDoc.FileContent == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "文档没有可对比的文本内容"})
+		return
+	}
+
+	redline, err := docdiff.NewRedline(&oldDoc, &newDoc)
+	if err != nil {
+		logger.Error("解析提取信息失败: OldID=%d, NewID=%d, 错误=%v", oldDoc.ID, newDoc.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+		return
+	}
+
+	resp := gin.H{
+		"oldDocument": gin.H{"id": oldDoc.ID, "fileName": oldDoc.FileName, "documentType": oldDoc.DocumentType, "version": oldDoc.Version},
+		"newDocument": gin.H{"id": newDoc.ID, "fileName": newDoc.FileName, "documentType": newDoc.DocumentType, "version": newDoc.Version},
+		"redline":     redline,
+	}
+	if !oldDoc.IsProcessed || !newDoc.IsProcessed {
+		resp["termsNote"] = "文档尚未完成AI分析，只对比了原文"
+	}
+	if (req.Commentary == nil || *req.Commentary) && redline.Changed() {
+		if err := redline.Annotate(newDoc.DocumentType, config.C.DiffCommentaryModel); err != nil {
+			logger.Warn("差异评价失败: OldID=%d, NewID=%d, 错误=%v", oldDoc.ID, newDoc.ID, err)
+			resp["commentaryError"] = "模型评价失败，仅提供按规则的初步判断"
+		}
+	}
+
+	logger.Info("文档对比: UserID=%s, OldID=%d, NewID=%d, 条款=%v, 字段变化=%d", userID, oldDoc.ID, newDoc.ID, redline.Stats, len(redline.Terms))
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errStorageUnavailable = errors.New("文件存储不可用")
+
+// blobRefs 保护按内容寻址的文件的引用：保存内容到创建文档记录期间持有读锁，统计引用并删除文件时持有写锁，
+// 避免同时上传相同内容的新文档指向刚被删除的文件。只在进程内有效
+var blobRefs sync.RWMutex
+
+// GetDocumentDownloadURL 返回文档的签名下载链接
+func GetDocumentDownloadURL(c *gin.Context) {
+	userID := c.Param("userId")
+	documentID := c.Param("documentId")
+
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	url, expiresAt := storage.SignPath(downloadPath(document.ID), config.C.StorageURLTTL)
+	c.JSON(http.StatusOK, gin.H{"url": url, "expiresAt": expiresAt})
+}
+
+// DownloadDocument 凭签名链接下载文档原文件。对象存储支持预签名地址时重定向到存储，否则由服务端流式返回
+func DownloadDocument(c *gin.Context) {
+	documentID := c.Param("id")
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ?", documentID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	if !storage.VerifyPath(downloadPath(document.ID), c.Query("expires"), c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "下载链接无效或已过期"})
+		return
+	}
+
+	if document.BlobKey != "" {
+		if store := storage.Default(); store != nil {
+			url, err := store.SignedURL(document.BlobKey, document.FileName, document.ContentType, config.C.StorageURLTTL)
+			if err != nil {
+				logger.Warn("生成存储下载地址失败: DocumentID=%d, 错误=%v", document.ID, err)
+			}
+			if url != "" {
+				c.Redirect(http.StatusFound, url)
+				return
+			}
+		}
+	}
+	serveDocumentFile(c, &document, true)
+}
+
+func downloadPath(documentID uint) string {
+	return fmt.Sprintf("/api/documents/%d/download", documentID)
+}
+
+// serveDocumentFile 流式返回文档原文件，attachment 为 true 时浏览器按原始文件名下载
+func serveDocumentFile(c *gin.Context, document *models.UserDocument, attachment bool) {
+	rc, err := openDocumentFile(document)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文件不存在"})
+			return
+		}
+		logger.Error("读取文档文件失败: DocumentID=%d, 错误=%v", document.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取文件失败"})
+		return
+	}
+	defer rc.Close()
+
+	contentType := document.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	headers := map[string]string{"Cache-Control": "private, max-age=300"}
+	if attachment {
+		headers["Content-Disposition"] = storage.ContentDisposition(document.FileName)
+	}
+	c.DataFromReader(http.StatusOK, document.FileSize, contentType, rc, headers)
+}
+
+// saveDocumentBlob 保存文件内容到文件存储。成功时返回的 release 须在创建引用该内容的文档记录后调用
+func saveDocumentBlob(r io.Reader, contentType string) (blob storage.Blob, release func(), err error) {
+	store := storage.Default()
+	if store == nil {
+		return storage.Blob{}, nil, errStorageUnavailable
+	}
+	blobRefs.RLock()
+	blob, err = store.Put(r, contentType)
+	if err != nil {
+		blobRefs.RUnlock()
+		return storage.Blob{}, nil, err
+	}
+	return blob, blobRefs.RUnlock, nil
+}
+
+// openDocumentFile 以流的方式读取文档原文件，兼容保存在本地 uploads 目录的旧文档
+func openDocumentFile(document *models.UserDocument) (io.ReadCloser, error) {
+	if document.BlobKey == "" {
+		if document.FilePath == "" {
+			return nil, storage.ErrNotFound
+		}
+		f, err := os.Open(document.FilePath)
+		if os.IsNotExist(err) {
+			return nil, storage.ErrNotFound
+		}
+		return f, err
+	}
+	store := storage.Default()
+	if store == nil {
+		return nil, errStorageUnavailable
+	}
+	return store.Open(document.BlobKey)
+}
+
+// readDocumentFile 读取文档原文件的全部内容
+func readDocumentFile(document *models.UserDocument) ([]byte, error) {
+	rc, err := openDocumentFile(document)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// deleteDocumentFile 删除已删除文档的原文件，内容仍被其他文档引用时保留
+func deleteDocumentFile(document *models.UserDocument) error {
+	if document.BlobKey == "" {
+		if document.FilePath == "" {
+			return nil
+		}
+		if err := os.Remove(document.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	blobRefs.Lock()
+	defer blobRefs.Unlock()
+	var refs int64
+	if err := db.Conn.Model(&models.UserDocument{}).Where("blob_key = ?", document.BlobKey).Count(&refs).Error; err != nil {
+		return err
+	}
+	if refs > 0 {
+		return nil
+	}
+	store := storage.Default()
+	if store == nil {
+		return errStorageUnavailable
+	}
+	return store.Delete(document.BlobKey)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/docdiff"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// documentVersion 版本列表中的一项，changes 为相对上一版本提取信息的变化
+type documentVersion struct {
+	Document models.UserDocument `json:"document"`
+	Changes  *docdiff.Diff       `json:"changes,omitempty"`
+}
+
+// GetDocumentVersions 获取文档所在版本组的全部版本，以及相邻版本之间提取信息的变化。
+// 传入 from、to（同组内的文档ID）时只返回这两个版本之间的变化
+func GetDocumentVersions(c *gin.Context) {
+	userID := c.Param("userId")
+	documentID := c.Param("documentId")
+
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+
+	groupID := versionGroupID(&document)
+	var versions []models.UserDocument
+	if err := db.Conn.Where("user_id = ? AND (group_id = ? OR id = ?)", document.UserID, groupID, groupID).
+		Order("version ASC, id ASC").Find(&versions).Error; err != nil {
+		logger.Error("获取文档版本失败: DocumentID=%s, 错误=%v", documentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	if from, to := c.Query("from"), c.Query("to"); from != "" || to != "" {
+		oldDoc, newDoc := findVersion(versions, from), findVersion(versions, to)
+		if oldDoc == nil || newDoc == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from 和 to 需为同一版本组内的文档ID"})
+			return
+		}
+		if !oldDoc.IsProcessed || !newDoc.IsProcessed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "文档尚未处理"})
+			return
+		}
+		diff, err := compareDocuments(oldDoc, newDoc)
+		if err != nil {
+			logger.Error("解析提取信息失败: DocumentID=%s, 错误=%v", documentID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"groupId": groupID, "from": oldDoc.ID, "to": newDoc.ID, "diff": diff})
+		return
+	}
+
+	items := make([]documentVersion, len(versions))
+	for i := range versions {
+		items[i].Document = versions[i]
+		if i == 0 || !versions[i-1].IsProcessed || !versions[i].IsProcessed {
+			continue
+		}
+		diff, err := compareDocuments(&versions[i-1], &versions[i])
+		if err != nil {
+			logger.Warn("比较文档版本失败: DocumentID=%d, 错误=%v", versions[i].ID, err)
+			continue
+		}
+		items[i].Changes = diff
+	}
+
+	logger.Info("获取文档版本: DocumentID=%s, GroupID=%d, 版本数=%d", documentID, groupID, len(items))
+	c.JSON(http.StatusOK, gin.H{"groupId": groupID, "versions": items})
+}
+
+func compareDocuments(oldDoc, newDoc *models.UserDocument) (*docdiff.Diff, error) {
+	oldInfo, err := oldDoc.GetExtractedInfo()
+	if err != nil {
+		return nil, err
+	}
+	newInfo, err := newDoc.GetExtractedInfo()
+	if err != nil {
+		return nil, err
+	}
+	return docdiff.Compare(oldInfo, newInfo), nil
+}
+
+func findVersion(versions []models.UserDocument, id string) *models.UserDocument {
+	for i := range versions {
+		if strconv.FormatUint(uint64(versions[i].ID), 10) == id {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// versionGroupID 文档所在的版本组，早期上传的文档没有版本组，以自身ID作为版本组
+func versionGroupID(document *models.UserDocument) uint {
+	if document.GroupID != 0 {
+		return document.GroupID
+	}
+	return document.ID
+}
+
+// findDuplicateDocument 查找用户已上传的同类型、内容完全相同的文档
+func findDuplicateDocument(userID, documentType, contentHash string) *models.UserDocument {
+	var existing models.UserDocument
+	err := db.Conn.Where("user_id = ? AND document_type = ? AND content_hash = ?", userID, documentType, contentHash).
+		Order("id DESC").First(&existing).Error
+	if err != nil {
+		return nil
+	}
+	return &existing
+}
+
+var (
+	errVersionNotFound     = errors.New("要更新版本的文档不存在")
+	errVersionTypeMismatch = errors.New("新版本的文档类型需与原文档一致")
+)
+
+// previousVersion 查找新上传文档的上一版本：指定了 versionOf 时取该文档所在版本组的最新版本，
+// 否则取用户最近一次手动上传的同类型、同名文档。没有上一版本时返回 nil
+func previousVersion(userID, documentType, fileName, versionOf string) (*models.UserDocument, error) {
+	var previous models.UserDocument
+	if versionOf != "" {
+		if err := db.Conn.Where("id = ? AND user_id = ?", versionOf, userID).First(&previous).Error; err != nil {
+			return nil, errVersionNotFound
+		}
+		if previous.DocumentType != documentType {
+			return nil, errVersionTypeMismatch
+		}
+		groupID := versionGroupID(&previous)
+		if err := db.Conn.Where("user_id = ? AND (group_id = ? OR id = ?)", userID, groupID, groupID).
+			Order("version DESC, id DESC").First(&previous).Error; err != nil {
+			return nil, err
+		}
+		return &previous, nil
+	}
+
+	err := db.Conn.Where("user_id = ? AND document_type = ? AND file_name = ? AND upload_source = ?", userID, documentType, fileName, "manual").
+		Order("id DESC").First(&previous).Error
+	if err != nil {
+		return nil, nil
+	}
+	return &previous, nil
+}
+
+// createVersioned 创建文档记录。没有上一版本时以自身ID作为版本组；有上一版本时加入其版本组，
+// 版本号取组内最大版本号加一，并在事务中先锁住组内已有的版本，避免并发上传得到相同的版本号
+func createVersioned(document, previous *models.UserDocument) error {
+	return db.Conn.Transaction(func(tx *gorm.DB) error {
+		if previous == nil {
+			if err := tx.Create(document).Error; err != nil {
+				return err
+			}
+			document.GroupID = document.ID
+			document.Version = 1
+			return tx.Model(document).Updates(map[string]interface{}{"group_id": document.ID, "version": 1}).Error
+		}
+		groupID := versionGroupID(previous)
+		var versions []models.UserDocument
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, group_id, version").
+			Where("user_id = ? AND (group_id = ? OR id = ?)", previous.UserID, groupID, groupID).
+			Find(&versions).Error; err != nil {
+			return err
+		}
+		if previous.GroupID == 0 {
+			previous.GroupID = groupID
+			if err := tx.Model(previous).Updates(map[string]interface{}{"group_id": groupID, "version": 1}).Error; err != nil {
+				return err
+			}
+		}
+		latest := 1
+		for _, v := range versions {
+			if v.Version > latest {
+				latest = v.Version
+			}
+		}
+		document.GroupID = groupID
+		document.Version = latest + 1
+		return tx.Create(document).Error
+	})
+}
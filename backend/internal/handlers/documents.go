@@ -11,9 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"ai-career-buddy/internal/company"
 	"ai-career-buddy/internal/db"
 	"ai-career-buddy/internal/logger"
 	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/rag"
 	"ai-career-buddy/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -95,45 +97,54 @@ func UploadUserDocument(c *gin.Context) {
 		return
 	}
 
-	// 创建存储目录
-	uploadDir := fmt.Sprintf("uploads/%s", userID)
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		logger.Error("创建上传目录失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建目录失败"})
+	// 上传新版本时的上一版本：显式指定 versionOf，或同类型同名文档
+	cleanedFileName := utils.SanitizeFileName(header.Filename)
+	previous, err := previousVersion(userID, documentType, cleanedFileName, c.PostForm("versionOf"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 生成唯一文件名
-	timestamp := time.Now().Unix()
-	fileName := fmt.Sprintf("%d_%s", timestamp, header.Filename)
-	filePath := filepath.Join(uploadDir, fileName)
-
-	// 保存文件
-	dst, err := os.Create(filePath)
+	// 保存到文件存储，按内容寻址，不使用原始文件名作为路径
+	contentType := "text/markdown; charset=utf-8"
+	blob, release, err := saveDocumentBlob(file, contentType)
 	if err != nil {
-		logger.Error("创建文件失败: %v", err)
+		logger.Error("保存文件失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败"})
 		return
 	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
-		logger.Error("保存文件失败: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存文件失败"})
+	defer release()
+
+	// 内容完全相同的文档不重复创建，也不重复调用模型提取
+	if existing := findDuplicateDocument(userID, documentType, blob.SHA256); existing != nil {
+		logger.Info("重复上传的文档: UserID=%s, DocumentID=%d, FileName=%s", userID, existing.ID, header.Filename)
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "文档已存在，未重复上传",
+			"document":    existing,
+			"duplicate":   true,
+			"autoAnalyze": false,
+		})
 		return
 	}
 
-	// 提取文件内容（仅支持Markdown格式）
+	// 提取文件内容（仅支持Markdown格式），从已上传的文件重新读取
 	var fileContent string
 	if fileExt == ".md" {
-		content, err := os.ReadFile(filePath)
-		if err == nil {
-			fileContent = string(content)
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			logger.Error("读取上传文件失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败"})
+			return
+		}
+		data, err := io.ReadAll(file)
+		if err != nil {
+			logger.Error("读取上传文件失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "读取文件失败"})
+			return
 		}
+		fileContent = string(data)
 	}
 
 	// 清理文档内容，移除不兼容字符
-	cleanedFileName := utils.SanitizeFileName(header.Filename)
 	cleanedFileContent := utils.CleanDocumentContent(fileContent)
 
 	// 创建文档记录
@@ -141,22 +152,29 @@ func UploadUserDocument(c *gin.Context) {
 		UserID:           userID,
 		DocumentType:     documentType,
 		FileName:         cleanedFileName,
-		FileSize:         header.Size,
+		FileSize:         blob.Size,
 		FileType:         strings.TrimPrefix(fileExt, "."),
-		FilePath:         filePath,
+		BlobKey:          blob.Key,
+		ContentType:      contentType,
+		ContentHash:      blob.SHA256,
+		Version:          1,
 		FileContent:      cleanedFileContent,
 		UploadSource:     "manual",
 		IsProcessed:      false,
 		ProcessingStatus: "pending",
 	}
-
-	if err := db.Conn.Create(&document).Error; err != nil {
+	if err := createVersioned(&document, previous); err != nil {
 		logger.Error("创建文档记录失败: UserID=%s, 错误=%v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建记录失败"})
 		return
 	}
 
-	logger.Info("用户文档上传成功: UserID=%s, DocumentType=%s, FileName=%s", userID, documentType, header.Filename)
+	logger.Info("用户文档上传成功: UserID=%s, DocumentType=%s, FileName=%s, Version=%d", userID, documentType, header.Filename, document.Version)
+
+	// 建立检索索引，AI分析完成后会带上提取要点重新索引
+	if ix := rag.Default(); ix != nil {
+		ix.IndexDocumentAsync(document)
+	}
 
 	// 如果有文件内容，自动触发分析（仅支持Markdown文件）
 	if fileContent != "" && fileExt == ".md" {
@@ -203,6 +221,19 @@ func GetUserDocument(c *gin.Context) {
 	c.JSON(http.StatusOK, document)
 }
 
+// GetDocumentFile 返回文档的原始文件，对话中上传的图片和PDF通过它查看
+func GetDocumentFile(c *gin.Context) {
+	userID := c.Param("userId")
+	documentID := c.Param("documentId")
+
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	serveDocumentFile(c, &document, false)
+}
+
 // DeleteUserDocument 删除用户文档
 func DeleteUserDocument(c *gin.Context) {
 	documentID := c.Param("documentId")
@@ -218,13 +249,6 @@ func DeleteUserDocument(c *gin.Context) {
 		return
 	}
 
-	// 删除文件
-	if document.FilePath != "" {
-		if err := os.Remove(document.FilePath); err != nil {
-			logger.Warn("删除文件失败: %v", err)
-		}
-	}
-
 	// 删除数据库记录
 	if err := db.Conn.Delete(&document).Error; err != nil {
 		logger.Error("删除文档记录失败: DocumentID=%s, 错误=%v", documentID, err)
@@ -232,6 +256,17 @@ func DeleteUserDocument(c *gin.Context) {
 		return
 	}
 
+	// 删除文件，内容相同的文件只保存一份，仍被其他文档引用时保留
+	if err := deleteDocumentFile(&document); err != nil {
+		logger.Warn("删除文件失败: DocumentID=%s, 错误=%v", documentID, err)
+	}
+
+	if ix := rag.Default(); ix != nil {
+		if err := ix.RemoveDocument(document.ID); err != nil {
+			logger.Warn("删除文档索引失败: DocumentID=%s, 错误=%v", documentID, err)
+		}
+	}
+
 	logger.Info("文档删除成功: DocumentID=%s", documentID)
 	c.JSON(http.StatusOK, gin.H{"message": "文档删除成功"})
 }
@@ -422,6 +457,13 @@ func processDocumentWithAI(document *models.UserDocument) error {
 		return err
 	}
 
+	// 合同、Offer、在职文档关联到企业
+	company.LinkDocument(document)
+
+	if ix := rag.Default(); ix != nil {
+		ix.IndexDocumentAsync(*document)
+	}
+
 	logger.Info("文档AI处理完成: DocumentID=%d, DocumentType=%s", document.ID, document.DocumentType)
 	return nil
 }
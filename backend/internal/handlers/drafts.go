@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/drafts"
+	"ai-career-buddy/internal/insights"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/resume"
+	"ai-career-buddy/internal/storage"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GenerateDraftRequest 生成文书草稿请求
+type GenerateDraftRequest struct {
+	Kind            string `json:"kind" binding:"required"`
+	OfferDocumentID uint   `json:"offerDocumentId"` // 依据的Offer，为空时使用最近一份已分析的Offer
+	Tone            string `json:"tone"`            // formal（默认）、friendly、assertive
+	Length          string `json:"length"`          // concise、standard（默认）、detailed
+	Recipient       string `json:"recipient"`       // 收件人邮箱
+	RecipientName   string `json:"recipientName"`
+	Company         string `json:"company"`      // 求职信的目标公司，或覆盖Offer中的公司
+	Position        string `json:"position"`     // 求职信的目标职位，或覆盖Offer中的职位
+	TargetSalary    string `json:"targetSalary"` // 还价的期望薪资，为空时使用建议值
+	LastDay         string `json:"lastDay"`      // 辞职信的最后工作日
+	Notes           string `json:"notes"`        // 需要在文书中体现的补充说明
+	UseModel        *bool  `json:"useModel"`     // 是否由模型撰写，默认是；为否时只用模板填写
+	ModelID         string `json:"modelId"`
+}
+
+// UpdateDraftRequest 编辑草稿请求，字段为空表示不修改
+type UpdateDraftRequest struct {
+	Title         *string `json:"title"`
+	Subject       *string `json:"subject"`
+	Body          *string `json:"body"`
+	Recipient     *string `json:"recipient"`
+	RecipientName *string `json:"recipientName"`
+}
+
+// maxHighlights 求职信中引用的简历经历条数
+const maxHighlights = 3
+
+// GetDraftOptions 文书类型、语气和篇幅选项
+func GetDraftOptions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"kinds": drafts.Kinds(), "tones": drafts.Tones(), "lengths": drafts.Lengths()})
+}
+
+// GenerateDraft 根据Offer、用户资料和薪酬分析生成文书草稿并保存
+func GenerateDraft(c *gin.Context) {
+	userID := c.Param("userId")
+	var req GenerateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供文书类型 kind"})
+		return
+	}
+	kind, err := drafts.LookupKind(req.Kind)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tone, err := drafts.LookupTone(req.Tone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	length, err := drafts.LookupLength(req.Length)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Recipient != "" {
+		address, name, err := parseRecipient(req.Recipient)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "收件人邮箱格式不正确"})
+			return
+		}
+		req.Recipient = address
+		if strings.TrimSpace(req.RecipientName) == "" {
+			req.RecipientName = name
+		}
+	}
+
+	ctx := draftContext(userID, &req)
+	var offerDoc *models.UserDocument
+	if kind.NeedsOffer {
+		offerDoc, err = findOfferDocument(userID, req.OfferDocumentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := applyOffer(ctx, offerDoc, userID, &req); err != nil {
+			logger.Error("解析提取信息失败: DocumentID=%d, 错误=%v", offerDoc.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+			return
+		}
+	}
+	if kind.Name == drafts.KindCoverLetter && ctx.Position == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供应聘的职位 position"})
+		return
+	}
+
+	draft := models.Draft{
+		UserID:        userID,
+		Kind:          kind.Name,
+		Tone:          tone.Name,
+		Length:        length.Name,
+		Recipient:     req.Recipient,
+		RecipientName: ctx.RecipientName,
+		Title:         strings.TrimSpace(kind.Title + " " + ctx.Company),
+	}
+	if offerDoc != nil {
+		draft.DocumentID = offerDoc.ID
+	}
+
+	var result *drafts.Result
+	var fallback string
+	if req.UseModel == nil || *req.UseModel {
+		modelID := req.ModelID
+		if modelID == "" {
+			modelID = config.C.DraftModel
+		}
+		result, err = drafts.Compose(kind, ctx, tone.Name, length.Name, modelID)
+		if err == nil {
+			draft.Source, draft.ModelID, draft.PromptVersion = "ai", modelID, result.PromptVersion
+		} else {
+			logger.Warn("模型撰写文书失败，使用模板: UserID=%s, 类型=%s, 错误=%v", userID, kind.Name, err)
+			fallback = "模型撰写失败，已使用内置模板生成"
+		}
+	}
+	if result == nil {
+		result, err = drafts.Fill(kind, ctx, tone.Name, length.Name)
+		if err != nil {
+			logger.Error("填写文书模板失败: 类型=%s, 错误=%v", kind.Name, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成失败"})
+			return
+		}
+		draft.Source = "template"
+	}
+
+	draft.Subject = utils.SanitizeForDatabase(result.Subject)
+	draft.Body = utils.SanitizeForDatabase(result.Body)
+	if data, err := json.Marshal(ctx); err == nil {
+		draft.Context = string(data)
+	}
+	if err := db.Conn.Create(&draft).Error; err != nil {
+		logger.Error("保存文书草稿失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	logger.Info("文书草稿已生成: UserID=%s, DraftID=%d, 类型=%s, 来源=%s", userID, draft.ID, draft.Kind, draft.Source)
+	resp := gin.H{"draft": draft, "compensation": ctx.Compensation}
+	if fallback != "" {
+		resp["warning"] = fallback
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetDrafts 获取用户的文书草稿，可按类型筛选
+func GetDrafts(c *gin.Context) {
+	userID := c.Param("userId")
+	query := db.Conn.Where("user_id = ?", userID)
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var list []models.Draft
+	if err := query.Order("updated_at DESC").Find(&list).Error; err != nil {
+		logger.Error("获取文书草稿失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"drafts": list})
+}
+
+// GetDraft 获取一份草稿
+func GetDraft(c *gin.Context) {
+	draft, ok := loadDraft(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// UpdateDraft 编辑草稿
+func UpdateDraft(c *gin.Context) {
+	draft, ok := loadDraft(c)
+	if !ok {
+		return
+	}
+	var req UpdateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Recipient != nil && strings.TrimSpace(*req.Recipient) != "" {
+		address, name, err := parseRecipient(*req.Recipient)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "收件人邮箱格式不正确"})
+			return
+		}
+		req.Recipient = &address
+		if req.RecipientName == nil && name != "" {
+			req.RecipientName = &name
+		}
+	}
+	for field, value := range map[*string]*string{
+		&draft.Title: req.Title, &draft.Subject: req.Subject, &draft.Body: req.Body,
+		&draft.Recipient: req.Recipient, &draft.RecipientName: req.RecipientName,
+	} {
+		if value != nil {
+			*field = utils.SanitizeForDatabase(strings.TrimSpace(*value))
+		}
+	}
+	draft.Edited = true
+	draft.UpdatedAt = time.Now()
+	if err := db.Conn.Save(draft).Error; err != nil {
+		logger.Error("更新文书草稿失败: DraftID=%d, 错误=%v", draft.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// DeleteDraft 删除草稿
+func DeleteDraft(c *gin.Context) {
+	draft, ok := loadDraft(c)
+	if !ok {
+		return
+	}
+	if err := db.Conn.Delete(draft).Error; err != nil {
+		logger.Error("删除文书草稿失败: DraftID=%d, 错误=%v", draft.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// ExportDraft 导出草稿，format=markdown（默认）或 eml
+func ExportDraft(c *gin.Context) {
+	draft, ok := loadDraft(c)
+	if !ok {
+		return
+	}
+	msg := &drafts.Message{Subject: draft.Subject, Body: draft.Body, Date: time.Now()}
+	if draft.Recipient != "" {
+		msg.To = &mail.Address{Name: draft.RecipientName, Address: draft.Recipient}
+	}
+	var profile models.UserProfile
+	if err := db.Conn.Where("user_id = ?", draft.UserID).First(&profile).Error; err == nil && profile.Email != "" {
+		msg.From = &mail.Address{Name: profile.Nickname, Address: profile.Email}
+	}
+
+	fileName := utils.SanitizeFileName(draft.Title)
+	if fileName == "" {
+		fileName = "draft"
+	}
+	switch c.DefaultQuery("format", "markdown") {
+	case "markdown":
+		c.Header("Content-Disposition", storage.ContentDisposition(fileName+".md"))
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", drafts.Markdown(msg))
+	case "eml":
+		c.Header("Content-Disposition", storage.ContentDisposition(fileName+".eml"))
+		c.Data(http.StatusOK, "message/rfc822", drafts.EML(msg))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 markdown、eml"})
+	}
+}
+
+// loadDraft 按路由参数加载草稿（校验归属用户），失败时直接写入响应
+func loadDraft(c *gin.Context) (*models.Draft, bool) {
+	userID := c.Param("userId")
+	draftID := c.Param("draftId")
+	var draft models.Draft
+	if err := db.Conn.Where("id = ? AND user_id = ?", draftID, userID).First(&draft).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "草稿不存在"})
+		return nil, false
+	}
+	return &draft, true
+}
+
+// parseRecipient 解析"张三 <a@b.com>"形式的收件人，分别返回邮箱和姓名
+func parseRecipient(raw string) (address, name string, err error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(raw))
+	if err != nil {
+		return "", "", err
+	}
+	return addr.Address, strings.TrimSpace(addr.Name), nil
+}
+
+// draftContext 用用户资料、最新简历和请求中的信息填写文书上下文
+func draftContext(userID string, req *GenerateDraftRequest) *drafts.Context {
+	ctx := &drafts.Context{
+		Company:       strings.TrimSpace(req.Company),
+		Position:      strings.TrimSpace(req.Position),
+		RecipientName: strings.TrimSpace(req.RecipientName),
+		TargetSalary:  strings.TrimSpace(req.TargetSalary),
+		LastDay:       strings.TrimSpace(req.LastDay),
+		Notes:         utils.SanitizeForDatabase(strings.TrimSpace(req.Notes)),
+	}
+	var profile models.UserProfile
+	if err := db.Conn.Where("user_id = ?", userID).First(&profile).Error; err == nil {
+		ctx.SenderName = profile.Nickname
+		ctx.SenderEmail = profile.Email
+		ctx.SenderPhone = profile.Phone
+		ctx.CurrentCompany = profile.Company
+		ctx.CurrentPosition = profile.Position
+		ctx.Experience = profile.Experience
+	}
+	if info := latestResumeInfo(userID); info != nil {
+		p := info.PersonalInfo
+		if p.Name != "" {
+			ctx.SenderName = p.Name
+		}
+		if ctx.SenderEmail == "" {
+			ctx.SenderEmail = p.Email
+		}
+		if ctx.SenderPhone == "" {
+			ctx.SenderPhone = p.Phone
+		}
+		if len(info.WorkExperience) > 0 {
+			latest := info.WorkExperience[0]
+			if ctx.CurrentCompany == "" {
+				ctx.CurrentCompany = latest.Company
+			}
+			if ctx.CurrentPosition == "" {
+				ctx.CurrentPosition = latest.Position
+			}
+		}
+		for _, w := range info.WorkExperience {
+			for _, b := range resume.Bullets(w.Description) {
+				if len(ctx.Highlights) < maxHighlights {
+					ctx.Highlights = append(ctx.Highlights, b)
+				}
+			}
+		}
+		ctx.Skills = info.Skills.Technical
+	}
+	return ctx
+}
+
+// findOfferDocument 指定的Offer，或最近一份已分析的Offer
+func findOfferDocument(userID string, documentID uint) (*models.UserDocument, error) {
+	var doc models.UserDocument
+	query := db.Conn.Where("user_id = ? AND document_type = ?", userID, "offer")
+	if documentID != 0 {
+		if err := query.Where("id = ?", documentID).First(&doc).Error; err != nil {
+			return nil, errors.New("Offer文档不存在")
+		}
+	} else if err := query.Where("is_processed = ?", true).Order("created_at DESC").First(&doc).Error; err != nil {
+		return nil, errors.New("请先上传Offer并完成AI分析，或指定 offerDocumentId")
+	}
+	if !doc.IsProcessed {
+		return nil, errors.New("Offer尚未完成AI分析")
+	}
+	return &doc, nil
+}
+
+// applyOffer 填写Offer条款和薪酬分析：与当前合同薪资、平台同企业同职位的薪资带比较。
+// 薪资带按Offer中的职位计算（不受请求覆盖），并排除用户本人的数据
+func applyOffer(ctx *drafts.Context, doc *models.UserDocument, userID string, req *GenerateDraftRequest) error {
+	info, err := doc.GetExtractedInfo()
+	if err != nil {
+		return err
+	}
+	o := info.OfferInfo
+	ctx.Offer = &drafts.OfferTerms{
+		Salary:       o.Salary,
+		Bonus:        o.Bonus,
+		Equity:       o.Equity,
+		StartDate:    o.StartDate,
+		WorkLocation: o.WorkLocation,
+		Benefits:     o.Benefits,
+	}
+	if ctx.Company == "" {
+		ctx.Company = o.CompanyName
+	}
+	if ctx.Position == "" {
+		ctx.Position = o.Position
+	}
+
+	ctx.Compensation = drafts.Analyze(o.Salary, currentSalary(userID, doc, o.CompanyName), offerMarketBand(doc, o.Position, userID))
+	return nil
+}
+
+// currentSalary 当前工作的合同薪资：取Offer之前上传的最新一份已分析合同，
+// 跳过与Offer同一企业的合同（可能是接受Offer后签的新合同）
+func currentSalary(userID string, offer *models.UserDocument, offerCompany string) string {
+	var contracts []models.UserDocument
+	if err := db.Conn.Where("user_id = ? AND document_type = ? AND is_processed = ? AND created_at <= ?", userID, "contract", true, offer.CreatedAt).
+		Order("created_at DESC").Find(&contracts).Error; err != nil {
+		return ""
+	}
+	offerCompany = strings.TrimSpace(offerCompany)
+	for i := range contracts {
+		if offer.CompanyID != nil && contracts[i].CompanyID != nil && *contracts[i].CompanyID == *offer.CompanyID {
+			continue
+		}
+		info, err := contracts[i].GetExtractedInfo()
+		if err != nil {
+			continue
+		}
+		if offerCompany != "" && strings.TrimSpace(info.ContractInfo.CompanyName) == offerCompany {
+			continue
+		}
+		return info.ContractInfo.Salary
+	}
+	return ""
+}
+
+// offerMarketBand Offer所在企业同职位的平台薪资带（不含用户本人），人数不足时为 nil
+func offerMarketBand(doc *models.UserDocument, position, userID string) *insights.SalaryBand {
+	if doc.CompanyID == nil || strings.TrimSpace(position) == "" {
+		return nil
+	}
+	var item models.Company
+	if err := db.Conn.First(&item, *doc.CompanyID).Error; err != nil {
+		return nil
+	}
+	result, err := insights.ComputeExcluding(&item, position, config.C.InsightsKAnonymity, userID)
+	if err != nil {
+		logger.Warn("计算企业洞察失败: CompanyID=%d, 错误=%v", item.ID, err)
+		return nil
+	}
+	if result.Salary == nil {
+		return nil
+	}
+	return result.Salary.Overall
+}
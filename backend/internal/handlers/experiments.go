@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/experiment"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListExperiments 获取所有实验
+func ListExperiments(c *gin.Context) {
+	var list []models.Experiment
+	query := db.Conn.Order("id DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		logger.Error("获取实验列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"experiments": list})
+}
+
+// CreateExperiment 创建实验，状态为 running 时立即开始分流
+func CreateExperiment(c *gin.Context) {
+	var e models.Experiment
+	if err := c.ShouldBindJSON(&e); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	e.ID = 0
+	if err := experiment.Validate(&e); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var count int64
+	db.Conn.Model(&models.Experiment{}).Where("experiment_key = ?", e.Key).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "实验标识已存在"})
+		return
+	}
+	e.StartedAt, e.StoppedAt = nil, nil
+	markStatusTime(&e)
+
+	if err := db.Conn.Create(&e).Error; err != nil {
+		logger.Error("创建实验失败: Key=%s, 错误=%v", e.Key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
+		return
+	}
+	logger.Info("实验已创建: Key=%s, Status=%s, 分组数=%d", e.Key, e.Status, len(e.Variants))
+	c.JSON(http.StatusOK, e)
+}
+
+// GetExperiment 获取实验配置
+func GetExperiment(c *gin.Context) {
+	e, ok := loadExperiment(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, e)
+}
+
+// UpdateExperiment 修改实验配置或状态；运行中的实验不能修改分组和分流方式，以免已分组的用户换组
+func UpdateExperiment(c *gin.Context) {
+	e, ok := loadExperiment(c)
+	if !ok {
+		return
+	}
+
+	var in models.Experiment
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated := *e
+	if in.Name != "" {
+		updated.Name = in.Name
+	}
+	if in.Description != "" {
+		updated.Description = in.Description
+	}
+	if in.Status != "" {
+		updated.Status = in.Status
+	}
+	if in.Traffic != 0 {
+		updated.Traffic = in.Traffic
+	}
+	routingChanged := (in.BucketBy != "" && in.BucketBy != e.BucketBy) ||
+		(in.Category != "" && in.Category != e.Category) || len(in.Variants) > 0
+	if routingChanged {
+		if e.Status == experiment.StatusRunning {
+			c.JSON(http.StatusConflict, gin.H{"error": "运行中的实验不能修改分组，请先停止实验"})
+			return
+		}
+		if in.BucketBy != "" {
+			updated.BucketBy = in.BucketBy
+		}
+		if in.Category != "" {
+			updated.Category = in.Category
+		}
+		if len(in.Variants) > 0 {
+			updated.Variants = in.Variants
+		}
+	}
+	if err := experiment.Validate(&updated); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if updated.Status != e.Status {
+		markStatusTime(&updated)
+	}
+
+	if err := db.Conn.Save(&updated).Error; err != nil {
+		logger.Error("更新实验失败: Key=%s, 错误=%v", e.Key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+	logger.Info("实验已更新: Key=%s, Status=%s", updated.Key, updated.Status)
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetExperimentReport 按分组汇总满意度、耗时和token成本
+func GetExperimentReport(c *gin.Context) {
+	e, ok := loadExperiment(c)
+	if !ok {
+		return
+	}
+	report, err := experiment.BuildReport(e)
+	if err != nil {
+		logger.Error("生成实验报告失败: Key=%s, 错误=%v", e.Key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成报告失败"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func loadExperiment(c *gin.Context) (*models.Experiment, bool) {
+	var e models.Experiment
+	err := db.Conn.Where("experiment_key = ?", c.Param("key")).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "实验不存在"})
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("获取实验失败: Key=%s, 错误=%v", c.Param("key"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return nil, false
+	}
+	return &e, true
+}
+
+// markStatusTime 记录实验开始和停止时间
+func markStatusTime(e *models.Experiment) {
+	now := time.Now()
+	switch e.Status {
+	case experiment.StatusRunning:
+		if e.StartedAt == nil {
+			e.StartedAt = &now
+		}
+		e.StoppedAt = nil
+	case experiment.StatusStopped:
+		e.StoppedAt = &now
+	}
+}
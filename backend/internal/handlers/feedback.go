@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// feedbackReasons 反馈原因代码
+var feedbackReasons = map[string]string{
+	"helpful":        "有帮助",
+	"actionable":     "建议可执行",
+	"well_explained": "解释清楚",
+	"inaccurate":     "内容不准确",
+	"irrelevant":     "答非所问",
+	"incomplete":     "不够完整",
+	"outdated":       "信息过时",
+	"too_long":       "篇幅太长",
+	"unsafe":         "存在风险或不当内容",
+	"other":          "其他",
+}
+
+// MessageFeedbackRequest 回复反馈请求
+type MessageFeedbackRequest struct {
+	UserID  string   `json:"userId"`
+	Thumbs  string   `json:"thumbs"` // up, down
+	Rating  int      `json:"rating"` // 1-5
+	Reasons []string `json:"reasons"`
+	Comment string   `json:"comment"`
+}
+
+// SubmitMessageFeedback 提交对助手回复的反馈，重复提交会覆盖之前的反馈，评分同步到对应的咨询记录
+func SubmitMessageFeedback(c *gin.Context) {
+	var in MessageFeedbackRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	msg, ok := loadAssistantMessage(c, in.UserID)
+	if !ok {
+		return
+	}
+	if in.Thumbs != "" && in.Thumbs != "up" && in.Thumbs != "down" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "thumbs 只能为 up 或 down"})
+		return
+	}
+	if in.Rating < 0 || in.Rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评分需在1-5之间"})
+		return
+	}
+	reasons := []string{}
+	for _, r := range in.Reasons {
+		r = strings.TrimSpace(r)
+		if _, ok := feedbackReasons[r]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的反馈原因: " + r})
+			return
+		}
+		if !containsString(reasons, r) {
+			reasons = append(reasons, r)
+		}
+	}
+	if in.Thumbs == "" && in.Rating == 0 && len(reasons) == 0 && strings.TrimSpace(in.Comment) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "反馈内容不能为空"})
+		return
+	}
+
+	var feedback models.MessageFeedback
+	err := db.Conn.Where("message_id = ?", msg.ID).First(&feedback).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Error("查询消息反馈失败: MessageID=%d, 错误=%v", msg.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	feedback.MessageID = msg.ID
+	feedback.UserID = msg.UserID
+	feedback.ThreadID = msg.ThreadID
+	feedback.Thumbs = in.Thumbs
+	feedback.Rating = in.Rating
+	feedback.Reasons = reasons
+	feedback.Comment = strings.TrimSpace(in.Comment)
+	if err := db.Conn.Save(&feedback).Error; err != nil {
+		logger.Error("保存消息反馈失败: MessageID=%d, 错误=%v", msg.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	mirrorToCareerHistory(msg, map[string]interface{}{"rating": feedbackRating(&feedback)})
+	logger.Info("消息反馈已保存: MessageID=%d, Thumbs=%s, Rating=%d, Reasons=%v", msg.ID, feedback.Thumbs, feedback.Rating, feedback.Reasons)
+	c.JSON(http.StatusOK, feedback)
+}
+
+// GetMessageFeedback 获取回复的反馈
+func GetMessageFeedback(c *gin.Context) {
+	msg, ok := loadAssistantMessage(c, c.Query("userId"))
+	if !ok {
+		return
+	}
+	var feedback models.MessageFeedback
+	err := db.Conn.Where("message_id = ?", msg.ID).First(&feedback).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusOK, gin.H{"feedback": nil})
+		return
+	}
+	if err != nil {
+		logger.Error("获取消息反馈失败: MessageID=%d, 错误=%v", msg.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"feedback": feedback})
+}
+
+// BookmarkMessage 收藏回复
+func BookmarkMessage(c *gin.Context) {
+	setMessageBookmark(c, true)
+}
+
+// UnbookmarkMessage 取消收藏回复
+func UnbookmarkMessage(c *gin.Context) {
+	setMessageBookmark(c, false)
+}
+
+func setMessageBookmark(c *gin.Context, bookmarked bool) {
+	msg, ok := loadAssistantMessage(c, c.Query("userId"))
+	if !ok {
+		return
+	}
+
+	var bookmarkedAt *time.Time
+	if bookmarked {
+		now := time.Now()
+		bookmarkedAt = &now
+	}
+	if err := db.Conn.Model(msg).Updates(map[string]interface{}{
+		"is_bookmarked": bookmarked,
+		"bookmarked_at": bookmarkedAt,
+	}).Error; err != nil {
+		logger.Error("更新消息收藏失败: MessageID=%d, 错误=%v", msg.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "操作失败"})
+		return
+	}
+	msg.IsBookmarked = bookmarked
+	msg.BookmarkedAt = bookmarkedAt
+
+	mirrorToCareerHistory(msg, map[string]interface{}{"is_bookmarked": bookmarked})
+	logger.Info("消息收藏已更新: MessageID=%d, 收藏=%t", msg.ID, bookmarked)
+	c.JSON(http.StatusOK, msg)
+}
+
+// bookmarkItem 收藏的回复及对应的提问
+type bookmarkItem struct {
+	Message  models.Message          `json:"message"`
+	Question string                  `json:"question"`
+	Feedback *models.MessageFeedback `json:"feedback,omitempty"`
+}
+
+// GetBookmarks 分页获取用户收藏的回复，按收藏时间倒序
+func GetBookmarks(c *gin.Context) {
+	userID := c.Param("userId")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := db.Conn.Model(&models.Message{}).Where("user_id = ? AND role = ? AND is_bookmarked = ?", userID, "assistant", true)
+	if threadID := c.Query("threadId"); threadID != "" {
+		query = query.Where("thread_id = ?", threadID)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Error("获取收藏失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	var messages []models.Message
+	if err := query.Order("bookmarked_at DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&messages).Error; err != nil {
+		logger.Error("获取收藏失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	ids := make([]uint, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+	feedbacks := map[uint]*models.MessageFeedback{}
+	if len(ids) > 0 {
+		var list []models.MessageFeedback
+		db.Conn.Where("message_id IN ?", ids).Find(&list)
+		for i := range list {
+			feedbacks[list[i].MessageID] = &list[i]
+		}
+	}
+
+	items := make([]bookmarkItem, 0, len(messages))
+	for _, m := range messages {
+		items = append(items, bookmarkItem{Message: m, Question: precedingQuestion(&m), Feedback: feedbacks[m.ID]})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"total":     total,
+		"page":      page,
+		"pageSize":  pageSize,
+		"bookmarks": items,
+	})
+}
+
+// loadAssistantMessage 按路由参数加载该用户会话中的助手消息，失败时已写入错误响应
+func loadAssistantMessage(c *gin.Context, userID string) (*models.Message, bool) {
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId 不能为空"})
+		return nil, false
+	}
+	var msg models.Message
+	err := db.Conn.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&msg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "消息不存在"})
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("获取消息失败: ID=%s, 错误=%v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return nil, false
+	}
+	if msg.Role != "assistant" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能对助手回复进行反馈或收藏"})
+		return nil, false
+	}
+	return &msg, true
+}
+
+// precedingQuestion 返回该回复对应的用户消息，早期没有父消息的回复取同一会话中之前的最后一条用户消息
+func precedingQuestion(msg *models.Message) string {
+	var question models.Message
+	if msg.ParentID != nil {
+		if err := db.Conn.Where("id = ? AND role = ?", *msg.ParentID, "user").First(&question).Error; err != nil {
+			return ""
+		}
+		return question.Content
+	}
+	err := db.Conn.Where("thread_id = ? AND user_id = ? AND role = ? AND id < ?", msg.ThreadID, msg.UserID, "user", msg.ID).
+		Order("id DESC").First(&question).Error
+	if err != nil {
+		return ""
+	}
+	return question.Content
+}
+
+// feedbackRating 同步到咨询记录的评分，只点赞或点踩时按5分或1分计
+func feedbackRating(f *models.MessageFeedback) int {
+	if f.Rating > 0 {
+		return f.Rating
+	}
+	switch f.Thumbs {
+	case "up":
+		return 5
+	case "down":
+		return 1
+	}
+	return 0
+}
+
+// mirrorToCareerHistory 将反馈和收藏同步到回复对应的咨询记录；
+// 早期的记录没有关联消息ID，按会话和回复内容匹配后补上关联
+func mirrorToCareerHistory(msg *models.Message, updates map[string]interface{}) {
+	result := db.Conn.Model(&models.CareerHistory{}).Where("message_id = ?", msg.ID).Updates(updates)
+	if result.Error != nil {
+		logger.Error("同步咨询记录失败: MessageID=%d, 错误=%v", msg.ID, result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		return
+	}
+
+	var history models.CareerHistory
+	err := db.Conn.Where("user_id = ? AND thread_id = ? AND message_id = 0 AND ai_response = ?", msg.UserID, msg.ThreadID, msg.Content).
+		Order("id DESC").First(&history).Error
+	if err != nil {
+		logger.Debug("未找到消息对应的咨询记录: MessageID=%d", msg.ID)
+		return
+	}
+	updates["message_id"] = msg.ID
+	if err := db.Conn.Model(&history).Updates(updates).Error; err != nil {
+		logger.Error("同步咨询记录失败: MessageID=%d, HistoryID=%d, 错误=%v", msg.ID, history.ID, err)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
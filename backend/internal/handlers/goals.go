@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GoalRequest 创建/更新职业目标请求，字段为空表示不修改
+type GoalRequest struct {
+	Title         *string    `json:"title"`
+	Description   *string    `json:"description"`
+	Category      *string    `json:"category"`
+	LinkedSkill   *string    `json:"linkedSkill"`
+	TargetDate    *time.Time `json:"targetDate"`
+	Status        *string    `json:"status"`
+	EvidenceLinks []string   `json:"evidenceLinks"`
+}
+
+// PlanTaskRequest 创建/更新计划任务请求，字段为空表示不修改
+type PlanTaskRequest struct {
+	Title         *string    `json:"title"`
+	Description   *string    `json:"description"`
+	WeekIndex     *int       `json:"weekIndex"`
+	DueDate       *time.Time `json:"dueDate"`
+	LinkedSkill   *string    `json:"linkedSkill"`
+	Status        *string    `json:"status"`
+	EvidenceLinks []string   `json:"evidenceLinks"`
+}
+
+// GeneratePlanRequest AI拆解目标请求
+type GeneratePlanRequest struct {
+	Weeks   int    `json:"weeks"`   // 计划周数，为空时按目标日期计算
+	ModelID string `json:"modelId"` // 使用的模型
+	Replace bool   `json:"replace"` // 是否替换已有的AI任务
+}
+
+var errInvalidStatus = errors.New("无效的状态")
+
+var validGoalStatuses = []string{models.GoalStatusActive, models.GoalStatusPaused, models.GoalStatusCompleted, models.GoalStatusAbandoned}
+var validTaskStatuses = []string{models.TaskStatusTodo, models.TaskStatusDoing, models.TaskStatusDone, models.TaskStatusSkipped}
+
+// GetGoals 获取用户的职业目标列表
+func GetGoals(c *gin.Context) {
+	userID := c.Param("userId")
+	status := c.Query("status") // active, paused, completed, abandoned
+
+	var goals []models.Goal
+	query := db.Conn.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Preload("Tasks", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("week_index ASC, id ASC")
+	}).Order("created_at DESC").Find(&goals).Error; err != nil {
+		logger.Error("获取职业目标失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	logger.Info("获取职业目标: UserID=%s, Status=%s, 数量=%d", userID, status, len(goals))
+	c.JSON(http.StatusOK, gin.H{"goals": goals})
+}
+
+// CreateGoal 创建职业目标
+func CreateGoal(c *gin.Context) {
+	userID := c.Param("userId")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "用户ID不能为空"})
+		return
+	}
+
+	var in GoalRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error("职业目标创建请求解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "目标标题不能为空"})
+		return
+	}
+
+	goal := models.Goal{UserID: userID, Status: models.GoalStatusActive}
+	if err := applyGoalRequest(&goal, &in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Conn.Create(&goal).Error; err != nil {
+		logger.Error("创建职业目标失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
+		return
+	}
+
+	logger.Info("职业目标创建成功: UserID=%s, GoalID=%d", userID, goal.ID)
+	c.JSON(http.StatusOK, goal)
+}
+
+// GetGoal 获取单个职业目标及其任务
+func GetGoal(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+// UpdateGoal 更新职业目标
+func UpdateGoal(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+
+	var in GoalRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error("职业目标更新请求解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyGoalRequest(goal, &in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	goal.UpdatedAt = time.Now()
+	if err := db.Conn.Omit("Tasks").Save(goal).Error; err != nil {
+		logger.Error("更新职业目标失败: GoalID=%d, 错误=%v", goal.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	logger.Info("职业目标更新成功: GoalID=%d", goal.ID)
+	c.JSON(http.StatusOK, goal)
+}
+
+// DeleteGoal 删除职业目标及其任务
+func DeleteGoal(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+
+	if err := db.Conn.Where("goal_id = ?", goal.ID).Delete(&models.PlanTask{}).Error; err != nil {
+		logger.Error("删除目标任务失败: GoalID=%d, 错误=%v", goal.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	if err := db.Conn.Delete(&models.Goal{}, goal.ID).Error; err != nil {
+		logger.Error("删除职业目标失败: GoalID=%d, 错误=%v", goal.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+
+	logger.Info("职业目标删除成功: GoalID=%d", goal.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetPlanTasks 获取目标下的计划任务
+func GetPlanTasks(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": goal.Tasks})
+}
+
+// CreatePlanTask 为目标添加计划任务
+func CreatePlanTask(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+
+	var in PlanTaskRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error("计划任务创建请求解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if in.Title == nil || strings.TrimSpace(*in.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务标题不能为空"})
+		return
+	}
+
+	task := models.PlanTask{
+		GoalID: goal.ID,
+		UserID: goal.UserID,
+		Status: models.TaskStatusTodo,
+		Source: "manual",
+	}
+	if err := applyPlanTaskRequest(&task, &in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := db.Conn.Create(&task).Error; err != nil {
+		logger.Error("创建计划任务失败: GoalID=%d, 错误=%v", goal.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建失败"})
+		return
+	}
+	refreshGoalProgress(goal.ID)
+
+	logger.Info("计划任务创建成功: GoalID=%d, TaskID=%d", goal.ID, task.ID)
+	c.JSON(http.StatusOK, task)
+}
+
+// UpdatePlanTask 更新计划任务（状态、证明材料等）
+func UpdatePlanTask(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	taskID := c.Param("taskId")
+
+	var task models.PlanTask
+	if err := db.Conn.Where("id = ? AND goal_id = ?", taskID, goal.ID).First(&task).Error; err != nil {
+		logger.Warn("计划任务不存在: GoalID=%d, TaskID=%s", goal.ID, taskID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	var in PlanTaskRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		logger.Error("计划任务更新请求解析失败: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := applyPlanTaskRequest(&task, &in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task.UpdatedAt = time.Now()
+	if err := db.Conn.Save(&task).Error; err != nil {
+		logger.Error("更新计划任务失败: TaskID=%d, 错误=%v", task.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+	refreshGoalProgress(goal.ID)
+
+	logger.Info("计划任务更新成功: GoalID=%d, TaskID=%d, Status=%s", goal.ID, task.ID, task.Status)
+	c.JSON(http.StatusOK, task)
+}
+
+// DeletePlanTask 删除计划任务
+func DeletePlanTask(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	taskID := c.Param("taskId")
+
+	result := db.Conn.Where("id = ? AND goal_id = ?", taskID, goal.ID).Delete(&models.PlanTask{})
+	if result.Error != nil {
+		logger.Error("删除计划任务失败: TaskID=%s, 错误=%v", taskID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+	refreshGoalProgress(goal.ID)
+
+	logger.Info("计划任务删除成功: GoalID=%d, TaskID=%s", goal.ID, taskID)
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GenerateGoalPlan 使用AI将目标拆解为每周任务
+func GenerateGoalPlan(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+
+	var in GeneratePlanRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&in); err != nil {
+			logger.Error("目标计划生成请求解析失败: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	weeks := in.Weeks
+	if weeks <= 0 {
+		weeks = utils.PlanWeeks(goal.TargetDate, now)
+	}
+	if weeks > 12 {
+		weeks = 12
+	}
+
+	input := utils.PlanInput{Goal: goal, Weeks: weeks, ModelID: in.ModelID}
+
+	var profile models.UserProfile
+	if err := db.Conn.Where("user_id = ?", goal.UserID).First(&profile).Error; err == nil {
+		input.Profile = &profile
+	}
+	input.Resume = latestResumeInfo(goal.UserID)
+
+	generated, err := utils.NewPlanGenerator().GeneratePlan(input)
+	if err != nil {
+		logger.Error("生成目标计划失败: GoalID=%d, 错误=%v", goal.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "生成计划失败: " + err.Error()})
+		return
+	}
+
+	tasks := make([]models.PlanTask, 0, len(generated))
+	for _, g := range generated {
+		due := now.AddDate(0, 0, 7*g.Week)
+		tasks = append(tasks, models.PlanTask{
+			GoalID:      goal.ID,
+			UserID:      goal.UserID,
+			Title:       utils.SanitizeForDatabase(g.Title),
+			Description: utils.SanitizeForDatabase(g.Description),
+			WeekIndex:   g.Week,
+			DueDate:     &due,
+			LinkedSkill: g.LinkedSkill,
+			Status:      models.TaskStatusTodo,
+			Source:      "ai",
+		})
+	}
+	// 替换时删除旧任务和写入新任务放在同一事务中，写入失败不会丢掉原计划
+	err = db.Conn.Transaction(func(tx *gorm.DB) error {
+		if in.Replace {
+			if err := tx.Where("goal_id = ? AND source = ?", goal.ID, "ai").Delete(&models.PlanTask{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		return tx.Create(&tasks).Error
+	})
+	if err != nil {
+		logger.Error("保存AI生成的任务失败: GoalID=%d, Replace=%v, 错误=%v", goal.ID, in.Replace, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+	refreshGoalProgress(goal.ID)
+
+	logger.Info("目标计划生成成功: GoalID=%d, 周数=%d, 任务数=%d", goal.ID, weeks, len(tasks))
+	c.JSON(http.StatusOK, gin.H{
+		"goalId": goal.ID,
+		"weeks":  weeks,
+		"tasks":  tasks,
+	})
+}
+
+// loadGoal 按路由参数加载目标（校验归属用户），失败时直接写入响应
+func loadGoal(c *gin.Context) (*models.Goal, bool) {
+	userID := c.Param("userId")
+	goalID := c.Param("goalId")
+
+	var goal models.Goal
+	if err := db.Conn.Where("id = ? AND user_id = ?", goalID, userID).
+		Preload("Tasks", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("week_index ASC, id ASC")
+		}).First(&goal).Error; err != nil {
+		logger.Warn("职业目标不存在: UserID=%s, GoalID=%s", userID, goalID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "目标不存在"})
+		return nil, false
+	}
+	return &goal, true
+}
+
+// applyGoalRequest 将请求中的非空字段写入目标
+func applyGoalRequest(goal *models.Goal, in *GoalRequest) error {
+	if in.Title != nil {
+		goal.Title = utils.SanitizeForDatabase(*in.Title)
+	}
+	if in.Description != nil {
+		goal.Description = utils.SanitizeForDatabase(*in.Description)
+	}
+	if in.Category != nil {
+		goal.Category = *in.Category
+	}
+	if in.LinkedSkill != nil {
+		goal.LinkedSkill = *in.LinkedSkill
+	}
+	if in.TargetDate != nil {
+		goal.TargetDate = in.TargetDate
+	}
+	if in.Status != nil {
+		if !contains(validGoalStatuses, *in.Status) {
+			return errInvalidStatus
+		}
+		if *in.Status == models.GoalStatusCompleted && goal.Status != models.GoalStatusCompleted {
+			now := time.Now()
+			goal.CompletedAt = &now
+		} else if *in.Status != models.GoalStatusCompleted {
+			goal.CompletedAt = nil
+		}
+		goal.Status = *in.Status
+	}
+	if in.EvidenceLinks != nil {
+		if err := goal.SetEvidenceLinks(in.EvidenceLinks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyPlanTaskRequest 将请求中的非空字段写入任务
+func applyPlanTaskRequest(task *models.PlanTask, in *PlanTaskRequest) error {
+	if in.Title != nil {
+		task.Title = utils.SanitizeForDatabase(*in.Title)
+	}
+	if in.Description != nil {
+		task.Description = utils.SanitizeForDatabase(*in.Description)
+	}
+	if in.WeekIndex != nil {
+		task.WeekIndex = *in.WeekIndex
+	}
+	if in.DueDate != nil {
+		task.DueDate = in.DueDate
+	}
+	if in.LinkedSkill != nil {
+		task.LinkedSkill = *in.LinkedSkill
+	}
+	if in.Status != nil {
+		if !contains(validTaskStatuses, *in.Status) {
+			return errInvalidStatus
+		}
+		if *in.Status == models.TaskStatusDone && task.Status != models.TaskStatusDone {
+			now := time.Now()
+			task.CompletedAt = &now
+		} else if *in.Status != models.TaskStatusDone {
+			task.CompletedAt = nil
+		}
+		task.Status = *in.Status
+	}
+	if in.EvidenceLinks != nil {
+		if err := task.SetEvidenceLinks(in.EvidenceLinks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshGoalProgress 按任务完成情况重新计算目标进度，全部完成时自动标记目标完成，
+// 已完成的目标又出现未完成任务时重新打开
+func refreshGoalProgress(goalID uint) {
+	var tasks []models.PlanTask
+	if err := db.Conn.Where("goal_id = ?", goalID).Find(&tasks).Error; err != nil {
+		logger.Error("计算目标进度失败: GoalID=%d, 错误=%v", goalID, err)
+		return
+	}
+
+	total, done := 0, 0
+	for _, t := range tasks {
+		if t.Status == models.TaskStatusSkipped {
+			continue
+		}
+		total++
+		if t.Status == models.TaskStatusDone {
+			done++
+		}
+	}
+
+	progress := 0
+	if total > 0 {
+		progress = done * 100 / total
+	}
+
+	updates := map[string]interface{}{"progress": progress, "updated_at": time.Now()}
+	var goal models.Goal
+	if err := db.Conn.First(&goal, goalID).Error; err == nil {
+		allDone := total > 0 && done == total
+		switch {
+		case allDone && goal.Status == models.GoalStatusActive:
+			updates["status"] = models.GoalStatusCompleted
+			updates["completed_at"] = time.Now()
+		case !allDone && total > 0 && goal.Status == models.GoalStatusCompleted:
+			updates["status"] = models.GoalStatusActive
+			updates["completed_at"] = nil
+		}
+	}
+
+	if err := db.Conn.Model(&models.Goal{}).Where("id = ?", goalID).Updates(updates).Error; err != nil {
+		logger.Error("更新目标进度失败: GoalID=%d, 错误=%v", goalID, err)
+	}
+}
+
+// latestResumeInfo 获取用户最近一份已分析简历的提取信息
+func latestResumeInfo(userID string) *models.DocumentExtractedInfo {
+	var document models.UserDocument
+	if err := db.Conn.Where("user_id = ? AND document_type = ? AND is_processed = ?", userID, "resume", true).
+		Order("created_at DESC").First(&document).Error; err != nil {
+		return nil
+	}
+	info, err := document.GetExtractedInfo()
+	if err != nil {
+		logger.Warn("解析简历提取信息失败: DocumentID=%d, 错误=%v", document.ID, err)
+		return nil
+	}
+	return info
+}
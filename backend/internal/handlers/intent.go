@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ai-career-buddy/internal/intent"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClassifyIntentRequest 意图识别请求
+type ClassifyIntentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ThreadID string `json:"threadId"`
+}
+
+// ClassifyIntent 识别问题的类别、子意图和置信度，便于调试分类效果
+func ClassifyIntent(c *gin.Context) {
+	var in ClassifyIntentRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	result := intent.Classify(in.Content, in.ThreadID)
+	c.JSON(http.StatusOK, gin.H{"intent": result, "subIntentName": result.SubIntentName()})
+}
+
+// GetIntentTaxonomy 返回可识别的类别和子意图
+func GetIntentTaxonomy(c *gin.Context) {
+	c.JSON(http.StatusOK, intent.Taxonomy())
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/rag"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchUserKnowledge 在用户的文档和历史咨询中做语义检索
+func SearchUserKnowledge(c *gin.Context) {
+	userID := c.Param("userId")
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "检索内容不能为空"})
+		return
+	}
+
+	ix := rag.Default()
+	if ix == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "用户资料检索未启用"})
+		return
+	}
+
+	opts := rag.SearchOptions{SourceType: c.Query("sourceType")}
+	if opts.SourceType != "" && opts.SourceType != rag.SourceDocument && opts.SourceType != rag.SourceCareerHistory {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的来源类型"})
+		return
+	}
+	if k, err := strconv.Atoi(c.Query("k")); err == nil && k > 0 {
+		opts.K = min(k, 20)
+	}
+
+	hits, err := ix.Search(userID, query, opts)
+	if err != nil {
+		logger.Error("检索用户资料失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "检索失败"})
+		return
+	}
+	if hits == nil {
+		hits = []rag.Hit{}
+	}
+	indexed, _ := ix.Stats(userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":    query,
+		"embedder": ix.Embedder().Name(),
+		"indexed":  indexed,
+		"hits":     hits,
+	})
+}
+
+// ReindexUserKnowledge 重建用户资料的检索索引，切换向量模型后需要调用
+func ReindexUserKnowledge(c *gin.Context) {
+	userID := c.Param("userId")
+
+	ix := rag.Default()
+	if ix == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "用户资料检索未启用"})
+		return
+	}
+
+	result, err := ix.ReindexUser(userID)
+	if err != nil {
+		logger.Error("重建用户资料索引失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重建索引失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"embedder": ix.Embedder().Name(),
+		"result":   result,
+	})
+}
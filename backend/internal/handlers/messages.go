@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,9 +11,18 @@ import (
 	"unicode/utf8"
 
 	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/company"
+	"ai-career-buddy/internal/config"
 	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/enrich"
+	"ai-career-buddy/internal/experiment"
+	"ai-career-buddy/internal/insights"
+	"ai-career-buddy/internal/intent"
 	"ai-career-buddy/internal/logger"
 	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/rag"
+	"ai-career-buddy/internal/summary"
 	"ai-career-buddy/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -26,6 +36,7 @@ type SendMessageRequest struct {
 	ModelID       string   `json:"modelId,omitempty"`
 	DeepThinking  bool     `json:"deepThinking,omitempty"`
 	NetworkSearch bool     `json:"networkSearch,omitempty"`
+	ParentID      *uint    `json:"parentId,omitempty"` // 在指定消息下继续对话，默认接在会话最新消息之后
 }
 
 // SendMessage stores the user message and returns an intelligent AI reply.
@@ -42,50 +53,17 @@ func SendMessage(c *gin.Context) {
 	logger.Info("收到消息请求: ThreadID=%s, ModelID=%s, Content长度=%d, 附件数量=%d",
 		in.ThreadID, in.ModelID, len(in.Content), len(in.Attachments))
 
-	// 处理附件，提取PDF文本
-	var attachmentsJSON string
-	var enhancedContent = in.Content
-
-	if len(in.Attachments) > 0 {
-		// 处理每个附件
-		var processedAttachments []string
-		var documentTexts []string
-
-		for _, attachment := range in.Attachments {
-			processedAttachments = append(processedAttachments, attachment)
-
-			// 注意：系统仅支持Markdown格式文档上传
-
-			// 检查是否为文档引用（document:格式）
-			if strings.HasPrefix(attachment, "document:") {
-				documentID := strings.TrimPrefix(attachment, "document:")
-				var document models.UserDocument
-				if err := db.Conn.Where("id = ?", documentID).First(&document).Error; err == nil {
-					// 优先使用分析结果
-					if document.IsProcessed && document.ExtractedInfo != "" {
-						var extractedInfo models.DocumentExtractedInfo
-						if err := json.Unmarshal([]byte(document.ExtractedInfo), &extractedInfo); err == nil {
-							documentTexts = append(documentTexts, fmt.Sprintf("[%s分析结果]:\n%s", document.DocumentType, document.ExtractedInfo))
-						}
-					} else {
-						// 如果没有分析结果，提供文档摘要而不是完整内容
-						if document.FileContent != "" {
-							summary := document.FileContent
-							documentTexts = append(documentTexts, fmt.Sprintf("[%s文档摘要]:\n%s", document.DocumentType, summary))
-						}
-					}
-				}
-			}
-		}
-
-		// 将文档内容添加到消息内容中
-		if len(documentTexts) > 0 {
-			enhancedContent += "\n\n" + strings.Join(documentTexts, "\n\n")
-		}
+	parentID, err := resolveParent(in.UserID, in.ThreadID, in.ParentID)
+	if err != nil {
+		respondParentError(c, err)
+		return
+	}
 
-		// 存储附件
-		attachmentsBytes, _ := json.Marshal(processedAttachments)
-		attachmentsJSON = string(attachmentsBytes)
+	// 处理附件，提取文档内容
+	enhancedContent, attachmentsJSON, attachedDocuments, err := processAttachments(in.UserID, in.Attachments, in.Content)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
 	}
 
 	userMsg := models.Message{
@@ -94,6 +72,8 @@ func SendMessage(c *gin.Context) {
 		Content:     enhancedContent,
 		ThreadID:    in.ThreadID,
 		Attachments: attachmentsJSON,
+		ParentID:    parentID,
+		Question:    attachedQuestion(in.Content, enhancedContent),
 	}
 	if err := db.Conn.Create(&userMsg).Error; err != nil {
 		logger.Error("保存用户消息失败: %v", err)
@@ -102,26 +82,18 @@ func SendMessage(c *gin.Context) {
 	}
 	logger.Debug("用户消息保存成功: ID=%d", userMsg.ID)
 
-	// 生成智能回复
-	logger.Info("开始生成AI回复: ModelID=%s, DeepThinking=%t, NetworkSearch=%t",
-		in.ModelID, in.DeepThinking, in.NetworkSearch)
-
-	aiReplyContent := generateAIResponse(in.Content, in.ThreadID, in.ModelID, in.DeepThinking, in.NetworkSearch)
-
-	logger.Debug("AI回复生成完成，内容长度: %d", len(aiReplyContent))
-
-	// 清理AI回复内容
-	cleanedAIReply := utils.SanitizeForDatabase(aiReplyContent)
-	aiReply := models.Message{UserID: in.UserID, Role: "assistant", Content: cleanedAIReply, ThreadID: in.ThreadID}
-	if err := db.Conn.Create(&aiReply).Error; err != nil {
+	aiReply, err := createAssistantReply(&userMsg, in.Content, replyOptions{
+		modelID:           in.ModelID,
+		deepThinking:      in.DeepThinking,
+		networkSearch:     in.NetworkSearch,
+		attachments:       decodeAttachments(attachmentsJSON),
+		attachedDocuments: attachedDocuments,
+	})
+	if err != nil {
 		logger.Error("保存AI回复失败: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	logger.Debug("AI回复保存成功: ID=%d", aiReply.ID)
-
-	// 保存职业历史记录
-	go saveCareerHistory(in.ThreadID, in.Content, aiReplyContent, in.ModelID, in.Attachments...)
 
 	duration := time.Since(startTime)
 	logger.Info("消息处理完成: ThreadID=%s, 总耗时=%v", in.ThreadID, duration)
@@ -186,6 +158,7 @@ type StreamMessageRequest struct {
 	ModelID       string   `json:"modelId,omitempty"`
 	DeepThinking  bool     `json:"deepThinking,omitempty"`
 	NetworkSearch bool     `json:"networkSearch,omitempty"`
+	ParentID      *uint    `json:"parentId,omitempty"` // 在指定消息下继续对话，默认接在会话最新消息之后
 }
 
 // StreamMessage 流式发送消息
@@ -202,59 +175,17 @@ func StreamMessage(c *gin.Context) {
 	logger.Info("收到流式消息请求: ThreadID=%s, ModelID=%s, Content长度=%d, 附件数量=%d",
 		req.ThreadID, req.ModelID, len(req.Content), len(req.Attachments))
 
-	// 处理附件，提取文档内容
-	var attachmentsJSON string
-	var enhancedContent = req.Content
-
-	if len(req.Attachments) > 0 {
-		// 处理每个附件
-		var processedAttachments []string
-		var documentTexts []string
-
-		for _, attachment := range req.Attachments {
-			processedAttachments = append(processedAttachments, attachment)
-
-			// 检查是否为PDF
-			if strings.HasPrefix(attachment, "data:application/pdf;base64,") {
-				pdfExtractor := utils.NewSimplePDFExtractor()
-				if pdfExtractor.IsValidPDF(attachment) {
-					pdfText, err := pdfExtractor.ExtractTextFromBase64PDF(attachment)
-					if err == nil && len(pdfText) > 0 {
-						documentTexts = append(documentTexts, "[PDF文档内容]:\n"+pdfText)
-					}
-				}
-			}
-
-			// 检查是否为文档引用（document:格式）
-			if strings.HasPrefix(attachment, "document:") {
-				documentID := strings.TrimPrefix(attachment, "document:")
-				var document models.UserDocument
-				if err := db.Conn.Where("id = ?", documentID).First(&document).Error; err == nil {
-					// 优先使用分析结果
-					if document.IsProcessed && document.ExtractedInfo != "" {
-						var extractedInfo models.DocumentExtractedInfo
-						if err := json.Unmarshal([]byte(document.ExtractedInfo), &extractedInfo); err == nil {
-							documentTexts = append(documentTexts, fmt.Sprintf("[%s分析结果]:\n%s", document.DocumentType, document.ExtractedInfo))
-						}
-					} else {
-						// 如果没有分析结果，提供文档摘要而不是完整内容
-						if document.FileContent != "" {
-							summary := document.FileContent
-							documentTexts = append(documentTexts, fmt.Sprintf("[%s文档摘要]:\n%s", document.DocumentType, summary))
-						}
-					}
-				}
-			}
-		}
-
-		// 将文档内容添加到消息内容中
-		if len(documentTexts) > 0 {
-			enhancedContent += "\n\n" + strings.Join(documentTexts, "\n\n")
-		}
+	parentID, err := resolveParent(req.UserID, req.ThreadID, req.ParentID)
+	if err != nil {
+		respondParentError(c, err)
+		return
+	}
 
-		// 存储附件
-		attachmentsBytes, _ := json.Marshal(processedAttachments)
-		attachmentsJSON = string(attachmentsBytes)
+	// 处理附件，提取文档内容
+	enhancedContent, attachmentsJSON, attachedDocuments, err := processAttachments(req.UserID, req.Attachments, req.Content)
+	if err != nil {
+		respondAttachmentError(c, err)
+		return
 	}
 
 	// 清理消息内容，移除不兼容字符
@@ -268,6 +199,8 @@ func StreamMessage(c *gin.Context) {
 		Content:     cleanedContent,
 		ThreadID:    req.ThreadID,
 		Attachments: cleanedAttachments,
+		ParentID:    parentID,
+		Question:    utils.SanitizeForDatabase(attachedQuestion(req.Content, enhancedContent)),
 	}
 	if err := db.Conn.Create(&userMsg).Error; err != nil {
 		logger.Error("保存用户消息失败: %v", err)
@@ -281,46 +214,55 @@ func StreamMessage(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 
 	var aiReplyContent string
+	var reply generatedReply
 
-	// 如果选择了百炼模型或Azure模型，使用流式API
-	if strings.HasPrefix(req.ModelID, "bailian/") || req.ModelID == "nbg-v3-33b" || strings.HasPrefix(req.ModelID, "azure/") {
-		logger.Info("使用百炼流式API: ModelID=%s", req.ModelID)
-		client := api.NewBailianClient()
-
-		// 构建系统提示词
-		systemPrompt := buildSystemPrompt(req.ModelID, req.DeepThinking, req.NetworkSearch)
-		fullInput := systemPrompt + "\n\n用户问题: " + enhancedContent
+	// 识别问题意图，实验分组可能覆盖模型、深度思考模式等参数
+	in := intent.Classify(req.Content, req.ThreadID)
+	assignment := experiment.Assign(req.UserID, req.ThreadID, in.Category)
+	opts := applyExperiment(assignment, &req.ModelID, &req.DeepThinking)
+	opts.history = summary.Context(&userMsg)
+	opts.images, opts.imageNote = attachmentImages(req.UserID, req.ModelID, attachedDocuments)
 
-		// 创建收集器来收集流式回复内容
-		var responseBuffer strings.Builder
-		writer := io.MultiWriter(c.Writer, &responseBuffer)
+	// 检索资料，来源列表通过响应头返回，流式正文中只包含引用编号
+	retrieval := retrieveForMessage(req.UserID, req.Content, req.NetworkSearch, attachedDocuments)
+	if sources := retrieval.sources(""); len(sources) > 0 {
+		if data, err := json.Marshal(sources); err == nil {
+			c.Header("X-Search-Sources", base64.StdEncoding.EncodeToString(data))
+		}
+	}
 
-		// 调用流式API
-		err := client.SendStreamMessage(req.ModelID, fullInput, req.Attachments, writer)
-		if err != nil {
-			logger.Error("百炼流式API调用失败: %v", err)
+	// 构建系统提示词
+	sel := prompts.Selector{Category: in.Category, ModelID: req.ModelID, Pinned: opts.prompts}
+	systemPrompt, promptRefs := buildSystemPrompt(sel, in.SubIntent, req.DeepThinking, req.NetworkSearch)
+	reply.promptVersion = promptRefs.String()
+	if req.NetworkSearch {
+		systemPrompt += companyInsightsPrompt(req.Content)
+	}
+	systemPrompt += retrieval.prompt()
+	fullInput := systemPrompt + opts.history + "\n\n用户问题: " + enhancedContent + opts.imageNote
+
+	// 创建收集器来收集流式回复内容，每段写入后立即推送给客户端
+	var responseBuffer strings.Builder
+	writer := flushWriter{w: io.MultiWriter(c.Writer, &responseBuffer), f: c.Writer}
+
+	// 调用流式API
+	logger.Info("开始流式调用对话模型: ModelID=%s", req.ModelID)
+	generateStart := time.Now()
+	usage, err := api.ProviderFor(req.ModelID).SendStreamMessageWithOptions(req.ModelID, fullInput, opts.images, writer, api.ChatOptions{Temperature: opts.temperature})
+	if err != nil {
+		logger.Error("流式调用对话模型失败: %v", err)
+		if responseBuffer.Len() == 0 {
 			c.String(http.StatusInternalServerError, "流式API调用失败: %v", err)
 			return
 		}
-		aiReplyContent = responseBuffer.String()
-		logger.Info("百炼流式API调用成功")
-	} else {
-		logger.Info("使用模拟流式回复: ModelID=%s", req.ModelID)
-		// 其他模型使用模拟流式回复
-		response := generateAIResponse(req.Content, req.ThreadID, req.ModelID, req.DeepThinking, req.NetworkSearch)
-		aiReplyContent = response
-
-		// 模拟流式输出 - 按词输出而不是按字符
-		words := strings.Fields(response)
-		for i, word := range words {
-			if i > 0 {
-				c.Writer.WriteString(" ")
-			}
-			c.Writer.WriteString(word)
-			c.Writer.Flush()
-			time.Sleep(50 * time.Millisecond) // 适当的延迟，模拟真实的流式体验
-		}
-		logger.Info("模拟流式回复完成")
+		// 已输出部分内容，无法再修改状态码，在正文末尾说明中断原因
+		fmt.Fprintf(writer, "\n\n[回复中断: %v]", err)
+	}
+	aiReplyContent = responseBuffer.String()
+	reply.latency = time.Since(generateStart)
+	if usage != nil {
+		reply.promptTokens = usage.PromptTokens
+		reply.completionTokens = usage.CompletionTokens
 	}
 
 	// 清理AI回复内容
@@ -328,571 +270,248 @@ func StreamMessage(c *gin.Context) {
 
 	// 保存AI回复
 	aiReply := models.Message{
-		UserID:   req.UserID,
-		Role:     "assistant",
-		Content:  cleanedAIReply,
-		ThreadID: req.ThreadID,
-	}
+		UserID:           req.UserID,
+		Role:             "assistant",
+		Content:          cleanedAIReply,
+		ThreadID:         req.ThreadID,
+		ParentID:         &userMsg.ID,
+		Sources:          retrieval.sources(aiReplyContent),
+		PromptVersion:    reply.promptVersion,
+		ModelID:          req.ModelID,
+		LatencyMs:        reply.latency.Milliseconds(),
+		PromptTokens:     reply.promptTokens,
+		CompletionTokens: reply.completionTokens,
+	}
+	assignment.Record(&aiReply)
 	if err := db.Conn.Create(&aiReply).Error; err != nil {
 		logger.Error("保存AI回复失败: %v", err)
 		// 注意：这里不返回错误，因为流式响应已经开始
 	}
 
-	// 保存职业历史记录
-	go saveCareerHistory(req.ThreadID, req.Content, aiReplyContent, req.ModelID, req.Attachments...)
+	// 保存职业历史记录，并在会话较长时更新摘要
+	summary.RefreshAsync(req.UserID, req.ThreadID, aiReply.ID)
+	go saveCareerHistory(req.UserID, req.ThreadID, aiReply.ID, req.Content, aiReplyContent, req.ModelID, in, decodeAttachments(attachmentsJSON)...)
 
 	duration := time.Since(startTime)
 	logger.Info("流式消息处理完成: ThreadID=%s, 总耗时=%v", req.ThreadID, duration)
 }
 
-// generateAIResponse 根据用户输入、会话类型和模型ID生成智能回复
-func generateAIResponse(userInput, threadID, modelID string, deepThinking, networkSearch bool) string {
-	// 如果选择了百炼模型或Azure模型，调用真实API
-	if strings.HasPrefix(modelID, "bailian/") || modelID == "nbg-v3-33b" || strings.HasPrefix(modelID, "azure/") {
-		return callBailianAPI(userInput, modelID, deepThinking, networkSearch)
-	}
+// flushWriter 每次写入后刷新响应，使流式内容及时到达客户端
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
 
-	// 其他模型使用模拟回复
-	// 根据threadID判断会话类型
-	var sessionType string
-	if len(threadID) > 7 {
-		sessionType = strings.Split(threadID, "-")[0] // 取前7个字符作为类型标识
-	}
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
+}
 
-	// 检查是否为案例问题，如果是则提供更详细的回复
-	enhancedInput := enhanceInputForExamples(userInput, sessionType)
+// replyOptions 生成助手回复的参数
+type replyOptions struct {
+	modelID           string
+	deepThinking      bool
+	networkSearch     bool
+	attachments       []string
+	attachedDocuments []uint
+}
 
-	// 根据会话类型生成不同的回复
-	var response string
-	switch sessionType {
-	case "career":
-		response = generateCareerResponse(enhancedInput, deepThinking, networkSearch)
-	case "offer":
-		response = generateOfferResponse(enhancedInput, deepThinking, networkSearch)
-	case "contract":
-		response = generateContractResponse(enhancedInput, deepThinking, networkSearch)
-	case "monitor":
-		response = generateMonitorResponse(enhancedInput, deepThinking, networkSearch)
-	default:
-		response = generateGeneralResponse(enhancedInput, deepThinking, networkSearch)
-	}
+// createAssistantReply 针对用户消息生成并保存一条助手回复，回复挂在该用户消息之下
+func createAssistantReply(userMsg *models.Message, question string, ro replyOptions) (models.Message, error) {
+	// 识别问题意图，实验分组可能覆盖模型、深度思考模式等参数
+	in := intent.Classify(question, userMsg.ThreadID)
+	assignment := experiment.Assign(userMsg.UserID, userMsg.ThreadID, in.Category)
+	opts := applyExperiment(assignment, &ro.modelID, &ro.deepThinking)
+	opts.history = summary.Context(userMsg)
+	opts.images, opts.imageNote = attachmentImages(userMsg.UserID, ro.modelID, ro.attachedDocuments)
 
-	// 添加文档引导功能
-	documentGuidance := generateDocumentGuidance(userInput)
-	if documentGuidance != "" {
-		response += "\n\n" + documentGuidance
-	}
+	// 生成智能回复
+	logger.Info("开始生成AI回复: ModelID=%s, DeepThinking=%t, NetworkSearch=%t",
+		ro.modelID, ro.deepThinking, ro.networkSearch)
+
+	retrieval := retrieveForMessage(userMsg.UserID, question, ro.networkSearch, ro.attachedDocuments)
+	generateStart := time.Now()
+	reply := generateAIResponse(question, in, ro.modelID, ro.deepThinking, ro.networkSearch, retrieval, opts)
+	aiReplyContent := reply.content
+
+	logger.Debug("AI回复生成完成，内容长度: %d", len(aiReplyContent))
 
-	// 添加模型信息到回复中
-	if modelID != "" {
-		response += fmt.Sprintf("\n\n[使用模型: %s]", modelID)
+	// 清理AI回复内容
+	cleanedAIReply := utils.SanitizeForDatabase(aiReplyContent)
+	aiReply := models.Message{
+		UserID:           userMsg.UserID,
+		Role:             "assistant",
+		Content:          cleanedAIReply,
+		ThreadID:         userMsg.ThreadID,
+		ParentID:         &userMsg.ID,
+		Sources:          retrieval.sources(aiReplyContent),
+		PromptVersion:    reply.promptVersion,
+		ModelID:          ro.modelID,
+		LatencyMs:        time.Since(generateStart).Milliseconds(),
+		PromptTokens:     reply.promptTokens,
+		CompletionTokens: reply.completionTokens,
+	}
+	assignment.Record(&aiReply)
+	if err := db.Conn.Create(&aiReply).Error; err != nil {
+		return aiReply, err
 	}
+	logger.Debug("AI回复保存成功: ID=%d", aiReply.ID)
 
-	return response
+	// 保存职业历史记录，并在会话较长时更新摘要
+	summary.RefreshAsync(userMsg.UserID, userMsg.ThreadID, aiReply.ID)
+	go saveCareerHistory(userMsg.UserID, userMsg.ThreadID, aiReply.ID, question, aiReplyContent, ro.modelID, in, ro.attachments...)
+	return aiReply, nil
 }
 
-// enhanceInputForExamples 为案例问题增强输入内容
-func enhanceInputForExamples(input, sessionType string) string {
-	// 定义案例关键词映射
-	exampleEnhancements := map[string]map[string]string{
-		"career": {
-			"职业转型": "这是一个关于职业转型的重要问题。让我为您提供详细的转型路径分析，包括技能转换、行业适应、时间规划等方面的专业建议。",
-			"技能提升": "技能提升是职业发展的核心。我将从当前市场需求、个人能力评估、学习路径设计等多个维度为您分析。",
-			"行业分析": "行业分析需要综合考虑市场趋势、政策环境、技术发展等因素。让我为您提供全面的行业前景分析。",
-			"个人品牌": "个人品牌建设是现代职场的重要竞争力。我将从定位策略、内容输出、网络建设等方面为您提供指导。",
-		},
-		"offer": {
-			"薪资谈判":    "薪资谈判需要策略和技巧。让我为您分析谈判要点、市场行情、谈判话术等关键要素。",
-			"offer对比": "多Offer选择需要综合考虑多个因素。我将从薪资、发展、文化、风险等维度为您提供决策框架。",
-			"福利分析":    "福利待遇的评估需要全面考虑。让我为您分析各种福利的实际价值和潜在风险。",
-			"市场行情":    "了解市场行情是做出明智决策的基础。我将为您提供最新的薪资数据和市场趋势分析。",
-		},
-		"contract": {
-			"合同条款": "合同条款的解读需要专业知识和经验。让我为您详细分析各项条款的含义和影响。",
-			"风险点":  "识别合同风险点至关重要。我将为您指出常见的风险条款和应对策略。",
-			"权益保护": "保护自身权益是每个职场人的必修课。让我为您提供权益保护的具体方法和建议。",
-			"合同修改": "合同修改需要技巧和策略。我将为您提供修改建议和沟通技巧。",
-		},
-		"monitor": {
-			"财务状况":  "企业财务状况分析需要专业视角。让我为您提供财务健康度评估和风险预警。",
-			"行业地位":  "行业地位评估需要多维度分析。我将为您提供竞争力分析和市场定位建议。",
-			"管理层变动": "管理层变动对企业影响深远。让我为您分析变动原因、影响范围和应对策略。",
-			"风险预警":  "风险预警需要前瞻性思维。我将为您提供风险识别和预防措施建议。",
-		},
-	}
+// generationOptions 回复生成参数，包括实验分组的调整和会话上下文
+type generationOptions struct {
+	temperature *float64
+	prompts     map[string]uint // 固定使用的提示词模板版本
+	history     string          // 会话摘要和最近的对话
+	images      []string        // 发送给视觉模型的图片（data URL）
+	imageNote   string          // 附件图片的补充说明
+}
 
-	// 检查是否包含案例关键词
-	if enhancements, exists := exampleEnhancements[sessionType]; exists {
-		for keyword, enhancement := range enhancements {
-			if strings.Contains(input, keyword) {
-				return enhancement + "\n\n" + input
-			}
-		}
-	}
+// generatedReply 生成的回复及用于效果统计的信息
+type generatedReply struct {
+	content          string
+	promptVersion    string
+	promptTokens     int
+	completionTokens int
+	latency          time.Duration
+}
 
-	return input
+// applyExperiment 按实验分组覆盖请求的模型和深度思考模式，返回其余生成参数
+func applyExperiment(a *experiment.Assignment, modelID *string, deepThinking *bool) generationOptions {
+	if a == nil {
+		return generationOptions{}
+	}
+	v := a.Variant
+	if v.ModelID != "" {
+		*modelID = v.ModelID
+	}
+	if v.DeepThinking != nil {
+		*deepThinking = *v.DeepThinking
+	}
+	logger.Info("命中实验分组: Experiment=%s, Variant=%s, ModelID=%s", a.Experiment, v.Name, *modelID)
+	return generationOptions{temperature: v.Temperature, prompts: v.Prompts}
 }
 
-// callBailianAPI 调用百炼API
-func callBailianAPI(userInput, modelID string, deepThinking, networkSearch bool) string {
+// generateAIResponse 根据用户输入、问题意图和模型ID调用对话模型生成回复
+func generateAIResponse(userInput string, in intent.Result, modelID string, deepThinking, networkSearch bool, retrieval *retrievalContext, opts generationOptions) generatedReply {
 	startTime := time.Now()
-	logger.Info("开始调用百炼API: ModelID=%s, Input长度=%d", modelID, len(userInput))
+	logger.Info("开始调用对话模型: ModelID=%s, Input长度=%d", modelID, len(userInput))
 
-	client := api.NewBailianClient()
+	provider := api.ProviderFor(modelID)
 
 	// 构建系统提示词
-	systemPrompt := buildSystemPrompt(modelID, deepThinking, networkSearch)
+	sel := prompts.Selector{Category: in.Category, ModelID: modelID, Pinned: opts.prompts}
+	systemPrompt, promptRefs := buildSystemPrompt(sel, in.SubIntent, deepThinking, networkSearch)
+	if networkSearch {
+		systemPrompt += companyInsightsPrompt(userInput)
+	}
+	systemPrompt += retrieval.prompt()
 
 	// 为案例问题增强系统提示词
-	enhancedPrompt := enhanceSystemPromptForExamples(systemPrompt, userInput)
+	enhancedPrompt, caseRefs := enhanceSystemPromptForExamples(systemPrompt, in, sel)
+	reply := generatedReply{promptVersion: append(promptRefs, caseRefs...).String()}
 
-	fullInput := enhancedPrompt + "\n\n用户问题: " + userInput
+	fullInput := enhancedPrompt + opts.history + "\n\n用户问题: " + userInput + opts.imageNote
 
 	// 调用API
-	response, err := client.SendMessage(modelID, fullInput, nil)
+	response, err := provider.SendMessageWithOptions(modelID, fullInput, opts.images, api.ChatOptions{Temperature: opts.temperature})
 	duration := time.Since(startTime)
 
 	if err != nil {
-		logger.Error("百炼API调用失败: ModelID=%s, 耗时=%v, 错误=%v", modelID, duration, err)
-		return fmt.Sprintf("抱歉，调用AI模型时出现错误: %v\n\n[使用模型: %s]", err, modelID)
+		logger.Error("对话模型调用失败: ModelID=%s, 耗时=%v, 错误=%v", modelID, duration, err)
+		reply.content = fmt.Sprintf("抱歉，调用AI模型时出现错误: %v\n\n[使用模型: %s]", err, modelID)
+		return reply
 	}
 
-	logger.Info("百炼API调用成功: ModelID=%s, 耗时=%v, 回复长度=%d",
-		modelID, duration, len(response.Choices[0].Message.Content))
+	reply.promptTokens = response.Usage.PromptTokens
+	reply.completionTokens = response.Usage.CompletionTokens
 
 	// 提取回复内容
 	if len(response.Choices) > 0 {
 		content := response.Choices[0].Message.Content
+		logger.Info("对话模型调用成功: ModelID=%s, 耗时=%v, 回复长度=%d", modelID, duration, len(content))
 		content += fmt.Sprintf("\n\n[使用模型: %s]", modelID)
-		return content
+		reply.content = content
+		return reply
 	}
 
-	logger.Warn("百炼API返回空回复: ModelID=%s", modelID)
-	return fmt.Sprintf("抱歉，AI模型没有返回有效回复。\n\n[使用模型: %s]", modelID)
+	logger.Warn("对话模型返回空回复: ModelID=%s", modelID)
+	reply.content = fmt.Sprintf("抱歉，AI模型没有返回有效回复。\n\n[使用模型: %s]", modelID)
+	return reply
 }
 
-// enhanceSystemPromptForExamples 为案例问题增强系统提示词
-func enhanceSystemPromptForExamples(basePrompt, userInput string) string {
-	// 检查是否为案例问题
-	caseKeywords := []string{
-		"职业转型", "技能提升", "行业分析", "个人品牌",
-		"薪资谈判", "offer对比", "福利分析", "市场行情",
-		"合同条款", "风险点", "权益保护", "合同修改",
-		"财务状况", "行业地位", "管理层变动", "风险预警",
-	}
-
-	for _, keyword := range caseKeywords {
-		if strings.Contains(userInput, keyword) {
-			basePrompt += fmt.Sprintf("\n\n【案例专项指导】用户询问的是关于'%s'的专业问题，请提供：\n"+
-				"1. 详细的分析框架和评估维度\n"+
-				"2. 具体的操作步骤和实用建议\n"+
-				"3. 相关的案例分享和经验总结\n"+
-				"4. 潜在风险和注意事项\n"+
-				"5. 后续跟进和持续优化的建议", keyword)
-			break
-		}
-	}
-
-	return basePrompt
-}
-
-// buildSystemPrompt 构建系统提示词
-func buildSystemPrompt(modelID string, deepThinking, networkSearch bool) string {
-	basePrompt := "你是AI职场管家，专业的职场顾问助手。请根据用户的问题提供专业、实用的建议。"
-
-	// 添加智能回复指令
-	basePrompt += "\n\n【智能回复策略】请根据用户问题的详细程度选择回复方式：\n" +
-		"- **直接回答**：优先直接提供专业建议和解决方案\n" +
-		"- **分析问题**：仔细理解用户的问题内容和背景\n" +
-		"- **提供建议**：基于现有信息给出实用的建议\n" +
-		"- **补充说明**：如果信息不足，在回答中说明需要更多信息\n" +
-		"- **友好语气**：使用自然、友好的语气进行回复\n" +
-		"- **避免重复询问**：不要总是先问问题，要直接提供价值\n\n" +
-		"**回复原则**：\n" +
-		"1. 优先直接回答用户问题，提供专业建议\n" +
-		"2. 如果信息不足，在回答中说明并给出一般性建议\n" +
-		"3. 只有在确实需要关键信息时才询问1-2个问题\n" +
-		"4. 提供实用的解决方案和具体步骤\n" +
-		"5. 使用专业知识和经验给出建议\n\n" +
-		"**回复格式**：\n" +
-		"- 优先直接提供专业建议和解决方案\n" +
-		"- 如果信息不足，先给出一般性建议，再询问关键信息\n" +
-		"- 提供具体的行动步骤和实用建议"
-
-	// 添加markdown格式化指令
-	basePrompt += "\n\n【回复格式要求】请使用markdown格式组织回复内容：\n" +
-		"- 使用标题（# ## ###）来组织内容结构\n" +
-		"- 使用**粗体**来强调重要信息\n" +
-		"- 使用列表（- 或 1.）来组织要点\n" +
-		"- 使用表格来对比数据\n" +
-		"- 使用> 引用重要提示\n" +
-		"- 使用`代码`来标记专业术语\n" +
-		"- 使用==高亮==来标记关键信息"
-
-	// 深度思考模式
-	if deepThinking {
-		basePrompt += "\n\n【深度思考模式】请进行深度分析：\n" +
-			"1. 多角度分析问题，考虑不同维度和可能性\n" +
-			"2. 提供详细的推理过程和逻辑链条\n" +
-			"3. 分析潜在风险和机会\n" +
-			"4. 给出具体的行动建议和步骤\n" +
-			"5. 提供相关的案例或经验分享\n" +
-			"6. 使用表格对比不同方案\n" +
-			"7. 提供任务清单格式的行动计划"
-	}
-
-	// 网络搜索模式
-	if networkSearch {
-		basePrompt += "\n\n【网络搜索模式】请结合最新信息：\n" +
-			"1. 提供最新的行业动态和趋势\n" +
-			"2. 引用权威数据和报告\n" +
-			"3. 分析当前市场状况\n" +
-			"4. 给出时效性强的建议\n" +
-			"5. 使用表格展示数据对比\n" +
-			"6. 提供数据来源链接"
-	}
-
-	// 根据模型类型添加特定提示
-	if strings.Contains(modelID, "azure/gpt") {
-		basePrompt += " 你基于Azure OpenAI GPT-5模型，拥有最新的AI技术，擅长多语言对话、逻辑推理和创意生成。"
-	} else if strings.Contains(modelID, "qwen") {
-		basePrompt += " 你基于通义千问模型，擅长中文理解和生成。"
-	} else if strings.Contains(modelID, "deepseek") {
-		basePrompt += " 你基于DeepSeek模型，擅长逻辑推理和代码分析。"
-	} else if strings.Contains(modelID, "gpt") {
-		basePrompt += " 你基于GPT模型，擅长多语言对话和创意生成。"
-	}
-
-	// 添加智能文档引导功能
-	basePrompt += "\n\n【智能文档引导】根据用户问题类型，智能引导上传相关文档：\n" +
-		"- **职业规划类**：引导上传简历、职业规划文档\n" +
-		"- **Offer分析类**：引导上传Offer邮件、薪资方案\n" +
-		"- **合同相关类**：引导上传劳动合同、协议文件\n" +
-		"- **在职证明类**：引导上传在职证明、工作证明\n" +
-		"- **技能提升类**：引导上传技能证书、培训记录\n" +
-		"- **行业分析类**：引导上传行业报告、市场分析\n\n" +
-		"**引导原则**：\n" +
-		"1. 在提供建议的同时，自然引导用户上传相关文档\n" +
-		"2. 说明上传文档的好处和价值\n" +
-		"3. 使用友好的语气，不要强制要求\n" +
-		"4. 提供具体的文档类型建议\n" +
-		"5. 说明文档分析的深度和准确性\n\n" +
-		"**智能引导策略**：\n" +
-		"- 识别用户问题中的关键词，判断需要的文档类型\n" +
-		"- 根据问题复杂度，决定是否需要引导上传文档\n" +
-		"- 提供具体的文档格式建议（仅支持Markdown格式）\n" +
-		"- 说明文档分析的具体价值和收益\n\n" +
-		"**引导话术模板**：\n" +
-		"- 职业规划：\"建议您上传简历，我可以分析您的技能匹配度和职业发展路径\"\n" +
-		"- Offer分析：\"如果您有Offer邮件，上传后我可以进行详细的薪资对比和条款分析\"\n" +
-		"- 合同审查：\"上传劳动合同后，我可以帮您识别风险点和权益保护建议\"\n" +
-		"- 在职证明：\"建议上传在职证明，我可以帮您分析职业发展机会\"\n" +
-		"- 技能提升：\"如果有技能证书，上传后我可以制定更具体的提升计划\"\n" +
-		"- 行业分析：\"上传行业报告后，我可以提供更精准的市场趋势分析\"\n\n" +
-		"**文档格式要求**：\n" +
-		"- 仅支持Markdown格式(.md)文件上传\n" +
-		"- Markdown格式便于AI准确分析和理解\n" +
-		"- 文档内容要清晰、完整，使用标准Markdown语法\n" +
-		"- 避免图片和复杂格式，纯文本内容效果最佳"
-
-	basePrompt += " 请用中文回复，保持专业、友好的语调，并确保使用markdown格式使内容更易读。"
-
-	return basePrompt
-}
-
-// generateDocumentGuidance 根据用户问题类型生成文档引导内容
-func generateDocumentGuidance(userInput string) string {
-	// 定义问题类型和对应的文档引导
-	guidanceMap := map[string]map[string]string{
-		"职业规划": {
-			"keywords":  "职业规划,职业发展,职业转型,职业选择,职业目标,职业路径",
-			"documents": "简历,职业规划文档,技能清单",
-			"benefits":  "分析技能匹配度,制定个性化发展路径,识别职业机会",
-			"template":  "建议您上传简历，我可以分析您的技能匹配度和职业发展路径，为您制定更精准的职业规划方案。",
-		},
-		"Offer分析": {
-			"keywords":  "offer,薪资,待遇,福利,入职,工作机会,面试结果",
-			"documents": "Offer邮件,薪资方案,福利清单",
-			"benefits":  "详细薪资对比,条款风险分析,市场行情评估",
-			"template":  "如果您有Offer邮件，上传后我可以进行详细的薪资对比和条款分析，帮您做出明智决策。",
-		},
-		"合同审查": {
-			"keywords":  "合同,协议,条款,权益,风险,劳动合同,保密协议",
-			"documents": "劳动合同,保密协议,竞业协议",
-			"benefits":  "识别风险点,权益保护建议,条款解读",
-			"template":  "上传劳动合同后，我可以帮您识别风险点和权益保护建议，确保您的合法权益。",
-		},
-		"在职证明": {
-			"keywords":  "在职证明,工作证明,离职证明,工作经历,职业背景",
-			"documents": "在职证明,工作证明,离职证明",
-			"benefits":  "职业发展机会分析,背景验证,经验总结",
-			"template":  "建议上传在职证明，我可以帮您分析职业发展机会和背景优势。",
-		},
-		"技能提升": {
-			"keywords":  "技能,能力,培训,学习,证书,认证,专业能力",
-			"documents": "技能证书,培训记录,学习证明",
-			"benefits":  "制定提升计划,技能差距分析,学习路径规划",
-			"template":  "如果有技能证书，上传后我可以制定更具体的提升计划和学习路径。",
-		},
-		"行业分析": {
-			"keywords":  "行业,市场,趋势,分析,报告,前景,发展",
-			"documents": "行业报告,市场分析,趋势研究",
-			"benefits":  "精准市场趋势分析,行业机会识别,竞争态势评估",
-			"template":  "上传行业报告后，我可以提供更精准的市场趋势分析和行业机会识别。",
-		},
-	}
-
-	// 检查用户输入中的关键词
-	for _, info := range guidanceMap {
-		keywords := strings.Split(info["keywords"], ",")
-		for _, keyword := range keywords {
-			if strings.Contains(userInput, strings.TrimSpace(keyword)) {
-				return fmt.Sprintf("## 📄 文档分析建议\n\n"+
-					"> **%s**\n\n"+
-					"**推荐文档类型**：%s\n\n"+
-					"**分析价值**：%s\n\n"+
-					"**格式要求**：仅支持Markdown格式(.md)文件，便于AI准确分析\n\n"+
-					"**上传方式**：点击页面右上角的文档上传按钮，选择相应文档类型进行上传",
-					info["template"], info["documents"], info["benefits"])
-			}
-		}
+// enhanceSystemPromptForExamples 为识别出子意图的案例问题追加专项指导
+func enhanceSystemPromptForExamples(basePrompt string, in intent.Result, sel prompts.Selector) (string, prompts.Refs) {
+	keyword := in.SubIntentName()
+	if keyword == "" {
+		return basePrompt, nil
 	}
 
-	return ""
-}
-
-// generateCareerResponse 生成职业规划相关的回复
-func generateCareerResponse(input string, deepThinking, networkSearch bool) string {
-	var response string
-
-	// 分析用户问题是否详细，决定是否需要询问
-	if len(input) < 10 || !containsKeywords(input, []string{"专业", "年级", "工作", "经验", "目标", "行业", "技能"}) {
-		// 问题不够详细，询问关键信息
-		response = "## 💼 职业规划咨询\n\n" +
-			"> **为了更好地为您提供个性化建议，请告诉我：**\n\n" +
-			"- 您当前的工作/学习状态（专业、年级、工作经验等）\n" +
-			"- 您的职业目标或期望\n" +
-			"- 您最关心的方面\n\n" +
-			"**请分享这些信息，我会为您制定个性化的职业规划方案！**"
-	} else {
-		// 问题已经详细，直接提供建议
-		response = "## 💼 职业规划建议\n\n" +
-			"基于您提供的信息，我为您分析如下：\n\n"
-
-		if deepThinking {
-			response += "## 🧠 深度分析\n\n" +
-				"**多维度评估**：\n" +
-				"- **个人能力**：技能、经验、性格匹配\n" +
-				"- **市场机会**：行业趋势、岗位需求\n" +
-				"- **时间规划**：短期(1-2年)、中期(3-5年)、长期(5+年)\n\n" +
-				"**风险评估**：\n" +
-				"- 技术风险：技能过时 vs 新兴机会\n" +
-				"- 市场风险：竞争加剧 vs 需求增长\n" +
-				"- 个人风险：能力瓶颈 vs 成长空间\n\n" +
-				"**行动计划**：\n" +
-				"- [ ] 制定SMART目标\n" +
-				"- [ ] 技能提升计划\n" +
-				"- [ ] 人脉网络建设\n" +
-				"- [ ] 定期复盘调整"
-		} else {
-			response += "## 📋 规划要点\n\n" +
-				"**核心要素**：\n" +
-				"- **现状评估**：技能水平、职业状态\n" +
-				"- **目标设定**：短期和长期目标\n" +
-				"- **技能提升**：针对性学习计划\n" +
-				"- **网络建设**：专业人脉建立\n\n" +
-				"**发展路径**：\n" +
-				"1. 短期(1-2年)：技能提升、经验积累\n" +
-				"2. 中期(3-5年)：职位晋升、专业深化\n" +
-				"3. 长期(5+年)：行业专家、创业机会"
-		}
-
-		if networkSearch {
-			response += "\n\n🌐 **最新信息**：\n" +
-				"- LinkedIn职场趋势报告\n" +
-				"- 招聘平台人才需求分析\n" +
-				"- 行业权威机构报告"
-		}
+	guidance, ref, err := prompts.Render(prompts.KeyChatCaseGuidance, sel, prompts.CaseGuidanceVars{Keyword: keyword})
+	if err != nil {
+		logger.Error("渲染案例专项指导失败: Keyword=%s, 错误=%v", keyword, err)
+		return basePrompt, nil
 	}
-
-	return response
+	return basePrompt + "\n\n" + guidance, prompts.Refs{ref}
 }
 
-// containsKeywords 检查输入是否包含关键词
-func containsKeywords(input string, keywords []string) bool {
-	for _, keyword := range keywords {
-		if strings.Contains(input, keyword) {
-			return true
-		}
+// companyInsightsPrompt 为对话中提到的企业附加平台匿名聚合数据
+func companyInsightsPrompt(userInput string) string {
+	companyIDs, err := company.FindMentions(userInput)
+	if err != nil || len(companyIDs) == 0 {
+		return ""
 	}
-	return false
-}
 
-// generateOfferResponse 生成Offer分析相关的回复
-func generateOfferResponse(input string, deepThinking, networkSearch bool) string {
-	var response string
-
-	// 分析用户问题是否详细，决定是否需要询问
-	if len(input) < 10 || !containsKeywords(input, []string{"薪资", "工资", "福利", "公司", "职位", "股权", "奖金", "待遇"}) {
-		// 问题不够详细，询问关键信息
-		response = "## 💰 Offer分析咨询\n\n" +
-			"> **为了更好地为您分析Offer，请告诉我：**\n\n" +
-			"- Offer的具体内容（薪资、福利、职位等）\n" +
-			"- 您当前的工作情况和薪资水平\n" +
-			"- 您最关心的评估维度\n\n" +
-			"**请分享这些信息，我会为您提供专业的分析和建议！**"
-	} else {
-		// 问题已经详细，直接提供建议
-		response = "## 💰 Offer分析建议\n\n" +
-			"基于您提供的信息，我为您分析如下：\n\n"
-
-		if deepThinking {
-			response += "## 🧠 深度分析\n\n" +
-				"**多维度评估**：\n" +
-				"- **财务维度**：薪资结构、股权激励、隐性收益\n" +
-				"- **发展维度**：技能匹配、成长空间、晋升路径\n" +
-				"- **风险维度**：公司稳定性、市场风险、机会成本\n\n" +
-				"**薪资对比**：\n" +
-				"| 项目 | 当前Offer | 市场平均 | 评估 |\n" +
-				"|------|-----------|----------|------|\n" +
-				"| 基本薪资 | - | - | ⭐⭐⭐⭐⭐ |\n" +
-				"| 绩效奖金 | - | - | ⭐⭐⭐⭐⭐ |\n" +
-				"| 股权激励 | - | - | ⭐⭐⭐⭐⭐ |\n\n" +
-				"**谈判策略**：\n" +
-				"- [ ] 市场薪资调研\n" +
-				"- [ ] 突出独特价值\n" +
-				"- [ ] 多轮谈判推进\n" +
-				"- [ ] 备选方案准备"
-		} else {
-			response += "## 📊 分析要点\n\n" +
-				"**核心评估**：\n" +
-				"- **薪资分析**：市场对比、增长空间\n" +
-				"- **福利待遇**：五险一金、假期政策、培训机会\n" +
-				"- **发展前景**：公司地位、晋升通道、技能提升\n\n" +
-				"**决策要点**：\n" +
-				"1. 综合收益评估（不只是薪资）\n" +
-				"2. 长期发展机会\n" +
-				"3. 风险承受能力\n" +
-				"4. 生活平衡考虑"
+	var sb strings.Builder
+	for i, id := range companyIDs {
+		if i >= 3 {
+			break
 		}
-
-		if networkSearch {
-			response += "\n\n🌐 **最新数据**：\n" +
-				"- 智联招聘、前程无忧薪资报告\n" +
-				"- Glassdoor、看准网公司评价\n" +
-				"- 行业薪资调研报告"
+		var item models.Company
+		if err := db.Conn.First(&item, id).Error; err != nil {
+			continue
 		}
-	}
-
-	return response
-}
-
-// generateContractResponse 生成合同审查相关的回复
-func generateContractResponse(input string, deepThinking, networkSearch bool) string {
-	var response string
-
-	// 分析用户问题是否详细，决定是否需要询问
-	if len(input) < 10 || !containsKeywords(input, []string{"合同", "薪资", "福利", "公司", "职位", "条款", "风险", "竞业"}) {
-		// 问题不够详细，询问关键信息
-		response = "## 📋 合同分析咨询\n\n" +
-			"> **为了更好地为您分析合同，请告诉我：**\n\n" +
-			"- 合同的具体内容（薪资、福利、条款等）\n" +
-			"- 您最关心的合同条款\n" +
-			"- 您希望了解的风险点\n\n" +
-			"**请分享这些信息，我会为您提供专业的合同分析！**"
-	} else {
-		// 问题已经详细，直接提供建议
-		response = "## 📋 合同分析建议\n\n" +
-			"基于您提供的信息，我为您分析如下：\n\n"
-
-		if deepThinking {
-			response += "## 🧠 深度分析\n\n" +
-				"**关键条款检查**：\n" +
-				"- **工作内容**：岗位职责、工作地点\n" +
-				"- **薪资待遇**：基本工资、绩效奖金、福利\n" +
-				"- **工作时间**：标准工时、加班费、假期\n" +
-				"- **试用期**：长度、薪资、转正条件\n\n" +
-				"**风险点识别**：\n" +
-				"- 竞业限制是否合理\n" +
-				"- 保密协议范围\n" +
-				"- 违约金设置\n" +
-				"- 解除合同条件\n\n" +
-				"**修改建议**：\n" +
-				"- [ ] 明确薪资结构\n" +
-				"- [ ] 规范工作时间\n" +
-				"- [ ] 合理试用期\n" +
-				"- [ ] 确保社保缴纳"
-		} else {
-			response += "## ⚠️ 风险提醒\n\n" +
-				"**核心检查**：\n" +
-				"- **薪资结构**：基本工资、绩效、福利\n" +
-				"- **工作时间**：标准工时、加班费\n" +
-				"- **试用期**：长度、薪资标准\n" +
-				"- **权益保护**：社保、假期、培训\n\n" +
-				"**常见陷阱**：\n" +
-				"1. 试用期过长（>6个月）\n" +
-				"2. 薪资条款模糊\n" +
-				"3. 加班费不明确\n" +
-				"4. 违约金过高"
+		result, err := insights.Compute(&item, "", config.C.InsightsKAnonymity)
+		if err != nil {
+			logger.Warn("计算企业洞察失败: CompanyID=%d, 错误=%v", id, err)
+			continue
 		}
-
-		if networkSearch {
-			response += "\n\n🌐 **最新信息**：\n" +
-				"- 劳动法最新规定\n" +
-				"- 行业合同标准\n" +
-				"- 法律风险提醒"
+		if text := insights.FormatForPrompt(result); text != "" {
+			sb.WriteString("\n\n" + text)
 		}
 	}
-
-	return response
+	return sb.String()
 }
 
-// generateMonitorResponse 生成企业监控相关的回复
-func generateMonitorResponse(input string, deepThinking, networkSearch bool) string {
-	if deepThinking {
-		return "## 🧠 深度分析\n\n" +
-			"**监控维度**：\n" +
-			"- **财务状况**：营收、利润、现金流、负债率\n" +
-			"- **业务发展**：市场份额、产品发布、重大合作\n" +
-			"- **管理层变动**：高管变动、战略调整\n" +
-			"- **行业地位**：竞争对手、政策变化\n\n" +
-			"**信息渠道**：\n" +
-			"- 官方渠道：年报、公告、官网\n" +
-			"- 媒体渠道：新闻、行业报告\n" +
-			"- 专业平台：天眼查、企查查\n\n" +
-			"**预警机制**：\n" +
-			"- [ ] 设置关键指标阈值\n" +
-			"- [ ] 建立定期报告制度\n" +
-			"- [ ] 制定应急响应预案\n" +
-			"- [ ] 建立信息验证机制"
-	} else {
-		return "## 企业监控建议\n\n" +
-			"**核心监控**：\n" +
-			"- **财务指标**：营收、利润、现金流\n" +
-			"- **业务指标**：市场份额、客户数量\n" +
-			"- **人员指标**：员工数量、离职率\n" +
-			"- **风险指标**：法律诉讼、负面新闻\n\n" +
-			"**风险预警**：\n" +
-			"1. 财务风险：现金流紧张、债务违约\n" +
-			"2. 经营风险：市场份额下降、客户流失\n" +
-			"3. 法律风险：监管变化、诉讼增加\n\n" +
-			"您最关心哪个方面？"
+// buildSystemPrompt 使用与问题类别和模型匹配的模板构建系统提示词
+func buildSystemPrompt(sel prompts.Selector, subIntent string, deepThinking, networkSearch bool) (string, prompts.Refs) {
+	vars := prompts.SystemVars{ModelID: sel.ModelID, Category: sel.Category, SubIntent: subIntent, DeepThinking: deepThinking, NetworkSearch: networkSearch}
+	text, ref, err := prompts.Render(prompts.KeyChatSystem, sel, vars)
+	if err != nil {
+		logger.Error("渲染系统提示词失败: ModelID=%s, 错误=%v", sel.ModelID, err)
 	}
+	return text, prompts.Refs{ref}
 }
 
-// generateGeneralResponse 生成通用回复
-func generateGeneralResponse(input string, deepThinking, networkSearch bool) string {
-	return "## AI职场管家服务\n\n" +
-		"**专业服务**：\n" +
-		"- 🎯 **职业规划**：路径规划、技能提升\n" +
-		"- 💰 **Offer分析**：薪资分析、谈判策略\n" +
-		"- 📋 **合同检查**：条款解读、风险识别\n" +
-		"- 🏢 **企业监控**：财务监控、风险预警\n\n" +
-		"请选择服务类型，我将提供专业帮助！"
-}
-
+// ListMessages 返回会话消息，view=branch 返回当前分支，view=tree 返回完整对话树，默认按时间平铺
 func ListMessages(c *gin.Context) {
 	threadID := c.Query("threadId")
+	switch view := c.DefaultQuery("view", "flat"); view {
+	case "flat":
+	case "branch", "tree":
+		listThreadMessages(c, c.Query("userId"), threadID, view)
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "view只支持flat、branch、tree"})
+		return
+	}
+
 	var msgs []models.Message
 	q := db.Conn.Order("created_at asc")
 	if threadID != "" {
@@ -906,31 +525,17 @@ func ListMessages(c *gin.Context) {
 }
 
 // saveCareerHistory 异步保存职业历史记录
-func saveCareerHistory(threadID, userInput, aiResponse, modelID string, attachments ...string) {
-	// 从threadID提取用户ID和分类
-	var userID, category string
-	userID = "default-user" // 暂时使用默认用户ID，后续可以从认证中获取
-
-	// 根据threadID前缀确定分类
-	if strings.HasPrefix(threadID, "career-") {
-		category = "career"
-	} else if strings.HasPrefix(threadID, "offer-") {
-		category = "offer"
-	} else if strings.HasPrefix(threadID, "contract-") {
-		category = "contract"
-	} else if strings.HasPrefix(threadID, "monitor-") {
-		category = "monitor"
-	} else {
-		category = "unknown" // 默认分类
+func saveCareerHistory(userID, threadID string, messageID uint, userInput, aiResponse, modelID string, in intent.Result, attachments ...string) {
+	if userID == "" {
+		userID = "default-user"
 	}
 
-	// 调试日志
-	logger.Info("保存职业历史记录: ThreadID=%s, Category=%s", threadID, category)
-
-	// 根据内容智能识别分类（覆盖threadID分类）
-	if isMonitorContent(userInput) {
-		category = "monitor"
+	// 按识别出的问题类别归类，与职场无关的问题沿用原有的 unknown 分类
+	category := in.Category
+	if category == intent.CategoryGeneral {
+		category = "unknown"
 	}
+	logger.Info("保存职业历史记录: ThreadID=%s, Category=%s, SubIntent=%s", threadID, category, in.SubIntent)
 
 	// 生成问题标题（取前50个字符）
 	title := userInput
@@ -943,27 +548,29 @@ func saveCareerHistory(threadID, userInput, aiResponse, modelID string, attachme
 	}
 
 	// 提取标签
-	tags := extractTags(userInput, category)
+	tags := extractTags(userInput, in)
 
-	// 构建元数据，包含附件信息
-	var metadata map[string]interface{}
+	// 构建元数据，包含意图识别结果、附件信息和提到的企业
+	metadata := map[string]interface{}{"intent": in}
 	if len(attachments) > 0 {
-		metadata = map[string]interface{}{
-			"attachments": attachments,
-		}
+		metadata["attachments"] = attachments
+	}
+	if companyIDs, err := company.FindMentions(userInput); err != nil {
+		logger.Warn("识别对话中的企业失败: ThreadID=%s, 错误=%v", threadID, err)
+	} else if len(companyIDs) > 0 {
+		metadata["companyIds"] = companyIDs
 	}
 
 	// 将元数据转换为JSON字符串
 	var metadataJSON string
-	if metadata != nil {
-		if metadataBytes, err := json.Marshal(metadata); err == nil {
-			metadataJSON = string(metadataBytes)
-		}
+	if metadataBytes, err := json.Marshal(metadata); err == nil {
+		metadataJSON = string(metadataBytes)
 	}
 
 	history := models.CareerHistory{
 		UserID:       userID,
 		ThreadID:     threadID,
+		MessageID:    messageID,
 		Category:     category,
 		Title:        title,
 		Content:      userInput,
@@ -981,33 +588,24 @@ func saveCareerHistory(threadID, userInput, aiResponse, modelID string, attachme
 	}
 
 	logger.Info("职业历史记录保存成功: ThreadID=%s, Category=%s", threadID, category)
-}
 
-// isMonitorContent 判断内容是否属于企业监控相关
-func isMonitorContent(content string) bool {
-	// 企业监控相关的关键词
-	monitorKeywords := []string{
-		"通风处", "监控", "企业", "公司", "风险", "预警", "财务", "管理层",
-		"市场", "股票", "股价", "财报", "业绩", "投资", "融资", "并购",
-		"高管", "CEO", "CFO", "董事会", "股东", "股权", "上市", "退市",
-		"监管", "合规", "审计", "内控", "风控", "法务", "诉讼", "仲裁",
+	// 写入标签表，并由模型异步生成标题、标签、情绪和关键实体
+	if err := enrich.SyncTags(&history); err != nil {
+		logger.Warn("保存咨询记录标签失败: HistoryID=%d, 错误=%v", history.ID, err)
 	}
+	enrich.HistoryAsync(history.ID)
 
-	contentLower := strings.ToLower(content)
-	for _, keyword := range monitorKeywords {
-		if strings.Contains(contentLower, keyword) {
-			return true
-		}
+	if ix := rag.Default(); ix != nil {
+		ix.IndexHistoryAsync(history)
 	}
-	return false
 }
 
-// extractTags 从用户输入中提取标签
-func extractTags(input, category string) string {
+// extractTags 根据问题意图和用户输入提取标签
+func extractTags(input string, in intent.Result) string {
 	var tags []string
 
 	// 根据分类添加基础标签
-	switch category {
+	switch in.Category {
 	case "career":
 		tags = append(tags, "职业规划")
 	case "offer":
@@ -1027,7 +625,7 @@ func extractTags(input, category string) string {
 	}
 
 	for keyword, tag := range keywordMap {
-		if strings.Contains(input, keyword) {
+		if keyword == in.SubIntentName() || strings.Contains(input, keyword) {
 			tags = append(tags, tag)
 		}
 	}
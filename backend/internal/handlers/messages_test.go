@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMain 使用离线模拟模型和临时 SQLite 数据库初始化一次，
+// 回复保存后的摘要、整理等后台任务会读取全局配置，不能在测试之间重新加载
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handlers-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("CHAT_PROVIDER", "fake")
+	os.Setenv("FAKE_MODEL_DIR", filepath.Join("..", "..", "fixtures", "fake"))
+	os.Setenv("FAKE_MODEL_STREAM_DELAY", "0s")
+	os.Setenv("RAG_ENABLED", "false")
+	os.Setenv("SEARCH_PROVIDER", "none")
+	os.Setenv("MONITOR_ENABLED", "false")
+	config.Load()
+	if err := logger.Init(dir); err != nil {
+		panic(err)
+	}
+	db.Connect("file:" + filepath.Join(dir, "chat.db"))
+	if err := db.Conn.AutoMigrate(
+		&models.Message{},
+		&models.UserProfile{},
+		&models.CareerHistory{},
+		&models.CareerHistoryTag{},
+		&models.UserDocument{},
+		&models.ContractRisk{},
+		&models.Company{},
+		&models.CompanyAlias{},
+		&models.PromptTemplate{},
+		&models.Experiment{},
+		&models.ThreadSummary{},
+		&models.EmbeddingChunk{},
+	); err != nil {
+		panic(err)
+	}
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func chatRouter() *gin.Engine {
+	r := gin.New()
+	r.POST("/api/messages", SendMessage)
+	r.POST("/api/messages/stream", StreamMessage)
+	return r
+}
+
+func postJSON(r *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSendMessageWithFakeProvider(t *testing.T) {
+	r := chatRouter()
+
+	w := postJSON(r, "/api/messages", SendMessageRequest{
+		UserID:   "u1",
+		ThreadID: "t1",
+		Content:  "下个月要和HR谈薪资，应该怎么准备？",
+		ModelID:  "fake/default",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var messages []models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 || messages[0].Role != "user" || messages[1].Role != "assistant" {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+	reply := messages[1].Content
+	if !strings.HasPrefix(reply, api.FakeLabel) || !strings.Contains(reply, "市场行情") {
+		t.Errorf("reply did not come from the salary rule: %q", reply)
+	}
+	if messages[1].ParentID == nil || *messages[1].ParentID != messages[0].ID {
+		t.Errorf("reply parent = %v, want %d", messages[1].ParentID, messages[0].ID)
+	}
+
+	var count int64
+	db.Conn.Model(&models.Message{}).Where("thread_id = ?", "t1").Count(&count)
+	if count != 2 {
+		t.Errorf("stored messages = %d, want 2", count)
+	}
+}
+
+func TestStreamMessageWithFakeProvider(t *testing.T) {
+	r := chatRouter()
+
+	w := postJSON(r, "/api/messages/stream", StreamMessageRequest{
+		UserID:   "u1",
+		ThreadID: "t2",
+		Content:  "周末适合读什么书",
+		ModelID:  "fake/default",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, api.FakeLabel) || !strings.Contains(body, "周末适合读什么书") {
+		t.Errorf("unexpected fallback reply: %q", body)
+	}
+
+	var stored models.Message
+	if err := db.Conn.Where("thread_id = ? AND role = ?", "t2", "assistant").First(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Content != body {
+		t.Errorf("stored reply differs from streamed reply")
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/expr"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/monitor"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateAlertRuleRequest 校验告警规则请求
+type ValidateAlertRuleRequest struct {
+	Condition string                 `json:"condition" binding:"required"`
+	Threshold string                 `json:"threshold"`
+	Signals   map[string]interface{} `json:"signals"`   // 试运行使用的信号快照
+	UserID    string                 `json:"userId"`    // 使用 monitorId 时必填，只能试运行自己的监控
+	MonitorID uint                   `json:"monitorId"` // 未提供 signals 时，拉取该监控企业的最新快照试运行
+}
+
+// RuleValidationError 单条规则的校验错误
+type RuleValidationError struct {
+	RuleID string        `json:"ruleId"`
+	Name   string        `json:"name"`
+	Errors []*expr.Error `json:"errors"`
+}
+
+// GetMonitorAlerts 获取用户的企业监控告警，可按监控ID筛选
+func GetMonitorAlerts(c *gin.Context) {
+	userID := c.Param("userId")
+	monitorID := c.Param("monitorId")
+	limitStr := c.DefaultQuery("limit", "20")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, _ := strconv.Atoi(limitStr)
+	offset, _ := strconv.Atoi(offsetStr)
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	var alerts []models.MonitorAlert
+	query := db.Conn.Where("user_id = ?", userID)
+	if monitorID != "" {
+		query = query.Where("monitor_id = ?", monitorID)
+	}
+	if severity := c.Query("severity"); severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+
+	if err := query.Order("triggered_at DESC").Limit(limit).Offset(offset).Find(&alerts).Error; err != nil {
+		logger.Error("获取企业监控告警失败: UserID=%s, MonitorID=%s, 错误=%v", userID, monitorID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	logger.Info("获取企业监控告警: UserID=%s, MonitorID=%s, 数量=%d", userID, monitorID, len(alerts))
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+// CheckCompanyMonitor 立即执行一次企业监控检查
+func CheckCompanyMonitor(c *gin.Context) {
+	userID := c.Param("userId")
+	monitorID := c.Param("monitorId")
+
+	var m models.CompanyMonitor
+	if err := db.Conn.Where("id = ? AND user_id = ?", monitorID, userID).First(&m).Error; err != nil {
+		logger.Warn("企业监控不存在: UserID=%s, MonitorID=%s", userID, monitorID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "监控不存在"})
+		return
+	}
+
+	alerts, err := monitor.Default().CheckMonitor(&m)
+	if err != nil {
+		logger.Warn("手动检查企业监控失败: MonitorID=%s, 错误=%v", monitorID, err)
+		switch {
+		case errors.Is(err, monitor.ErrMonitorInactive):
+			c.JSON(http.StatusConflict, gin.H{"error": "监控已暂停或停止", "status": m.Status})
+		case errors.Is(err, monitor.ErrNoData):
+			c.JSON(http.StatusNotFound, gin.H{"error": "暂无该企业的监控数据"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	logger.Info("手动检查企业监控完成: MonitorID=%s, 新告警=%d", monitorID, len(alerts))
+	c.JSON(http.StatusOK, gin.H{
+		"monitor": m,
+		"alerts":  alerts,
+	})
+}
+
+// ValidateAlertRule 校验告警规则表达式，并在提供快照时试运行
+func ValidateAlertRule(c *gin.Context) {
+	var req ValidateAlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule := models.AlertRule{Condition: req.Condition, Threshold: req.Threshold}
+	source, _ := monitor.RuleExpression(rule)
+
+	var snapshot *monitor.Snapshot
+	var snapshotErr string
+	switch {
+	case req.Signals != nil:
+		snapshot = &monitor.Snapshot{Source: "request", Signals: req.Signals}
+	case req.MonitorID != 0:
+		if req.UserID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "使用监控快照试运行时必须提供 userId"})
+			return
+		}
+		var m models.CompanyMonitor
+		if err := db.Conn.Where("id = ? AND user_id = ?", req.MonitorID, req.UserID).First(&m).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "监控不存在"})
+			return
+		}
+		fetched, err := monitor.Default().FetchSnapshot(&m)
+		if err != nil {
+			snapshotErr = err.Error()
+		} else {
+			snapshot = fetched
+		}
+	}
+
+	var schema expr.Schema
+	if snapshot != nil {
+		schema = expr.SchemaOf(snapshot.Signals)
+	}
+
+	compiled, errs := monitor.CompileRule(rule, schema)
+	if len(errs) > 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"valid":      false,
+			"expression": source,
+			"errors":     errs,
+		})
+		return
+	}
+
+	resp := gin.H{
+		"valid":      true,
+		"expression": source,
+		"normalized": compiled.Program.String(),
+		"variables":  compiled.Program.Variables(),
+		"errors":     []*expr.Error{},
+	}
+	if snapshotErr != "" {
+		resp["snapshotError"] = snapshotErr
+	}
+	if snapshot != nil {
+		dryRun := gin.H{"source": snapshot.Source}
+		triggered, detail, err := compiled.Evaluate(snapshot)
+		if err != nil {
+			dryRun["error"] = err.Error()
+		} else {
+			dryRun["triggered"] = triggered
+			dryRun["detail"] = detail
+		}
+		resp["dryRun"] = dryRun
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// validateAlertRules 检查监控中所有告警规则的语法和类型，信号是否存在留到运行时检查
+func validateAlertRules(rules []models.AlertRule) []RuleValidationError {
+	var invalid []RuleValidationError
+	for _, rule := range rules {
+		if _, errs := monitor.CompileRule(rule, nil); len(errs) > 0 {
+			invalid = append(invalid, RuleValidationError{RuleID: rule.ID, Name: rule.Name, Errors: errs})
+		}
+	}
+	return invalid
+}
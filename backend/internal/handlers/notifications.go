@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationPreferenceRequest 更新通知偏好请求，字段为空表示不修改
+type NotificationPreferenceRequest struct {
+	InAppEnabled   *bool   `json:"inAppEnabled"`
+	EmailEnabled   *bool   `json:"emailEnabled"`
+	Email          *string `json:"email"`
+	WebhookEnabled *bool   `json:"webhookEnabled"`
+	WebhookURL     *string `json:"webhookUrl"`
+	WebhookFormat  *string `json:"webhookFormat"`
+	WebhookSecret  *string `json:"webhookSecret"`
+	MinSeverity    *string `json:"minSeverity"`
+}
+
+// TestNotificationRequest 测试通知请求
+type TestNotificationRequest struct {
+	Channels []string `json:"channels"` // 为空时测试所有启用的渠道
+}
+
+var validWebhookFormats = []string{"generic", "wecom", "feishu", "dingtalk"}
+
+// GetNotifications 获取用户的站内通知
+func GetNotifications(c *gin.Context) {
+	userID := c.Param("userId")
+	limitStr := c.DefaultQuery("limit", "20")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, _ := strconv.Atoi(limitStr)
+	offset, _ := strconv.Atoi(offsetStr)
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	var notifications []models.Notification
+	query := db.Conn.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("is_read = ?", false)
+	}
+	if eventType := c.Query("eventType"); eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		logger.Error("获取站内通知失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	var unread int64
+	db.Conn.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&unread)
+
+	logger.Info("获取站内通知: UserID=%s, 数量=%d, 未读=%d", userID, len(notifications), unread)
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"unreadCount":   unread,
+	})
+}
+
+// GetUnreadNotificationCount 获取未读通知数量
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID := c.Param("userId")
+
+	var unread int64
+	if err := db.Conn.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&unread).Error; err != nil {
+		logger.Error("获取未读通知数量失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unreadCount": unread})
+}
+
+// MarkNotificationRead 标记通知为已读
+func MarkNotificationRead(c *gin.Context) {
+	setNotificationRead(c, true)
+}
+
+// MarkNotificationUnread 标记通知为未读
+func MarkNotificationUnread(c *gin.Context) {
+	setNotificationRead(c, false)
+}
+
+func setNotificationRead(c *gin.Context, read bool) {
+	userID := c.Param("userId")
+	notificationID := c.Param("notificationId")
+
+	var notification models.Notification
+	if err := db.Conn.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		logger.Warn("通知不存在: UserID=%s, NotificationID=%s", userID, notificationID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在"})
+		return
+	}
+
+	notification.IsRead = read
+	notification.ReadAt = nil
+	if read {
+		now := time.Now()
+		notification.ReadAt = &now
+	}
+
+	if err := db.Conn.Save(&notification).Error; err != nil {
+		logger.Error("更新通知状态失败: NotificationID=%s, 错误=%v", notificationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notification": notification})
+}
+
+// MarkAllNotificationsRead 将用户所有未读通知标记为已读
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.Param("userId")
+
+	result := db.Conn.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		logger.Error("标记全部通知已读失败: UserID=%s, 错误=%v", userID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	logger.Info("标记全部通知已读: UserID=%s, 数量=%d", userID, result.RowsAffected)
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// DeleteNotification 删除通知
+func DeleteNotification(c *gin.Context) {
+	userID := c.Param("userId")
+	notificationID := c.Param("notificationId")
+
+	result := db.Conn.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		logger.Error("删除通知失败: NotificationID=%s, 错误=%v", notificationID, result.Error)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// GetNotificationPreference 获取用户通知偏好
+func GetNotificationPreference(c *gin.Context) {
+	userID := c.Param("userId")
+
+	pref, err := notify.LoadPreference(userID)
+	if err != nil {
+		logger.Error("获取通知偏好失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preference":       pref,
+		"webhookSecretSet": pref.WebhookSecret != "",
+	})
+}
+
+// UpdateNotificationPreference 更新用户通知偏好
+func UpdateNotificationPreference(c *gin.Context) {
+	userID := c.Param("userId")
+
+	var req NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pref, err := notify.LoadPreference(userID)
+	if err != nil {
+		logger.Error("获取通知偏好失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	if req.InAppEnabled != nil {
+		pref.InAppEnabled = *req.InAppEnabled
+	}
+	if req.EmailEnabled != nil {
+		pref.EmailEnabled = *req.EmailEnabled
+	}
+	if req.Email != nil {
+		pref.Email = strings.TrimSpace(*req.Email)
+		if pref.Email != "" {
+			email, err := notify.NormalizeEmail(pref.Email)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			pref.Email = email
+		}
+	}
+	if req.WebhookEnabled != nil {
+		pref.WebhookEnabled = *req.WebhookEnabled
+	}
+	if req.WebhookURL != nil {
+		pref.WebhookURL = *req.WebhookURL
+	}
+	if req.WebhookFormat != nil {
+		if !contains(validWebhookFormats, *req.WebhookFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的webhook格式"})
+			return
+		}
+		pref.WebhookFormat = *req.WebhookFormat
+	}
+	if req.WebhookSecret != nil {
+		pref.WebhookSecret = *req.WebhookSecret
+	}
+	if req.MinSeverity != nil {
+		if !notify.ValidSeverity(*req.MinSeverity) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的严重程度"})
+			return
+		}
+		pref.MinSeverity = *req.MinSeverity
+	}
+
+	if pref.WebhookEnabled || (req.WebhookURL != nil && pref.WebhookURL != "") {
+		if err := notify.ValidateWebhookURL(pref.WebhookURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := db.Conn.Save(pref).Error; err != nil {
+		logger.Error("保存通知偏好失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	logger.Info("更新通知偏好: UserID=%s, 站内=%t, 邮件=%t, Webhook=%t", userID, pref.InAppEnabled, pref.EmailEnabled, pref.WebhookEnabled)
+	c.JSON(http.StatusOK, gin.H{
+		"preference":       pref,
+		"webhookSecretSet": pref.WebhookSecret != "",
+	})
+}
+
+// GetNotificationDeliveries 获取通知投递记录
+func GetNotificationDeliveries(c *gin.Context) {
+	userID := c.Param("userId")
+	limitStr := c.DefaultQuery("limit", "20")
+	offsetStr := c.DefaultQuery("offset", "0")
+
+	limit, _ := strconv.Atoi(limitStr)
+	offset, _ := strconv.Atoi(offsetStr)
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	var deliveries []models.NotificationDelivery
+	query := db.Conn.Where("user_id = ?", userID)
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		logger.Error("获取通知投递记录失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+// SendTestNotification 向用户启用的渠道发送测试通知
+func SendTestNotification(c *gin.Context) {
+	userID := c.Param("userId")
+
+	var req TestNotificationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var results []notify.Result
+	channels := req.Channels
+	if len(channels) == 0 {
+		channels = []string{notify.ChannelInApp, notify.ChannelEmail, notify.ChannelWebhook}
+	}
+	// 每个渠道单独渲染，模板中显示对应的渠道名称
+	for _, channel := range channels {
+		res, err := notify.Default().Dispatch(&notify.Event{
+			Type:     notify.EventTest,
+			UserID:   userID,
+			Severity: "critical",
+			Data:     map[string]interface{}{"Channel": channel},
+			Channels: []string{channel},
+		})
+		if err != nil {
+			logger.Error("发送测试通知失败: UserID=%s, Channel=%s, 错误=%v", userID, channel, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		results = append(results, res...)
+	}
+
+	logger.Info("发送测试通知: UserID=%s, 渠道数=%d", userID, len(results))
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// promptOverview 模板键的默认来源和启用中的变体
+type promptOverview struct {
+	prompts.Definition
+	DefaultSource string                  `json:"defaultSource"`
+	Active        []models.PromptTemplate `json:"active"`
+}
+
+// ListPromptTemplates 列出所有可配置的提示词模板及其启用版本
+func ListPromptTemplates(c *gin.Context) {
+	registry := prompts.Default()
+	result := make([]promptOverview, 0, len(prompts.Definitions()))
+	for _, def := range prompts.Definitions() {
+		versions, err := prompts.Versions(def.Key, nil)
+		if err != nil {
+			logger.Error("获取提示词模板失败: Key=%s, 错误=%v", def.Key, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+			return
+		}
+		_, source, _ := registry.DefaultContent(def.Key)
+		item := promptOverview{Definition: def, DefaultSource: source, Active: []models.PromptTemplate{}}
+		for _, v := range versions {
+			if v.IsActive {
+				item.Active = append(item.Active, v)
+			}
+		}
+		result = append(result, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": result})
+}
+
+// GetPromptTemplate 获取模板的默认内容和版本历史，可按 category、modelId 筛选变体
+func GetPromptTemplate(c *gin.Context) {
+	key := c.Param("key")
+	def, ok := prompts.Lookup(key)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提示词模板不存在"})
+		return
+	}
+
+	var sel *prompts.Selector
+	if _, hasCategory := c.GetQuery("category"); hasCategory {
+		sel = &prompts.Selector{Category: c.Query("category"), ModelID: c.Query("modelId")}
+	} else if _, hasModel := c.GetQuery("modelId"); hasModel {
+		sel = &prompts.Selector{ModelID: c.Query("modelId")}
+	}
+	versions, err := prompts.Versions(key, sel)
+	if err != nil {
+		logger.Error("获取提示词模板版本失败: Key=%s, 错误=%v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	content, source, _ := prompts.Default().DefaultContent(key)
+	c.JSON(http.StatusOK, gin.H{
+		"definition": def,
+		"default":    gin.H{"source": source, "content": content},
+		"versions":   versions,
+	})
+}
+
+// PromptTemplateRequest 保存模板新版本的请求
+type PromptTemplateRequest struct {
+	Category    string `json:"category"`
+	ModelID     string `json:"modelId"`
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description"`
+	CreatedBy   string `json:"createdBy"`
+	Activate    *bool  `json:"activate"` // 默认立即启用
+}
+
+// CreatePromptTemplate 保存模板的新版本，旧版本保留用于回滚
+func CreatePromptTemplate(c *gin.Context) {
+	key := c.Param("key")
+	if _, ok := prompts.Lookup(key); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提示词模板不存在"})
+		return
+	}
+
+	var in PromptTemplateRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	activate := in.Activate == nil || *in.Activate
+
+	t := models.PromptTemplate{
+		Key:         key,
+		Category:    strings.TrimSpace(in.Category),
+		ModelID:     strings.TrimSpace(in.ModelID),
+		Content:     in.Content,
+		Description: in.Description,
+		CreatedBy:   in.CreatedBy,
+	}
+	if _, err := prompts.RenderSample(key, t.Content); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := prompts.Create(&t, activate); err != nil {
+		logger.Error("保存提示词模板失败: Key=%s, 错误=%v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	logger.Info("提示词模板已保存: Key=%s, Category=%s, ModelID=%s, Version=%d, 启用=%t",
+		key, t.Category, t.ModelID, t.Version, t.IsActive)
+	c.JSON(http.StatusOK, t)
+}
+
+// PreviewPromptTemplate 使用示例变量渲染模板内容，不保存
+func PreviewPromptTemplate(c *gin.Context) {
+	key := c.Param("key")
+	var in struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	text, err := prompts.RenderSample(key, in.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rendered": text})
+}
+
+// ActivatePromptTemplate 启用指定版本，用于回滚到任意历史版本
+func ActivatePromptTemplate(c *gin.Context) {
+	key := c.Param("key")
+	id, err := strconv.ParseUint(c.Param("versionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本ID"})
+		return
+	}
+
+	t, err := prompts.Activate(key, uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "模板版本不存在"})
+		return
+	}
+	if err != nil {
+		logger.Error("启用提示词模板失败: Key=%s, ID=%d, 错误=%v", key, id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "启用失败"})
+		return
+	}
+
+	logger.Info("提示词模板已启用: %s", prompts.Ref{Key: t.Key, Source: prompts.SourceDB, Version: t.Version, Category: t.Category, ModelID: t.ModelID})
+	c.JSON(http.StatusOK, t)
+}
+
+// RollbackPromptTemplate 将变体回滚到上一个版本，没有更早的版本时恢复使用默认模板
+func RollbackPromptTemplate(c *gin.Context) {
+	key := c.Param("key")
+	if _, ok := prompts.Lookup(key); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "提示词模板不存在"})
+		return
+	}
+	var in struct {
+		Category string `json:"category"`
+		ModelID  string `json:"modelId"`
+	}
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := prompts.Rollback(key, prompts.Selector{Category: in.Category, ModelID: in.ModelID})
+	if errors.Is(err, prompts.ErrNoPreviousVersion) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该变体没有启用中的版本"})
+		return
+	}
+	if err != nil {
+		logger.Error("回滚提示词模板失败: Key=%s, 错误=%v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "回滚失败"})
+		return
+	}
+
+	if t == nil {
+		logger.Info("提示词模板已恢复为默认: Key=%s, Category=%s, ModelID=%s", key, in.Category, in.ModelID)
+		c.JSON(http.StatusOK, gin.H{"active": nil, "usingDefault": true})
+		return
+	}
+	logger.Info("提示词模板已回滚: Key=%s, Version=%d", key, t.Version)
+	c.JSON(http.StatusOK, gin.H{"active": t, "usingDefault": false})
+}
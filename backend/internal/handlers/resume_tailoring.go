@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/resume"
+	"ai-career-buddy/internal/storage"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TailorResumeRequest 简历针对目标岗位优化请求
+type TailorResumeRequest struct {
+	TargetPosition string `json:"targetPosition" binding:"required"`
+	JobDescription string `json:"jobDescription"` // 岗位描述(JD)
+	ModelID        string `json:"modelId"`        // 使用的模型，默认 RESUME_TAILOR_MODEL
+}
+
+// UpdateSuggestionRequest 采纳或拒绝改写建议，采纳时可修改改写内容
+type UpdateSuggestionRequest struct {
+	Status  string  `json:"status" binding:"required"`
+	Rewrite *string `json:"rewrite"`
+}
+
+// ApplyTailoringRequest 应用已采纳的建议生成新版本简历
+type ApplyTailoringRequest struct {
+	Template          string `json:"template"`
+	Format            string `json:"format"` // pdf（默认）、html、markdown
+	FileName          string `json:"fileName"`
+	ApplySectionOrder *bool  `json:"applySectionOrder"` // 是否使用建议的章节顺序，默认是
+}
+
+// tailoringCoverage 优化前、采纳全部建议后和按当前采纳情况的关键词覆盖
+type tailoringCoverage struct {
+	Before    resume.Coverage `json:"before"`
+	Projected resume.Coverage `json:"projected"`
+	Accepted  resume.Coverage `json:"accepted"`
+}
+
+var validSuggestionStatuses = []string{models.SuggestionStatusPending, models.SuggestionStatusAccepted, models.SuggestionStatusRejected}
+
+// TailorResume 针对目标岗位生成简历优化建议：逐条要点的STAR改写、章节顺序和关键词覆盖
+func TailorResume(c *gin.Context) {
+	userID := c.Param("userId")
+	documentID := c.Param("documentId")
+
+	var req TailorResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供目标岗位 targetPosition"})
+		return
+	}
+
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", documentID, userID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+		return
+	}
+	if document.DocumentType != "resume" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "只能优化简历"})
+		return
+	}
+	if !document.IsProcessed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "简历尚未完成AI分析"})
+		return
+	}
+	info, err := document.GetExtractedInfo()
+	if err != nil {
+		logger.Error("解析提取信息失败: DocumentID=%d, 错误=%v", document.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+		return
+	}
+	if len(info.WorkExperience) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "简历中没有工作经历，无法给出改写建议"})
+		return
+	}
+
+	modelID := req.ModelID
+	if modelID == "" {
+		modelID = config.C.ResumeTailorModel
+	}
+	targetPosition := utils.SanitizeForDatabase(strings.TrimSpace(req.TargetPosition))
+	jobDescription := utils.SanitizeForDatabase(strings.TrimSpace(req.JobDescription))
+	plan, err := resume.Tailor(info, targetPosition, jobDescription, modelID)
+	if err != nil {
+		logger.Error("生成简历优化建议失败: DocumentID=%d, 错误=%v", document.ID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "生成优化建议失败: " + err.Error()})
+		return
+	}
+
+	tailoring := models.ResumeTailoring{
+		UserID:         userID,
+		DocumentID:     document.ID,
+		TargetPosition: targetPosition,
+		JobDescription: jobDescription,
+		SectionReason:  plan.SectionReason,
+		Summary:        plan.Summary,
+		Status:         models.TailoringStatusPending,
+		ModelID:        modelID,
+		PromptVersion:  plan.PromptVersion,
+	}
+	tailoring.SetKeywords(plan.Keywords)
+	tailoring.SetSectionOrder(plan.SectionOrder)
+	for _, r := range plan.Rewrites {
+		s := models.ResumeSuggestion{
+			ExperienceIndex: r.Experience,
+			BulletIndex:     r.Bullet,
+			Original:        r.Original,
+			Rewrite:         utils.SanitizeForDatabase(r.Text),
+			Reason:          utils.SanitizeForDatabase(r.Reason),
+			Status:          models.SuggestionStatusPending,
+		}
+		s.SetKeywords(r.Keywords)
+		tailoring.Suggestions = append(tailoring.Suggestions, s)
+	}
+	if err := db.Conn.Create(&tailoring).Error; err != nil {
+		logger.Error("保存简历优化建议失败: DocumentID=%d, 错误=%v", document.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败"})
+		return
+	}
+
+	coverage, err := computeTailoringCoverage(&tailoring, info)
+	if err != nil {
+		logger.Error("计算关键词覆盖失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算关键词覆盖失败"})
+		return
+	}
+	logger.Info("简历优化建议生成成功: UserID=%s, DocumentID=%d, TailoringID=%d, 建议数=%d", userID, document.ID, tailoring.ID, len(tailoring.Suggestions))
+	c.JSON(http.StatusOK, gin.H{"tailoring": tailoring, "coverage": coverage})
+}
+
+// GetResumeTailoring 获取优化建议及当前采纳情况下的关键词覆盖
+func GetResumeTailoring(c *gin.Context) {
+	tailoring, info, ok := loadTailoring(c)
+	if !ok {
+		return
+	}
+	coverage, err := computeTailoringCoverage(tailoring, info)
+	if err != nil {
+		logger.Error("计算关键词覆盖失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算关键词覆盖失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tailoring": tailoring, "coverage": coverage})
+}
+
+// UpdateResumeSuggestion 采纳或拒绝一条改写建议
+func UpdateResumeSuggestion(c *gin.Context) {
+	tailoring, info, ok := loadTailoring(c)
+	if !ok {
+		return
+	}
+	suggestionID := c.Param("suggestionId")
+
+	var req UpdateSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供 status"})
+		return
+	}
+	if !contains(validSuggestionStatuses, req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errInvalidStatus.Error()})
+		return
+	}
+
+	var suggestion *models.ResumeSuggestion
+	for i := range tailoring.Suggestions {
+		if strconv.FormatUint(uint64(tailoring.Suggestions[i].ID), 10) == suggestionID {
+			suggestion = &tailoring.Suggestions[i]
+		}
+	}
+	if suggestion == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "建议不存在"})
+		return
+	}
+	suggestion.Status = req.Status
+	if req.Rewrite != nil {
+		rewrite := utils.SanitizeForDatabase(strings.TrimSpace(*req.Rewrite))
+		if rewrite == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "改写内容不能为空"})
+			return
+		}
+		suggestion.Rewrite = rewrite
+	}
+	suggestion.UpdatedAt = time.Now()
+	if err := db.Conn.Save(suggestion).Error; err != nil {
+		logger.Error("更新改写建议失败: SuggestionID=%d, 错误=%v", suggestion.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失败"})
+		return
+	}
+
+	coverage, err := computeTailoringCoverage(tailoring, info)
+	if err != nil {
+		logger.Error("计算关键词覆盖失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算关键词覆盖失败"})
+		return
+	}
+	logger.Info("改写建议更新成功: TailoringID=%d, SuggestionID=%d, Status=%s", tailoring.ID, suggestion.ID, suggestion.Status)
+	c.JSON(http.StatusOK, gin.H{"suggestion": suggestion, "coverage": coverage})
+}
+
+// ApplyResumeTailoring 把已采纳的改写和建议的章节顺序应用到简历，生成原简历的新版本
+func ApplyResumeTailoring(c *gin.Context) {
+	tailoring, info, ok := loadTailoring(c)
+	if !ok {
+		return
+	}
+	userID := c.Param("userId")
+
+	var req ApplyTailoringRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = "pdf"
+	}
+	if _, ok := resumeFormats[req.Format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 pdf、html、markdown"})
+		return
+	}
+
+	edits := acceptedEdits(tailoring)
+	applySectionOrder := req.ApplySectionOrder == nil || *req.ApplySectionOrder
+	if len(edits) == 0 && !applySectionOrder {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有采纳任何建议"})
+		return
+	}
+	tailored, err := resume.ApplyRewrites(info, edits)
+	if err != nil {
+		logger.Error("应用改写建议失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "应用建议失败"})
+		return
+	}
+
+	previous, err := previousVersion(userID, "resume", "", strconv.FormatUint(uint64(tailoring.DocumentID), 10))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	opts := resume.Options{Template: req.Template}
+	if applySectionOrder {
+		opts.Sections = tailoring.GetSectionOrder()
+	}
+	document, out, err := saveGeneratedResume(userID, tailored, opts, req.Format, req.FileName, tailoring.DocumentID, previous)
+	if err != nil {
+		if isResumeInputError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("生成优化后的简历失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成简历失败"})
+		return
+	}
+
+	now := time.Now()
+	tailoring.Status = models.TailoringStatusApplied
+	tailoring.ResultDocumentID = document.ID
+	tailoring.AppliedAt = &now
+	if err := db.Conn.Model(tailoring).Updates(map[string]interface{}{
+		"status": tailoring.Status, "result_document_id": document.ID, "applied_at": now,
+	}).Error; err != nil {
+		logger.Warn("更新简历优化状态失败: TailoringID=%d, 错误=%v", tailoring.ID, err)
+	}
+
+	keywords := tailoring.GetKeywords()
+	url, expiresAt := storage.SignPath(downloadPath(document.ID), config.C.StorageURLTTL)
+	logger.Info("简历优化已应用: TailoringID=%d, DocumentID=%d, Version=%d, 采纳=%d", tailoring.ID, document.ID, document.Version, len(edits))
+	c.JSON(http.StatusOK, gin.H{
+		"tailoring":   tailoring,
+		"document":    document,
+		"markdown":    out.Markdown,
+		"downloadUrl": url,
+		"expiresAt":   expiresAt,
+		"coverage": gin.H{
+			"before": resume.KeywordCoverage(info, keywords),
+			"after":  resume.KeywordCoverage(tailored, keywords),
+		},
+	})
+}
+
+// loadTailoring 按路由参数加载优化建议及其对应简历的提取信息，失败时直接写入响应
+func loadTailoring(c *gin.Context) (*models.ResumeTailoring, *models.DocumentExtractedInfo, bool) {
+	userID := c.Param("userId")
+	tailoringID := c.Param("tailoringId")
+
+	var tailoring models.ResumeTailoring
+	if err := db.Conn.Where("id = ? AND user_id = ?", tailoringID, userID).
+		Preload("Suggestions", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("experience_index ASC, bullet_index ASC")
+		}).First(&tailoring).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "优化建议不存在"})
+		return nil, nil, false
+	}
+	var document models.UserDocument
+	if err := db.Conn.Where("id = ? AND user_id = ?", tailoring.DocumentID, userID).First(&document).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "原简历已删除"})
+		return nil, nil, false
+	}
+	info, err := document.GetExtractedInfo()
+	if err != nil {
+		logger.Error("解析提取信息失败: DocumentID=%d, 错误=%v", document.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+		return nil, nil, false
+	}
+	return &tailoring, info, true
+}
+
+// acceptedEdits 已采纳的改写
+func acceptedEdits(tailoring *models.ResumeTailoring) []resume.Edit {
+	var edits []resume.Edit
+	for _, s := range tailoring.Suggestions {
+		if s.Status == models.SuggestionStatusAccepted {
+			edits = append(edits, resume.Edit{Experience: s.ExperienceIndex, Bullet: s.BulletIndex, Original: s.Original, Text: s.Rewrite})
+		}
+	}
+	return edits
+}
+
+func computeTailoringCoverage(tailoring *models.ResumeTailoring, info *models.DocumentExtractedInfo) (*tailoringCoverage, error) {
+	keywords := tailoring.GetKeywords()
+	all := make([]resume.Edit, 0, len(tailoring.Suggestions))
+	for _, s := range tailoring.Suggestions {
+		all = append(all, resume.Edit{Experience: s.ExperienceIndex, Bullet: s.BulletIndex, Original: s.Original, Text: s.Rewrite})
+	}
+	projected, err := resume.ApplyRewrites(info, all)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := resume.ApplyRewrites(info, acceptedEdits(tailoring))
+	if err != nil {
+		return nil, err
+	}
+	return &tailoringCoverage{
+		Before:    resume.KeywordCoverage(info, keywords),
+		Projected: resume.KeywordCoverage(projected, keywords),
+		Accepted:  resume.KeywordCoverage(accepted, keywords),
+	}, nil
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/rag"
+	"ai-career-buddy/internal/resume"
+	"ai-career-buddy/internal/storage"
+	"ai-career-buddy/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenderResumeRequest 简历生成请求
+type RenderResumeRequest struct {
+	DocumentID   uint                          `json:"documentId"` // 已分析的简历，未提供 data 时使用其提取信息
+	Data         *models.DocumentExtractedInfo `json:"data"`       // 编辑后的简历信息
+	Template     string                        `json:"template"`
+	Sections     []string                      `json:"sections"`     // 章节顺序，如 ["education","experience","skills"]
+	Format       string                        `json:"format"`       // 保存的文件格式：pdf（默认）、html、markdown
+	FileName     string                        `json:"fileName"`     // 不含扩展名
+	AsNewVersion bool                          `json:"asNewVersion"` // 保存为 documentId 对应简历的新版本
+}
+
+// resumeFormats 生成文件的扩展名和类型
+var resumeFormats = map[string]struct{ ext, contentType string }{
+	"pdf":      {"pdf", "application/pdf"},
+	"html":     {"html", "text/html; charset=utf-8"},
+	"markdown": {"md", "text/markdown; charset=utf-8"},
+}
+
+// GetResumeTemplates 可用的简历模板
+func GetResumeTemplates(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"templates": resume.Templates(), "sections": resume.DefaultSections})
+}
+
+// RenderResume 把结构化简历信息按模板生成 Markdown、HTML 和 PDF，所选格式保存为新文档
+func RenderResume(c *gin.Context) {
+	userID := c.Param("userId")
+	var req RenderResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "pdf"
+	}
+	if _, ok := resumeFormats[req.Format]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 只支持 pdf、html、markdown"})
+		return
+	}
+	if req.DocumentID == 0 && req.Data == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请提供 documentId 或 data"})
+		return
+	}
+	if req.AsNewVersion && req.DocumentID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "保存为新版本时需要提供 documentId"})
+		return
+	}
+
+	var source *models.UserDocument
+	info := req.Data
+	if req.DocumentID != 0 {
+		var doc models.UserDocument
+		if err := db.Conn.Where("id = ? AND user_id = ?", req.DocumentID, userID).First(&doc).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "文档不存在"})
+			return
+		}
+		if doc.DocumentType != "resume" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "只能根据简历生成"})
+			return
+		}
+		source = &doc
+		if info == nil {
+			if !doc.IsProcessed {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "简历尚未完成AI分析"})
+				return
+			}
+			extracted, err := doc.GetExtractedInfo()
+			if err != nil {
+				logger.Error("解析提取信息失败: DocumentID=%d, 错误=%v", doc.ID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "解析信息失败"})
+				return
+			}
+			info = extracted
+		}
+	}
+
+	var previous *models.UserDocument
+	if req.AsNewVersion {
+		var err error
+		if previous, err = previousVersion(userID, "resume", "", strconv.FormatUint(uint64(source.ID), 10)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	opts := resume.Options{Template: req.Template, Sections: req.Sections}
+	document, out, err := saveGeneratedResume(userID, info, opts, req.Format, req.FileName, req.DocumentID, previous)
+	if err != nil {
+		if isResumeInputError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Error("生成简历失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成简历失败"})
+		return
+	}
+
+	url, expiresAt := storage.SignPath(downloadPath(document.ID), config.C.StorageURLTTL)
+	c.JSON(http.StatusOK, gin.H{
+		"document":    document,
+		"markdown":    out.Markdown,
+		"html":        out.HTML,
+		"downloadUrl": url,
+		"expiresAt":   expiresAt,
+		"font":        gin.H{"name": out.FontName, "embedded": out.FontEmbedded},
+	})
+}
+
+func isResumeInputError(err error) bool {
+	return errors.Is(err, resume.ErrUnknownTemplate) || errors.Is(err, resume.ErrUnknownSection) || errors.Is(err, resume.ErrEmpty)
+}
+
+// saveGeneratedResume 生成简历并把 format 格式的文件保存为来源为 generated 的简历文档。
+// sourceID 为生成所依据的简历，previous 不为空时作为其所在版本组的新版本
+func saveGeneratedResume(userID string, info *models.DocumentExtractedInfo, opts resume.Options, format, fileName string,
+	sourceID uint, previous *models.UserDocument) (*models.UserDocument, *resume.Output, error) {
+	out, err := resume.Render(info, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	f := resumeFormats[format]
+	var data []byte
+	switch format {
+	case "pdf":
+		data = out.PDF
+	case "html":
+		data = []byte(out.HTML)
+	default:
+		data = []byte(out.Markdown)
+	}
+	blob, release, err := saveDocumentBlob(bytes.NewReader(data), f.contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	fileName = utils.SanitizeFileName(strings.TrimSpace(fileName))
+	if fileName == "" {
+		fileName = "简历_" + time.Now().Format("20060102150405")
+		if name := utils.SanitizeFileName(strings.TrimSpace(info.PersonalInfo.Name)); name != "" {
+			fileName = name + "_" + fileName
+		}
+	}
+
+	document := models.UserDocument{
+		UserID:           userID,
+		DocumentType:     "resume",
+		FileName:         fileName + "." + f.ext,
+		FileSize:         blob.Size,
+		FileType:         f.ext,
+		BlobKey:          blob.Key,
+		ContentType:      f.contentType,
+		ContentHash:      blob.SHA256,
+		Version:          1,
+		FileContent:      out.Markdown,
+		UploadSource:     "generated",
+		IsProcessed:      true,
+		ProcessingStatus: "completed",
+	}
+	if err := document.SetExtractedInfo(info); err != nil {
+		return nil, nil, err
+	}
+	metadata := map[string]interface{}{
+		"template": out.Template,
+		"sections": out.Sections,
+		"font":     out.FontName,
+	}
+	if sourceID != 0 {
+		metadata["sourceDocumentId"] = sourceID
+	}
+	if err := document.SetMetadata(metadata); err != nil {
+		return nil, nil, err
+	}
+	if err := createVersioned(&document, previous); err != nil {
+		return nil, nil, err
+	}
+	logger.Info("简历已生成: UserID=%s, DocumentID=%d, 模板=%s, 格式=%s, Version=%d", userID, document.ID, out.Template, format, document.Version)
+
+	if ix := rag.Default(); ix != nil {
+		ix.IndexDocumentAsync(document)
+	}
+	return &document, out, nil
+}
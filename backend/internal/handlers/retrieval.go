@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/rag"
+	"ai-career-buddy/internal/websearch"
+)
+
+// 附件文档最多注入的片段数，以及未建立索引时的截断长度
+const (
+	attachmentChunks    = 6
+	attachmentMaxLength = 3000
+)
+
+// retrievalContext 本轮对话检索到的资料，联网检索结果和用户资料共用一套引用编号
+type retrievalContext struct {
+	web       []websearch.Result
+	knowledge []rag.Hit
+}
+
+// retrieveForMessage 联网搜索模式下检索网络资料，并自动检索用户自己的文档和历史咨询
+//
+// excludeDocuments 为本轮已作为附件的文档，避免同一内容重复注入。
+func retrieveForMessage(userID, query string, networkSearch bool, excludeDocuments []uint) *retrievalContext {
+	rc := &retrievalContext{}
+	if networkSearch {
+		rc.web = websearch.Retrieve(query)
+	}
+
+	if ix := rag.Default(); ix != nil {
+		hits, err := ix.Search(userID, query, rag.SearchOptions{})
+		if err != nil {
+			logger.Warn("检索用户资料失败: UserID=%s, 错误=%v", userID, err)
+		}
+		for _, hit := range hits {
+			if hit.Chunk.SourceType == rag.SourceDocument && containsID(excludeDocuments, hit.Chunk.SourceID) {
+				continue
+			}
+			rc.knowledge = append(rc.knowledge, hit)
+		}
+	}
+	return rc
+}
+
+// prompt 注入系统提示词的检索资料
+func (rc *retrievalContext) prompt() string {
+	var parts []string
+	if p := websearch.BuildPrompt(rc.web); p != "" {
+		parts = append(parts, p)
+	}
+	if p := rag.BuildPrompt(rc.knowledge, len(rc.web)+1); p != "" {
+		parts = append(parts, p)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(parts, "\n")
+}
+
+// sources 转换为消息来源，并标记回复中实际引用的资料
+func (rc *retrievalContext) sources(answer string) []models.MessageSource {
+	if len(rc.web) == 0 && len(rc.knowledge) == 0 {
+		return nil
+	}
+	cited := websearch.CitedIDs(answer)
+	sources := make([]models.MessageSource, 0, len(rc.web)+len(rc.knowledge))
+	for _, r := range rc.web {
+		sources = append(sources, models.MessageSource{
+			ID:          r.ID,
+			Type:        "web",
+			Title:       r.Title,
+			URL:         r.URL,
+			Snippet:     r.Snippet,
+			Source:      r.Source,
+			PublishedAt: r.PublishedAt,
+			Cited:       cited[r.ID],
+		})
+	}
+	for i, hit := range rc.knowledge {
+		id := len(rc.web) + 1 + i
+		sources = append(sources, models.MessageSource{
+			ID:      id,
+			Type:    hit.Chunk.SourceType,
+			RefID:   hit.Chunk.SourceID,
+			Title:   hit.Chunk.Title,
+			Snippet: truncateText(hit.Chunk.Content, 200),
+			Source:  rag.SourceLabel(hit.Chunk.SourceType),
+			Cited:   cited[id],
+		})
+	}
+	return sources
+}
+
+// documentAttachmentText 文档附件只注入与问题相关的片段，而不是整份提取结果或原文
+func documentAttachmentText(document *models.UserDocument, question string) string {
+	if ix := rag.Default(); ix != nil {
+		hits, err := ix.SearchDocument(document, question, attachmentChunks)
+		if err != nil {
+			logger.Warn("检索附件文档失败: DocumentID=%d, 错误=%v", document.ID, err)
+		}
+		if len(hits) > 0 {
+			pieces := make([]string, len(hits))
+			for i, hit := range hits {
+				pieces[i] = hit.Chunk.Content
+			}
+			return fmt.Sprintf("[%s相关片段]:\n%s", document.DocumentType, strings.Join(pieces, "\n...\n"))
+		}
+	}
+
+	// 未启用检索时退回到截断后的分析结果或原文
+	if document.IsProcessed && document.ExtractedInfo != "" {
+		return fmt.Sprintf("[%s分析结果]:\n%s", document.DocumentType, truncateText(document.ExtractedInfo, attachmentMaxLength))
+	}
+	if document.FileContent != "" {
+		return fmt.Sprintf("[%s文档摘要]:\n%s", document.DocumentType, truncateText(document.FileContent, attachmentMaxLength))
+	}
+	return ""
+}
+
+func truncateText(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
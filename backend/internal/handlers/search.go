@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/fulltext"
+	"ai-career-buddy/internal/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchUser 在用户的对话消息、笔记、咨询记录和文档中做关键词检索
+func SearchUser(c *gin.Context) {
+	userID := c.Param("userId")
+	q := fulltext.Query{Text: strings.TrimSpace(c.Query("q"))}
+	if q.Text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "检索内容不能为空"})
+		return
+	}
+	if types := c.Query("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			if !fulltext.ValidType(t) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的检索类型: " + t})
+				return
+			}
+			q.Types = append(q.Types, t)
+		}
+	}
+	q.Page, _ = strconv.Atoi(c.Query("page"))
+	q.PageSize, _ = strconv.Atoi(c.Query("pageSize"))
+
+	result, err := fulltext.Search(userID, q)
+	if err == fulltext.ErrEmptyQuery {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Error("全文检索失败: UserID=%s, 检索内容=%s, 错误=%v", userID, q.Text, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "检索失败"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
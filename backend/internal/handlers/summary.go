@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/summary"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetThreadSummary 返回会话的滚动摘要，refresh=true 时先把最近几轮之前的对话全部并入摘要
+func GetThreadSummary(c *gin.Context) {
+	userID := c.Param("userId")
+	threadID := c.Param("threadId")
+
+	var latest models.Message
+	err := db.Conn.Where("thread_id = ? AND user_id = ?", threadID, userID).Order("id DESC").First(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+	if err != nil {
+		logger.Error("获取会话消息失败: ThreadID=%s, 错误=%v", threadID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	var s *models.ThreadSummary
+	if c.Query("refresh") == "true" {
+		if err := linkLegacyMessages(userID, threadID); err != nil {
+			logger.Error("补全会话消息父子关系失败: ThreadID=%s, 错误=%v", threadID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+			return
+		}
+		s, err = summary.Refresh(userID, threadID, latest.ID, true)
+		if err != nil {
+			logger.Error("生成会话摘要失败: ThreadID=%s, 错误=%v", threadID, err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "生成摘要失败"})
+			return
+		}
+	} else if s, err = summary.Get(userID, threadID); err != nil {
+		logger.Error("获取会话摘要失败: ThreadID=%s, 错误=%v", threadID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+
+	// pendingTurns 为当前分支上尚未并入摘要的轮数
+	pending, err := summary.Pending(s, userID, threadID, latest.ID)
+	if err != nil {
+		logger.Error("统计未摘要对话失败: ThreadID=%s, 错误=%v", threadID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"threadId": threadID, "summary": s, "pendingTurns": pending})
+}
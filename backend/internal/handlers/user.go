@@ -5,9 +5,13 @@ import (
 	"strconv"
 	"time"
 
+	"ai-career-buddy/internal/company"
 	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/enrich"
 	"ai-career-buddy/internal/logger"
 	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/notify"
+	"ai-career-buddy/internal/rag"
 
 	"github.com/gin-gonic/gin"
 )
@@ -81,6 +85,10 @@ func GetCareerHistory(c *gin.Context) {
 		query = query.Where("category = ? OR category = ?", category, category+"-")
 	}
 
+	if tag := enrich.NormalizeTag(c.Query("tag")); tag != "" {
+		query = query.Where("id IN (?)", db.Conn.Model(&models.CareerHistoryTag{}).Select("history_id").Where("user_id = ? AND tag = ?", userID, tag))
+	}
+
 	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&histories).Error; err != nil {
 		logger.Error("获取职业历史记录失败: UserID=%s, 错误=%v", userID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
@@ -110,9 +118,34 @@ func SaveCareerHistory(c *gin.Context) {
 	}
 
 	logger.Info("职业历史记录保存成功: UserID=%s, Category=%s", history.UserID, history.Category)
+	if err := enrich.SyncTags(&history); err != nil {
+		logger.Warn("保存咨询记录标签失败: HistoryID=%d, 错误=%v", history.ID, err)
+	}
+	enrich.HistoryAsync(history.ID)
+	if ix := rag.Default(); ix != nil {
+		ix.IndexHistoryAsync(history)
+	}
 	c.JSON(http.StatusOK, history)
 }
 
+// GetUserTags 获取用户咨询记录的标签及使用次数，用于标签云和筛选
+func GetUserTags(c *gin.Context) {
+	userID := c.Param("userId")
+	category := c.Query("category")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	tags, err := enrich.TopTags(userID, category, limit)
+	if err != nil {
+		logger.Error("获取用户标签失败: UserID=%s, 错误=%v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
 // GetContractRisks 获取劳动合同风险点
 func GetContractRisks(c *gin.Context) {
 	userID := c.Param("userId")
@@ -153,6 +186,7 @@ func SaveContractRisk(c *gin.Context) {
 
 	risk.CreatedAt = time.Now()
 	risk.UpdatedAt = time.Now()
+	company.LinkRisk(&risk)
 
 	if err := db.Conn.Create(&risk).Error; err != nil {
 		logger.Error("保存合同风险点失败: UserID=%s, 错误=%v", risk.UserID, err)
@@ -178,6 +212,9 @@ func UpdateContractRisk(c *gin.Context) {
 
 	risk.ID = 0 // 防止ID被覆盖
 	risk.UpdatedAt = time.Now()
+	if risk.CompanyName != "" && risk.CompanyID == nil {
+		company.LinkRisk(&risk)
+	}
 
 	if err := db.Conn.Model(&models.ContractRisk{}).Where("id = ?", riskID).Updates(&risk).Error; err != nil {
 		logger.Error("更新合同风险点失败: RiskID=%s, 错误=%v", riskID, err)
@@ -220,9 +257,23 @@ func SaveCompanyMonitor(c *gin.Context) {
 		return
 	}
 
+	if invalid := validateAlertRules(monitor.GetAlertRules()); len(invalid) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "告警规则无效", "rules": invalid})
+		return
+	}
+	if monitor.AlertEmail != "" {
+		email, err := notify.NormalizeEmail(monitor.AlertEmail)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "告警" + err.Error()})
+			return
+		}
+		monitor.AlertEmail = email
+	}
+
 	monitor.CreatedAt = time.Now()
 	monitor.UpdatedAt = time.Now()
 	monitor.Status = "active" // 默认状态
+	company.LinkMonitor(&monitor)
 
 	if err := db.Conn.Create(&monitor).Error; err != nil {
 		logger.Error("保存企业监控失败: UserID=%s, 错误=%v", monitor.UserID, err)
@@ -245,8 +296,24 @@ func UpdateCompanyMonitor(c *gin.Context) {
 		return
 	}
 
+	if invalid := validateAlertRules(monitor.GetAlertRules()); len(invalid) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "告警规则无效", "rules": invalid})
+		return
+	}
+	if monitor.AlertEmail != "" {
+		email, err := notify.NormalizeEmail(monitor.AlertEmail)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "告警" + err.Error()})
+			return
+		}
+		monitor.AlertEmail = email
+	}
+
 	monitor.ID = 0 // 防止ID被覆盖
 	monitor.UpdatedAt = time.Now()
+	if monitor.CompanyName != "" && monitor.CompanyID == nil {
+		company.LinkMonitor(&monitor)
+	}
 
 	if err := db.Conn.Model(&models.CompanyMonitor{}).Where("id = ?", monitorID).Updates(&monitor).Error; err != nil {
 		logger.Error("更新企业监控失败: MonitorID=%s, 错误=%v", monitorID, err)
@@ -306,55 +373,6 @@ func UpdatePersonalMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
-// GetCareerStages 获取职业阶段定义
-func GetCareerStages(c *gin.Context) {
-	stages := []models.CareerStage{
-		{
-			Stage:       "职场新人",
-			Description: "刚进入职场，学习基础技能",
-			Skills:      "基础技能、沟通能力、学习能力",
-			Goals:       "快速适应工作环境，掌握基本技能",
-			Duration:    "1-2年",
-			Progress:    0,
-		},
-		{
-			Stage:       "技能提升",
-			Description: "专业技能快速提升期",
-			Skills:      "专业技能、项目管理、团队协作",
-			Goals:       "成为团队核心成员，承担重要项目",
-			Duration:    "2-3年",
-			Progress:    0,
-		},
-		{
-			Stage:       "专业专家",
-			Description: "在专业领域有一定影响力",
-			Skills:      "深度专业技能、领导力、行业洞察",
-			Goals:       "成为行业专家，指导他人",
-			Duration:    "3-5年",
-			Progress:    0,
-		},
-		{
-			Stage:       "管理转型",
-			Description: "从专业向管理转型",
-			Skills:      "管理技能、战略思维、人员管理",
-			Goals:       "带领团队，制定战略",
-			Duration:    "5-8年",
-			Progress:    0,
-		},
-		{
-			Stage:       "高级管理",
-			Description: "高级管理层",
-			Skills:      "高级管理、战略规划、商业洞察",
-			Goals:       "制定公司战略，影响行业发展",
-			Duration:    "8年以上",
-			Progress:    0,
-		},
-	}
-
-	logger.Info("获取职业阶段定义")
-	c.JSON(http.StatusOK, gin.H{"stages": stages})
-}
-
 // UpdateUserDefaultModel 更新用户默认模型
 func UpdateUserDefaultModel(c *gin.Context) {
 	userID := c.Param("userId")
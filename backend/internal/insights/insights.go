@@ -0,0 +1,468 @@
+// Package insights 基于用户上传的Offer、合同和风险点，生成匿名化的企业聚合数据
+//
+// 所有统计分组都满足 k-匿名：只有包含至少 k（不低于 MinK）名不同用户的数据才会输出。
+// 分位数按粗粒度区间取整（月薪2千元、年薪2万元），不输出最小值和最大值；
+// 职位筛选按归一化后的职位精确匹配，避免用重叠的分组相减反推出单个用户的数据。
+package insights
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// Distribution 分位数分布
+type Distribution struct {
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+}
+
+// SalaryBand 某个职位（或全部职位）的薪资带
+type SalaryBand struct {
+	Position string       `json:"position"`
+	Users    int          `json:"users"`
+	Monthly  Distribution `json:"monthly"`
+	Annual   Distribution `json:"annual"`
+	Months   float64      `json:"months"` // 发放月数中位数
+}
+
+// SalaryInsight 薪资聚合
+type SalaryInsight struct {
+	Users               int          `json:"users"`
+	Overall             *SalaryBand  `json:"overall,omitempty"`
+	Positions           []SalaryBand `json:"positions"`
+	SuppressedPositions int          `json:"suppressedPositions"` // 因人数不足未展示的职位数
+}
+
+// CountItem 计数项
+type CountItem struct {
+	Name  string  `json:"name"`
+	Users int     `json:"users"`
+	Share float64 `json:"share"`
+}
+
+// NonCompeteInsight 竞业限制条款聚合
+type NonCompeteInsight struct {
+	Users             int           `json:"users"`             // 提供了合同的用户数
+	WithNonCompete    float64       `json:"withNonCompete"`    // 含竞业限制条款的比例
+	Durations         []CountItem   `json:"durations"`         // 竞业期限分布
+	CompensationRatio *Distribution `json:"compensationRatio"` // 竞业补偿占原工资比例
+	CommonTerms       []CountItem   `json:"commonTerms"`       // 常见条款
+}
+
+// RiskTypeItem 风险类型统计
+type RiskTypeItem struct {
+	RiskType  string  `json:"riskType"`
+	Users     int     `json:"users"`
+	Share     float64 `json:"share"`     // 占提交过该企业风险点的用户比例
+	HighShare float64 `json:"highShare"` // 高风险及以上占比
+}
+
+// RiskInsight 合同风险点聚合
+type RiskInsight struct {
+	Users           int            `json:"users"`
+	Types           []RiskTypeItem `json:"types"`
+	SuppressedTypes int            `json:"suppressedTypes"`
+}
+
+// CompanyInsights 企业聚合洞察
+type CompanyInsights struct {
+	CompanyID   uint               `json:"companyId"`
+	CompanyName string             `json:"companyName"`
+	Position    string             `json:"position,omitempty"` // 职位筛选条件
+	K           int                `json:"k"`
+	Salary      *SalaryInsight     `json:"salary,omitempty"`
+	NonCompete  *NonCompeteInsight `json:"nonCompete,omitempty"`
+	Risks       *RiskInsight       `json:"risks,omitempty"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+}
+
+// HasData 是否有任何满足匿名阈值的数据
+func (ci *CompanyInsights) HasData() bool {
+	return ci.Salary != nil || ci.NonCompete != nil || ci.Risks != nil
+}
+
+// MinK 匿名分组的最少用户数，配置更小的值时按此处理
+const MinK = 10
+
+// 薪资分位数的取整步长：人数刚过阈值时线性插值的分位数正好落在某个用户的薪资上，按粗粒度取整后不再精确
+const (
+	monthlyStep = 2000
+	annualStep  = 20000
+)
+
+type salarySample struct {
+	userID   string
+	position string
+	salary   *utils.SalaryRange
+}
+
+type nonCompeteSample struct {
+	userID string
+	terms  *NonCompeteTerms
+}
+
+// Compute 计算企业的聚合洞察，position 不为空时只统计归一化后与之相同的职位的薪资
+func Compute(company *models.Company, position string, k int) (*CompanyInsights, error) {
+	return ComputeExcluding(company, position, k, "")
+}
+
+// ComputeExcluding 同 Compute，但不统计 excludeUserID 自己的数据，用于和用户本人的Offer比较
+func ComputeExcluding(company *models.Company, position string, k int, excludeUserID string) (*CompanyInsights, error) {
+	if k < MinK {
+		k = MinK
+	}
+	result := &CompanyInsights{
+		CompanyID:   company.ID,
+		CompanyName: company.Name,
+		Position:    position,
+		K:           k,
+		GeneratedAt: time.Now(),
+	}
+
+	var docs []models.UserDocument
+	query := db.Conn.Select("id, user_id, document_type, extracted_info, updated_at").
+		Where("company_id = ? AND document_type IN ? AND extracted_info <> ''", company.ID, []string{"offer", "contract"})
+	if excludeUserID != "" {
+		query = query.Where("user_id <> ?", excludeUserID)
+	}
+	if err := query.Order("updated_at DESC").
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+
+	filter := normalizePosition(position)
+	var salaries []salarySample
+	var nonCompetes []nonCompeteSample
+	seenSalary := map[string]bool{}
+	seenContract := map[string]bool{}
+
+	for i := range docs {
+		info, err := docs[i].GetExtractedInfo()
+		if err != nil {
+			continue
+		}
+
+		var pos, salary string
+		switch docs[i].DocumentType {
+		case "offer":
+			pos, salary = info.OfferInfo.Position, info.OfferInfo.Salary
+		case "contract":
+			pos, salary = info.ContractInfo.Position, info.ContractInfo.Salary
+			// 每个用户只取最新的一份合同
+			if !seenContract[docs[i].UserID] {
+				seenContract[docs[i].UserID] = true
+				nonCompetes = append(nonCompetes, nonCompeteSample{
+					userID: docs[i].UserID,
+					terms:  ParseNonCompete(info.ContractInfo.NonCompete),
+				})
+			}
+		}
+
+		normalized := normalizePosition(pos)
+		if filter != "" && normalized != filter {
+			continue
+		}
+		// 同一用户同一职位只取最新的一份
+		key := docs[i].UserID + "|" + normalized
+		if seenSalary[key] {
+			continue
+		}
+		if parsed := utils.ParseSalary(salary); parsed != nil {
+			seenSalary[key] = true
+			salaries = append(salaries, salarySample{userID: docs[i].UserID, position: strings.TrimSpace(pos), salary: parsed})
+		}
+	}
+
+	result.Salary = aggregateSalaries(salaries, k)
+	result.NonCompete = aggregateNonCompete(nonCompetes, k)
+
+	risks, err := aggregateRisks(company.ID, k, excludeUserID)
+	if err != nil {
+		return nil, err
+	}
+	result.Risks = risks
+	return result, nil
+}
+
+func aggregateSalaries(samples []salarySample, k int) *SalaryInsight {
+	// 全部职位：每个用户只计一次（样本已按时间倒序，取最新）
+	var overall []salarySample
+	seen := map[string]bool{}
+	for _, s := range samples {
+		if !seen[s.userID] {
+			seen[s.userID] = true
+			overall = append(overall, s)
+		}
+	}
+	if len(overall) < k {
+		return nil
+	}
+
+	insight := &SalaryInsight{Users: len(overall), Positions: []SalaryBand{}}
+	band := salaryBand("", overall)
+	insight.Overall = &band
+
+	groups := map[string][]salarySample{}
+	for _, s := range samples {
+		key := normalizePosition(s.position)
+		if key == "" {
+			continue
+		}
+		groups[key] = append(groups[key], s)
+	}
+	for _, group := range groups {
+		if distinctUsers(group) < k {
+			insight.SuppressedPositions++
+			continue
+		}
+		insight.Positions = append(insight.Positions, salaryBand(mostCommonName(group), group))
+	}
+	sort.Slice(insight.Positions, func(i, j int) bool {
+		return insight.Positions[i].Users > insight.Positions[j].Users
+	})
+	return insight
+}
+
+func salaryBand(position string, samples []salarySample) SalaryBand {
+	monthly := make([]float64, len(samples))
+	annual := make([]float64, len(samples))
+	months := make([]float64, len(samples))
+	for i, s := range samples {
+		monthly[i] = s.salary.MonthlyMid()
+		annual[i] = s.salary.AnnualMid()
+		months[i] = float64(s.salary.Months)
+	}
+	return SalaryBand{
+		Position: position,
+		Users:    distinctUsers(samples),
+		Monthly:  distribution(monthly, monthlyStep),
+		Annual:   distribution(annual, annualStep),
+		Months:   percentile(months, 0.5),
+	}
+}
+
+func aggregateNonCompete(samples []nonCompeteSample, k int) *NonCompeteInsight {
+	if len(samples) < k {
+		return nil
+	}
+
+	insight := &NonCompeteInsight{Users: len(samples), Durations: []CountItem{}, CommonTerms: []CountItem{}}
+	withClause := 0
+	durations := map[string]int{}
+	terms := map[string]int{}
+	var ratios []float64
+	for _, s := range samples {
+		if !s.terms.Present {
+			continue
+		}
+		withClause++
+		if bucket := s.terms.DurationBucket(); bucket != "" {
+			durations[bucket]++
+		}
+		if s.terms.CompensationRatio > 0 {
+			ratios = append(ratios, s.terms.CompensationRatio)
+		}
+		for _, term := range s.terms.Terms {
+			terms[term]++
+		}
+	}
+	insight.WithNonCompete = round(float64(withClause)/float64(len(samples)), 0.01)
+
+	insight.Durations = countItems(durations, len(samples), k)
+	insight.CommonTerms = countItems(terms, len(samples), k)
+	if len(ratios) >= k {
+		d := distribution(ratios, 0.05)
+		insight.CompensationRatio = &d
+	}
+	return insight
+}
+
+func aggregateRisks(companyID uint, k int, excludeUserID string) (*RiskInsight, error) {
+	scope := db.Conn.Model(&models.ContractRisk{}).Where("company_id = ?", companyID)
+	if excludeUserID != "" {
+		scope = scope.Where("user_id <> ?", excludeUserID)
+	}
+
+	var total int64
+	if err := scope.Session(&gorm.Session{}).Distinct("user_id").Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if int(total) < k {
+		return nil, nil
+	}
+
+	var rows []struct {
+		RiskType string
+		Users    int
+		High     int
+		Total    int
+	}
+	if err := scope.Session(&gorm.Session{}).
+		Select("risk_type, COUNT(DISTINCT user_id) AS users, " +
+			"SUM(CASE WHEN risk_level IN ('high', 'critical') THEN 1 ELSE 0 END) AS high, COUNT(*) AS total").
+		Where("risk_type <> ''").
+		Group("risk_type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	insight := &RiskInsight{Users: int(total), Types: []RiskTypeItem{}}
+	for _, row := range rows {
+		if row.Users < k {
+			insight.SuppressedTypes++
+			continue
+		}
+		insight.Types = append(insight.Types, RiskTypeItem{
+			RiskType:  row.RiskType,
+			Users:     row.Users,
+			Share:     round(float64(row.Users)/float64(total), 0.01),
+			HighShare: round(float64(row.High)/float64(row.Total), 0.01),
+		})
+	}
+	sort.Slice(insight.Types, func(i, j int) bool {
+		return insight.Types[i].Users > insight.Types[j].Users
+	})
+	return insight, nil
+}
+
+// FormatForPrompt 将洞察整理为可注入对话提示词的文本
+func FormatForPrompt(ci *CompanyInsights) string {
+	if !ci.HasData() {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("【平台数据：%s】以下为平台用户匿名汇总数据（每项至少%d名用户），可作为参考：\n", ci.CompanyName, ci.K))
+	if s := ci.Salary; s != nil && s.Overall != nil {
+		sb.WriteString(fmt.Sprintf("- 薪资（%d人）：月薪中位数%.0f元，四分位区间%.0f-%.0f元，年薪中位数%.0f元，常见%.0f薪\n",
+			s.Users, s.Overall.Monthly.P50, s.Overall.Monthly.P25, s.Overall.Monthly.P75, s.Overall.Annual.P50, s.Overall.Months))
+		for _, p := range s.Positions {
+			sb.WriteString(fmt.Sprintf("  - %s（%d人）：月薪中位数%.0f元，区间%.0f-%.0f元\n",
+				p.Position, p.Users, p.Monthly.P50, p.Monthly.P25, p.Monthly.P75))
+		}
+	}
+	if n := ci.NonCompete; n != nil {
+		sb.WriteString(fmt.Sprintf("- 竞业限制（%d份合同）：%.0f%%包含竞业条款", n.Users, n.WithNonCompete*100))
+		if len(n.Durations) > 0 {
+			sb.WriteString(fmt.Sprintf("，最常见期限为%s", n.Durations[0].Name))
+		}
+		if n.CompensationRatio != nil {
+			sb.WriteString(fmt.Sprintf("，补偿比例中位数%.0f%%", n.CompensationRatio.P50*100))
+		}
+		sb.WriteString("\n")
+	}
+	if r := ci.Risks; r != nil && len(r.Types) > 0 {
+		names := make([]string, 0, 3)
+		for i, t := range r.Types {
+			if i >= 3 {
+				break
+			}
+			names = append(names, fmt.Sprintf("%s(%.0f%%)", t.RiskType, t.Share*100))
+		}
+		sb.WriteString(fmt.Sprintf("- 常见合同风险（%d人）：%s\n", r.Users, strings.Join(names, "、")))
+	}
+	return sb.String()
+}
+
+// countItems 统计项按人数排序，剔除人数不足 k 的项
+func countItems(counts map[string]int, total, k int) []CountItem {
+	items := []CountItem{}
+	for name, users := range counts {
+		if users < k {
+			continue
+		}
+		items = append(items, CountItem{Name: name, Users: users, Share: round(float64(users)/float64(total), 0.01)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Users != items[j].Users {
+			return items[i].Users > items[j].Users
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+func distinctUsers(samples []salarySample) int {
+	users := map[string]bool{}
+	for _, s := range samples {
+		users[s.userID] = true
+	}
+	return len(users)
+}
+
+func mostCommonName(samples []salarySample) string {
+	counts := map[string]int{}
+	best := ""
+	for _, s := range samples {
+		counts[s.position]++
+		if counts[s.position] > counts[best] || (counts[s.position] == counts[best] && s.position < best) {
+			best = s.position
+		}
+	}
+	return best
+}
+
+// normalizePosition 职位归一化：小写、去掉括号内容和空白
+func normalizePosition(position string) string {
+	var sb strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(position) {
+		switch r {
+		case '(', '（':
+			depth++
+			continue
+		case ')', '）':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		case ' ', '\t', '-', '_', '/':
+			continue
+		}
+		if depth == 0 {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func distribution(values []float64, step float64) Distribution {
+	return Distribution{
+		P25: round(percentile(values, 0.25), step),
+		P50: round(percentile(values, 0.5), step),
+		P75: round(percentile(values, 0.75), step),
+	}
+}
+
+// percentile 线性插值计算分位数
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+// round 按步长取整，步长小于1时用除法避免 0.30000000000000004 之类的浮点误差
+func round(v, step float64) float64 {
+	if step < 1 {
+		return math.Round(v/step) / math.Round(1/step)
+	}
+	return math.Round(v/step) * step
+}
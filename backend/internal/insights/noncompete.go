@@ -0,0 +1,117 @@
+package insights
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NonCompeteTerms 从合同竞业限制描述中解析出的条款
+type NonCompeteTerms struct {
+	Present           bool     `json:"present"`
+	Months            int      `json:"months"`            // 竞业期限（月），未写明为0
+	CompensationRatio float64  `json:"compensationRatio"` // 补偿占原工资比例，未写明为0
+	Terms             []string `json:"terms"`
+}
+
+// 法定竞业限制期限上限（月）
+const legalNonCompeteMonths = 24
+
+var (
+	nonCompeteAbsent  = regexp.MustCompile(`^(无|没有|未约定|未提及|不适用|none|n/?a|-)?$|无竞业|不含竞业|未约定竞业|没有竞业`)
+	nonCompeteMonths  = regexp.MustCompile(`([0-9]+|[一二两三四五六七八九十]+)\s*个?\s*(月|年)`)
+	nonCompeteHalf    = regexp.MustCompile(`半年`)
+	nonCompeteRatio   = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)\s*[%％]`)
+	nonCompeteKeyword = []struct {
+		name     string
+		keywords []string
+	}{
+		{"同行业限制", []string{"同行业", "同业", "相同行业"}},
+		{"竞争对手清单", []string{"竞争对手", "竞品", "竞争企业", "竞争公司"}},
+		{"全国范围", []string{"全国", "中国境内"}},
+		{"全球范围", []string{"全球", "世界范围"}},
+		{"违约金", []string{"违约金"}},
+		{"经济补偿", []string{"补偿", "补偿金"}},
+	}
+)
+
+// ParseNonCompete 解析竞业限制描述，如"离职后2年内不得到竞争对手任职，按月支付30%补偿"
+func ParseNonCompete(text string) *NonCompeteTerms {
+	text = strings.TrimSpace(text)
+	terms := &NonCompeteTerms{Terms: []string{}}
+	if nonCompeteAbsent.MatchString(strings.ToLower(text)) {
+		return terms
+	}
+	terms.Present = true
+
+	if nonCompeteHalf.MatchString(text) {
+		terms.Months = 6
+	}
+	if m := nonCompeteMonths.FindStringSubmatch(text); m != nil {
+		if n := parseCount(m[1]); n > 0 {
+			if m[2] == "年" {
+				n *= 12
+			}
+			terms.Months = n
+		}
+	}
+	if m := nonCompeteRatio.FindStringSubmatch(text); m != nil {
+		if v, err := strconv.ParseFloat(m[1], 64); err == nil && v > 0 && v <= 100 {
+			terms.CompensationRatio = v / 100
+		}
+	}
+
+	for _, kw := range nonCompeteKeyword {
+		for _, k := range kw.keywords {
+			if strings.Contains(text, k) {
+				terms.Terms = append(terms.Terms, kw.name)
+				break
+			}
+		}
+	}
+	if terms.Months > legalNonCompeteMonths {
+		terms.Terms = append(terms.Terms, "期限超过法定2年")
+	}
+	return terms
+}
+
+// DurationBucket 竞业期限分组
+func (t *NonCompeteTerms) DurationBucket() string {
+	switch {
+	case t.Months <= 0:
+		return ""
+	case t.Months <= 6:
+		return "6个月及以内"
+	case t.Months <= 12:
+		return "6-12个月"
+	case t.Months <= legalNonCompeteMonths:
+		return "12-24个月"
+	}
+	return "超过24个月"
+}
+
+var chineseDigits = map[rune]int{'一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// parseCount 解析阿拉伯数字或一百以内的中文数字
+func parseCount(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	total, current := 0, 0
+	for _, r := range s {
+		if r == '十' {
+			if current == 0 {
+				current = 1
+			}
+			total += current * 10
+			current = 0
+			continue
+		}
+		d, ok := chineseDigits[r]
+		if !ok {
+			return 0
+		}
+		current = d
+	}
+	return total + current
+}
@@ -0,0 +1,140 @@
+package intent
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// bayes 以分类体系中的样本训练的多项式朴素贝叶斯分类器，特征为汉字二元组和英文单词。
+// 标签为“类别/子意图”，类别的概率为其下各标签概率之和
+type bayes struct {
+	labels []bayesLabel
+	vocab  map[string]bool
+}
+
+type bayesLabel struct {
+	category  string
+	subIntent string
+	prior     float64 // 对数先验，各类别先验相同
+	counts    map[string]int
+	total     int
+}
+
+func newBayes() *bayes {
+	b := &bayes{vocab: map[string]bool{}}
+	for _, c := range taxonomy {
+		n := float64(len(c.SubIntents) + 1)
+		prior := math.Log(1/float64(len(taxonomy))) + math.Log(1/n)
+		b.add(c.Code, "", prior, c.examples)
+		for _, s := range c.SubIntents {
+			// 子意图名称本身是最直接的特征，加倍计入
+			b.add(c.Code, s.Code, prior, append([]string{s.Name, s.Name}, s.examples...))
+		}
+	}
+	return b
+}
+
+func (b *bayes) add(category, subIntent string, prior float64, examples []string) {
+	l := bayesLabel{category: category, subIntent: subIntent, prior: prior, counts: map[string]int{}}
+	for _, e := range examples {
+		for _, t := range tokenize(e) {
+			l.counts[t]++
+			l.total++
+			b.vocab[t] = true
+		}
+	}
+	b.labels = append(b.labels, l)
+}
+
+// classify 返回最可能的类别、类别内占比过半的子意图和类别的后验概率；
+// 输入中没有任何已知特征时返回 general
+func (b *bayes) classify(input string) Result {
+	var tokens []string
+	for _, t := range tokenize(input) {
+		if b.vocab[t] {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return Result{Category: CategoryGeneral, Confidence: 1 / float64(len(taxonomy)), Source: SourceBayes}
+	}
+
+	vocabSize := float64(len(b.vocab))
+	scores := make([]float64, len(b.labels))
+	best := math.Inf(-1)
+	for i, l := range b.labels {
+		s := l.prior
+		for _, t := range tokens {
+			s += math.Log((float64(l.counts[t]) + 1) / (float64(l.total) + vocabSize))
+		}
+		scores[i] = s
+		best = math.Max(best, s)
+	}
+
+	// 归一化为后验概率并按类别汇总
+	var sum float64
+	for i := range scores {
+		scores[i] = math.Exp(scores[i] - best)
+		sum += scores[i]
+	}
+	byCategory := map[string]float64{}
+	for i, l := range b.labels {
+		scores[i] /= sum
+		byCategory[l.category] += scores[i]
+	}
+
+	r := Result{Source: SourceBayes}
+	for _, c := range taxonomy {
+		if byCategory[c.Code] > r.Confidence {
+			r.Category, r.Confidence = c.Code, byCategory[c.Code]
+		}
+	}
+	var top float64
+	for i, l := range b.labels {
+		if l.category == r.Category && l.subIntent != "" && scores[i] > top {
+			top = scores[i]
+			if scores[i]/r.Confidence >= 0.5 {
+				r.SubIntent = l.subIntent
+			}
+		}
+	}
+	return r
+}
+
+// tokenize 切分特征：连续汉字取二元组（单字保留单字），字母数字串取小写单词
+func tokenize(s string) []string {
+	var tokens []string
+	var han []rune
+	var word []rune
+	flush := func() {
+		if len(han) == 1 {
+			tokens = append(tokens, string(han))
+		}
+		for i := 0; i+1 < len(han); i++ {
+			tokens = append(tokens, string(han[i:i+2]))
+		}
+		if len(word) > 0 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		han, word = han[:0], word[:0]
+	}
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			if len(word) > 0 {
+				flush()
+			}
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(han) > 0 {
+				flush()
+			}
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
@@ -0,0 +1,106 @@
+// Package intent 识别用户问题的意图，返回类别、子意图和置信度
+//
+// 默认先用低成本模型（INTENT_MODEL）分类，调用失败、超时或结果无效时退回到以分类体系样本
+// 训练的朴素贝叶斯分类器。置信度低于 INTENT_MIN_CONFIDENCE 时以会话入口（threadID 前缀）
+// 对应的类别为准。识别结果决定提示词变体、案例专项指导、标签和咨询记录的分类。
+package intent
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/logger"
+)
+
+// 识别来源
+const (
+	SourceLLM    = "llm"
+	SourceBayes  = "bayes"
+	SourceThread = "thread"
+)
+
+// Result 意图识别结果
+type Result struct {
+	Category   string  `json:"category"`
+	SubIntent  string  `json:"subIntent,omitempty"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// SubIntentName 返回子意图的中文名称，如“薪资谈判”
+func (r Result) SubIntentName() string {
+	if c, ok := lookup(r.Category); ok {
+		if s, ok := c.subIntent(r.SubIntent); ok {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// Classifier 意图分类器
+type Classifier struct {
+	llm           *llmClassifier // 为 nil 时只使用朴素贝叶斯
+	bayes         *bayes
+	minConfidence float64
+}
+
+var (
+	defaultOnce       sync.Once
+	defaultClassifier *Classifier
+)
+
+// Default 按配置创建全局分类器
+func Default() *Classifier {
+	defaultOnce.Do(func() {
+		defaultClassifier = &Classifier{bayes: newBayes(), minConfidence: config.C.IntentMinConfidence}
+		if config.C.IntentClassifier == SourceLLM {
+			defaultClassifier.llm = &llmClassifier{modelID: config.C.IntentModel, timeout: config.C.IntentTimeout}
+		}
+		logger.Info("意图识别已启用: Classifier=%s, Model=%s", config.C.IntentClassifier, config.C.IntentModel)
+	})
+	return defaultClassifier
+}
+
+// Classify 使用全局分类器识别意图
+func Classify(input, threadID string) Result {
+	return Default().Classify(input, threadID)
+}
+
+// Classify 识别用户问题的意图，threadID 前缀（如 offer-xxx）作为参考
+func (c *Classifier) Classify(input, threadID string) Result {
+	start := time.Now()
+	hint := threadHint(threadID)
+
+	var r Result
+	var err error
+	if c.llm != nil {
+		r, err = c.llm.classify(input, hint)
+		if err != nil {
+			logger.Warn("模型意图识别失败，使用朴素贝叶斯: %v", err)
+		}
+	}
+	if c.llm == nil || err != nil {
+		r = c.bayes.classify(input)
+	}
+
+	if r.Confidence < c.minConfidence && hint != "" && hint != r.Category {
+		r = Result{Category: hint, Confidence: r.Confidence, Source: SourceThread}
+	}
+	logger.Info("意图识别: Category=%s, SubIntent=%s, Confidence=%.2f, Source=%s, 耗时=%v",
+		r.Category, r.SubIntent, r.Confidence, r.Source, time.Since(start))
+	return r
+}
+
+// threadHint 会话入口对应的类别，如 offer-1700000000 -> offer
+func threadHint(threadID string) string {
+	prefix, _, ok := strings.Cut(threadID, "-")
+	if !ok {
+		return ""
+	}
+	if _, ok := lookup(prefix); ok {
+		return prefix
+	}
+	return ""
+}
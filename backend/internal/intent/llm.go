@@ -0,0 +1,86 @@
+package intent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/utils"
+)
+
+// 送入分类模型的问题最大长度
+const maxInputRunes = 500
+
+var errTimeout = errors.New("意图识别超时")
+
+// llmClassifier 调用低成本模型进行分类
+type llmClassifier struct {
+	modelID string
+	timeout time.Duration
+}
+
+type llmOutput struct {
+	Category   string  `json:"category"`
+	SubIntent  string  `json:"subIntent"`
+	Confidence float64 `json:"confidence"`
+}
+
+func (l *llmClassifier) classify(input, hint string) (Result, error) {
+	type outcome struct {
+		r   Result
+		err error
+	}
+	ch := make(chan outcome, 1)
+	go func() {
+		r, err := l.call(input, hint)
+		ch <- outcome{r, err}
+	}()
+
+	select {
+	case o := <-ch:
+		return o.r, o.err
+	case <-time.After(l.timeout):
+		return Result{}, errTimeout
+	}
+}
+
+func (l *llmClassifier) call(input, hint string) (Result, error) {
+	if r := []rune(input); len(r) > maxInputRunes {
+		input = string(r[:maxInputRunes])
+	}
+	vars := prompts.IntentVars{Input: input, Hint: hint, Taxonomy: describe()}
+	prompt, _, err := prompts.Render(prompts.KeyIntentClassify, prompts.Selector{ModelID: l.modelID}, vars)
+	if err != nil {
+		return Result{}, err
+	}
+	response, err := api.ProviderFor(l.modelID).SendMessage(l.modelID, prompt, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(response.Choices) == 0 {
+		return Result{}, fmt.Errorf("模型未返回结果")
+	}
+	return parseOutput(response.Choices[0].Message.Content)
+}
+
+// parseOutput 解析模型输出的JSON，类别不在分类体系中时视为无效，子意图不属于该类别时忽略
+func parseOutput(content string) (Result, error) {
+	var out llmOutput
+	if err := json.Unmarshal([]byte(utils.CleanJSONContent(content)), &out); err != nil {
+		return Result{}, fmt.Errorf("无法解析模型输出: %v", err)
+	}
+
+	c, ok := lookup(strings.ToLower(strings.TrimSpace(out.Category)))
+	if !ok {
+		return Result{}, fmt.Errorf("未知类别: %s", out.Category)
+	}
+	r := Result{Category: c.Code, Confidence: min(max(out.Confidence, 0), 1), Source: SourceLLM}
+	if s, ok := c.subIntent(strings.TrimSpace(out.SubIntent)); ok {
+		r.SubIntent = s.Code
+	}
+	return r, nil
+}
@@ -0,0 +1,106 @@
+package intent
+
+import "strings"
+
+// 类别
+const (
+	CategoryCareer   = "career"
+	CategoryOffer    = "offer"
+	CategoryContract = "contract"
+	CategoryMonitor  = "monitor"
+	CategoryGeneral  = "general"
+)
+
+// SubIntent 类别下的子意图
+type SubIntent struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	examples []string
+}
+
+// Category 意图类别，examples 为朴素贝叶斯的训练样本
+type Category struct {
+	Code       string      `json:"code"`
+	Name       string      `json:"name"`
+	SubIntents []SubIntent `json:"subIntents"`
+	examples   []string
+}
+
+var taxonomy = []Category{
+	{Code: CategoryCareer, Name: "职业规划",
+		examples: []string{"职业规划", "职业发展", "未来几年怎么发展", "要不要换工作", "适合做什么工作", "工作没有方向很迷茫", "晋升", "升职加薪"},
+		SubIntents: []SubIntent{
+			{Code: "transition", Name: "职业转型", examples: []string{"职业转型", "转行", "想转到产品经理", "开发转管理", "跨行业求职", "三十岁转行来得及吗"}},
+			{Code: "skills", Name: "技能提升", examples: []string{"技能提升", "学习路线", "需要学什么技能", "考什么证书", "提升能力", "如何学习编程"}},
+			{Code: "industry", Name: "行业分析", examples: []string{"行业分析", "行业前景", "哪个行业发展好", "互联网行业趋势", "新能源行业怎么样"}},
+			{Code: "personal_brand", Name: "个人品牌", examples: []string{"个人品牌", "打造影响力", "写技术博客", "经营社交媒体", "在领英上展示自己"}},
+		}},
+	{Code: CategoryOffer, Name: "Offer分析",
+		examples: []string{"offer", "收到录用通知", "要不要接这个offer", "入职", "面试通过", "年包", "期权"},
+		SubIntents: []SubIntent{
+			{Code: "salary_negotiation", Name: "薪资谈判", examples: []string{"薪资谈判", "怎么谈薪资", "HR压价", "期望薪资怎么开口", "能不能再要高一点", "涨薪幅度"}},
+			{Code: "offer_compare", Name: "offer对比", examples: []string{"offer对比", "两个offer怎么选", "大厂和创业公司选哪个", "多个offer选择", "选哪家"}},
+			{Code: "benefits", Name: "福利分析", examples: []string{"福利分析", "五险一金", "公积金比例", "年终奖", "补充医疗", "带薪年假"}},
+			{Code: "market_rate", Name: "市场行情", examples: []string{"市场行情", "这个岗位一般多少钱", "薪资水平", "同行薪资", "工资是不是偏低"}},
+		}},
+	{Code: CategoryContract, Name: "合同审查",
+		examples: []string{"劳动合同", "合同", "签合同", "试用期", "解除劳动关系", "劳动仲裁", "违约金"},
+		SubIntents: []SubIntent{
+			{Code: "clauses", Name: "合同条款", examples: []string{"合同条款", "这条条款什么意思", "工作地点条款", "保密条款", "加班条款"}},
+			{Code: "risks", Name: "风险点", examples: []string{"合同风险点", "有没有坑", "霸王条款", "竞业限制", "违约金过高", "不合理的条款"}},
+			{Code: "rights", Name: "权益保护", examples: []string{"权益保护", "被辞退怎么办", "经济补偿", "拖欠工资", "维权", "申请劳动仲裁"}},
+			{Code: "amendment", Name: "合同修改", examples: []string{"合同修改", "要求修改条款", "补充协议", "变更劳动合同", "怎么和HR沟通改合同"}},
+		}},
+	{Code: CategoryMonitor, Name: "企业监控",
+		examples: []string{"企业监控", "公司经营状况", "公司靠谱吗", "企业风险", "工商信息", "裁员传闻"},
+		SubIntents: []SubIntent{
+			{Code: "financials", Name: "财务状况", examples: []string{"财务状况", "财报", "营收下滑", "现金流", "亏损", "融资情况"}},
+			{Code: "industry_position", Name: "行业地位", examples: []string{"行业地位", "市场份额", "竞争对手", "行业排名", "龙头企业"}},
+			{Code: "leadership_change", Name: "管理层变动", examples: []string{"管理层变动", "高管离职", "CEO换人", "董事会变动", "创始人出走"}},
+			{Code: "risk_alert", Name: "风险预警", examples: []string{"风险预警", "被起诉", "诉讼", "经营异常", "被列入失信", "股价暴跌", "监管处罚"}},
+		}},
+	{Code: CategoryGeneral, Name: "通用",
+		examples: []string{"你好", "您好", "谢谢", "你是谁", "你能做什么", "怎么使用", "帮助", "在吗"}},
+}
+
+// Taxonomy 返回所有类别及其子意图
+func Taxonomy() []Category {
+	return taxonomy
+}
+
+// lookup 按代码查找类别
+func lookup(code string) (*Category, bool) {
+	for i := range taxonomy {
+		if taxonomy[i].Code == code {
+			return &taxonomy[i], true
+		}
+	}
+	return nil, false
+}
+
+// subIntent 按代码查找类别下的子意图
+func (c *Category) subIntent(code string) (*SubIntent, bool) {
+	for i := range c.SubIntents {
+		if c.SubIntents[i].Code == code {
+			return &c.SubIntents[i], true
+		}
+	}
+	return nil, false
+}
+
+// describe 把类别和子意图整理为提示词中的说明
+func describe() string {
+	var sb strings.Builder
+	for _, c := range taxonomy {
+		sb.WriteString("- " + c.Code + "（" + c.Name + "）")
+		if len(c.SubIntents) > 0 {
+			subs := make([]string, len(c.SubIntents))
+			for i, s := range c.SubIntents {
+				subs[i] = s.Code + "（" + s.Name + "）"
+			}
+			sb.WriteString("：" + strings.Join(subs, "、"))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -16,6 +16,9 @@ func CORSMiddleware() gin.HandlerFunc {
 		// 允许的请求头
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With")
 
+		// 允许前端读取的响应头
+		c.Header("Access-Control-Expose-Headers", "X-Search-Sources")
+
 		// 允许携带凭证
 		c.Header("Access-Control-Allow-Credentials", "true")
 
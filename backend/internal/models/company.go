@@ -0,0 +1,44 @@
+package models
+
+import "encoding/json"
+
+// Company 企业主数据，跨用户共享
+type Company struct {
+	BaseModel
+	Name         string         `json:"name" gorm:"size:200;index"`                    // 企业全称
+	ShortName    string         `json:"shortName" gorm:"size:100"`                     // 简称
+	EnglishName  string         `json:"englishName" gorm:"size:200"`                   // 英文名
+	Normalized   string         `json:"normalized" gorm:"size:200;index"`              // 归一化名称，用于匹配
+	CreditCode   string         `json:"creditCode" gorm:"size:18;index"`               // 统一社会信用代码
+	Tickers      string         `json:"tickers" gorm:"size:255"`                       // 股票代码(JSON数组)，如 ["600000.SH"]
+	Industry     string         `json:"industry" gorm:"size:100"`                      // 行业
+	IndustryCode string         `json:"industryCode" gorm:"size:20"`                   // 国民经济行业分类代码，如 I65
+	Website      string         `json:"website" gorm:"size:255"`                       // 官网
+	Aliases      []CompanyAlias `json:"aliases,omitempty" gorm:"foreignKey:CompanyID"` // 别名
+}
+
+// CompanyAlias 企业别名，包括简称、英文名、曾用名以及从文档和对话中识别到的写法
+type CompanyAlias struct {
+	BaseModel
+	CompanyID  uint   `json:"companyId" gorm:"index"`
+	Alias      string `json:"alias" gorm:"size:200"`
+	Normalized string `json:"normalized" gorm:"size:200;index"`
+	Source     string `json:"source" gorm:"size:20"` // manual, extraction, chat, monitor, risk
+}
+
+func (c *Company) GetTickers() []string {
+	var tickers []string
+	if c.Tickers != "" {
+		json.Unmarshal([]byte(c.Tickers), &tickers)
+	}
+	return tickers
+}
+
+func (c *Company) SetTickers(tickers []string) error {
+	data, err := json.Marshal(tickers)
+	if err != nil {
+		return err
+	}
+	c.Tickers = string(data)
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+// Draft 求职沟通文书草稿：还价邮件、接受/婉拒Offer、辞职信、求职信
+type Draft struct {
+	BaseModel
+	UserID        string `json:"userId" gorm:"size:64;index"`
+	Kind          string `json:"kind" gorm:"size:30;index"` // counter_offer, offer_acceptance, offer_rejection, resignation, cover_letter
+	Tone          string `json:"tone" gorm:"size:20"`       // formal, friendly, assertive
+	Length        string `json:"length" gorm:"size:20"`     // concise, standard, detailed
+	Title         string `json:"title" gorm:"size:200"`     // 草稿列表中显示的名称
+	Subject       string `json:"subject" gorm:"size:300"`   // 邮件主题
+	Recipient     string `json:"recipient" gorm:"size:255"` // 收件人邮箱
+	RecipientName string `json:"recipientName" gorm:"size:100"`
+	Body          string `json:"body" gorm:"type:text"`    // 正文(Markdown)
+	DocumentID    uint   `json:"documentId" gorm:"index"`  // 依据的Offer文档
+	Context       string `json:"context" gorm:"type:text"` // 生成时使用的信息(JSON)
+	Source        string `json:"source" gorm:"size:20"`    // 生成方式: ai, template
+	Edited        bool   `json:"edited"`                   // 用户是否修改过
+	ModelID       string `json:"modelId,omitempty" gorm:"size:100"`
+	PromptVersion string `json:"promptVersion,omitempty" gorm:"size:255"`
+}
@@ -0,0 +1,15 @@
+package models
+
+// EmbeddingChunk 用户资料的向量索引块，用于对话时检索相关内容
+type EmbeddingChunk struct {
+	BaseModel
+	UserID     string `json:"userId" gorm:"size:64;index:idx_embedding_user_embedder"`
+	SourceType string `json:"sourceType" gorm:"size:30;index:idx_embedding_source"` // document, career_history
+	SourceID   uint   `json:"sourceId" gorm:"index:idx_embedding_source"`
+	ChunkIndex int    `json:"chunkIndex"`
+	Title      string `json:"title" gorm:"size:255"`
+	Content    string `json:"content" gorm:"type:text"`
+	Embedder   string `json:"embedder" gorm:"size:100;index:idx_embedding_user_embedder"` // 生成向量的模型，切换模型后旧向量不参与检索
+	Dimensions int    `json:"dimensions"`
+	Vector     []byte `json:"-" gorm:"type:blob"` // float32 小端序
+}
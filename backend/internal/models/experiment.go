@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Experiment 回复生成的A/B实验，按用户或会话确定性分组
+type Experiment struct {
+	BaseModel
+	Key         string              `json:"key" gorm:"column:experiment_key;size:100;uniqueIndex"`
+	Name        string              `json:"name" gorm:"size:200"`
+	Description string              `json:"description" gorm:"type:text"`
+	Status      string              `json:"status" gorm:"size:20;index;default:'draft'"` // draft, running, stopped
+	BucketBy    string              `json:"bucketBy" gorm:"size:20;default:'user'"`      // user, thread
+	Category    string              `json:"category" gorm:"size:50"`                     // 只对该会话类别生效，空表示全部
+	Traffic     int                 `json:"traffic" gorm:"default:100"`                  // 进入实验的流量百分比
+	Variants    []ExperimentVariant `json:"variants" gorm:"type:text;serializer:json"`
+	StartedAt   *time.Time          `json:"startedAt"`
+	StoppedAt   *time.Time          `json:"stoppedAt"`
+}
+
+// ExperimentVariant 实验分组，未设置的参数沿用请求本身的值
+type ExperimentVariant struct {
+	Name         string          `json:"name"`
+	Weight       int             `json:"weight"`                 // 分流权重
+	ModelID      string          `json:"modelId,omitempty"`      // 覆盖使用的模型
+	Temperature  *float64        `json:"temperature,omitempty"`  // 覆盖采样温度
+	DeepThinking *bool           `json:"deepThinking,omitempty"` // 覆盖深度思考模式
+	Prompts      map[string]uint `json:"prompts,omitempty"`      // 固定使用的提示词模板版本（模板键 -> 版本ID）
+}
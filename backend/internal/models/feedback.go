@@ -0,0 +1,13 @@
+package models
+
+// MessageFeedback 用户对助手回复的反馈，每条回复保留最新一次反馈
+type MessageFeedback struct {
+	BaseModel
+	MessageID uint     `json:"messageId" gorm:"uniqueIndex"`
+	UserID    string   `json:"userId" gorm:"size:64;index"`
+	ThreadID  string   `json:"threadId" gorm:"size:64"`
+	Thumbs    string   `json:"thumbs" gorm:"size:10"`                    // up, down，空表示未点选
+	Rating    int      `json:"rating"`                                   // 评分 1-5，0表示未评分
+	Reasons   []string `json:"reasons" gorm:"type:text;serializer:json"` // 原因代码，见 handlers.feedbackReasons
+	Comment   string   `json:"comment" gorm:"type:text"`                 // 补充说明
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Goal 职业目标
+type Goal struct {
+	BaseModel
+	UserID        string     `json:"userId" gorm:"size:64;index"`
+	Title         string     `json:"title" gorm:"size:200"`
+	Description   string     `json:"description" gorm:"type:text"`
+	Category      string     `json:"category" gorm:"size:50"`                      // skill, position, salary, certificate, other
+	LinkedSkill   string     `json:"linkedSkill" gorm:"size:100"`                  // 关联技能
+	TargetDate    *time.Time `json:"targetDate"`                                   // 目标日期
+	Status        string     `json:"status" gorm:"size:20;default:'active';index"` // 状态: active, paused, completed, abandoned
+	Progress      int        `json:"progress"`                                     // 完成进度 0-100，按任务完成情况计算
+	EvidenceLinks string     `json:"evidenceLinks" gorm:"type:text"`               // 证明材料链接(JSON数组)
+	CompletedAt   *time.Time `json:"completedAt"`                                  // 完成时间
+	Tasks         []PlanTask `json:"tasks,omitempty" gorm:"foreignKey:GoalID"`
+}
+
+// PlanTask 目标拆解后的计划任务
+type PlanTask struct {
+	BaseModel
+	GoalID        uint       `json:"goalId" gorm:"index"`
+	UserID        string     `json:"userId" gorm:"size:64;index"`
+	Title         string     `json:"title" gorm:"size:200"`
+	Description   string     `json:"description" gorm:"type:text"`
+	WeekIndex     int        `json:"weekIndex"`                              // 第几周(从1开始)，0表示未排期
+	DueDate       *time.Time `json:"dueDate"`                                // 截止日期
+	LinkedSkill   string     `json:"linkedSkill" gorm:"size:100"`            // 关联技能
+	Status        string     `json:"status" gorm:"size:20;default:'todo'"`   // 状态: todo, doing, done, skipped
+	EvidenceLinks string     `json:"evidenceLinks" gorm:"type:text"`         // 证明材料链接(JSON数组)
+	Source        string     `json:"source" gorm:"size:20;default:'manual'"` // 来源: manual, ai
+	CompletedAt   *time.Time `json:"completedAt"`                            // 完成时间
+}
+
+// 目标状态
+const (
+	GoalStatusActive    = "active"
+	GoalStatusPaused    = "paused"
+	GoalStatusCompleted = "completed"
+	GoalStatusAbandoned = "abandoned"
+)
+
+// 任务状态
+const (
+	TaskStatusTodo    = "todo"
+	TaskStatusDoing   = "doing"
+	TaskStatusDone    = "done"
+	TaskStatusSkipped = "skipped"
+)
+
+func (g *Goal) GetEvidenceLinks() []string {
+	var links []string
+	if g.EvidenceLinks != "" {
+		json.Unmarshal([]byte(g.EvidenceLinks), &links)
+	}
+	return links
+}
+
+func (g *Goal) SetEvidenceLinks(links []string) error {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return err
+	}
+	g.EvidenceLinks = string(data)
+	return nil
+}
+
+func (t *PlanTask) GetEvidenceLinks() []string {
+	var links []string
+	if t.EvidenceLinks != "" {
+		json.Unmarshal([]byte(t.EvidenceLinks), &links)
+	}
+	return links
+}
+
+func (t *PlanTask) SetEvidenceLinks(links []string) error {
+	data, err := json.Marshal(links)
+	if err != nil {
+		return err
+	}
+	t.EvidenceLinks = string(data)
+	return nil
+}
@@ -19,11 +19,43 @@ type Message struct {
 	Content     string `json:"content" gorm:"type:text"`
 	ThreadID    string `json:"threadId" gorm:"size:64;index"`
 	Attachments string `json:"attachments,omitempty" gorm:"type:text"`
+	// 用户原始输入，仅在 Content 附加了附件文本时保存，重新生成回复时使用
+	Question string `json:"-" gorm:"type:text"`
+	// 对话树中的父消息，重新生成或编辑后同一父消息下会有多条候选消息
+	ParentID *uint `json:"parentId,omitempty" gorm:"index"`
+	// 联网检索模式下回复引用的资料，编号与回复中的 [1]、[2] 对应
+	Sources []MessageSource `json:"sources,omitempty" gorm:"type:text;serializer:json"`
+	// 生成回复所用的提示词模板版本，如 chat.system@v3
+	PromptVersion string `json:"promptVersion,omitempty" gorm:"size:255"`
+	// 以下字段仅助手消息使用，用于实验效果统计
+	ModelID          string `json:"modelId,omitempty" gorm:"size:100"`
+	Experiment       string `json:"experiment,omitempty" gorm:"size:100;index"` // 所在实验
+	Variant          string `json:"variant,omitempty" gorm:"size:100"`          // 实验分组
+	LatencyMs        int64  `json:"latencyMs,omitempty"`                        // 生成耗时（毫秒）
+	PromptTokens     int    `json:"promptTokens,omitempty"`
+	CompletionTokens int    `json:"completionTokens,omitempty"`
+	// 用户收藏的回复
+	IsBookmarked bool       `json:"isBookmarked,omitempty" gorm:"index"`
+	BookmarkedAt *time.Time `json:"bookmarkedAt,omitempty"`
+}
+
+// MessageSource 回复引用的检索资料
+type MessageSource struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`            // web, document, career_history
+	RefID       uint   `json:"refId,omitempty"` // 文档或咨询记录ID
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Snippet     string `json:"snippet"`
+	Source      string `json:"source,omitempty"`
+	PublishedAt string `json:"publishedAt,omitempty"`
+	Cited       bool   `json:"cited"` // 回复中是否实际引用了该资料
 }
 
 // Note is a simple personal note item
 type Note struct {
 	BaseModel
+	UserID  string `json:"userId" gorm:"size:64;index"` // 为空表示未归属用户的历史笔记，不参与全文检索
 	Title   string `json:"title" gorm:"size:255"`
 	Content string `json:"content" gorm:"type:text"`
 }
@@ -49,6 +81,7 @@ type CareerHistory struct {
 	BaseModel
 	UserID       string `json:"userId" gorm:"size:64;index"`
 	ThreadID     string `json:"threadId" gorm:"size:64;index"`
+	MessageID    uint   `json:"messageId" gorm:"index"`      // 对应的助手消息，手动保存的记录为0
 	Category     string `json:"category" gorm:"size:50"`     // career, offer, contract, monitor
 	Title        string `json:"title" gorm:"size:200"`       // 问题标题
 	Content      string `json:"content" gorm:"type:text"`    // 问题内容
@@ -58,6 +91,25 @@ type CareerHistory struct {
 	Rating       int    `json:"rating"`                      // 用户评分 1-5
 	IsBookmarked bool   `json:"isBookmarked"`                // 是否收藏
 	Metadata     string `json:"metadata" gorm:"type:text"`   // 额外元数据(JSON)
+	// 以下字段由模型在对话保存后异步生成
+	Sentiment  string          `json:"sentiment,omitempty" gorm:"size:20"` // positive, neutral, negative
+	Entities   HistoryEntities `json:"entities" gorm:"type:text;serializer:json"`
+	EnrichedAt *time.Time      `json:"enrichedAt,omitempty"`
+}
+
+// HistoryEntities 咨询中提到的关键实体
+type HistoryEntities struct {
+	Companies []string `json:"companies,omitempty"`
+	Positions []string `json:"positions,omitempty"`
+	Amounts   []string `json:"amounts,omitempty"` // 如 25k、年薪40万
+}
+
+// CareerHistoryTag 咨询记录的标签，每个标签一行，用于标签统计和筛选
+type CareerHistoryTag struct {
+	BaseModel
+	HistoryID uint   `json:"historyId" gorm:"index"`
+	UserID    string `json:"userId" gorm:"size:64;index:idx_history_tag_user_tag"`
+	Tag       string `json:"tag" gorm:"size:50;index:idx_history_tag_user_tag"`
 }
 
 // ContractRisk 劳动合同风险点
@@ -65,6 +117,7 @@ type ContractRisk struct {
 	BaseModel
 	UserID      string     `json:"userId" gorm:"size:64;index"`
 	ThreadID    string     `json:"threadId" gorm:"size:64;index"`
+	CompanyID   *uint      `json:"companyId" gorm:"index"` // 关联企业
 	CompanyName string     `json:"companyName" gorm:"size:200"`
 	RiskType    string     `json:"riskType" gorm:"size:50"`      // 风险类型
 	RiskLevel   string     `json:"riskLevel" gorm:"size:20"`     // 风险等级: low, medium, high, critical
@@ -80,6 +133,7 @@ type ContractRisk struct {
 type CompanyMonitor struct {
 	BaseModel
 	UserID       string     `json:"userId" gorm:"size:64;index"`
+	CompanyID    *uint      `json:"companyId" gorm:"index"` // 关联企业
 	CompanyName  string     `json:"companyName" gorm:"size:200"`
 	CompanyCode  string     `json:"companyCode" gorm:"size:50"`  // 公司代码/股票代码
 	Industry     string     `json:"industry" gorm:"size:100"`    // 行业
@@ -88,6 +142,7 @@ type CompanyMonitor struct {
 	AlertEnabled bool       `json:"alertEnabled"`                // 是否启用告警
 	AlertRules   string     `json:"alertRules" gorm:"type:text"` // 告警规则(JSON)
 	LastAlertAt  *time.Time `json:"lastAlertAt"`                 // 最后告警时间
+	LastCheckAt  *time.Time `json:"lastCheckAt"`                 // 最后检查时间
 	AlertCount   int        `json:"alertCount"`                  // 告警次数
 	Status       string     `json:"status" gorm:"size:20"`       // 状态: active, paused, stopped
 	Notes        string     `json:"notes" gorm:"type:text"`      // 备注
@@ -95,12 +150,31 @@ type CompanyMonitor struct {
 
 // CareerStage 职业阶段定义
 type CareerStage struct {
-	Stage       string `json:"stage"`       // 阶段名称
-	Description string `json:"description"` // 阶段描述
-	Skills      string `json:"skills"`      // 所需技能
-	Goals       string `json:"goals"`       // 阶段目标
-	Duration    string `json:"duration"`    // 预计时长
-	Progress    int    `json:"progress"`    // 当前进度 0-100
+	Stage       string `json:"stage"`             // 阶段名称
+	Description string `json:"description"`       // 阶段描述
+	Skills      string `json:"skills"`            // 所需技能
+	Goals       string `json:"goals"`             // 阶段目标
+	Duration    string `json:"duration"`          // 预计时长
+	Progress    int    `json:"progress"`          // 当前进度 0-100
+	Track       string `json:"track,omitempty"`   // 发展路线: general, technical, management
+	Current     bool   `json:"current,omitempty"` // 是否为用户当前所处阶段
+}
+
+// CareerStageDefinition 职业阶段配置，按行业和发展路线区分
+type CareerStageDefinition struct {
+	BaseModel
+	Track            string `json:"track" gorm:"size:50;index"`       // 发展路线: general, technical, management
+	Industry         string `json:"industry" gorm:"size:100;index"`   // 适用行业，空表示通用
+	StageOrder       int    `json:"stageOrder"`                       // 阶段顺序，从0开始
+	Stage            string `json:"stage" gorm:"size:50"`             // 阶段名称
+	Description      string `json:"description" gorm:"size:500"`      // 阶段描述
+	Skills           string `json:"skills" gorm:"size:500"`           // 所需技能
+	Goals            string `json:"goals" gorm:"size:500"`            // 阶段目标
+	Duration         string `json:"duration" gorm:"size:50"`          // 预计时长
+	MinExperience    int    `json:"minExperience"`                    // 进入该阶段的最低工作年限
+	MaxExperience    int    `json:"maxExperience"`                    // 离开该阶段的工作年限，0表示无上限
+	PositionKeywords string `json:"positionKeywords" gorm:"size:500"` // 职位关键词(JSON数组)
+	RequiredGoals    int    `json:"requiredGoals"`                    // 该阶段建议完成的目标数
 }
 
 // PersonalMetrics 个性化指标
@@ -137,13 +211,19 @@ type UserDocument struct {
 	BaseModel
 	UserID           string `json:"userId" gorm:"size:64;index"`
 	DocumentType     string `json:"documentType" gorm:"size:50"`                       // resume, contract, offer, employment, other
+	CompanyID        *uint  `json:"companyId" gorm:"index"`                            // 关联企业(合同、Offer、在职文档)
 	FileName         string `json:"fileName" gorm:"size:255"`                          // 原始文件名
 	FileSize         int64  `json:"fileSize"`                                          // 文件大小(字节)
 	FileType         string `json:"fileType" gorm:"size:50"`                           // pdf, doc, docx, txt
-	FilePath         string `json:"filePath" gorm:"size:500"`                          // 文件存储路径
+	FilePath         string `json:"filePath" gorm:"size:500"`                          // 旧版本保存在本地 uploads 目录的文件路径
+	BlobKey          string `json:"-" gorm:"size:100;index"`                           // 文件存储中的键（按内容SHA-256寻址）
+	ContentType      string `json:"contentType" gorm:"size:100"`                       // 文件MIME类型
+	ContentHash      string `json:"contentHash" gorm:"size:64;index"`                  // 文件内容SHA-256，用于识别重复上传
+	GroupID          uint   `json:"groupId" gorm:"index"`                              // 版本组，取第一版文档ID
+	Version          int    `json:"version" gorm:"default:1"`                          // 版本号，从1开始
 	FileContent      string `json:"fileContent" gorm:"type:text"`                      // 文件内容(提取的文本)
 	ExtractedInfo    string `json:"extractedInfo" gorm:"type:text"`                    // AI提取的结构化信息(JSON)
-	UploadSource     string `json:"uploadSource" gorm:"size:50;default:'manual'"`      // manual, api, import
+	UploadSource     string `json:"uploadSource" gorm:"size:50;default:'manual'"`      // manual, api, import, chat, generated
 	IsProcessed      bool   `json:"isProcessed"`                                       // 是否已处理
 	ProcessingStatus string `json:"processingStatus" gorm:"size:20;default:'pending'"` // pending, processing, completed, failed
 	ProcessingError  string `json:"processingError" gorm:"type:text"`                  // 处理错误信息
@@ -338,3 +418,20 @@ func (ud *UserDocument) SetMetadata(metadata map[string]interface{}) error {
 	ud.Metadata = string(data)
 	return nil
 }
+
+func (d *CareerStageDefinition) GetPositionKeywords() []string {
+	var keywords []string
+	if d.PositionKeywords != "" {
+		json.Unmarshal([]byte(d.PositionKeywords), &keywords)
+	}
+	return keywords
+}
+
+func (d *CareerStageDefinition) SetPositionKeywords(keywords []string) error {
+	data, err := json.Marshal(keywords)
+	if err != nil {
+		return err
+	}
+	d.PositionKeywords = string(data)
+	return nil
+}
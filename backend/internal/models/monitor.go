@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MonitorAlert 企业监控触发的告警事件
+type MonitorAlert struct {
+	BaseModel
+	MonitorID   uint      `json:"monitorId" gorm:"index"`
+	UserID      string    `json:"userId" gorm:"size:64;index"`
+	CompanyName string    `json:"companyName" gorm:"size:200"`
+	RuleID      string    `json:"ruleId" gorm:"size:64;index"`
+	RuleName    string    `json:"ruleName" gorm:"size:200"`
+	RuleType    string    `json:"ruleType" gorm:"size:50"`   // financial, management, market
+	Severity    string    `json:"severity" gorm:"size:20"`   // 严重程度
+	Condition   string    `json:"condition" gorm:"size:500"` // 触发时的规则条件
+	Threshold   string    `json:"threshold" gorm:"size:200"` // 触发时的规则阈值
+	Message     string    `json:"message" gorm:"type:text"`  // 告警内容
+	Snapshot    string    `json:"snapshot" gorm:"type:text"` // 触发时的企业信号快照(JSON)
+	Source      string    `json:"source" gorm:"size:100"`    // 信号来源
+	TriggeredAt time.Time `json:"triggeredAt" gorm:"index"`  // 触发时间
+}
+
+func (a *MonitorAlert) GetSnapshot() map[string]interface{} {
+	var snapshot map[string]interface{}
+	if a.Snapshot != "" {
+		json.Unmarshal([]byte(a.Snapshot), &snapshot)
+	}
+	return snapshot
+}
+
+func (a *MonitorAlert) SetSnapshot(snapshot map[string]interface{}) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	a.Snapshot = string(data)
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Notification 站内通知（收件箱）
+type Notification struct {
+	BaseModel
+	UserID     string     `json:"userId" gorm:"size:64;index"`
+	EventType  string     `json:"eventType" gorm:"size:50;index"` // 事件类型: monitor_alert, test
+	Title      string     `json:"title" gorm:"size:255"`
+	Content    string     `json:"content" gorm:"type:text"`
+	Severity   string     `json:"severity" gorm:"size:20"`        // low, medium, high, critical
+	SourceType string     `json:"sourceType" gorm:"size:50"`      // 来源对象类型，如 monitor_alert
+	SourceID   uint       `json:"sourceId"`                       // 来源对象ID
+	DedupKey   string     `json:"dedupKey" gorm:"size:200;index"` // 去重键
+	IsRead     bool       `json:"isRead" gorm:"index"`
+	ReadAt     *time.Time `json:"readAt"`
+}
+
+// NotificationPreference 用户通知渠道偏好
+type NotificationPreference struct {
+	BaseModel
+	UserID         string `json:"userId" gorm:"size:64;uniqueIndex"`
+	InAppEnabled   bool   `json:"inAppEnabled"`
+	EmailEnabled   bool   `json:"emailEnabled"`
+	Email          string `json:"email" gorm:"size:255"` // 为空时使用监控上配置的告警邮箱
+	WebhookEnabled bool   `json:"webhookEnabled"`
+	WebhookURL     string `json:"webhookUrl" gorm:"size:500"`
+	WebhookFormat  string `json:"webhookFormat" gorm:"size:20;default:'generic'"` // generic, wecom, feishu, dingtalk
+	WebhookSecret  string `json:"-" gorm:"size:255"`                              // 签名密钥，不对外返回
+	MinSeverity    string `json:"minSeverity" gorm:"size:20;default:'low'"`       // 低于该级别的事件不通知
+}
+
+// NotificationDelivery 通知投递记录，用于去重和重试追踪
+type NotificationDelivery struct {
+	BaseModel
+	UserID    string     `json:"userId" gorm:"size:64;index"`
+	EventType string     `json:"eventType" gorm:"size:50"`
+	DedupKey  string     `json:"dedupKey" gorm:"size:200;index"`
+	Channel   string     `json:"channel" gorm:"size:20"` // inapp, email, webhook
+	Target    string     `json:"target" gorm:"size:500"` // 收件地址或webhook地址
+	Subject   string     `json:"subject" gorm:"size:255"`
+	Status    string     `json:"status" gorm:"size:20;index"` // pending, sent, failed
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"lastError" gorm:"type:text"`
+	SentAt    *time.Time `json:"sentAt"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// PromptTemplate 提示词模板的一个版本
+// 同一模板键、会话类别和模型组成一个变体，每个变体最多一个启用版本；
+// 没有启用版本时使用程序内置（或 PROMPT_DIR 中）的默认模板
+type PromptTemplate struct {
+	BaseModel
+	Key         string `json:"key" gorm:"column:template_key;size:100;index:idx_prompt_variant"`
+	Category    string `json:"category" gorm:"size:50;index:idx_prompt_variant"` // 会话类别: career, offer, contract, monitor，空表示通用
+	ModelID     string `json:"modelId" gorm:"size:100;index:idx_prompt_variant"` // 模型ID，以*结尾表示前缀匹配，空表示通用
+	Version     int    `json:"version"`                                          // 变体内递增的版本号
+	Content     string `json:"content" gorm:"type:text"`
+	Description string `json:"description" gorm:"size:500"` // 修改说明
+	IsActive    bool   `json:"isActive" gorm:"index"`
+	CreatedBy   string `json:"createdBy" gorm:"size:64"`
+}
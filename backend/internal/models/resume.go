@@ -0,0 +1,102 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ResumeTailoring 针对目标岗位的简历优化：逐条要点的改写建议、章节顺序和关键词覆盖
+type ResumeTailoring struct {
+	BaseModel
+	UserID           string             `json:"userId" gorm:"size:64;index"`
+	DocumentID       uint               `json:"documentId" gorm:"index"` // 优化的简历
+	TargetPosition   string             `json:"targetPosition" gorm:"size:200"`
+	JobDescription   string             `json:"jobDescription" gorm:"type:text"`
+	Keywords         string             `json:"keywords" gorm:"type:text"`      // 岗位关键词(JSON数组)
+	SectionOrder     string             `json:"sectionOrder" gorm:"size:200"`   // 建议的章节顺序(JSON数组)
+	SectionReason    string             `json:"sectionReason" gorm:"type:text"` // 调整章节顺序的理由
+	Summary          string             `json:"summary" gorm:"type:text"`       // 整体优化建议
+	Status           string             `json:"status" gorm:"size:20;index"`    // 状态: pending, applied
+	ResultDocumentID uint               `json:"resultDocumentId"`               // 应用建议后生成的新版本简历
+	AppliedAt        *time.Time         `json:"appliedAt"`                      // 应用时间
+	ModelID          string             `json:"modelId,omitempty" gorm:"size:100"`
+	PromptVersion    string             `json:"promptVersion,omitempty" gorm:"size:255"`
+	Suggestions      []ResumeSuggestion `json:"suggestions,omitempty" gorm:"foreignKey:TailoringID"`
+}
+
+// ResumeSuggestion 一条工作经历要点的改写建议
+type ResumeSuggestion struct {
+	BaseModel
+	TailoringID     uint   `json:"tailoringId" gorm:"index"`
+	ExperienceIndex int    `json:"experienceIndex"`                         // 工作经历序号(从0开始)
+	BulletIndex     int    `json:"bulletIndex"`                             // 要点序号(从0开始)
+	Original        string `json:"original" gorm:"type:text"`               // 原要点
+	Rewrite         string `json:"rewrite" gorm:"type:text"`                // 建议的改写（STAR结构，量化成果）
+	Reason          string `json:"reason" gorm:"type:text"`                 // 改写理由
+	Keywords        string `json:"keywords" gorm:"type:text"`               // 改写后新覆盖的岗位关键词(JSON数组)
+	Status          string `json:"status" gorm:"size:20;default:'pending'"` // 状态: pending, accepted, rejected
+}
+
+// 简历优化状态
+const (
+	TailoringStatusPending = "pending"
+	TailoringStatusApplied = "applied"
+)
+
+// 改写建议状态
+const (
+	SuggestionStatusPending  = "pending"
+	SuggestionStatusAccepted = "accepted"
+	SuggestionStatusRejected = "rejected"
+)
+
+func (t *ResumeTailoring) GetKeywords() []string {
+	var keywords []string
+	if t.Keywords != "" {
+		json.Unmarshal([]byte(t.Keywords), &keywords)
+	}
+	return keywords
+}
+
+func (t *ResumeTailoring) SetKeywords(keywords []string) error {
+	data, err := json.Marshal(keywords)
+	if err != nil {
+		return err
+	}
+	t.Keywords = string(data)
+	return nil
+}
+
+func (t *ResumeTailoring) GetSectionOrder() []string {
+	var order []string
+	if t.SectionOrder != "" {
+		json.Unmarshal([]byte(t.SectionOrder), &order)
+	}
+	return order
+}
+
+func (t *ResumeTailoring) SetSectionOrder(order []string) error {
+	data, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+	t.SectionOrder = string(data)
+	return nil
+}
+
+func (s *ResumeSuggestion) GetKeywords() []string {
+	var keywords []string
+	if s.Keywords != "" {
+		json.Unmarshal([]byte(s.Keywords), &keywords)
+	}
+	return keywords
+}
+
+func (s *ResumeSuggestion) SetKeywords(keywords []string) error {
+	data, err := json.Marshal(keywords)
+	if err != nil {
+		return err
+	}
+	s.Keywords = string(data)
+	return nil
+}
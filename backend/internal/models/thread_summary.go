@@ -0,0 +1,14 @@
+package models
+
+// ThreadSummary 长会话的滚动摘要，较早的消息合并为摘要后代替原始消息作为对话上下文。
+// 会话名称由客户端决定，不同用户可能相同，摘要按用户和会话区分
+type ThreadSummary struct {
+	BaseModel
+	ThreadID         string `json:"threadId" gorm:"size:64;uniqueIndex:idx_thread_summaries_user_thread,priority:2"`
+	UserID           string `json:"userId" gorm:"size:64;uniqueIndex:idx_thread_summaries_user_thread,priority:1"`
+	Summary          string `json:"summary" gorm:"type:text"`
+	CoveredMessageID uint   `json:"coveredMessageId"` // 摘要已覆盖到的最后一条消息
+	CoveredTurns     int    `json:"coveredTurns"`     // 摘要已覆盖的对话轮数
+	ModelID          string `json:"modelId,omitempty" gorm:"size:100"`
+	PromptVersion    string `json:"promptVersion,omitempty" gorm:"size:255"`
+}
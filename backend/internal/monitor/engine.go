@@ -0,0 +1,261 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+// ErrMonitorInactive 监控处于暂停或停止状态
+var ErrMonitorInactive = errors.New("监控未处于运行状态")
+
+func init() {
+	RegisterSource("file", func() (DataSource, error) {
+		return NewFileSource(config.C.MonitorFixtureDir), nil
+	})
+}
+
+// Engine 企业监控引擎，定期拉取企业信号并评估告警规则
+type Engine struct {
+	sources  []DataSource
+	interval time.Duration
+	cooldown time.Duration
+
+	runMu  sync.Mutex // 防止两轮检查重叠执行
+	stopCh chan struct{}
+	once   sync.Once
+}
+
+var (
+	defaultEngine     *Engine
+	defaultEngineOnce sync.Once
+)
+
+// Default 返回按配置创建的全局监控引擎
+func Default() *Engine {
+	defaultEngineOnce.Do(func() {
+		sources, err := NewSources(config.C.MonitorSources)
+		if err != nil {
+			logger.Error("初始化监控数据源失败: %v", err)
+		}
+		defaultEngine = NewEngine(sources, config.C.MonitorInterval, config.C.MonitorCooldown)
+	})
+	return defaultEngine
+}
+
+// NewEngine 创建监控引擎
+func NewEngine(sources []DataSource, interval, cooldown time.Duration) *Engine {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Engine{
+		sources:  sources,
+		interval: interval,
+		cooldown: cooldown,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台定时检查
+func (e *Engine) Start() {
+	logger.Info("企业监控引擎启动: 间隔=%v, 数据源数量=%d", e.interval, len(e.sources))
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		e.RunOnce()
+		for {
+			select {
+			case <-ticker.C:
+				e.RunOnce()
+			case <-e.stopCh:
+				logger.Info("企业监控引擎已停止")
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台定时检查
+func (e *Engine) Stop() {
+	e.once.Do(func() { close(e.stopCh) })
+}
+
+// RunOnce 检查所有运行中的监控，返回检查的监控数和产生的告警数
+func (e *Engine) RunOnce() (int, int) {
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	var monitors []models.CompanyMonitor
+	if err := db.Conn.Where("status = ? AND alert_enabled = ?", "active", true).Find(&monitors).Error; err != nil {
+		logger.Error("获取运行中的企业监控失败: %v", err)
+		return 0, 0
+	}
+
+	checked, alerted := 0, 0
+	for i := range monitors {
+		alerts, err := e.CheckMonitor(&monitors[i])
+		if err != nil {
+			logger.Warn("企业监控检查失败: MonitorID=%d, Company=%s, 错误=%v",
+				monitors[i].ID, monitors[i].CompanyName, err)
+			continue
+		}
+		checked++
+		alerted += len(alerts)
+	}
+
+	logger.Info("企业监控检查完成: 监控数=%d, 成功=%d, 新告警=%d", len(monitors), checked, alerted)
+	return checked, alerted
+}
+
+// CheckMonitor 检查单个监控：拉取信号、评估规则、记录告警并更新监控统计
+func (e *Engine) CheckMonitor(monitor *models.CompanyMonitor) ([]models.MonitorAlert, error) {
+	if monitor.Status != "active" {
+		return nil, ErrMonitorInactive
+	}
+
+	snapshot, err := e.FetchSnapshot(monitor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var alerts []models.MonitorAlert
+	if monitor.AlertEnabled {
+		for _, rule := range monitor.GetAlertRules() {
+			if !rule.Enabled {
+				continue
+			}
+
+			triggered, detail, err := EvaluateRule(rule, snapshot)
+			if err != nil {
+				logger.Warn("告警规则评估失败: MonitorID=%d, RuleID=%s, 错误=%v", monitor.ID, rule.ID, err)
+				continue
+			}
+			if !triggered || e.inCooldown(monitor.ID, rule.ID, now) {
+				continue
+			}
+
+			alert := models.MonitorAlert{
+				MonitorID:   monitor.ID,
+				UserID:      monitor.UserID,
+				CompanyName: monitor.CompanyName,
+				RuleID:      rule.ID,
+				RuleName:    rule.Name,
+				RuleType:    rule.Type,
+				Severity:    rule.Severity,
+				Condition:   rule.Condition,
+				Threshold:   rule.Threshold,
+				Message:     fmt.Sprintf("【%s】%s 触发告警规则「%s」: %s", rule.Severity, monitor.CompanyName, rule.Name, detail),
+				Source:      snapshot.Source,
+				TriggeredAt: now,
+			}
+			alert.SetSnapshot(snapshot.Signals)
+			alerts = append(alerts, alert)
+		}
+	}
+
+	err = db.Conn.Transaction(func(tx *gorm.DB) error {
+		if len(alerts) > 0 {
+			if err := tx.Create(&alerts).Error; err != nil {
+				return err
+			}
+		}
+		updates := map[string]interface{}{"last_check_at": now}
+		if len(alerts) > 0 {
+			updates["last_alert_at"] = now
+			updates["alert_count"] = gorm.Expr("alert_count + ?", len(alerts))
+		}
+		return tx.Model(&models.CompanyMonitor{}).Where("id = ?", monitor.ID).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("保存告警失败: %v", err)
+	}
+
+	monitor.LastCheckAt = &now
+	if len(alerts) > 0 {
+		monitor.LastAlertAt = &now
+		monitor.AlertCount += len(alerts)
+		logger.Info("企业监控产生告警: MonitorID=%d, Company=%s, 告警数=%d", monitor.ID, monitor.CompanyName, len(alerts))
+		for i := range alerts {
+			notify.Default().DispatchAsync(alertEvent(monitor, &alerts[i]))
+		}
+	}
+	return alerts, nil
+}
+
+// alertEvent 将告警转换为通知事件
+func alertEvent(monitor *models.CompanyMonitor, alert *models.MonitorAlert) *notify.Event {
+	return &notify.Event{
+		Type:     notify.EventMonitorAlert,
+		UserID:   alert.UserID,
+		DedupKey: alertDedupKey(alert),
+		Severity: alert.Severity,
+		Data: map[string]interface{}{
+			"CompanyName": alert.CompanyName,
+			"RuleName":    alert.RuleName,
+			"Severity":    alert.Severity,
+			"Message":     alert.Message,
+			"TriggeredAt": alert.TriggeredAt.Format("2006-01-02 15:04:05"),
+			"MonitorID":   alert.MonitorID,
+			"AlertID":     alert.ID,
+		},
+		Email:      monitor.AlertEmail,
+		SourceType: "monitor_alert",
+		SourceID:   alert.ID,
+	}
+}
+
+// alertDedupKey 通知去重键：同一监控、同一规则、相同的信号快照只通知一次，
+// 数据源没有新数据时重复评估不会重复通知
+func alertDedupKey(alert *models.MonitorAlert) string {
+	sum := sha256.Sum256([]byte(alert.Snapshot))
+	return fmt.Sprintf("monitor_alert:%d:%s:%s", alert.MonitorID, alert.RuleID, hex.EncodeToString(sum[:8]))
+}
+
+// FetchSnapshot 从所有数据源拉取并合并企业信号
+func (e *Engine) FetchSnapshot(monitor *models.CompanyMonitor) (*Snapshot, error) {
+	if len(e.sources) == 0 {
+		return nil, fmt.Errorf("未配置监控数据源")
+	}
+
+	var snapshots []*Snapshot
+	for _, source := range e.sources {
+		snapshot, err := source.Fetch(monitor)
+		if errors.Is(err, ErrNoData) {
+			continue
+		}
+		if err != nil {
+			logger.Warn("监控数据源拉取失败: Source=%s, Company=%s, 错误=%v", source.Name(), monitor.CompanyName, err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if len(snapshots) == 0 {
+		return nil, ErrNoData
+	}
+	return mergeSnapshots(monitor.CompanyName, snapshots), nil
+}
+
+// inCooldown 同一规则在冷却期内已告警过则不再重复告警
+func (e *Engine) inCooldown(monitorID uint, ruleID string, now time.Time) bool {
+	if e.cooldown <= 0 {
+		return false
+	}
+	var count int64
+	db.Conn.Model(&models.MonitorAlert{}).
+		Where("monitor_id = ? AND rule_id = ? AND triggered_at > ?", monitorID, ruleID, now.Add(-e.cooldown)).
+		Count(&count)
+	return count > 0
+}
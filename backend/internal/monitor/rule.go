@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/expr"
+	"ai-career-buddy/internal/models"
+)
+
+// comparisonOperators 按长度排列，保证 ">=" 优先于 ">" 匹配
+var comparisonOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+var identPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_.]*$`)
+
+// CompiledRule 编译后的告警规则
+type CompiledRule struct {
+	Program *expr.Program
+	Params  map[string]interface{} // 由 Threshold 绑定的参数变量
+}
+
+// RuleExpression 将规则的 Condition 和 Threshold 组合为表达式
+//
+// Condition 是完整的表达式，如 "revenue_yoy < -0.1 && layoffs_reported"，此时
+// Threshold 留空。为兼容旧规则：
+//   - Threshold 以比较运算符开头时直接拼接到 Condition 后，如 "revenue_yoy" + "< -0.1"；
+//   - Condition 为单个信号名且 Threshold 只写值时按 ">=" 比较；
+//   - 其他情况下 Threshold 的值绑定为变量 threshold，可在 Condition 中引用。
+func RuleExpression(rule models.AlertRule) (string, map[string]interface{}) {
+	condition := strings.TrimSpace(rule.Condition)
+	threshold := strings.TrimSpace(rule.Threshold)
+	if threshold == "" {
+		return condition, nil
+	}
+
+	for _, op := range comparisonOperators {
+		if strings.HasPrefix(threshold, op) {
+			rest := strings.TrimSpace(strings.TrimPrefix(threshold, op))
+			return fmt.Sprintf("%s %s %s", condition, op, literal(rest)), nil
+		}
+	}
+
+	if identPattern.MatchString(condition) {
+		return fmt.Sprintf("%s >= %s", condition, literal(threshold)), nil
+	}
+	return condition, map[string]interface{}{"threshold": literalValue(threshold)}
+}
+
+// CompileRule 编译告警规则，schema 为 nil 时不检查信号是否存在
+func CompileRule(rule models.AlertRule, schema expr.Schema) (*CompiledRule, []*expr.Error) {
+	src, params := RuleExpression(rule)
+	if schema != nil && len(params) > 0 {
+		merged := make(expr.Schema, len(schema)+len(params))
+		for name, t := range schema {
+			merged[name] = t
+		}
+		for name, v := range params {
+			merged[name] = expr.TypeOf(v)
+		}
+		schema = merged
+	}
+
+	program, errs := expr.Compile(src, schema)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &CompiledRule{Program: program, Params: params}, nil
+}
+
+// Evaluate 使用快照评估规则，返回是否触发以及涉及信号的取值说明
+func (r *CompiledRule) Evaluate(snapshot *Snapshot) (bool, string, error) {
+	vars := snapshot.Signals
+	if len(r.Params) > 0 {
+		vars = make(map[string]interface{}, len(snapshot.Signals)+len(r.Params))
+		for name, v := range snapshot.Signals {
+			vars[name] = v
+		}
+		for name, v := range r.Params {
+			vars[name] = v
+		}
+	}
+
+	triggered, err := r.Program.Run(vars)
+	if err != nil {
+		return false, "", err
+	}
+
+	parts := make([]string, 0, len(r.Program.Variables()))
+	for _, name := range r.Program.Variables() {
+		if v, ok := vars[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s = %s", name, formatValue(v)))
+		}
+	}
+	return triggered, fmt.Sprintf("%s（%s）", r.Program.Source, strings.Join(parts, ", ")), nil
+}
+
+// EvaluateRule 使用快照评估单条告警规则，返回是否触发以及触发说明
+func EvaluateRule(rule models.AlertRule, snapshot *Snapshot) (bool, string, error) {
+	compiled, errs := CompileRule(rule, expr.SchemaOf(snapshot.Signals))
+	if len(errs) > 0 {
+		return false, "", errs[0]
+	}
+	return compiled.Evaluate(snapshot)
+}
+
+// literal 将旧规则阈值中的裸值转换为表达式字面量，非数字和布尔值按字符串处理
+func literal(s string) string {
+	switch v := literalValue(s).(type) {
+	case string:
+		if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+			return s
+		}
+		return strconv.Quote(v)
+	}
+	return s
+}
+
+func literalValue(s string) interface{} {
+	if f, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64); err == nil {
+		if strings.HasSuffix(s, "%") {
+			return f / 100
+		}
+		return f
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/models"
+)
+
+// ErrNoData 数据源中没有该企业的信号
+var ErrNoData = errors.New("数据源中没有该企业的数据")
+
+// Snapshot 某一时刻企业信号的快照
+//
+// Signals 的值只允许 float64、bool、string 三种类型，
+// 例如 revenue_yoy=-0.12、layoffs_reported=true、ceo_name="张三"。
+type Snapshot struct {
+	Company   string                 `json:"company"`
+	Source    string                 `json:"source"`
+	FetchedAt time.Time              `json:"fetchedAt"`
+	Signals   map[string]interface{} `json:"signals"`
+}
+
+// DataSource 企业信号数据源
+type DataSource interface {
+	// Name 数据源名称，用于日志和告警记录
+	Name() string
+	// Fetch 获取企业的最新信号，没有数据时返回 ErrNoData
+	Fetch(monitor *models.CompanyMonitor) (*Snapshot, error)
+}
+
+// SourceFactory 根据配置创建数据源
+type SourceFactory func() (DataSource, error)
+
+var sourceFactories = map[string]SourceFactory{}
+
+// RegisterSource 注册数据源，name 对应 MONITOR_SOURCES 配置中的名称
+func RegisterSource(name string, factory SourceFactory) {
+	sourceFactories[name] = factory
+}
+
+// NewSources 按逗号分隔的名称列表创建数据源
+func NewSources(names string) ([]DataSource, error) {
+	var sources []DataSource
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		factory, ok := sourceFactories[name]
+		if !ok {
+			return nil, fmt.Errorf("未知的监控数据源: %s", name)
+		}
+		source, err := factory()
+		if err != nil {
+			return nil, fmt.Errorf("创建监控数据源 %s 失败: %v", name, err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// FileSource 基于本地JSON文件的数据源，用于本地开发和测试
+//
+// 目录下每个企业一个文件，文件名为股票代码或公司名称，例如
+// fixtures/monitor/BABA.json、fixtures/monitor/字节跳动.json：
+//
+//	{"company": "字节跳动", "fetchedAt": "2025-09-01T00:00:00Z", "signals": {"revenue_yoy": 0.3}}
+type FileSource struct {
+	dir string
+}
+
+// NewFileSource 创建文件数据源
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (s *FileSource) Name() string {
+	return "file"
+}
+
+func (s *FileSource) Fetch(monitor *models.CompanyMonitor) (*Snapshot, error) {
+	for _, key := range []string{monitor.CompanyCode, monitor.CompanyName} {
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, `/\`) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, key+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取监控数据文件失败: %v", err)
+		}
+
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("解析监控数据文件 %s.json 失败: %v", key, err)
+		}
+		if snapshot.Company == "" {
+			snapshot.Company = monitor.CompanyName
+		}
+		if snapshot.FetchedAt.IsZero() {
+			snapshot.FetchedAt = time.Now()
+		}
+		snapshot.Source = s.Name()
+		return &snapshot, nil
+	}
+	return nil, ErrNoData
+}
+
+// mergeSnapshots 合并多个数据源的快照，排在前面的数据源优先
+func mergeSnapshots(company string, snapshots []*Snapshot) *Snapshot {
+	merged := &Snapshot{Company: company, Signals: map[string]interface{}{}}
+	var sources []string
+	for _, snapshot := range snapshots {
+		sources = append(sources, snapshot.Source)
+		if snapshot.FetchedAt.After(merged.FetchedAt) {
+			merged.FetchedAt = snapshot.FetchedAt
+		}
+		for name, value := range snapshot.Signals {
+			if _, exists := merged.Signals[name]; !exists {
+				merged.Signals[name] = value
+			}
+		}
+	}
+	merged.Source = strings.Join(sources, ",")
+	return merged
+}
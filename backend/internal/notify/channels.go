@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+)
+
+// 通知渠道名称
+const (
+	ChannelInApp   = "inapp"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+)
+
+// Channel 通知投递渠道
+type Channel interface {
+	// Name 渠道名称
+	Name() string
+	// Target 根据用户偏好和事件决定投递目标，返回空字符串表示该渠道不投递
+	Target(pref *models.NotificationPreference, event *Event) string
+	// Send 投递一条通知
+	Send(target string, msg *Message, event *Event, pref *models.NotificationPreference) error
+}
+
+// InAppChannel 站内信渠道，写入通知收件箱
+type InAppChannel struct{}
+
+func (InAppChannel) Name() string { return ChannelInApp }
+
+func (InAppChannel) Target(pref *models.NotificationPreference, event *Event) string {
+	if !pref.InAppEnabled {
+		return ""
+	}
+	return event.UserID
+}
+
+func (InAppChannel) Send(target string, msg *Message, event *Event, pref *models.NotificationPreference) error {
+	notification := models.Notification{
+		UserID:     target,
+		EventType:  event.Type,
+		Title:      msg.Title,
+		Content:    msg.Body,
+		Severity:   event.Severity,
+		SourceType: event.SourceType,
+		SourceID:   event.SourceID,
+		DedupKey:   event.DedupKey,
+	}
+	return db.Conn.Create(&notification).Error
+}
+
+// EmailChannel SMTP邮件渠道
+type EmailChannel struct {
+	host, port, username, password, from string
+}
+
+// NewEmailChannel 按配置创建邮件渠道
+func NewEmailChannel() *EmailChannel {
+	return &EmailChannel{
+		host:     config.C.SMTPHost,
+		port:     config.C.SMTPPort,
+		username: config.C.SMTPUsername,
+		password: config.C.SMTPPassword,
+		from:     config.C.SMTPFrom,
+	}
+}
+
+func (ch *EmailChannel) Name() string { return ChannelEmail }
+
+func (ch *EmailChannel) Target(pref *models.NotificationPreference, event *Event) string {
+	if ch.host == "" || !pref.EmailEnabled {
+		return ""
+	}
+	if pref.Email != "" {
+		return pref.Email
+	}
+	return event.Email
+}
+
+func (ch *EmailChannel) Send(target string, msg *Message, event *Event, pref *models.NotificationPreference) error {
+	fromAddr := ch.from
+	if addr, err := parseAddress(ch.from); err == nil {
+		fromAddr = addr
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("From: " + ch.from + "\r\n")
+	buf.WriteString("To: " + target + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Title) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	var auth smtp.Auth
+	if ch.username != "" {
+		auth = smtp.PlainAuth("", ch.username, ch.password, ch.host)
+	}
+	return smtp.SendMail(ch.host+":"+ch.port, auth, fromAddr, []string{target}, buf.Bytes())
+}
+
+// WebhookChannel 出站webhook渠道，支持通用格式及企业微信/飞书/钉钉机器人
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel 创建webhook渠道
+func NewWebhookChannel() *WebhookChannel {
+	return &WebhookChannel{client: &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: webhookDialer().DialContext, Proxy: nil},
+	}}
+}
+
+func (ch *WebhookChannel) Name() string { return ChannelWebhook }
+
+func (ch *WebhookChannel) Target(pref *models.NotificationPreference, event *Event) string {
+	if !pref.WebhookEnabled {
+		return ""
+	}
+	return pref.WebhookURL
+}
+
+func (ch *WebhookChannel) Send(target string, msg *Message, event *Event, pref *models.NotificationPreference) error {
+	now := time.Now()
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	var payload interface{}
+	switch pref.WebhookFormat {
+	case "wecom":
+		// 企业微信群机器人通过URL中的key鉴权，不需要签名
+		payload = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"content": "### " + msg.Title + "\n" + msg.Body},
+		}
+	case "feishu":
+		body := map[string]interface{}{
+			"msg_type": "text",
+			"content":  map[string]string{"text": msg.Title + "\n" + msg.Body},
+		}
+		if pref.WebhookSecret != "" {
+			ts := strconv.FormatInt(now.Unix(), 10)
+			body["timestamp"] = ts
+			body["sign"] = feishuSign(ts, pref.WebhookSecret)
+		}
+		payload = body
+	case "dingtalk":
+		payload = map[string]interface{}{
+			"msgtype":  "markdown",
+			"markdown": map[string]string{"title": msg.Title, "text": "### " + msg.Title + "\n" + msg.Body},
+		}
+		if pref.WebhookSecret != "" {
+			ts := strconv.FormatInt(now.UnixMilli(), 10)
+			signed, err := appendQuery(target, map[string]string{
+				"timestamp": ts,
+				"sign":      dingtalkSign(ts, pref.WebhookSecret),
+			})
+			if err != nil {
+				return err
+			}
+			target = signed
+		}
+	default:
+		payload = map[string]interface{}{
+			"event":     event.Type,
+			"title":     msg.Title,
+			"content":   msg.Body,
+			"severity":  event.Severity,
+			"dedupKey":  event.DedupKey,
+			"data":      event.Data,
+			"timestamp": now.Unix(),
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化webhook负载失败: %v", err)
+	}
+
+	if pref.WebhookFormat == "" || pref.WebhookFormat == "generic" {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		headers["X-Career-Buddy-Timestamp"] = ts
+		if pref.WebhookSecret != "" {
+			headers["X-Career-Buddy-Signature"] = "sha256=" + genericSign(ts, body, pref.WebhookSecret)
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建webhook请求失败: %v", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送webhook失败: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook返回状态码 %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	// 机器人接口在HTTP 200时也可能通过错误码返回失败
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(respBody, &result) == nil {
+		if result.ErrCode != nil && *result.ErrCode != 0 {
+			return fmt.Errorf("webhook返回错误 %d: %s", *result.ErrCode, result.ErrMsg)
+		}
+		if result.Code != nil && *result.Code != 0 {
+			return fmt.Errorf("webhook返回错误 %d: %s", *result.Code, result.Msg)
+		}
+	}
+	return nil
+}
+
+// genericSign 通用webhook签名: HMAC-SHA256(secret, timestamp + "." + body)
+func genericSign(timestamp string, body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// feishuSign 飞书机器人签名: 以 timestamp + "\n" + secret 为密钥对空串做HMAC-SHA256
+func feishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// dingtalkSign 钉钉机器人签名: 以 secret 为密钥对 timestamp + "\n" + secret 做HMAC-SHA256
+func dingtalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func appendQuery(rawURL string, params map[string]string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("无效的webhook地址: %v", err)
+	}
+	q := u.Query()
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// parseAddress 从 "名称 <地址>" 格式中取出邮箱地址
+func parseAddress(s string) (string, error) {
+	start := strings.LastIndex(s, "<")
+	end := strings.LastIndex(s, ">")
+	if start == -1 || end <= start {
+		return "", fmt.Errorf("无效的邮箱地址: %s", s)
+	}
+	return strings.TrimSpace(s[start+1 : end]), nil
+}
@@ -0,0 +1,225 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+)
+
+// 事件类型
+const (
+	EventMonitorAlert = "monitor_alert"
+	EventTest         = "test"
+)
+
+// 投递状态
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// severityRank 严重程度排序，用于按用户设置的最低级别过滤
+var severityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// Event 待通知的事件
+type Event struct {
+	Type       string
+	UserID     string
+	DedupKey   string                 // 同一去重键在同一渠道只成功投递一次
+	Severity   string                 // low, medium, high, critical
+	Data       map[string]interface{} // 模板变量
+	Email      string                 // 用户偏好未设置邮箱时的收件邮箱
+	SourceType string
+	SourceID   uint
+	Channels   []string // 仅投递到指定渠道，为空时投递到所有启用的渠道
+}
+
+// Result 单个渠道的投递结果
+type Result struct {
+	Channel  string `json:"channel"`
+	Target   string `json:"target"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Dispatcher 通知分发器，按用户偏好将事件投递到各渠道
+type Dispatcher struct {
+	channels    []Channel
+	maxAttempts int
+	backoff     time.Duration
+}
+
+var (
+	defaultDispatcher     *Dispatcher
+	defaultDispatcherOnce sync.Once
+)
+
+// Default 返回按配置创建的全局分发器
+func Default() *Dispatcher {
+	defaultDispatcherOnce.Do(func() {
+		defaultDispatcher = NewDispatcher(
+			[]Channel{InAppChannel{}, NewEmailChannel(), NewWebhookChannel()},
+			config.C.NotifyMaxAttempts,
+			config.C.NotifyRetryBackoff,
+		)
+	})
+	return defaultDispatcher
+}
+
+// NewDispatcher 创建通知分发器
+func NewDispatcher(channels []Channel, maxAttempts int, backoff time.Duration) *Dispatcher {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &Dispatcher{channels: channels, maxAttempts: maxAttempts, backoff: backoff}
+}
+
+// DispatchAsync 在后台投递事件，不阻塞调用方
+func (d *Dispatcher) DispatchAsync(event *Event) {
+	go func() {
+		if _, err := d.Dispatch(event); err != nil {
+			logger.Error("通知投递失败: UserID=%s, Event=%s, 错误=%v", event.UserID, event.Type, err)
+		}
+	}()
+}
+
+// Dispatch 投递事件到用户启用的各个渠道，返回每个渠道的投递结果
+func (d *Dispatcher) Dispatch(event *Event) ([]Result, error) {
+	pref, err := LoadPreference(event.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("加载通知偏好失败: %v", err)
+	}
+	if severityRank[event.Severity] < severityRank[pref.MinSeverity] {
+		logger.Debug("事件严重程度低于用户设置，跳过通知: UserID=%s, Severity=%s", event.UserID, event.Severity)
+		return nil, nil
+	}
+
+	msg, err := Render(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, ch := range d.channels {
+		if len(event.Channels) > 0 && !contains(event.Channels, ch.Name()) {
+			continue
+		}
+		target := ch.Target(pref, event)
+		if target == "" {
+			continue
+		}
+		if event.DedupKey != "" && alreadyDelivered(event.UserID, event.DedupKey, ch.Name()) {
+			logger.Debug("通知已投递过，跳过: Channel=%s, DedupKey=%s", ch.Name(), event.DedupKey)
+			continue
+		}
+		results = append(results, d.deliver(ch, target, msg, event, pref))
+	}
+	return results, nil
+}
+
+// deliver 向单个渠道投递，失败时按退避间隔重试，并记录投递日志
+func (d *Dispatcher) deliver(ch Channel, target string, msg *Message, event *Event, pref *models.NotificationPreference) Result {
+	delivery := models.NotificationDelivery{
+		UserID:    event.UserID,
+		EventType: event.Type,
+		DedupKey:  event.DedupKey,
+		Channel:   ch.Name(),
+		Target:    target,
+		Subject:   msg.Title,
+		Status:    DeliveryPending,
+	}
+	if err := db.Conn.Create(&delivery).Error; err != nil {
+		logger.Error("创建通知投递记录失败: %v", err)
+	}
+
+	var err error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		delivery.Attempts = attempt
+		if err = ch.Send(target, msg, event, pref); err == nil {
+			break
+		}
+		logger.Warn("通知投递失败: Channel=%s, Target=%s, 第%d次, 错误=%v", ch.Name(), target, attempt, err)
+		if attempt < d.maxAttempts && d.backoff > 0 {
+			time.Sleep(d.backoff * time.Duration(1<<(attempt-1)))
+		}
+	}
+
+	if err != nil {
+		delivery.Status = DeliveryFailed
+		delivery.LastError = err.Error()
+	} else {
+		now := time.Now()
+		delivery.Status = DeliverySent
+		delivery.SentAt = &now
+		logger.Info("通知投递成功: Channel=%s, UserID=%s, Event=%s", ch.Name(), event.UserID, event.Type)
+	}
+	if delivery.ID != 0 {
+		db.Conn.Save(&delivery)
+	}
+
+	return Result{
+		Channel:  delivery.Channel,
+		Target:   delivery.Target,
+		Status:   delivery.Status,
+		Attempts: delivery.Attempts,
+		Error:    delivery.LastError,
+	}
+}
+
+// LoadPreference 获取用户的通知偏好，未设置时返回默认偏好（站内信和邮件开启）
+func LoadPreference(userID string) (*models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	if err := db.Conn.Where("user_id = ?", userID).Limit(1).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	if len(prefs) > 0 {
+		return &prefs[0], nil
+	}
+	return DefaultPreference(userID), nil
+}
+
+// DefaultPreference 用户未设置时的默认通知偏好
+func DefaultPreference(userID string) *models.NotificationPreference {
+	return &models.NotificationPreference{
+		UserID:        userID,
+		InAppEnabled:  true,
+		EmailEnabled:  true,
+		WebhookFormat: "generic",
+		MinSeverity:   "low",
+	}
+}
+
+// ValidSeverity 判断严重程度是否合法
+func ValidSeverity(severity string) bool {
+	_, ok := severityRank[severity]
+	return ok
+}
+
+func alreadyDelivered(userID, dedupKey, channel string) bool {
+	var count int64
+	db.Conn.Model(&models.NotificationDelivery{}).
+		Where("user_id = ? AND dedup_key = ? AND channel = ? AND status = ?", userID, dedupKey, channel, DeliverySent).
+		Count(&count)
+	return count > 0
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
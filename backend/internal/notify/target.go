@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"syscall"
+	"time"
+
+	"ai-career-buddy/internal/config"
+)
+
+var (
+	// ErrInvalidWebhookURL webhook地址格式无效
+	ErrInvalidWebhookURL = errors.New("webhook地址必须是有效的 http/https 地址")
+	// ErrPrivateWebhookTarget webhook地址指向内网、本机或链路本地地址
+	ErrPrivateWebhookTarget = errors.New("webhook地址不能指向内网、本机或链路本地地址")
+	// ErrInvalidEmail 邮箱格式无效
+	ErrInvalidEmail = errors.New("邮箱格式不正确")
+)
+
+// NormalizeEmail 校验邮箱并返回其中的地址部分，"张三 <a@b.com>" 返回 a@b.com
+func NormalizeEmail(raw string) (string, error) {
+	addr, err := mail.ParseAddress(raw)
+	if err != nil {
+		return "", ErrInvalidEmail
+	}
+	return addr.Address, nil
+}
+
+// ValidateWebhookURL 校验webhook地址：只允许 http/https，主机名解析出的地址都不能是内网地址，
+// 避免用户借助通知投递访问服务端所在网络（SSRF）。NOTIFY_ALLOW_PRIVATE=true 时跳过地址检查，便于本地调试
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if config.C.NotifyAllowPrivate {
+		return nil
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateIP(ip) {
+			return ErrPrivateWebhookTarget
+		}
+		return nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return fmt.Errorf("无法解析webhook地址 %s: %v", host, err)
+	}
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			return ErrPrivateWebhookTarget
+		}
+	}
+	return nil
+}
+
+// isPrivateIP 内网、本机、链路本地、组播和未指定地址
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// webhookDialer 投递时再检查一次实际连接的地址，防止保存后域名被改为解析到内网（DNS rebinding）
+func webhookDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if config.C.NotifyAllowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return ErrPrivateWebhookTarget
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Message 渲染后的通知内容
+type Message struct {
+	Title string
+	Body  string // Markdown 格式正文
+}
+
+// messageTemplate 某类事件的标题和正文模板
+type messageTemplate struct {
+	title *template.Template
+	body  *template.Template
+}
+
+var templates = map[string]messageTemplate{}
+
+// RegisterTemplate 注册事件模板，模板变量来自 Event.Data
+func RegisterTemplate(eventType, title, body string) {
+	templates[eventType] = messageTemplate{
+		title: template.Must(template.New(eventType + ".title").Parse(title)),
+		body:  template.Must(template.New(eventType + ".body").Parse(body)),
+	}
+}
+
+func init() {
+	RegisterTemplate(EventMonitorAlert,
+		`【{{.Severity}}】{{.CompanyName}} 触发监控告警：{{.RuleName}}`,
+		`### 企业监控告警
+
+- **企业**：{{.CompanyName}}
+- **规则**：{{.RuleName}}
+- **严重程度**：{{.Severity}}
+- **触发时间**：{{.TriggeredAt}}
+
+> {{.Message}}
+
+请登录AI职场管家查看详情，并评估对您职业发展的影响。`)
+
+	RegisterTemplate(EventTest,
+		`AI职场管家通知测试`,
+		`这是一条测试通知，说明您的 **{{.Channel}}** 通知渠道配置正常。`)
+}
+
+// Render 使用事件对应的模板渲染通知内容
+func Render(event *Event) (*Message, error) {
+	tmpl, ok := templates[event.Type]
+	if !ok {
+		return nil, fmt.Errorf("未注册的通知模板: %s", event.Type)
+	}
+
+	var title, body bytes.Buffer
+	if err := tmpl.title.Execute(&title, event.Data); err != nil {
+		return nil, fmt.Errorf("渲染通知标题失败: %v", err)
+	}
+	if err := tmpl.body.Execute(&body, event.Data); err != nil {
+		return nil, fmt.Errorf("渲染通知正文失败: %v", err)
+	}
+	return &Message{Title: title.String(), Body: body.String()}, nil
+}
@@ -0,0 +1,60 @@
+package pdfgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Font PDF 文档使用的字体
+type Font interface {
+	// Name 字体名称
+	Name() string
+	// Embedded 字体是否嵌入文档
+	Embedded() bool
+	// Width 字符宽度，单位为字号的千分之一
+	Width(r rune) float64
+
+	encode(s string) string
+	objects(w *writer, id int) error
+}
+
+// builtinCJK 阅读器内置的 Adobe 简体中文字体，不嵌入文档，依赖阅读器安装亚洲语言字体包
+type builtinCJK struct{}
+
+// BuiltinCJK 返回 STSong-Light 字体，用于没有可嵌入的中文字体时
+func BuiltinCJK() Font {
+	return builtinCJK{}
+}
+
+func (builtinCJK) Name() string   { return "STSong-Light" }
+func (builtinCJK) Embedded() bool { return false }
+
+// Width 字体中的英文字符为半角，其余按全角计算
+func (builtinCJK) Width(r rune) float64 {
+	if r < 0x80 {
+		return 500
+	}
+	return 1000
+}
+
+// encode UniGB-UCS2-H 编码，即 UCS-2 大端序，超出基本平面的字符替换为问号
+func (builtinCJK) encode(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r > 0xFFFF || utf16.IsSurrogate(r) {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	return sb.String()
+}
+
+func (builtinCJK) objects(w *writer, id int) error {
+	descriptor := w.add("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	cid := w.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor %d 0 R /DW 1000 /W [1 95 500] >>", descriptor))
+	w.set(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [%d 0 R] >>", cid))
+	return nil
+}
@@ -0,0 +1,215 @@
+// Package pdfgen 纯 Go 生成简单的 PDF 文档
+//
+// 只支持生成简历等排版简单的文档所需的功能：A4 页面、单一字体的文字（可加粗和着色）、
+// 直线和填充矩形。中文字体使用 TrueType 字体子集嵌入（Identity-H 编码，附 ToUnicode
+// 映射以便复制和搜索）；未配置字体时使用阅读器内置的 STSong-Light，不嵌入字体。
+package pdfgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 页面尺寸，单位为点（1/72英寸）
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color RGB 颜色，各分量取值 0-1
+type Color [3]float64
+
+// 常用颜色
+var (
+	Black = Color{0, 0, 0}
+	Gray  = Color{0.4, 0.4, 0.4}
+)
+
+// TextStyle 文字样式
+type TextStyle struct {
+	Size  float64
+	Bold  bool // 用描边模拟加粗，不需要单独的粗体字体
+	Color Color
+}
+
+// Document PDF 文档
+type Document struct {
+	font   Font
+	pages  []*Page
+	title  string
+	author string
+}
+
+// Page 一页内容
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// New 创建使用指定字体的文档
+func New(font Font) *Document {
+	return &Document{font: font}
+}
+
+// SetInfo 设置文档标题和作者
+func (d *Document) SetInfo(title, author string) {
+	d.title, d.author = title, author
+}
+
+// Font 文档使用的字体
+func (d *Document) Font() Font {
+	return d.font
+}
+
+// AddPage 添加一页
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Pages 已添加的页面
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// TextWidth 文字按指定字号排版后的宽度
+func (d *Document) TextWidth(s string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += d.font.Width(r)
+	}
+	return w * size / 1000
+}
+
+// Text 在 (x, y) 处绘制一行文字，y 为基线位置，原点在页面左下角
+func (p *Page) Text(x, y float64, s string, style TextStyle) {
+	if s == "" {
+		return
+	}
+	c := style.Color
+	fmt.Fprintf(&p.content, "q %.3f %.3f %.3f rg ", c[0], c[1], c[2])
+	if style.Bold {
+		fmt.Fprintf(&p.content, "%.3f %.3f %.3f RG %.2f w 2 Tr ", c[0], c[1], c[2], style.Size*0.03)
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %.2f Tf %.2f %.2f Td <%s> Tj ET Q\n", style.Size, x, y, p.doc.font.encode(s))
+}
+
+// Line 绘制直线
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "q %.2f w %.3f %.3f %.3f RG %.2f %.2f m %.2f %.2f l S Q\n", width, c[0], c[1], c[2], x1, y1, x2, y2)
+}
+
+// Rect 绘制填充矩形，(x, y) 为左下角
+func (p *Page) Rect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(&p.content, "q %.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f Q\n", c[0], c[1], c[2], x, y, w, h)
+}
+
+// Bytes 生成 PDF 文件内容
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	w := &writer{}
+	catalog := w.reserve()
+	pages := w.reserve()
+	font := w.reserve()
+
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		pageID := w.reserve()
+		contentID := w.stream(nil, p.content.Bytes())
+		w.set(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, PageWidth, PageHeight, font, contentID))
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+	}
+	w.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	w.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+	if err := d.font.objects(w, font); err != nil {
+		return nil, err
+	}
+
+	info := fmt.Sprintf("<< /Producer (ai-career-buddy) /CreationDate (D:%s)", time.Now().UTC().Format("20060102150405Z"))
+	if d.title != "" {
+		info += " /Title " + textString(d.title)
+	}
+	if d.author != "" {
+		info += " /Author " + textString(d.author)
+	}
+	infoID := w.add(info + " >>")
+	return w.bytes(catalog, infoID), nil
+}
+
+// textString 文档信息中的文字使用带 BOM 的 UTF-16BE 编码
+func textString(s string) string {
+	var sb strings.Builder
+	sb.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&sb, "%04X", u)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+// writer 按对象号收集对象并生成交叉引用表
+type writer struct {
+	objects [][]byte
+}
+
+func (w *writer) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+func (w *writer) set(id int, body string) {
+	w.objects[id-1] = []byte(body)
+}
+
+func (w *writer) add(body string) int {
+	id := w.reserve()
+	w.set(id, body)
+	return id
+}
+
+// stream 添加 Flate 压缩的流对象，extra 为字典中的其他条目
+func (w *writer) stream(extra []string, data []byte) int {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+
+	id := w.reserve()
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< /Length %d /Filter /FlateDecode", buf.Len())
+	for _, e := range extra {
+		obj.WriteString(" " + e)
+	}
+	obj.WriteString(" >>\nstream\n")
+	obj.Write(buf.Bytes())
+	obj.WriteString("\nendstream")
+	w.objects[id-1] = obj.Bytes()
+	return id
+}
+
+func (w *writer) bytes(root, info int) []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(w.objects))
+	for i, body := range w.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.objects)+1, root, info, xref)
+	return out.Bytes()
+}
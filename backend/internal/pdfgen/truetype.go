@@ -0,0 +1,525 @@
+package pdfgen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// 子集字体需要的表，PDF 中的 TrueType 字体不需要 cmap
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// TrueTypeFont 可嵌入的 TrueType 字体，只嵌入文档中用到的字形
+type TrueTypeFont struct {
+	tables     map[string][]byte
+	psName     string
+	unitsPerEm float64
+	bbox       [4]int16
+	ascent     int16
+	descent    int16
+	capHeight  int16
+	numGlyphs  int
+	advances   []uint16
+	cmap       map[rune]uint16
+	longLoca   bool
+
+	mu   sync.Mutex
+	used map[uint16]rune // 文档中用到的字形及其对应的字符
+}
+
+// ParseTrueType 解析 TTF 字体或 TTC 字体集合中的第一个字体。只支持 TrueType 轮廓（glyf），
+// 不支持 CFF 轮廓的 OTF 字体
+func ParseTrueType(data []byte) (*TrueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("字体文件过短")
+	}
+	offset := 0
+	if string(data[:4]) == "ttcf" {
+		if len(data) < 16 || binary.BigEndian.Uint32(data[8:]) == 0 {
+			return nil, errors.New("无效的TTC字体集合")
+		}
+		offset = int(binary.BigEndian.Uint32(data[12:]))
+	}
+	if offset+12 > len(data) {
+		return nil, errors.New("无效的字体文件")
+	}
+	switch binary.BigEndian.Uint32(data[offset:]) {
+	case 0x00010000, 0x74727565: // 1.0 或 'true'
+	case 0x4F54544F: // 'OTTO'
+		return nil, errors.New("不支持CFF轮廓的OTF字体，请使用TrueType字体")
+	default:
+		return nil, errors.New("无法识别的字体格式")
+	}
+
+	f := &TrueTypeFont{tables: map[string][]byte{}, used: map[uint16]rune{}}
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	for i := 0; i < numTables; i++ {
+		rec := offset + 12 + i*16
+		if rec+16 > len(data) {
+			return nil, errors.New("字体表目录不完整")
+		}
+		tag := string(data[rec : rec+4])
+		start := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("字体表 %s 越界", tag)
+		}
+		f.tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "loca", "glyf", "cmap"} {
+		if f.tables[tag] == nil {
+			return nil, fmt.Errorf("字体缺少 %s 表", strings.TrimSpace(tag))
+		}
+	}
+	if err := f.parseMetrics(); err != nil {
+		return nil, err
+	}
+	if err := f.parseCmap(); err != nil {
+		return nil, err
+	}
+	f.psName = f.parseName()
+	return f, nil
+}
+
+func (f *TrueTypeFont) parseMetrics() error {
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return errors.New("字体头信息不完整")
+	}
+	f.unitsPerEm = float64(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return errors.New("字体 unitsPerEm 无效")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int16(binary.BigEndian.Uint16(head[36+i*2:]))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = int16(binary.BigEndian.Uint16(hhea[4:]))
+	f.descent = int16(binary.BigEndian.Uint16(hhea[6:]))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int16(binary.BigEndian.Uint16(os2[88:]))
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < numMetrics*4 {
+		return errors.New("字体 hmtx 表不完整")
+	}
+	f.advances = make([]uint16, f.numGlyphs)
+	for g := 0; g < f.numGlyphs; g++ {
+		if g < numMetrics {
+			f.advances[g] = binary.BigEndian.Uint16(hmtx[g*4:])
+		} else {
+			f.advances[g] = f.advances[numMetrics-1]
+		}
+	}
+	return nil
+}
+
+// parseCmap 读取 Unicode 字符到字形的映射，优先使用支持全部平面的 format 12
+func (f *TrueTypeFont) parseCmap() error {
+	cmap := f.tables["cmap"]
+	if len(cmap) < 4 {
+		return errors.New("字体 cmap 表不完整")
+	}
+	var best []byte
+	bestScore := 0
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n && 4+i*8+8 <= len(cmap); i++ {
+		rec := cmap[4+i*8:]
+		platform, encoding := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[2:])
+		off := int(binary.BigEndian.Uint32(rec[4:]))
+		if off+2 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		format := binary.BigEndian.Uint16(sub)
+		score := 0
+		switch {
+		case format == 12 && (platform == 0 || (platform == 3 && encoding == 10)):
+			score = 3
+		case format == 4 && platform == 3 && encoding == 1:
+			score = 2
+		case format == 4 && platform == 0:
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = sub, score
+		}
+	}
+	if best == nil {
+		return errors.New("字体没有Unicode字符映射")
+	}
+
+	f.cmap = map[rune]uint16{}
+	if binary.BigEndian.Uint16(best) == 12 {
+		if len(best) < 16 {
+			return errors.New("cmap format 12 不完整")
+		}
+		groups := int(binary.BigEndian.Uint32(best[12:]))
+		for i := 0; i < groups && 16+i*12+12 <= len(best); i++ {
+			g := best[16+i*12:]
+			start, end, gid := binary.BigEndian.Uint32(g), binary.BigEndian.Uint32(g[4:]), binary.BigEndian.Uint32(g[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				f.cmap[rune(c)] = uint16(gid + c - start)
+			}
+		}
+		return nil
+	}
+
+	if len(best) < 14 {
+		return errors.New("cmap format 4 不完整")
+	}
+	segX2 := int(binary.BigEndian.Uint16(best[6:]))
+	ends, starts := 14, 16+segX2
+	deltas, rangeOffsets := starts+segX2, starts+2*segX2
+	if rangeOffsets+segX2 > len(best) {
+		return errors.New("cmap format 4 不完整")
+	}
+	for s := 0; s < segX2/2; s++ {
+		end := int(binary.BigEndian.Uint16(best[ends+s*2:]))
+		start := int(binary.BigEndian.Uint16(best[starts+s*2:]))
+		delta := int(binary.BigEndian.Uint16(best[deltas+s*2:]))
+		ro := int(binary.BigEndian.Uint16(best[rangeOffsets+s*2:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var gid int
+			if ro == 0 {
+				gid = (c + delta) & 0xFFFF
+			} else {
+				pos := rangeOffsets + s*2 + ro + (c-start)*2
+				if pos+2 > len(best) {
+					continue
+				}
+				if gid = int(binary.BigEndian.Uint16(best[pos:])); gid != 0 {
+					gid = (gid + delta) & 0xFFFF
+				}
+			}
+			if gid != 0 {
+				f.cmap[rune(c)] = uint16(gid)
+			}
+		}
+	}
+	return nil
+}
+
+// parseName 读取 PostScript 名称，用于 PDF 中的字体名
+func (f *TrueTypeFont) parseName() string {
+	name := f.tables["name"]
+	clean := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r < '!' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if len(name) >= 6 {
+		count := int(binary.BigEndian.Uint16(name[2:]))
+		storage := int(binary.BigEndian.Uint16(name[4:]))
+		for i := 0; i < count && 6+i*12+12 <= len(name); i++ {
+			rec := name[6+i*12:]
+			platform, nameID := binary.BigEndian.Uint16(rec), binary.BigEndian.Uint16(rec[6:])
+			length, off := int(binary.BigEndian.Uint16(rec[8:])), int(binary.BigEndian.Uint16(rec[10:]))
+			if nameID != 6 || storage+off+length > len(name) {
+				continue
+			}
+			raw := name[storage+off : storage+off+length]
+			var s string
+			if platform == 3 || platform == 0 {
+				u := make([]uint16, len(raw)/2)
+				for j := range u {
+					u[j] = binary.BigEndian.Uint16(raw[j*2:])
+				}
+				s = string(utf16.Decode(u))
+			} else {
+				s = string(raw)
+			}
+			if s = clean(s); s != "" {
+				return s
+			}
+		}
+	}
+	return "EmbeddedFont"
+}
+
+// Name 字体的 PostScript 名称
+func (f *TrueTypeFont) Name() string { return f.psName }
+
+// Embedded 字体子集嵌入文档
+func (f *TrueTypeFont) Embedded() bool { return true }
+
+// Has 字体中是否有该字符
+func (f *TrueTypeFont) Has(r rune) bool {
+	_, ok := f.cmap[r]
+	return ok
+}
+
+// Width 字符宽度，字体中没有的字符按 .notdef 字形计算
+func (f *TrueTypeFont) Width(r rune) float64 {
+	return f.glyphWidth(int(f.cmap[r]))
+}
+
+// glyphWidth 字形宽度（千分之一字号），字形编号超出 hmtx 范围时使用 .notdef 的宽度
+func (f *TrueTypeFont) glyphWidth(gid int) float64 {
+	if gid >= len(f.advances) {
+		gid = 0
+	}
+	if len(f.advances) == 0 {
+		return 1000
+	}
+	return float64(f.advances[gid]) * 1000 / f.unitsPerEm
+}
+
+// encode Identity-H 编码，每个字符写为两字节的字形编号，同时记录用到的字形
+func (f *TrueTypeFont) encode(s string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sb strings.Builder
+	for _, r := range s {
+		gid := f.cmap[r]
+		if gid != 0 {
+			f.used[gid] = r
+		}
+		fmt.Fprintf(&sb, "%04X", gid)
+	}
+	return sb.String()
+}
+
+// NewDocumentFont 为单个文档创建字体实例：共享解析结果，独立记录用到的字形。
+// 解析后的字体可以缓存，同时生成的多个文档互不影响
+func (f *TrueTypeFont) NewDocumentFont() Font {
+	return &TrueTypeFont{
+		tables: f.tables, psName: f.psName, unitsPerEm: f.unitsPerEm, bbox: f.bbox,
+		ascent: f.ascent, descent: f.descent, capHeight: f.capHeight, numGlyphs: f.numGlyphs,
+		advances: f.advances, cmap: f.cmap, longLoca: f.longLoca, used: map[uint16]rune{},
+	}
+}
+
+func (f *TrueTypeFont) objects(w *writer, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	gids := make([]int, 0, len(f.used))
+	for g := range f.used {
+		gids = append(gids, int(g))
+	}
+	sort.Ints(gids)
+
+	font, err := f.subset()
+	if err != nil {
+		return err
+	}
+	// 子集字体名需要六个大写字母的前缀，按用到的字形生成，相同内容得到相同名称
+	var h uint32 = 2166136261
+	for _, g := range gids {
+		h = (h ^ uint32(g)) * 16777619
+	}
+	var tag [6]byte
+	for i := range tag {
+		tag[i] = byte('A' + h%26)
+		h /= 26
+	}
+	baseFont := string(tag[:]) + "+" + f.psName
+
+	scale := func(v int16) int { return int(float64(v) * 1000 / f.unitsPerEm) }
+	fontFile := w.stream([]string{fmt.Sprintf("/Length1 %d", len(font))}, font)
+	descriptor := w.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+		"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		baseFont, scale(f.bbox[0]), scale(f.bbox[1]), scale(f.bbox[2]), scale(f.bbox[3]),
+		scale(f.ascent), scale(f.descent), scale(f.capHeight), fontFile))
+
+	var widths strings.Builder
+	for _, g := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", g, int(f.glyphWidth(g)))
+	}
+	cid := w.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R "+
+		"/DW %d /W [%s] /CIDToGIDMap /Identity >>", baseFont, descriptor, int(f.glyphWidth(0)), widths.String()))
+	toUnicode := w.stream(nil, f.toUnicode(gids))
+	w.set(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		baseFont, cid, toUnicode))
+	return nil
+}
+
+// toUnicode 字形到字符的映射，用于从 PDF 中复制和搜索文字
+func (f *TrueTypeFont) toUnicode(gids []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		chunk := gids[i:min(i+100, len(gids))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, g := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune{f.used[uint16(g)]}) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// glyph 返回字形数据
+func (f *TrueTypeFont) glyph(gid int) []byte {
+	loca, glyf := f.tables["loca"], f.tables["glyf"]
+	var start, end int
+	if f.longLoca {
+		if (gid+1)*4+4 > len(loca) {
+			return nil
+		}
+		start, end = int(binary.BigEndian.Uint32(loca[gid*4:])), int(binary.BigEndian.Uint32(loca[gid*4+4:]))
+	} else {
+		if (gid+1)*2+2 > len(loca) {
+			return nil
+		}
+		start, end = int(binary.BigEndian.Uint16(loca[gid*2:]))*2, int(binary.BigEndian.Uint16(loca[gid*2+2:]))*2
+	}
+	if start >= end || end > len(glyf) {
+		return nil
+	}
+	return glyf[start:end]
+}
+
+// components 组合字形引用的其他字形
+func components(glyph []byte) []int {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+	const (
+		argWords     = 0x0001
+		hasScale     = 0x0008
+		moreComps    = 0x0020
+		hasXYScale   = 0x0040
+		hasTwoByTwo  = 0x0080
+		maxComponent = 64
+	)
+	var refs []int
+	pos := 10
+	for len(refs) < maxComponent && pos+4 <= len(glyph) {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		refs = append(refs, int(binary.BigEndian.Uint16(glyph[pos+2:])))
+		pos += 4
+		if flags&argWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+		switch {
+		case flags&hasScale != 0:
+			pos += 2
+		case flags&hasXYScale != 0:
+			pos += 4
+		case flags&hasTwoByTwo != 0:
+			pos += 8
+		}
+		if flags&moreComps == 0 {
+			break
+		}
+	}
+	return refs
+}
+
+// subset 生成只包含用到字形的字体，保留原字形编号，未用到的字形为空
+func (f *TrueTypeFont) subset() ([]byte, error) {
+	keep := map[int]bool{0: true}
+	queue := []int{0}
+	for g := range f.used {
+		queue = append(queue, int(g))
+	}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		keep[g] = true
+		for _, c := range components(f.glyph(g)) {
+			if c < f.numGlyphs && !keep[c] {
+				keep[c] = true
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	var glyf bytes.Buffer
+	loca := make([]byte, (f.numGlyphs+1)*4)
+	for g := 0; g < f.numGlyphs; g++ {
+		binary.BigEndian.PutUint32(loca[g*4:], uint32(glyf.Len()))
+		if keep[g] {
+			glyf.Write(f.glyph(g))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(loca[f.numGlyphs*4:], uint32(glyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment，最后计算
+	binary.BigEndian.PutUint16(head[50:], 1) // indexToLocFormat：长偏移
+
+	tables := map[string][]byte{"glyf": glyf.Bytes(), "loca": loca, "head": head}
+	var tags []string
+	for _, tag := range subsetTables {
+		if tables[tag] == nil && f.tables[tag] != nil {
+			tables[tag] = f.tables[tag]
+		}
+		if tables[tag] != nil {
+			tags = append(tags, tag)
+		}
+	}
+	return buildFont(tags, tables), nil
+}
+
+// buildFont 按表名顺序写出 TrueType 字体文件
+func buildFont(tags []string, tables map[string][]byte) []byte {
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	var out bytes.Buffer
+	binary.Write(&out, binary.BigEndian, []uint32{0x00010000})
+	binary.Write(&out, binary.BigEndian, []uint16{uint16(n), uint16(searchRange), uint16(entrySelector), uint16(n*16 - searchRange)})
+	offset := 12 + n*16
+	for _, tag := range tags {
+		data := tables[tag]
+		out.WriteString(tag)
+		binary.Write(&out, binary.BigEndian, []uint32{checksum(data), uint32(offset), uint32(len(data))})
+		offset += (len(data) + 3) &^ 3
+	}
+	headOffset := 0
+	for _, tag := range tags {
+		if tag == "head" {
+			headOffset = out.Len()
+		}
+		out.Write(tables[tag])
+		for out.Len()%4 != 0 {
+			out.WriteByte(0)
+		}
+	}
+	font := out.Bytes()
+	binary.BigEndian.PutUint32(font[headOffset+8:], 0xB1B0AFBA-checksum(font))
+	return font
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,207 @@
+// Package prompts 管理提示词模板
+//
+// 模板使用 text/template 语法。内置默认模板随程序发布（defaults 目录），可被 PROMPT_DIR
+// 中的同名文件覆盖；数据库中的模板按版本保存，可针对会话类别和模型配置变体，
+// 启用的数据库版本优先于默认模板，修改后无需重新部署。
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 模板键
+const (
+	KeyChatSystem        = "chat.system"
+	KeyChatCaseGuidance  = "chat.case_guidance"
+	KeyChatSummary       = "chat.summary"
+	KeyIntentClassify    = "intent.classify"
+	KeyHistoryEnrich     = "history.enrich"
+	KeyExtractResume     = "extract.resume"
+	KeyExtractContract   = "extract.contract"
+	KeyExtractOffer      = "extract.offer"
+	KeyExtractEmployment = "extract.employment"
+	KeyExtractGeneral    = "extract.general"
+	KeyDiffCommentary    = "diff.commentary"
+	KeyResumeTailor      = "resume.tailor"
+	KeyDraftCompose      = "draft.compose"
+)
+
+// 模板来源
+const (
+	SourceBuiltin = "builtin"
+	SourceFile    = "file"
+	SourceDB      = "db"
+)
+
+// SystemVars 对话系统提示词的模板变量
+type SystemVars struct {
+	ModelID       string
+	Category      string // 问题类别: career, offer, contract, monitor, general
+	SubIntent     string // 子意图，如 salary_negotiation
+	DeepThinking  bool
+	NetworkSearch bool
+}
+
+// CaseGuidanceVars 案例专项指导的模板变量
+type CaseGuidanceVars struct {
+	Keyword string
+}
+
+// SummaryVars 会话摘要的模板变量
+type SummaryVars struct {
+	Previous   string // 已有摘要，首次生成时为空
+	Transcript string // 需要并入摘要的对话
+}
+
+// IntentVars 意图识别的模板变量
+type IntentVars struct {
+	Input    string
+	Hint     string // 会话入口对应的类别，仅供参考
+	Taxonomy string // 可选的类别和子意图说明
+}
+
+// EnrichVars 咨询记录整理的模板变量
+type EnrichVars struct {
+	Category     string
+	Question     string
+	Answer       string
+	ExistingTags string // 用户已有的常用标签，用于复用同义标签
+}
+
+// ExtractVars 文档信息提取的模板变量
+type ExtractVars struct {
+	Content      string
+	DocumentType string
+	FileName     string
+}
+
+// DiffCommentaryVars 合同/Offer差异评价的模板变量
+type DiffCommentaryVars struct {
+	DocumentType string // contract, offer
+	Changes      string // 带编号的变化列表
+}
+
+// ResumeTailorVars 简历针对目标岗位优化的模板变量
+type ResumeTailorVars struct {
+	TargetPosition string
+	JobDescription string
+	Resume         string // 带编号的简历内容
+}
+
+// DraftComposeVars 求职沟通文书撰写的模板变量
+type DraftComposeVars struct {
+	Kind      string // counter_offer, offer_acceptance, offer_rejection, resignation, cover_letter
+	KindTitle string
+	Tone      string // 语气及说明
+	Length    string // 篇幅及说明
+	Facts     string // 已知信息列表
+	Notes     string // 用户补充说明
+}
+
+// Definition 一个可配置的模板
+type Definition struct {
+	Key         string   `json:"key"`
+	Description string   `json:"description"`
+	Variables   []string `json:"variables"`
+	sample      interface{}
+}
+
+var definitions = []Definition{
+	{Key: KeyChatSystem, Description: "对话系统提示词", Variables: []string{"ModelID", "Category", "SubIntent", "DeepThinking", "NetworkSearch"},
+		sample: SystemVars{ModelID: "bailian/qwen-plus", Category: "career", SubIntent: "transition", DeepThinking: true, NetworkSearch: true}},
+	{Key: KeyChatCaseGuidance, Description: "案例问题的专项指导，追加在系统提示词之后", Variables: []string{"Keyword"},
+		sample: CaseGuidanceVars{Keyword: "薪资谈判"}},
+	{Key: KeyChatSummary, Description: "长会话滚动摘要，较早的对话合并为摘要后作为上下文", Variables: []string{"Previous", "Transcript"},
+		sample: SummaryVars{Previous: "- 用户有5年Go开发经验，计划跳槽", Transcript: "用户: 期望涨薪多少合适？\n助手: 一般建议20%-30%。"}},
+	{Key: KeyIntentClassify, Description: "问题意图识别，输出类别、子意图和置信度", Variables: []string{"Input", "Hint", "Taxonomy"},
+		sample: IntentVars{Input: "HR说薪资只能给到25k，我该怎么谈？", Hint: "offer", Taxonomy: "- offer（Offer分析）：salary_negotiation（薪资谈判）"}},
+	{Key: KeyHistoryEnrich, Description: "为咨询记录生成标题、标签、情绪和关键实体", Variables: []string{"Category", "Question", "Answer", "ExistingTags"},
+		sample: EnrichVars{Category: "offer", Question: "字节给了35k，腾讯给了32k，怎么选？", Answer: "可以从薪资结构、团队和发展空间比较……", ExistingTags: "薪资谈判、offer选择"}},
+	{Key: KeyExtractResume, Description: "简历信息提取", Variables: []string{"Content", "DocumentType", "FileName"},
+		sample: ExtractVars{Content: "张三，5年Go开发经验", DocumentType: "resume", FileName: "resume.md"}},
+	{Key: KeyExtractContract, Description: "劳动合同信息提取", Variables: []string{"Content", "DocumentType", "FileName"},
+		sample: ExtractVars{Content: "甲方：某科技有限公司", DocumentType: "contract", FileName: "contract.md"}},
+	{Key: KeyExtractOffer, Description: "Offer信息提取", Variables: []string{"Content", "DocumentType", "FileName"},
+		sample: ExtractVars{Content: "月薪30000元，14薪", DocumentType: "offer", FileName: "offer.md"}},
+	{Key: KeyExtractEmployment, Description: "在职证明信息提取", Variables: []string{"Content", "DocumentType", "FileName"},
+		sample: ExtractVars{Content: "兹证明张三自2020年起在我司任职", DocumentType: "employment", FileName: "employment.md"}},
+	{Key: KeyExtractGeneral, Description: "通用文档信息提取", Variables: []string{"Content", "DocumentType", "FileName"},
+		sample: ExtractVars{Content: "文档内容", DocumentType: "other", FileName: "notes.md"}},
+	{Key: KeyDiffCommentary, Description: "逐条评价合同或Offer修订对员工是否有利", Variables: []string{"DocumentType", "Changes"},
+		sample: DiffCommentaryVars{DocumentType: "contract", Changes: "T1 合同薪资：25k → 28k（年薪中值 +12.0%）\nC2 [新增] 第九条 竞业限制\n  新文：离职后两年内不得入职竞争对手"}},
+	{Key: KeyResumeTailor, Description: "针对目标岗位逐条改写简历要点，给出关键词和章节顺序", Variables: []string{"TargetPosition", "JobDescription", "Resume"},
+		sample: ResumeTailorVars{TargetPosition: "高级Go开发工程师", JobDescription: "熟悉微服务架构，有高并发系统经验", Resume: "[E1] 某科技 · 后端工程师（2020-至今）\n  E1.1 负责订单系统开发"}},
+	{Key: KeyDraftCompose, Description: "撰写还价邮件、接受/婉拒Offer、辞职信、求职信等文书", Variables: []string{"Kind", "KindTitle", "Tone", "Length", "Facts", "Notes"},
+		sample: DraftComposeVars{Kind: "counter_offer", KindTitle: "薪资还价", Tone: "坚定：表达明确、有理有据", Length: "适中：三到四段", Facts: "- 目标公司：某科技\n- Offer薪资：30k*15\n- 期望薪资：月薪34k", Notes: "希望入职时间推迟两周"}},
+}
+
+// Definitions 返回所有可配置的模板
+func Definitions() []Definition {
+	return definitions
+}
+
+// Lookup 按模板键查找模板定义
+func Lookup(key string) (*Definition, bool) {
+	for i := range definitions {
+		if definitions[i].Key == key {
+			return &definitions[i], true
+		}
+	}
+	return nil, false
+}
+
+// Selector 选择模板变体的条件
+type Selector struct {
+	Category string
+	ModelID  string
+	// Pinned 固定使用的模板版本（模板键 -> 版本ID），不论其是否启用，用于实验分组
+	Pinned map[string]uint
+}
+
+// Ref 一次渲染所用的模板版本
+type Ref struct {
+	Key        string `json:"key"`
+	Source     string `json:"source"` // builtin, file, db
+	TemplateID uint   `json:"templateId,omitempty"`
+	Version    int    `json:"version"`
+	Category   string `json:"category,omitempty"`
+	ModelID    string `json:"modelId,omitempty"`
+}
+
+// String 返回版本标识，如 chat.system@builtin、chat.system[offer|bailian/*]@v3
+func (r Ref) String() string {
+	var sb strings.Builder
+	sb.WriteString(r.Key)
+	if r.Category != "" || r.ModelID != "" {
+		var variant []string
+		for _, v := range []string{r.Category, r.ModelID} {
+			if v != "" {
+				variant = append(variant, v)
+			}
+		}
+		sb.WriteString("[" + strings.Join(variant, "|") + "]")
+	}
+	if r.Source == SourceDB {
+		sb.WriteString(fmt.Sprintf("@v%d", r.Version))
+	} else {
+		sb.WriteString("@" + r.Source)
+	}
+	return sb.String()
+}
+
+// Refs 组合生成一段提示词所用的多个模板版本
+type Refs []Ref
+
+func (rs Refs) String() string {
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Render 使用默认注册表渲染模板
+func Render(key string, sel Selector, data interface{}) (string, Ref, error) {
+	return Default().Render(key, sel, data)
+}
@@ -0,0 +1,248 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+)
+
+//go:embed defaults/*.tmpl
+var builtinFS embed.FS
+
+var funcs = template.FuncMap{
+	"contains":  strings.Contains,
+	"hasPrefix": strings.HasPrefix,
+	"lower":     strings.ToLower,
+}
+
+// fallback 默认模板（内置或 PROMPT_DIR 中的文件）
+type fallback struct {
+	source  string
+	content string
+}
+
+// Registry 提示词模板注册表，缓存数据库中启用的模板版本
+type Registry struct {
+	defaults map[string]fallback
+	ttl      time.Duration
+
+	mu       sync.RWMutex
+	active   map[string][]models.PromptTemplate // 模板键 -> 启用的各变体
+	loadedAt time.Time
+	parsed   map[string]*template.Template // 缓存键 -> 解析后的模板
+}
+
+var (
+	defaultRegistry *Registry
+	defaultOnce     sync.Once
+)
+
+// Default 返回按配置初始化的全局注册表
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		defaultRegistry = NewRegistry(config.C.PromptDir, config.C.PromptCacheTTL)
+	})
+	return defaultRegistry
+}
+
+// NewRegistry 加载内置模板，dir 非空时用其中的 <模板键>.tmpl 覆盖内置模板
+func NewRegistry(dir string, ttl time.Duration) *Registry {
+	r := &Registry{
+		defaults: map[string]fallback{},
+		ttl:      ttl,
+		parsed:   map[string]*template.Template{},
+	}
+	for _, def := range definitions {
+		data, err := builtinFS.ReadFile("defaults/" + def.Key + ".tmpl")
+		if err != nil {
+			panic(fmt.Sprintf("缺少内置提示词模板: %s", def.Key))
+		}
+		r.defaults[def.Key] = fallback{source: SourceBuiltin, content: string(data)}
+
+		if dir == "" {
+			continue
+		}
+		data, err = os.ReadFile(filepath.Join(dir, def.Key+".tmpl"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Warn("读取提示词模板文件失败: Key=%s, 错误=%v", def.Key, err)
+			}
+			continue
+		}
+		if _, err := parse(def.Key, string(data)); err != nil {
+			logger.Warn("提示词模板文件解析失败，使用内置模板: Key=%s, 错误=%v", def.Key, err)
+			continue
+		}
+		r.defaults[def.Key] = fallback{source: SourceFile, content: string(data)}
+		logger.Info("使用提示词模板文件: Key=%s", def.Key)
+	}
+	return r
+}
+
+// DefaultContent 返回模板键的默认模板内容及来源
+func (r *Registry) DefaultContent(key string) (string, string, bool) {
+	f, ok := r.defaults[key]
+	return f.content, f.source, ok
+}
+
+// Render 渲染与条件最匹配的模板版本；数据库版本渲染失败时退回到默认模板
+func (r *Registry) Render(key string, sel Selector, data interface{}) (string, Ref, error) {
+	t := r.pinned(key, sel)
+	if t == nil {
+		t = r.match(key, sel)
+	}
+	if t != nil {
+		ref := Ref{Key: key, Source: SourceDB, TemplateID: t.ID, Version: t.Version, Category: t.Category, ModelID: t.ModelID}
+		text, err := r.execute(fmt.Sprintf("db:%d", t.ID), key, t.Content, data)
+		if err == nil {
+			return text, ref, nil
+		}
+		logger.Warn("提示词模板渲染失败，使用默认模板: %s, 错误=%v", ref, err)
+	}
+
+	f, ok := r.defaults[key]
+	if !ok {
+		return "", Ref{}, fmt.Errorf("未知的提示词模板: %s", key)
+	}
+	text, err := r.execute(f.source+":"+key, key, f.content, data)
+	return text, Ref{Key: key, Source: f.source}, err
+}
+
+// Invalidate 清空缓存，修改数据库中的模板后调用
+func (r *Registry) Invalidate() {
+	r.mu.Lock()
+	r.active = nil
+	r.mu.Unlock()
+}
+
+// pinned 加载条件中固定的模板版本，版本不存在或不属于该模板键时返回 nil
+func (r *Registry) pinned(key string, sel Selector) *models.PromptTemplate {
+	id, ok := sel.Pinned[key]
+	if !ok || db.Conn == nil {
+		return nil
+	}
+	var t models.PromptTemplate
+	if err := db.Conn.Where("id = ? AND template_key = ?", id, key).First(&t).Error; err != nil {
+		logger.Warn("固定的提示词模板版本不可用: Key=%s, ID=%d, 错误=%v", key, id, err)
+		return nil
+	}
+	return &t
+}
+
+// match 选出与条件最匹配的启用版本：模型+类别 > 模型 > 类别 > 通用，模型精确匹配优先于前缀匹配
+func (r *Registry) match(key string, sel Selector) *models.PromptTemplate {
+	var best *models.PromptTemplate
+	bestScore := -1
+	variants := r.variants(key)
+	for i := range variants {
+		t := &variants[i]
+		score, ok := matchScore(t, sel)
+		if ok && score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+func matchScore(t *models.PromptTemplate, sel Selector) (int, bool) {
+	score := 0
+	switch {
+	case t.ModelID == "":
+	case t.ModelID == sel.ModelID:
+		score += 4000
+	case strings.HasSuffix(t.ModelID, "*") && strings.HasPrefix(sel.ModelID, strings.TrimSuffix(t.ModelID, "*")):
+		score += 2000 + len(t.ModelID) // 前缀越长越具体
+	default:
+		return 0, false
+	}
+	switch t.Category {
+	case "":
+	case sel.Category:
+		score += 1000
+	default:
+		return 0, false
+	}
+	return score, true
+}
+
+// variants 返回模板键下启用的各变体，缓存过期后从数据库重新加载
+func (r *Registry) variants(key string) []models.PromptTemplate {
+	r.mu.RLock()
+	if r.active != nil && time.Since(r.loadedAt) < r.ttl {
+		defer r.mu.RUnlock()
+		return r.active[key]
+	}
+	r.mu.RUnlock()
+
+	if db.Conn == nil {
+		return nil
+	}
+	var rows []models.PromptTemplate
+	if err := db.Conn.Where("is_active = ?", true).Find(&rows).Error; err != nil {
+		logger.Error("加载提示词模板失败: %v", err)
+		return nil
+	}
+	active := map[string][]models.PromptTemplate{}
+	for _, t := range rows {
+		active[t.Key] = append(active[t.Key], t)
+	}
+
+	r.mu.Lock()
+	r.active = active
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return active[key]
+}
+
+func (r *Registry) execute(cacheKey, key, content string, data interface{}) (string, error) {
+	r.mu.RLock()
+	tmpl, ok := r.parsed[cacheKey]
+	r.mu.RUnlock()
+	if !ok {
+		var err error
+		if tmpl, err = parse(key, content); err != nil {
+			return "", err
+		}
+		r.mu.Lock()
+		r.parsed[cacheKey] = tmpl
+		r.mu.Unlock()
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func parse(key, content string) (*template.Template, error) {
+	return template.New(key).Funcs(funcs).Option("missingkey=error").Parse(content)
+}
+
+// RenderSample 使用示例变量渲染模板内容，用于保存前校验和预览
+func RenderSample(key, content string) (string, error) {
+	def, ok := Lookup(key)
+	if !ok {
+		return "", fmt.Errorf("未知的提示词模板: %s", key)
+	}
+	tmpl, err := parse(key, content)
+	if err != nil {
+		return "", fmt.Errorf("模板语法错误: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, def.sample); err != nil {
+		return "", fmt.Errorf("模板渲染失败: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
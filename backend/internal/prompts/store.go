@@ -0,0 +1,112 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoPreviousVersion 变体没有可回滚的版本
+var ErrNoPreviousVersion = errors.New("没有可回滚的版本")
+
+// Versions 返回模板键下的所有版本，sel 非空时只返回该变体的版本
+func Versions(key string, sel *Selector) ([]models.PromptTemplate, error) {
+	query := db.Conn.Where("template_key = ?", key)
+	if sel != nil {
+		query = query.Where("category = ? AND model_id = ?", sel.Category, sel.ModelID)
+	}
+	var rows []models.PromptTemplate
+	err := query.Order("category ASC, model_id ASC, version DESC").Find(&rows).Error
+	return rows, err
+}
+
+// Create 保存模板的新版本，版本号在变体内递增；activate 为 true 时同时启用该版本
+func Create(t *models.PromptTemplate, activate bool) error {
+	if _, ok := Lookup(t.Key); !ok {
+		return fmt.Errorf("未知的提示词模板: %s", t.Key)
+	}
+	if _, err := RenderSample(t.Key, t.Content); err != nil {
+		return err
+	}
+
+	err := db.Conn.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.PromptTemplate{}).
+			Where("template_key = ? AND category = ? AND model_id = ?", t.Key, t.Category, t.ModelID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		t.ID = 0
+		t.Version = latest + 1
+		t.IsActive = activate
+		if activate {
+			if err := deactivateVariant(tx, t.Key, t.Category, t.ModelID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(t).Error
+	})
+	if err == nil {
+		Default().Invalidate()
+	}
+	return err
+}
+
+// Activate 启用模板键下的指定版本并停用同一变体的其他版本，可用于回滚到任意历史版本
+func Activate(key string, id uint) (*models.PromptTemplate, error) {
+	var t models.PromptTemplate
+	err := db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND template_key = ?", id, key).First(&t).Error; err != nil {
+			return err
+		}
+		if err := deactivateVariant(tx, t.Key, t.Category, t.ModelID); err != nil {
+			return err
+		}
+		t.IsActive = true
+		return tx.Model(&t).Update("is_active", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	Default().Invalidate()
+	return &t, nil
+}
+
+// Rollback 将变体回滚到当前启用版本的上一个版本；没有更早的版本时停用该变体，
+// 恢复使用默认模板，此时返回 nil
+func Rollback(key string, sel Selector) (*models.PromptTemplate, error) {
+	var current models.PromptTemplate
+	err := db.Conn.Where("template_key = ? AND category = ? AND model_id = ? AND is_active = ?",
+		key, sel.Category, sel.ModelID, true).First(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoPreviousVersion
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var previous models.PromptTemplate
+	err = db.Conn.Where("template_key = ? AND category = ? AND model_id = ? AND version < ?",
+		key, sel.Category, sel.ModelID, current.Version).Order("version DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := deactivateVariant(db.Conn, key, sel.Category, sel.ModelID); err != nil {
+			return nil, err
+		}
+		Default().Invalidate()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Activate(key, previous.ID)
+}
+
+func deactivateVariant(tx *gorm.DB, key, category, modelID string) error {
+	return tx.Model(&models.PromptTemplate{}).
+		Where("template_key = ? AND category = ? AND model_id = ? AND is_active = ?", key, category, modelID, true).
+		Update("is_active", false).Error
+}
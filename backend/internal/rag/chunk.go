@@ -0,0 +1,146 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-career-buddy/internal/models"
+)
+
+// 切块参数（按字符数计）
+const (
+	chunkSize    = 160
+	chunkOverlap = 30
+)
+
+// SplitText 按段落切块，过长的段落按句子切分，相邻块之间保留少量重叠
+func SplitText(text string, size, overlap int) []string {
+	if size <= 0 {
+		size = chunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var pieces []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if len([]rune(para)) <= size {
+			pieces = append(pieces, para)
+			continue
+		}
+		pieces = append(pieces, splitLong(para, size)...)
+	}
+
+	var chunks []string
+	var current []rune
+	for _, piece := range pieces {
+		runes := []rune(piece)
+		if len(current) > 0 && len(current)+len(runes)+1 > size {
+			chunks = append(chunks, string(current))
+			// 下一块以上一块的结尾开头，避免关键信息被切断
+			tail := current[max(0, len(current)-overlap):]
+			for i, r := range tail {
+				// 尽量从句子开头重叠
+				if (r == '。' || r == '\n') && i+1 < len(tail) {
+					tail = tail[i+1:]
+					break
+				}
+			}
+			current = append([]rune{}, tail...)
+		}
+		if len(current) > 0 {
+			current = append(current, '\n')
+		}
+		current = append(current, runes...)
+	}
+	if len(strings.TrimSpace(string(current))) > 0 {
+		chunks = append(chunks, string(current))
+	}
+	return chunks
+}
+
+// splitLong 将超长段落按句末标点切分，单句仍过长时强制截断
+func splitLong(para string, size int) []string {
+	var out []string
+	var current []rune
+	for _, r := range para {
+		current = append(current, r)
+		end := r == '。' || r == '！' || r == '？' || r == '；' || r == '\n' || r == '.'
+		if (end && len(current) >= size/2) || len(current) >= size {
+			out = append(out, strings.TrimSpace(string(current)))
+			current = current[:0]
+		}
+	}
+	if s := strings.TrimSpace(string(current)); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// documentTexts 文档的待索引文本：AI提取的要点（如有）加上原文切块
+func documentTexts(doc *models.UserDocument) []string {
+	var texts []string
+	if info, err := doc.GetExtractedInfo(); err == nil && info != nil {
+		if summary := documentSummary(doc.DocumentType, info); summary != "" {
+			texts = append(texts, summary)
+		}
+	}
+	return append(texts, SplitText(doc.FileContent, chunkSize, chunkOverlap)...)
+}
+
+// documentSummary 将结构化提取结果整理成一段便于检索的文本
+func documentSummary(documentType string, info *models.DocumentExtractedInfo) string {
+	var fields [][2]string
+	switch documentType {
+	case "offer":
+		o := info.OfferInfo
+		fields = [][2]string{{"公司", o.CompanyName}, {"职位", o.Position}, {"薪资", o.Salary}, {"奖金", o.Bonus},
+			{"股权", o.Equity}, {"入职日期", o.StartDate}, {"工作地点", o.WorkLocation}, {"工作时间", o.WorkingHours},
+			{"汇报对象", o.ReportingTo}, {"福利", strings.Join(o.Benefits, "、")}}
+	case "contract":
+		ct := info.ContractInfo
+		fields = [][2]string{{"公司", ct.CompanyName}, {"职位", ct.Position}, {"薪资", ct.Salary}, {"合同类型", ct.ContractType},
+			{"开始日期", ct.StartDate}, {"工作地点", ct.WorkLocation}, {"工作时间", ct.WorkingHours}, {"通知期", ct.NoticePeriod},
+			{"竞业限制", ct.NonCompete}, {"保密条款", ct.Confidentiality}, {"福利", strings.Join(ct.Benefits, "、")}}
+	case "employment":
+		e := info.EmploymentInfo
+		fields = [][2]string{{"公司", e.CompanyName}, {"职位", e.Position}, {"部门", e.Department}, {"上级", e.Manager},
+			{"职责", strings.Join(e.Responsibilities, "；")}, {"成果", strings.Join(e.Achievements, "；")},
+			{"项目", strings.Join(e.Projects, "；")}}
+	case "resume":
+		var exp []string
+		for _, w := range info.WorkExperience {
+			exp = append(exp, strings.TrimSpace(fmt.Sprintf("%s %s %s", w.Company, w.Position, w.Duration)))
+		}
+		var edu []string
+		for _, e := range info.Education {
+			edu = append(edu, strings.TrimSpace(fmt.Sprintf("%s %s %s", e.School, e.Degree, e.Major)))
+		}
+		fields = [][2]string{{"工作经历", strings.Join(exp, "；")}, {"教育背景", strings.Join(edu, "；")},
+			{"技术技能", strings.Join(info.Skills.Technical, "、")}, {"证书", strings.Join(info.Skills.Certifications, "、")}}
+	}
+
+	var parts []string
+	for _, f := range fields {
+		if v := strings.TrimSpace(f[1]); v != "" {
+			parts = append(parts, f[0]+"："+v)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "文档要点 - " + strings.Join(parts, "；")
+}
+
+// historyTexts 职业咨询记录的待索引文本，问题和回答放在同一块中便于整体召回
+func historyTexts(h *models.CareerHistory) []string {
+	text := "问题：" + strings.TrimSpace(h.Content)
+	if answer := strings.TrimSpace(h.AIResponse); answer != "" {
+		text += "\n\n回答：" + answer
+	}
+	return SplitText(text, chunkSize, chunkOverlap)
+}
@@ -0,0 +1,229 @@
+package rag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"ai-career-buddy/internal/utils"
+)
+
+// Embedder 文本向量化
+type Embedder interface {
+	// Name 模型标识，写入索引块，切换模型后旧向量不再参与检索
+	Name() string
+	// Embed 批量生成向量，返回的向量已归一化
+	Embed(texts []string) ([][]float32, error)
+}
+
+// HashEmbedder 基于特征哈希的本地向量化，不依赖外部服务
+//
+// 对中文二元组和英文单词做哈希分桶并按 log(1+tf) 加权，语义能力有限，
+// 但足以支持关键词级别的相关性检索，适合本地开发和未配置向量模型的部署。
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder 创建哈希向量化器
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = 1024
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-%d", e.dims)
+}
+
+func (e *HashEmbedder) Embed(texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		counts := map[string]int{}
+		for _, t := range utils.Tokenize(text) {
+			if !hasStopRune(t) {
+				counts[t]++
+			}
+		}
+		vec := make([]float32, e.dims)
+		for token, tf := range counts {
+			h := fnv.New32a()
+			h.Write([]byte(token))
+			sum := h.Sum32()
+			weight := float32(math.Log1p(float64(tf)))
+			// 最高位决定符号，减少哈希冲突带来的偏差
+			if sum&0x80000000 != 0 {
+				weight = -weight
+			}
+			vec[int(sum%uint32(e.dims))] += weight
+		}
+		vectors[i] = normalize(vec)
+	}
+	return vectors, nil
+}
+
+// 虚词和代词构成的二元组（"我的"、"什么"、"怎么"）几乎不携带信息，却会稀释短问题的向量
+var stopRunes = map[rune]bool{
+	'的': true, '了': true, '吗': true, '呢': true, '吧': true, '么': true, '什': true, '怎': true,
+	'我': true, '你': true, '您': true, '他': true, '她': true, '是': true, '在': true, '和': true,
+	'与': true, '及': true, '或': true, '也': true, '都': true, '就': true, '这': true, '那': true,
+}
+
+func hasStopRune(token string) bool {
+	for _, r := range token {
+		if stopRunes[r] {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPEmbedder 调用 OpenAI 兼容的 /embeddings 接口
+type HTTPEmbedder struct {
+	apiURL string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+// 单次请求的最大文本数
+const embedBatchSize = 16
+
+// NewHTTPEmbedder 创建HTTP向量化器
+func NewHTTPEmbedder(apiURL, apiKey, model string) (*HTTPEmbedder, error) {
+	if apiURL == "" {
+		return nil, errors.New("RAG_EMBEDDING_URL 未配置")
+	}
+	if model == "" {
+		return nil, errors.New("RAG_EMBEDDING_MODEL 未配置")
+	}
+	return &HTTPEmbedder{
+		apiURL: apiURL,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (e *HTTPEmbedder) Name() string {
+	return "http-" + e.model
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e *HTTPEmbedder) Embed(texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatchSize {
+		batch := texts[start:min(start+embedBatchSize, len(texts))]
+		result, err := e.embedBatch(batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, result...)
+	}
+	return vectors, nil
+}
+
+func (e *HTTPEmbedder) embedBatch(texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(embeddingRequest{Model: e.model, Input: texts})
+	req, err := http.NewRequest(http.MethodPost, e.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求向量模型失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取向量模型响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("向量模型返回状态码 %d: %s", resp.StatusCode, string(data))
+	}
+
+	var parsed embeddingResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("解析向量模型响应失败: %v", err)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("向量模型返回 %d 个向量，期望 %d 个", len(parsed.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, item := range parsed.Data {
+		index := item.Index
+		if index < 0 || index >= len(texts) {
+			index = i
+		}
+		vectors[index] = normalize(item.Embedding)
+	}
+	return vectors, nil
+}
+
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
+
+// dot 归一化向量的点积即余弦相似度
+func dot(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec
+}
@@ -0,0 +1,307 @@
+// Package rag 对用户上传的文档和历史咨询记录建立向量索引，对话时自动检索相关片段
+package rag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+)
+
+// 索引来源类型
+const (
+	SourceDocument      = "document"
+	SourceCareerHistory = "career_history"
+)
+
+// SearchOptions 检索选项
+type SearchOptions struct {
+	K          int
+	MinScore   float64
+	SourceType string
+	SourceID   uint
+}
+
+// ReindexResult 重建索引结果
+type ReindexResult struct {
+	Documents int `json:"documents"`
+	Histories int `json:"histories"`
+	Chunks    int `json:"chunks"`
+	Failed    int `json:"failed"`
+}
+
+// Indexer 负责切块、向量化和检索
+type Indexer struct {
+	embedder Embedder
+	store    VectorStore
+	topK     int
+	minScore float64
+
+	mu     sync.Mutex
+	warmed map[string]bool // 非持久化存储中已经建立索引的用户
+}
+
+// NewIndexer 创建索引器
+func NewIndexer(embedder Embedder, store VectorStore, topK int, minScore float64) *Indexer {
+	if topK <= 0 {
+		topK = 4
+	}
+	return &Indexer{embedder: embedder, store: store, topK: topK, minScore: minScore, warmed: map[string]bool{}}
+}
+
+var (
+	defaultIndexer *Indexer
+	defaultOnce    sync.Once
+)
+
+// Default 返回按配置创建的索引器，未启用或创建失败时返回 nil
+func Default() *Indexer {
+	defaultOnce.Do(func() {
+		if !config.C.RAGEnabled {
+			logger.Info("用户资料检索未启用")
+			return
+		}
+
+		var embedder Embedder
+		switch config.C.RAGEmbedder {
+		case "", "hash":
+			embedder = NewHashEmbedder(0)
+		case "http":
+			e, err := NewHTTPEmbedder(config.C.RAGEmbeddingURL, config.C.RAGEmbeddingKey, config.C.RAGEmbeddingModel)
+			if err != nil {
+				logger.Error("创建向量模型失败: %v", err)
+				return
+			}
+			embedder = e
+		default:
+			logger.Error("未知的向量模型类型: %s", config.C.RAGEmbedder)
+			return
+		}
+
+		var store VectorStore
+		switch config.C.RAGStore {
+		case "", "db":
+			store = NewDBStore()
+		case "memory":
+			store = NewMemoryStore()
+		default:
+			logger.Error("未知的向量存储类型: %s", config.C.RAGStore)
+			return
+		}
+
+		defaultIndexer = NewIndexer(embedder, store, config.C.RAGTopK, config.C.RAGMinScore)
+		logger.Info("用户资料检索已启用: Embedder=%s, Store=%s", embedder.Name(), config.C.RAGStore)
+	})
+	return defaultIndexer
+}
+
+// Embedder 当前使用的向量模型
+func (ix *Indexer) Embedder() Embedder {
+	return ix.embedder
+}
+
+// IndexDocument 为文档建立索引，替换该文档已有的索引块
+func (ix *Indexer) IndexDocument(doc *models.UserDocument) (int, error) {
+	title := doc.FileName
+	if label := documentTypeLabel(doc.DocumentType); label != "" {
+		title = fmt.Sprintf("%s（%s）", doc.FileName, label)
+	}
+	return ix.index(doc.UserID, SourceDocument, doc.ID, title, documentTexts(doc))
+}
+
+// IndexHistory 为职业咨询记录建立索引
+func (ix *Indexer) IndexHistory(h *models.CareerHistory) (int, error) {
+	return ix.index(h.UserID, SourceCareerHistory, h.ID, h.Title, historyTexts(h))
+}
+
+// IndexDocumentAsync 后台建立文档索引，失败只记录日志
+func (ix *Indexer) IndexDocumentAsync(doc models.UserDocument) {
+	go func() {
+		if n, err := ix.IndexDocument(&doc); err != nil {
+			logger.Warn("文档索引失败: DocumentID=%d, 错误=%v", doc.ID, err)
+		} else {
+			logger.Debug("文档索引完成: DocumentID=%d, 块数=%d", doc.ID, n)
+		}
+	}()
+}
+
+// IndexHistoryAsync 后台建立咨询记录索引
+func (ix *Indexer) IndexHistoryAsync(h models.CareerHistory) {
+	go func() {
+		if n, err := ix.IndexHistory(&h); err != nil {
+			logger.Warn("咨询记录索引失败: HistoryID=%d, 错误=%v", h.ID, err)
+		} else {
+			logger.Debug("咨询记录索引完成: HistoryID=%d, 块数=%d", h.ID, n)
+		}
+	}()
+}
+
+// RemoveDocument 删除文档的索引块
+func (ix *Indexer) RemoveDocument(documentID uint) error {
+	return ix.store.Delete(SourceDocument, documentID)
+}
+
+// ReindexUser 重建用户的全部索引
+func (ix *Indexer) ReindexUser(userID string) (*ReindexResult, error) {
+	var docs []models.UserDocument
+	if err := db.Conn.Where("user_id = ?", userID).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	var histories []models.CareerHistory
+	if err := db.Conn.Where("user_id = ?", userID).Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	if err := ix.store.DeleteUser(userID); err != nil {
+		return nil, err
+	}
+
+	result := &ReindexResult{}
+	for i := range docs {
+		n, err := ix.IndexDocument(&docs[i])
+		if err != nil {
+			logger.Warn("文档索引失败: DocumentID=%d, 错误=%v", docs[i].ID, err)
+			result.Failed++
+			continue
+		}
+		result.Documents++
+		result.Chunks += n
+	}
+	for i := range histories {
+		n, err := ix.IndexHistory(&histories[i])
+		if err != nil {
+			logger.Warn("咨询记录索引失败: HistoryID=%d, 错误=%v", histories[i].ID, err)
+			result.Failed++
+			continue
+		}
+		result.Histories++
+		result.Chunks += n
+	}
+
+	ix.mu.Lock()
+	ix.warmed[userID] = true
+	ix.mu.Unlock()
+	logger.Info("用户资料索引重建完成: UserID=%s, 文档=%d, 咨询记录=%d, 块数=%d, 失败=%d",
+		userID, result.Documents, result.Histories, result.Chunks, result.Failed)
+	return result, nil
+}
+
+// Search 检索用户资料中与问题最相关的片段
+func (ix *Indexer) Search(userID, query string, opts SearchOptions) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if userID == "" || query == "" {
+		return nil, nil
+	}
+	ix.ensureIndexed(userID)
+
+	vectors, err := ix.embedder.Embed([]string{query})
+	if err != nil {
+		return nil, err
+	}
+	if opts.K <= 0 {
+		opts.K = ix.topK
+	}
+	if opts.MinScore == 0 {
+		opts.MinScore = ix.minScore
+	}
+	hits, err := ix.store.Search(Query{
+		UserID:     userID,
+		Embedder:   ix.embedder.Name(),
+		Vector:     vectors[0],
+		K:          opts.K,
+		MinScore:   opts.MinScore,
+		SourceType: opts.SourceType,
+		SourceID:   opts.SourceID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Chunk.Vector = nil
+	}
+	return hits, nil
+}
+
+// SearchDocument 检索单个文档中与问题最相关的片段，文档尚未建立索引时先建立索引
+func (ix *Indexer) SearchDocument(doc *models.UserDocument, query string, k int) ([]Hit, error) {
+	opts := SearchOptions{K: k, MinScore: -1, SourceType: SourceDocument, SourceID: doc.ID}
+	hits, err := ix.Search(doc.UserID, query, opts)
+	if err == nil && len(hits) == 0 {
+		if _, err = ix.IndexDocument(doc); err == nil {
+			hits, err = ix.Search(doc.UserID, query, opts)
+		}
+	}
+	// 按原文顺序拼接，阅读起来更连贯
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Chunk.ChunkIndex < hits[j].Chunk.ChunkIndex })
+	return hits, err
+}
+
+// Stats 用户在当前向量模型下的索引块数量
+func (ix *Indexer) Stats(userID string) (int64, error) {
+	return ix.store.Count(userID, ix.embedder.Name())
+}
+
+// ensureIndexed 内存存储重启后为空，首次检索时为该用户重建索引
+func (ix *Indexer) ensureIndexed(userID string) {
+	if ix.store.Persistent() {
+		return
+	}
+	ix.mu.Lock()
+	warmed := ix.warmed[userID]
+	ix.mu.Unlock()
+	if !warmed {
+		if _, err := ix.ReindexUser(userID); err != nil {
+			logger.Warn("重建用户资料索引失败: UserID=%s, 错误=%v", userID, err)
+		}
+	}
+}
+
+func (ix *Indexer) index(userID, sourceType string, sourceID uint, title string, texts []string) (int, error) {
+	var nonEmpty []string
+	for _, t := range texts {
+		if t = strings.TrimSpace(t); t != "" {
+			nonEmpty = append(nonEmpty, t)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return 0, ix.store.Replace(userID, sourceType, sourceID, nil)
+	}
+
+	vectors, err := ix.embedder.Embed(nonEmpty)
+	if err != nil {
+		return 0, err
+	}
+	chunks := make([]models.EmbeddingChunk, len(nonEmpty))
+	for i, text := range nonEmpty {
+		chunks[i] = models.EmbeddingChunk{
+			UserID:     userID,
+			SourceType: sourceType,
+			SourceID:   sourceID,
+			ChunkIndex: i,
+			Title:      title,
+			Content:    text,
+			Embedder:   ix.embedder.Name(),
+			Dimensions: len(vectors[i]),
+			Vector:     encodeVector(vectors[i]),
+		}
+	}
+	return len(chunks), ix.store.Replace(userID, sourceType, sourceID, chunks)
+}
+
+func documentTypeLabel(documentType string) string {
+	switch documentType {
+	case "resume":
+		return "简历"
+	case "contract":
+		return "劳动合同"
+	case "offer":
+		return "Offer"
+	case "employment":
+		return "在职证明"
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceLabel 索引来源的展示名称
+func SourceLabel(sourceType string) string {
+	switch sourceType {
+	case SourceDocument:
+		return "我的文档"
+	case SourceCareerHistory:
+		return "历史咨询"
+	}
+	return sourceType
+}
+
+// BuildPrompt 将命中片段整理为带引用编号的提示词，编号从 firstID 开始，
+// 以便与联网检索结果共用一套编号
+func BuildPrompt(hits []Hit, firstID int) string {
+	if len(hits) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【用户资料】以下是从用户上传的文档和历史咨询记录中检索到的相关片段，" +
+		"回答涉及用户自身情况时请优先依据这些内容，并在句末标注编号：\n")
+	for i, hit := range hits {
+		sb.WriteString(fmt.Sprintf("\n[%d] %s·%s\n%s\n", firstID+i, SourceLabel(hit.Chunk.SourceType), hit.Chunk.Title, hit.Chunk.Content))
+	}
+	return sb.String()
+}
@@ -0,0 +1,209 @@
+package rag
+
+import (
+	"sort"
+	"sync"
+
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/models"
+)
+
+// Hit 一条检索命中
+type Hit struct {
+	Chunk models.EmbeddingChunk `json:"chunk"`
+	Score float64               `json:"score"`
+}
+
+// Query 向量检索条件
+type Query struct {
+	UserID     string
+	Embedder   string
+	Vector     []float32
+	K          int
+	MinScore   float64
+	SourceType string // 为空时检索全部来源
+	SourceID   uint   // 限定某个来源对象，0表示不限
+}
+
+// VectorStore 向量存储，按用户隔离
+type VectorStore interface {
+	// Replace 用新的索引块替换某个来源对象的全部索引块
+	Replace(userID, sourceType string, sourceID uint, chunks []models.EmbeddingChunk) error
+	// Delete 删除某个来源对象的索引块
+	Delete(sourceType string, sourceID uint) error
+	// DeleteUser 删除用户的全部索引块
+	DeleteUser(userID string) error
+	// Search 返回相似度最高的至多 K 个索引块
+	Search(q Query) ([]Hit, error)
+	// Count 用户在指定模型下的索引块数量
+	Count(userID, embedder string) (int64, error)
+	// Persistent 重启后索引是否仍然存在
+	Persistent() bool
+}
+
+// topK 按相似度筛选命中
+func topK(hits []Hit, k int) []Hit {
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if k > 0 && len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+func (q Query) matches(c *models.EmbeddingChunk) bool {
+	if c.UserID != q.UserID || c.Embedder != q.Embedder {
+		return false
+	}
+	if q.SourceType != "" && c.SourceType != q.SourceType {
+		return false
+	}
+	return q.SourceID == 0 || c.SourceID == q.SourceID
+}
+
+// MemoryStore 纯内存向量存储，适合测试和单机小规模使用，重启后需要重建索引
+type MemoryStore struct {
+	mu     sync.RWMutex
+	chunks map[string][]models.EmbeddingChunk // userID -> 索引块
+}
+
+// NewMemoryStore 创建内存向量存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{chunks: map[string][]models.EmbeddingChunk{}}
+}
+
+func (s *MemoryStore) Replace(userID, sourceType string, sourceID uint, chunks []models.EmbeddingChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.chunks[userID][:0:0]
+	for _, c := range s.chunks[userID] {
+		if c.SourceType != sourceType || c.SourceID != sourceID {
+			kept = append(kept, c)
+		}
+	}
+	s.chunks[userID] = append(kept, chunks...)
+	return nil
+}
+
+func (s *MemoryStore) Delete(sourceType string, sourceID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, chunks := range s.chunks {
+		kept := chunks[:0:0]
+		for _, c := range chunks {
+			if c.SourceType != sourceType || c.SourceID != sourceID {
+				kept = append(kept, c)
+			}
+		}
+		s.chunks[userID] = kept
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chunks, userID)
+	return nil
+}
+
+func (s *MemoryStore) Search(q Query) ([]Hit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var hits []Hit
+	for i := range s.chunks[q.UserID] {
+		c := &s.chunks[q.UserID][i]
+		if !q.matches(c) {
+			continue
+		}
+		if score := dot(q.Vector, decodeVector(c.Vector)); score >= q.MinScore {
+			hits = append(hits, Hit{Chunk: *c, Score: score})
+		}
+	}
+	return topK(hits, q.K), nil
+}
+
+func (s *MemoryStore) Count(userID, embedder string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var n int64
+	for _, c := range s.chunks[userID] {
+		if c.Embedder == embedder {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) Persistent() bool {
+	return false
+}
+
+// DBStore 基于数据库表的向量存储，SQLite 和 MySQL 均可使用
+//
+// 检索时加载用户在当前模型下的全部向量做暴力计算，单个用户的资料量通常在
+// 几百到几千个块，耗时可以接受。
+type DBStore struct{}
+
+// NewDBStore 创建数据库向量存储
+func NewDBStore() *DBStore {
+	return &DBStore{}
+}
+
+func (s *DBStore) Replace(userID, sourceType string, sourceID uint, chunks []models.EmbeddingChunk) error {
+	tx := db.Conn.Begin()
+	if err := tx.Where("user_id = ? AND source_type = ? AND source_id = ?", userID, sourceType, sourceID).
+		Delete(&models.EmbeddingChunk{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(chunks) > 0 {
+		if err := tx.CreateInBatches(chunks, 100).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func (s *DBStore) Delete(sourceType string, sourceID uint) error {
+	return db.Conn.Where("source_type = ? AND source_id = ?", sourceType, sourceID).
+		Delete(&models.EmbeddingChunk{}).Error
+}
+
+func (s *DBStore) DeleteUser(userID string) error {
+	return db.Conn.Where("user_id = ?", userID).Delete(&models.EmbeddingChunk{}).Error
+}
+
+func (s *DBStore) Search(q Query) ([]Hit, error) {
+	tx := db.Conn.Where("user_id = ? AND embedder = ?", q.UserID, q.Embedder)
+	if q.SourceType != "" {
+		tx = tx.Where("source_type = ?", q.SourceType)
+	}
+	if q.SourceID != 0 {
+		tx = tx.Where("source_id = ?", q.SourceID)
+	}
+
+	var chunks []models.EmbeddingChunk
+	if err := tx.Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	var hits []Hit
+	for _, c := range chunks {
+		if score := dot(q.Vector, decodeVector(c.Vector)); score >= q.MinScore {
+			hits = append(hits, Hit{Chunk: c, Score: score})
+		}
+	}
+	return topK(hits, q.K), nil
+}
+
+func (s *DBStore) Count(userID, embedder string) (int64, error) {
+	var n int64
+	err := db.Conn.Model(&models.EmbeddingChunk{}).
+		Where("user_id = ? AND embedder = ?", userID, embedder).
+		Count(&n).Error
+	return n, err
+}
+
+func (s *DBStore) Persistent() bool {
+	return true
+}
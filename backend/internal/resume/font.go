@@ -0,0 +1,68 @@
+package resume
+
+import (
+	"os"
+	"sync"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/pdfgen"
+)
+
+// fontCandidates 常见的系统中文字体，需为 TrueType 轮廓（思源、苹方等 CFF 轮廓的字体不支持）
+var fontCandidates = []string{
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-zenhei.ttc",
+	"/usr/share/fonts/wqy-zenhei/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+	"/usr/share/fonts/google-droid/DroidSansFallbackFull.ttf",
+	"/System/Library/Fonts/STHeiti Light.ttc",
+	"/Library/Fonts/Arial Unicode.ttf",
+	`C:\Windows\Fonts\msyh.ttc`,
+	`C:\Windows\Fonts\simhei.ttf`,
+	`C:\Windows\Fonts\simsun.ttc`,
+}
+
+var (
+	fontOnce sync.Once
+	ttfFont  *pdfgen.TrueTypeFont
+)
+
+// pdfFont 返回一个文档使用的字体。字体文件只在第一次生成时加载
+func pdfFont() pdfgen.Font {
+	fontOnce.Do(loadFont)
+	if ttfFont != nil {
+		return ttfFont.NewDocumentFont()
+	}
+	return pdfgen.BuiltinCJK()
+}
+
+func loadFont() {
+	paths := fontCandidates
+	if config.C.ResumeFontPath != "" {
+		paths = []string{config.C.ResumeFontPath}
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if config.C.ResumeFontPath != "" {
+				logger.Error("读取简历字体失败: Path=%s, 错误=%v", path, err)
+			}
+			continue
+		}
+		f, err := pdfgen.ParseTrueType(data)
+		if err != nil {
+			logger.Warn("简历字体不可用: Path=%s, 错误=%v", path, err)
+			continue
+		}
+		if !f.Has('简') {
+			logger.Warn("简历字体不包含中文字符: Path=%s", path)
+			continue
+		}
+		ttfFont = f
+		logger.Info("简历PDF字体: Path=%s, Name=%s", path, f.Name())
+		return
+	}
+	logger.Warn("未找到可嵌入的中文字体，简历PDF使用阅读器内置的 STSong-Light，可通过 RESUME_FONT_PATH 指定字体")
+}
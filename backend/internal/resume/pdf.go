@@ -0,0 +1,258 @@
+package resume
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"ai-career-buddy/internal/pdfgen"
+)
+
+// 页边距（点）
+const (
+	marginX      = 56
+	marginTop    = 52
+	marginBottom = 56
+)
+
+// noLineStart 不能出现在行首的标点，排版时跟随前一行
+const noLineStart = "，。、；：！？）》」』】”’,.;:!?)]%"
+
+// layout PDF 排版状态，y 为下一行顶部的位置
+type layout struct {
+	doc    *pdfgen.Document
+	page   *pdfgen.Page
+	tpl    Template
+	accent pdfgen.Color
+	y      float64
+}
+
+func renderPDF(v *view, font pdfgen.Font) ([]byte, error) {
+	doc := pdfgen.New(font)
+	title := "简历"
+	if v.Name != "" {
+		title = v.Name + " - 简历"
+	}
+	doc.SetInfo(title, v.Name)
+
+	l := &layout{doc: doc, tpl: v.Template, accent: parseColor(v.Template.Accent)}
+	l.newPage()
+	size := v.Template.FontSize
+
+	if v.Name != "" {
+		l.text(marginX, v.Name, pdfgen.TextStyle{Size: size * 2, Bold: true, Color: l.accent}, 1.3)
+	}
+	if len(v.Contact) > 0 {
+		l.paragraph(marginX, strings.Join(v.Contact, "  |  "), pdfgen.TextStyle{Size: size * 0.9, Color: pdfgen.Gray})
+	}
+
+	for _, s := range v.Sections {
+		l.heading(s.Title)
+		for _, e := range s.Experience {
+			l.entry(e.Headline, e.Duration)
+			for _, b := range e.Bullets {
+				l.bullet(b)
+			}
+			if e.Skills != "" {
+				l.paragraph(marginX+12, "技能："+e.Skills, pdfgen.TextStyle{Size: size * 0.9, Color: pdfgen.Gray})
+			}
+			l.y -= size * 0.5
+		}
+		for _, e := range s.Education {
+			l.entry(e.Headline, e.Duration)
+			if e.GPA != "" {
+				l.paragraph(marginX, "GPA "+e.GPA, pdfgen.TextStyle{Size: size * 0.9, Color: pdfgen.Gray})
+			}
+			l.y -= size * 0.3
+		}
+		for _, g := range s.Skills {
+			l.labeled(g.Label+"：", g.Items)
+		}
+	}
+
+	l.pageNumbers()
+	return doc.Bytes()
+}
+
+func (l *layout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = pdfgen.PageHeight - marginTop
+}
+
+// ensure 当前页剩余空间不足 h 时换页
+func (l *layout) ensure(h float64) {
+	if l.y-h < marginBottom {
+		l.newPage()
+	}
+}
+
+func (l *layout) lineHeight(size float64) float64 {
+	return size * l.tpl.LineHeight
+}
+
+func (l *layout) contentWidth() float64 {
+	return pdfgen.PageWidth - 2*marginX
+}
+
+// text 输出单行文字，leading 为行高相对字号的倍数
+func (l *layout) text(x float64, s string, style pdfgen.TextStyle, leading float64) {
+	h := style.Size * leading
+	l.ensure(h)
+	l.page.Text(x, l.baseline(h, style.Size), s, style)
+	l.y -= h
+}
+
+// baseline 行高为 h 的一行文字的基线位置，文字在行内垂直居中
+func (l *layout) baseline(h, size float64) float64 {
+	return l.y - (h+size*0.7)/2
+}
+
+// paragraph 从 x 开始自动换行输出一段文字
+func (l *layout) paragraph(x float64, s string, style pdfgen.TextStyle) {
+	for _, line := range l.wrap(s, style.Size, pdfgen.PageWidth-marginX-x) {
+		l.text(x, line, style, l.tpl.LineHeight)
+	}
+}
+
+// heading 章节标题和分隔线
+func (l *layout) heading(title string) {
+	size := l.tpl.FontSize * 1.25
+	l.y -= l.tpl.FontSize * 0.8
+	// 标题不单独留在页尾
+	l.ensure(size*1.4 + l.lineHeight(l.tpl.FontSize)*2)
+	l.text(marginX, title, pdfgen.TextStyle{Size: size, Bold: true, Color: l.accent}, 1.4)
+	l.page.Line(marginX, l.y, pdfgen.PageWidth-marginX, l.y, 0.8, l.accent)
+	l.y -= l.tpl.FontSize * 0.5
+}
+
+// entry 经历或教育的标题行，右侧对齐时间
+func (l *layout) entry(title, duration string) {
+	size := l.tpl.FontSize
+	h := l.lineHeight(size)
+	l.ensure(h * 2)
+	width := l.contentWidth()
+	if duration != "" {
+		dsize := size * 0.9
+		dw := l.doc.TextWidth(duration, dsize)
+		l.page.Text(pdfgen.PageWidth-marginX-dw, l.baseline(h, size), duration, pdfgen.TextStyle{Size: dsize, Color: pdfgen.Gray})
+		width -= dw + 12
+	}
+	for _, line := range l.wrap(title, size, width) {
+		l.text(marginX, line, pdfgen.TextStyle{Size: size, Bold: true, Color: pdfgen.Black}, l.tpl.LineHeight)
+	}
+}
+
+// bullet 带项目符号的要点，换行后悬挂缩进
+func (l *layout) bullet(s string) {
+	size := l.tpl.FontSize
+	indent := marginX + 12.0
+	style := pdfgen.TextStyle{Size: size, Color: pdfgen.Black}
+	for i, line := range l.wrap(s, size, pdfgen.PageWidth-marginX-indent) {
+		if i == 0 {
+			l.ensure(l.lineHeight(size))
+			l.page.Text(marginX+2, l.baseline(l.lineHeight(size), size), "•", style)
+		}
+		l.text(indent, line, style, l.tpl.LineHeight)
+	}
+}
+
+// labeled 加粗的标签加内容，内容换行后与第一行对齐
+func (l *layout) labeled(label, s string) {
+	size := l.tpl.FontSize
+	h := l.lineHeight(size)
+	x := marginX + l.doc.TextWidth(label, size)
+	for i, line := range l.wrap(s, size, pdfgen.PageWidth-marginX-x) {
+		if i == 0 {
+			l.ensure(h)
+			l.page.Text(marginX, l.baseline(h, size), label, pdfgen.TextStyle{Size: size, Bold: true, Color: pdfgen.Black})
+		}
+		l.text(x, line, pdfgen.TextStyle{Size: size, Color: pdfgen.Black}, l.tpl.LineHeight)
+	}
+}
+
+// pageNumbers 多页时在页脚居中标注页码
+func (l *layout) pageNumbers() {
+	pages := l.doc.Pages()
+	if len(pages) < 2 {
+		return
+	}
+	size := l.tpl.FontSize * 0.8
+	for i, p := range pages {
+		s := fmt.Sprintf("%d / %d", i+1, len(pages))
+		p.Text((pdfgen.PageWidth-l.doc.TextWidth(s, size))/2, marginBottom/2, s, pdfgen.TextStyle{Size: size, Color: pdfgen.Gray})
+	}
+}
+
+// wrap 按宽度折行：中文逐字断行，英文和数字按单词断行，行首避开闭合标点
+func (l *layout) wrap(s string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		var line strings.Builder
+		var lineWidth float64
+		for _, tok := range tokenize(para) {
+			w := l.doc.TextWidth(tok, size)
+			if line.Len() > 0 && lineWidth+w > width && !strings.ContainsRune(noLineStart, []rune(tok)[0]) {
+				lines = append(lines, strings.TrimRight(line.String(), " "))
+				line.Reset()
+				lineWidth = 0
+				tok = strings.TrimLeft(tok, " ")
+				w = l.doc.TextWidth(tok, size)
+			}
+			// 超过整行宽度的长单词（如网址）逐字断开
+			if w > width {
+				for _, r := range tok {
+					rw := l.doc.TextWidth(string(r), size)
+					if line.Len() > 0 && lineWidth+rw > width {
+						lines = append(lines, line.String())
+						line.Reset()
+						lineWidth = 0
+					}
+					line.WriteRune(r)
+					lineWidth += rw
+				}
+				continue
+			}
+			line.WriteString(tok)
+			lineWidth += w
+		}
+		if text := strings.TrimSpace(line.String()); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return lines
+}
+
+// tokenize 把文字拆成断行单位：连续的字母数字（连同后面的空格）为一个单位，其他字符各自为一个单位
+func tokenize(s string) []string {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		if isWordRune(runes[i]) {
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+		}
+		for j < len(runes) && runes[j] == ' ' {
+			j++
+		}
+		tokens = append(tokens, string(runes[i:j]))
+		i = j
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r < 0x3000 && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_./@+#&'", r))
+}
+
+// parseColor 解析 #RRGGBB 格式的颜色，无效时为黑色
+func parseColor(hex string) pdfgen.Color {
+	hex = strings.TrimPrefix(hex, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return pdfgen.Black
+	}
+	return pdfgen.Color{float64(v>>16&0xFF) / 255, float64(v>>8&0xFF) / 255, float64(v&0xFF) / 255}
+}
@@ -0,0 +1,341 @@
+// Package resume 把结构化的简历信息（DocumentExtractedInfo）生成 Markdown、HTML 和 PDF
+//
+// 模板决定配色、字号和排版密度，三种格式使用相同的内容和章节顺序。PDF 由 pdfgen 生成，
+// 中文字体优先使用 RESUME_FONT_PATH，其次是常见的系统字体，都没有时使用阅读器内置字体。
+package resume
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"ai-career-buddy/internal/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(templateFS, "templates/resume.html.tmpl"))
+
+// 章节
+const (
+	SectionExperience = "experience"
+	SectionEducation  = "education"
+	SectionSkills     = "skills"
+)
+
+// DefaultSections 默认章节顺序
+var DefaultSections = []string{SectionExperience, SectionEducation, SectionSkills}
+
+var sectionTitles = map[string]string{
+	SectionExperience: "工作经历",
+	SectionEducation:  "教育背景",
+	SectionSkills:     "专业技能",
+}
+
+var (
+	// ErrUnknownTemplate 模板不存在
+	ErrUnknownTemplate = errors.New("简历模板不存在")
+	// ErrUnknownSection 章节不存在
+	ErrUnknownSection = errors.New("未知的简历章节")
+	// ErrEmpty 没有可生成的内容
+	ErrEmpty = errors.New("简历内容为空")
+)
+
+// Template 简历模板
+type Template struct {
+	Name        string  `json:"name"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Accent      string  `json:"accent"`   // 标题颜色，#RRGGBB
+	FontSize    float64 `json:"fontSize"` // 正文字号（点）
+	LineHeight  float64 `json:"lineHeight"`
+}
+
+var templates = []Template{
+	{Name: "classic", Title: "经典", Description: "黑白单栏排版，适合大多数岗位", Accent: "#222222", FontSize: 10.5, LineHeight: 1.55},
+	{Name: "modern", Title: "现代", Description: "蓝色标题和分隔线，适合互联网和科技公司", Accent: "#1F5FBF", FontSize: 10.5, LineHeight: 1.55},
+	{Name: "compact", Title: "紧凑", Description: "较小字号和行距，经历较多时尽量压缩到一页", Accent: "#333333", FontSize: 9.5, LineHeight: 1.4},
+}
+
+// Templates 可用的模板
+func Templates() []Template {
+	return templates
+}
+
+// Options 生成选项
+type Options struct {
+	Template string   // 模板名，默认 classic
+	Sections []string // 章节及顺序，默认 DefaultSections，未列出的章节不输出
+}
+
+// Output 生成结果
+type Output struct {
+	Template     string   // 实际使用的模板名
+	Sections     []string // 实际使用的章节顺序
+	Markdown     string
+	HTML         string
+	PDF          []byte
+	FontName     string
+	FontEmbedded bool
+}
+
+// Render 按模板生成三种格式的简历
+func Render(info *models.DocumentExtractedInfo, opts Options) (*Output, error) {
+	tpl, err := lookupTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := normalizeSections(opts.Sections)
+	if err != nil {
+		return nil, err
+	}
+	v := buildView(info, tpl, sections)
+	if v.empty() {
+		return nil, ErrEmpty
+	}
+
+	var html bytes.Buffer
+	if err := htmlTemplate.Execute(&html, v); err != nil {
+		return nil, fmt.Errorf("生成HTML失败: %v", err)
+	}
+	font := pdfFont()
+	pdf, err := renderPDF(v, font)
+	if err != nil {
+		return nil, fmt.Errorf("生成PDF失败: %v", err)
+	}
+	return &Output{
+		Template:     tpl.Name,
+		Sections:     sections,
+		Markdown:     renderMarkdown(v),
+		HTML:         html.String(),
+		PDF:          pdf,
+		FontName:     font.Name(),
+		FontEmbedded: font.Embedded(),
+	}, nil
+}
+
+func lookupTemplate(name string) (Template, error) {
+	if name == "" {
+		return templates[0], nil
+	}
+	for _, t := range templates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return Template{}, ErrUnknownTemplate
+}
+
+func normalizeSections(sections []string) ([]string, error) {
+	if len(sections) == 0 {
+		return DefaultSections, nil
+	}
+	seen := map[string]bool{}
+	var result []string
+	for _, s := range sections {
+		s = strings.TrimSpace(s)
+		if _, ok := sectionTitles[s]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownSection, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+type experience struct {
+	Headline string // 公司 · 职位
+	Company  string
+	Position string
+	Duration string
+	Bullets  []string
+	Skills   string
+}
+
+type education struct {
+	Headline string // 学校 · 学位 · 专业
+	School   string
+	Degree   string
+	Major    string
+	Duration string
+	GPA      string
+}
+
+type skillGroup struct {
+	Label string
+	Items string
+}
+
+type section struct {
+	Key        string
+	Title      string
+	Experience []experience
+	Education  []education
+	Skills     []skillGroup
+}
+
+// view 三种格式共用的简历内容
+type view struct {
+	Template Template
+	Name     string
+	Contact  []string
+	Sections []section
+}
+
+func (v *view) empty() bool {
+	if v.Name != "" {
+		return false
+	}
+	for _, s := range v.Sections {
+		if len(s.Experience)+len(s.Education)+len(s.Skills) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func buildView(info *models.DocumentExtractedInfo, tpl Template, sections []string) *view {
+	p := info.PersonalInfo
+	v := &view{Template: tpl, Name: strings.TrimSpace(p.Name)}
+	for _, c := range []string{p.Phone, p.Email, p.Location, p.LinkedIn, p.GitHub} {
+		if c = strings.TrimSpace(c); c != "" {
+			v.Contact = append(v.Contact, c)
+		}
+	}
+
+	for _, key := range sections {
+		s := section{Key: key, Title: sectionTitles[key]}
+		switch key {
+		case SectionExperience:
+			for _, w := range info.WorkExperience {
+				e := experience{
+					Company:  strings.TrimSpace(w.Company),
+					Position: strings.TrimSpace(w.Position),
+					Duration: strings.TrimSpace(w.Duration),
+					Bullets:  Bullets(w.Description),
+					Skills:   joinNonEmpty(w.Skills, "、"),
+				}
+				e.Headline = headline(e.Company, e.Position)
+				if e.Headline != "" || len(e.Bullets) > 0 {
+					s.Experience = append(s.Experience, e)
+				}
+			}
+		case SectionEducation:
+			for _, e := range info.Education {
+				item := education{
+					School:   strings.TrimSpace(e.School),
+					Degree:   strings.TrimSpace(e.Degree),
+					Major:    strings.TrimSpace(e.Major),
+					Duration: strings.TrimSpace(e.Duration),
+					GPA:      strings.TrimSpace(e.GPA),
+				}
+				item.Headline = headline(item.School, item.Degree, item.Major)
+				if item.School != "" || item.Major != "" {
+					s.Education = append(s.Education, item)
+				}
+			}
+		case SectionSkills:
+			for _, g := range []skillGroup{
+				{"技术", joinNonEmpty(info.Skills.Technical, "、")},
+				{"通用能力", joinNonEmpty(info.Skills.Soft, "、")},
+				{"语言", joinNonEmpty(info.Skills.Languages, "、")},
+				{"证书", joinNonEmpty(info.Skills.Certifications, "、")},
+			} {
+				if g.Items != "" {
+					s.Skills = append(s.Skills, g)
+				}
+			}
+		}
+		if len(s.Experience)+len(s.Education)+len(s.Skills) > 0 {
+			v.Sections = append(v.Sections, s)
+		}
+	}
+	return v
+}
+
+var bulletPrefix = regexp.MustCompile(`^\s*(?:[-*•·●▪]|\d+[.、)）])\s*`)
+
+// Bullets 把工作描述按行拆分为要点，去掉行首的列表符号和编号
+func Bullets(description string) []string {
+	var bullets []string
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(bulletPrefix.ReplaceAllString(line, ""))
+		if line != "" {
+			bullets = append(bullets, line)
+		}
+	}
+	return bullets
+}
+
+func joinNonEmpty(items []string, sep string) string {
+	var kept []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			kept = append(kept, item)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+// headline 经历或教育的标题行，如 “某公司 · 高级工程师”
+func headline(parts ...string) string {
+	return joinNonEmpty(parts, " · ")
+}
+
+var markdownSpecial = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "#", `\#`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+func renderMarkdown(v *view) string {
+	var b strings.Builder
+	esc := markdownSpecial.Replace
+	if v.Name != "" {
+		fmt.Fprintf(&b, "# %s\n\n", esc(v.Name))
+	}
+	if len(v.Contact) > 0 {
+		fmt.Fprintf(&b, "%s\n\n", esc(strings.Join(v.Contact, " | ")))
+	}
+	for _, s := range v.Sections {
+		fmt.Fprintf(&b, "## %s\n\n", s.Title)
+		for _, e := range s.Experience {
+			fmt.Fprintf(&b, "### %s\n\n", esc(e.Headline))
+			if e.Duration != "" {
+				fmt.Fprintf(&b, "*%s*\n\n", esc(e.Duration))
+			}
+			for _, bullet := range e.Bullets {
+				fmt.Fprintf(&b, "- %s\n", esc(bullet))
+			}
+			if len(e.Bullets) > 0 {
+				b.WriteString("\n")
+			}
+			if e.Skills != "" {
+				fmt.Fprintf(&b, "**技能**：%s\n\n", esc(e.Skills))
+			}
+		}
+		for _, e := range s.Education {
+			fmt.Fprintf(&b, "### %s\n\n", esc(e.Headline))
+			var meta []string
+			if e.Duration != "" {
+				meta = append(meta, esc(e.Duration))
+			}
+			if e.GPA != "" {
+				meta = append(meta, "GPA "+esc(e.GPA))
+			}
+			if len(meta) > 0 {
+				fmt.Fprintf(&b, "*%s*\n\n", strings.Join(meta, " | "))
+			}
+		}
+		for _, g := range s.Skills {
+			fmt.Fprintf(&b, "- **%s**：%s\n", g.Label, esc(g.Items))
+		}
+		if len(s.Skills) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
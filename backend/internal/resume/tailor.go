@@ -0,0 +1,276 @@
+package resume
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+	"ai-career-buddy/internal/utils"
+)
+
+// maxKeywords 岗位关键词数量上限
+const maxKeywords = 20
+
+// Plan 针对目标岗位的优化方案
+type Plan struct {
+	Keywords      []string
+	SectionOrder  []string
+	SectionReason string
+	Summary       string
+	Rewrites      []Rewrite
+	PromptVersion string
+}
+
+// Rewrite 一条要点的改写建议
+type Rewrite struct {
+	Experience int // 工作经历序号(从0开始)
+	Bullet     int // 要点序号(从0开始)
+	Original   string
+	Text       string
+	Reason     string
+	Keywords   []string // 改写后简历新覆盖的岗位关键词
+}
+
+// Edit 采纳的改写
+type Edit struct {
+	Experience int
+	Bullet     int
+	Original   string // 生成建议时的原要点，不为空且与当前要点不一致时不替换
+	Text       string
+}
+
+// Coverage 岗位关键词在简历中的覆盖情况
+type Coverage struct {
+	Total   int      `json:"total"`
+	Covered []string `json:"covered"`
+	Missing []string `json:"missing"`
+	Percent int      `json:"percent"`
+}
+
+type tailorOutput struct {
+	Keywords      []string `json:"keywords"`
+	SectionOrder  []string `json:"sectionOrder"`
+	SectionReason string   `json:"sectionReason"`
+	Summary       string   `json:"summary"`
+	Rewrites      []struct {
+		ID      string `json:"id"`
+		Rewrite string `json:"rewrite"`
+		Reason  string `json:"reason"`
+	} `json:"rewrites"`
+}
+
+var bulletID = regexp.MustCompile(`^E(\d+)\.(\d+)$`)
+
+// Tailor 由模型针对目标岗位给出要点改写、关键词和章节顺序。
+// 模型返回的编号不存在或改写与原文相同的建议会被丢弃
+func Tailor(info *models.DocumentExtractedInfo, targetPosition, jobDescription, modelID string) (*Plan, error) {
+	vars := prompts.ResumeTailorVars{
+		TargetPosition: targetPosition,
+		JobDescription: jobDescription,
+		Resume:         describeResume(info),
+	}
+	prompt, ref, err := prompts.Render(prompts.KeyResumeTailor, prompts.Selector{Category: "career", ModelID: modelID}, vars)
+	if err != nil {
+		return nil, err
+	}
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("模型未返回结果")
+	}
+	content := response.Choices[0].Message.Content
+	var out tailorOutput
+	if err := json.Unmarshal([]byte(utils.CleanJSONContent(content)), &out); err != nil {
+		return nil, fmt.Errorf("无法解析模型输出: %v", err)
+	}
+
+	plan := &Plan{
+		Keywords:      cleanKeywords(out.Keywords),
+		SectionOrder:  completeSections(out.SectionOrder),
+		SectionReason: strings.TrimSpace(out.SectionReason),
+		Summary:       strings.TrimSpace(out.Summary),
+		PromptVersion: ref.String(),
+	}
+	before := KeywordCoverage(info, plan.Keywords)
+	seen := map[string]bool{}
+	for _, r := range out.Rewrites {
+		id := strings.TrimSpace(r.ID)
+		m := bulletID.FindStringSubmatch(id)
+		text := strings.TrimSpace(r.Rewrite)
+		if m == nil || seen[id] || text == "" {
+			continue
+		}
+		exp, _ := strconv.Atoi(m[1])
+		bullet, _ := strconv.Atoi(m[2])
+		if exp < 1 || exp > len(info.WorkExperience) {
+			continue
+		}
+		bullets := Bullets(info.WorkExperience[exp-1].Description)
+		if bullet < 1 || bullet > len(bullets) || bullets[bullet-1] == text {
+			continue
+		}
+		seen[id] = true
+		rw := Rewrite{Experience: exp - 1, Bullet: bullet - 1, Original: bullets[bullet-1], Text: text, Reason: strings.TrimSpace(r.Reason)}
+		for _, k := range before.Missing {
+			if containsKeyword(text, k) {
+				rw.Keywords = append(rw.Keywords, k)
+			}
+		}
+		plan.Rewrites = append(plan.Rewrites, rw)
+	}
+	logger.Info("简历优化建议生成: 模板=%s, 关键词=%d, 改写=%d/%d", ref, len(plan.Keywords), len(plan.Rewrites), len(out.Rewrites))
+	return plan, nil
+}
+
+// describeResume 把简历整理为带编号的文本，工作经历要点编号为 E经历序号.要点序号
+func describeResume(info *models.DocumentExtractedInfo) string {
+	var lines []string
+	if info.PersonalInfo.Name != "" {
+		lines = append(lines, "姓名："+info.PersonalInfo.Name)
+	}
+	if skills := joinNonEmpty(append(append([]string{}, info.Skills.Technical...), info.Skills.Certifications...), "、"); skills != "" {
+		lines = append(lines, "技能："+skills)
+	}
+	for _, e := range info.Education {
+		if h := headline(e.School, e.Degree, e.Major); h != "" {
+			lines = append(lines, "教育："+h)
+		}
+	}
+	lines = append(lines, "工作经历：")
+	for i, w := range info.WorkExperience {
+		line := fmt.Sprintf("[E%d] %s", i+1, headline(w.Company, w.Position))
+		if d := strings.TrimSpace(w.Duration); d != "" {
+			line += "（" + d + "）"
+		}
+		lines = append(lines, line)
+		for j, b := range Bullets(w.Description) {
+			lines = append(lines, fmt.Sprintf("  E%d.%d %s", i+1, j+1, b))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ApplyRewrites 返回替换了采纳要点后的简历信息副本，不修改 info。
+// 生成建议后简历被重新分析、要点已变化时跳过对应的改写
+func ApplyRewrites(info *models.DocumentExtractedInfo, edits []Edit) (*models.DocumentExtractedInfo, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	var result models.DocumentExtractedInfo
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	byExperience := map[int][]Edit{}
+	for _, e := range edits {
+		byExperience[e.Experience] = append(byExperience[e.Experience], e)
+	}
+	for i, list := range byExperience {
+		if i < 0 || i >= len(result.WorkExperience) {
+			continue
+		}
+		bullets := Bullets(result.WorkExperience[i].Description)
+		for _, e := range list {
+			if e.Bullet < 0 || e.Bullet >= len(bullets) || (e.Original != "" && bullets[e.Bullet] != e.Original) {
+				continue
+			}
+			if strings.TrimSpace(e.Text) != "" {
+				bullets[e.Bullet] = strings.TrimSpace(e.Text)
+			}
+		}
+		result.WorkExperience[i].Description = strings.Join(bullets, "\n")
+	}
+	return &result, nil
+}
+
+// KeywordCoverage 计算关键词在简历中的覆盖情况，不区分大小写
+func KeywordCoverage(info *models.DocumentExtractedInfo, keywords []string) Coverage {
+	text := resumeText(info)
+	c := Coverage{Total: len(keywords), Covered: []string{}, Missing: []string{}}
+	for _, k := range keywords {
+		if containsKeyword(text, k) {
+			c.Covered = append(c.Covered, k)
+		} else {
+			c.Missing = append(c.Missing, k)
+		}
+	}
+	if c.Total > 0 {
+		c.Percent = len(c.Covered) * 100 / c.Total
+	}
+	return c
+}
+
+// resumeText 简历中会出现在生成文件里的全部文字
+func resumeText(info *models.DocumentExtractedInfo) string {
+	var parts []string
+	for _, w := range info.WorkExperience {
+		parts = append(parts, w.Company, w.Position, w.Description)
+		parts = append(parts, w.Skills...)
+	}
+	for _, e := range info.Education {
+		parts = append(parts, e.School, e.Degree, e.Major)
+	}
+	s := info.Skills
+	for _, list := range [][]string{s.Technical, s.Soft, s.Languages, s.Certifications} {
+		parts = append(parts, list...)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func containsKeyword(text, keyword string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(keyword))
+}
+
+func cleanKeywords(keywords []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, k := range keywords {
+		k = strings.TrimSpace(k)
+		if k == "" || seen[strings.ToLower(k)] {
+			continue
+		}
+		seen[strings.ToLower(k)] = true
+		result = append(result, k)
+		if len(result) == maxKeywords {
+			break
+		}
+	}
+	return result
+}
+
+// completeSections 校验模型建议的章节顺序，忽略未知章节，遗漏的章节按默认顺序补在后面
+func completeSections(order []string) []string {
+	var result []string
+	for _, s := range append(order, DefaultSections...) {
+		s = strings.TrimSpace(s)
+		if _, ok := sectionTitles[s]; ok && !containsString(result, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
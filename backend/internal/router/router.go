@@ -24,7 +24,21 @@ func Setup() *gin.Engine {
 		api.GET("/messages", handlers.ListMessages)
 		api.POST("/messages", handlers.SendMessage)
 		api.POST("/messages/stream", handlers.StreamMessage)
+		api.POST("/messages/:id/regenerate", handlers.RegenerateMessage)
+		api.POST("/messages/:id/edit", handlers.EditMessage)
 		api.POST("/pdf/extract", handlers.ExtractPDFText)
+		api.GET("/users/:userId/threads/:threadId/summary", handlers.GetThreadSummary)
+
+		// 意图识别
+		api.GET("/intent/taxonomy", handlers.GetIntentTaxonomy)
+		api.POST("/intent/classify", handlers.ClassifyIntent)
+
+		// 回复反馈与收藏
+		api.GET("/messages/:id/feedback", handlers.GetMessageFeedback)
+		api.POST("/messages/:id/feedback", handlers.SubmitMessageFeedback)
+		api.POST("/messages/:id/bookmark", handlers.BookmarkMessage)
+		api.DELETE("/messages/:id/bookmark", handlers.UnbookmarkMessage)
+		api.GET("/users/:userId/bookmarks", handlers.GetBookmarks)
 
 		// 笔记相关
 		api.GET("/notes", handlers.ListNotes)
@@ -43,6 +57,7 @@ func Setup() *gin.Engine {
 		// 职业历史记录
 		api.GET("/users/:userId/career-history", handlers.GetCareerHistory)
 		api.POST("/users/:userId/career-history", handlers.SaveCareerHistory)
+		api.GET("/users/:userId/tags", handlers.GetUserTags)
 
 		// 合同风险点
 		api.GET("/users/:userId/contract-risks", handlers.GetContractRisks)
@@ -53,23 +68,106 @@ func Setup() *gin.Engine {
 		api.GET("/users/:userId/company-monitors", handlers.GetCompanyMonitors)
 		api.POST("/users/:userId/company-monitors", handlers.SaveCompanyMonitor)
 		api.PUT("/users/:userId/company-monitors/:monitorId", handlers.UpdateCompanyMonitor)
+		api.POST("/users/:userId/company-monitors/:monitorId/check", handlers.CheckCompanyMonitor)
+		api.GET("/users/:userId/company-monitors/:monitorId/alerts", handlers.GetMonitorAlerts)
+		api.GET("/users/:userId/monitor-alerts", handlers.GetMonitorAlerts)
+		api.POST("/alert-rules/validate", handlers.ValidateAlertRule)
+
+		// 通知
+		api.GET("/users/:userId/notifications", handlers.GetNotifications)
+		api.GET("/users/:userId/notifications/unread-count", handlers.GetUnreadNotificationCount)
+		api.PUT("/users/:userId/notifications/read-all", handlers.MarkAllNotificationsRead)
+		api.PUT("/users/:userId/notifications/:notificationId/read", handlers.MarkNotificationRead)
+		api.PUT("/users/:userId/notifications/:notificationId/unread", handlers.MarkNotificationUnread)
+		api.DELETE("/users/:userId/notifications/:notificationId", handlers.DeleteNotification)
+		api.GET("/users/:userId/notification-preferences", handlers.GetNotificationPreference)
+		api.PUT("/users/:userId/notification-preferences", handlers.UpdateNotificationPreference)
+		api.GET("/users/:userId/notification-deliveries", handlers.GetNotificationDeliveries)
+		api.POST("/users/:userId/notifications/test", handlers.SendTestNotification)
+
+		// 企业主数据
+		api.GET("/companies", handlers.GetCompanies)
+		api.POST("/companies", handlers.CreateCompany)
+		api.POST("/companies/resolve", handlers.ResolveCompany)
+		api.POST("/companies/backfill", handlers.BackfillCompanies)
+		api.GET("/companies/:companyId", handlers.GetCompany)
+		api.GET("/companies/:companyId/insights", handlers.GetCompanyInsights)
+		api.PUT("/companies/:companyId", handlers.UpdateCompany)
+		api.DELETE("/companies/:companyId", handlers.DeleteCompany)
+		api.POST("/companies/:companyId/aliases", handlers.AddCompanyAlias)
+		api.DELETE("/companies/:companyId/aliases/:aliasId", handlers.DeleteCompanyAlias)
+		api.POST("/companies/:companyId/merge", handlers.MergeCompany)
 
 		// 个性化指标
 		api.GET("/users/:userId/personal-metrics", handlers.GetPersonalMetrics)
 		api.PUT("/users/:userId/personal-metrics", handlers.UpdatePersonalMetrics)
 
+		// 职业目标与计划任务
+		api.GET("/users/:userId/goals", handlers.GetGoals)
+		api.POST("/users/:userId/goals", handlers.CreateGoal)
+		api.GET("/users/:userId/goals/:goalId", handlers.GetGoal)
+		api.PUT("/users/:userId/goals/:goalId", handlers.UpdateGoal)
+		api.DELETE("/users/:userId/goals/:goalId", handlers.DeleteGoal)
+		api.POST("/users/:userId/goals/:goalId/generate-plan", handlers.GenerateGoalPlan)
+		api.GET("/users/:userId/goals/:goalId/tasks", handlers.GetPlanTasks)
+		api.POST("/users/:userId/goals/:goalId/tasks", handlers.CreatePlanTask)
+		api.PUT("/users/:userId/goals/:goalId/tasks/:taskId", handlers.UpdatePlanTask)
+		api.DELETE("/users/:userId/goals/:goalId/tasks/:taskId", handlers.DeletePlanTask)
+
 		// 职业阶段
 		api.GET("/career-stages", handlers.GetCareerStages)
+		api.PUT("/career-stages/:track", handlers.UpdateCareerStageDefinitions)
+		api.GET("/users/:userId/career-stage", handlers.GetUserCareerStage)
 
 		// 用户文档管理
 		api.GET("/users/:userId/documents", handlers.GetUserDocuments)
 		api.POST("/users/:userId/documents", handlers.UploadUserDocument)
+		api.POST("/users/:userId/documents/diff", handlers.DiffUserDocuments)
 		api.GET("/users/:userId/documents/:documentId", handlers.GetUserDocument)
 		api.DELETE("/users/:userId/documents/:documentId", handlers.DeleteUserDocument)
 		api.POST("/users/:userId/documents/:documentId/process", handlers.ProcessDocument)
+		api.GET("/users/:userId/documents/:documentId/file", handlers.GetDocumentFile)
+		api.GET("/users/:userId/documents/:documentId/download-url", handlers.GetDocumentDownloadURL)
+		api.GET("/users/:userId/documents/:documentId/versions", handlers.GetDocumentVersions)
+		api.GET("/documents/:id/download", handlers.DownloadDocument)
+		api.GET("/resumes/templates", handlers.GetResumeTemplates)
+		api.POST("/users/:userId/resumes/render", handlers.RenderResume)
+		api.POST("/users/:userId/resumes/:documentId/tailor", handlers.TailorResume)
+		api.GET("/users/:userId/resume-tailorings/:tailoringId", handlers.GetResumeTailoring)
+		api.PUT("/users/:userId/resume-tailorings/:tailoringId/suggestions/:suggestionId", handlers.UpdateResumeSuggestion)
+		api.POST("/users/:userId/resume-tailorings/:tailoringId/apply", handlers.ApplyResumeTailoring)
+		api.GET("/drafts/options", handlers.GetDraftOptions)
+		api.POST("/users/:userId/drafts/generate", handlers.GenerateDraft)
+		api.GET("/users/:userId/drafts", handlers.GetDrafts)
+		api.GET("/users/:userId/drafts/:draftId", handlers.GetDraft)
+		api.PUT("/users/:userId/drafts/:draftId", handlers.UpdateDraft)
+		api.DELETE("/users/:userId/drafts/:draftId", handlers.DeleteDraft)
+		api.GET("/users/:userId/drafts/:draftId/export", handlers.ExportDraft)
 		api.GET("/users/:userId/documents/:documentId/extracted-info", handlers.GetDocumentExtractedInfo)
 		api.GET("/users/:userId/documents/:documentId/visualization", handlers.GenerateDocumentVisualization)
 		api.POST("/users/:userId/documents/:documentId/retry", handlers.RetryDocumentProcessing)
+
+		// 全文检索
+		api.GET("/users/:userId/search", handlers.SearchUser)
+
+		// 用户资料检索
+		api.GET("/users/:userId/knowledge/search", handlers.SearchUserKnowledge)
+		api.POST("/users/:userId/knowledge/reindex", handlers.ReindexUserKnowledge)
+
+		// 提示词模板管理
+		api.GET("/admin/prompts", handlers.ListPromptTemplates)
+		api.GET("/admin/prompts/:key", handlers.GetPromptTemplate)
+		api.POST("/admin/prompts/:key/versions", handlers.CreatePromptTemplate)
+		api.POST("/admin/prompts/:key/preview", handlers.PreviewPromptTemplate)
+		api.POST("/admin/prompts/:key/versions/:versionId/activate", handlers.ActivatePromptTemplate)
+		api.POST("/admin/prompts/:key/rollback", handlers.RollbackPromptTemplate)
+
+		// 实验管理
+		api.GET("/admin/experiments", handlers.ListExperiments)
+		api.POST("/admin/experiments", handlers.CreateExperiment)
+		api.GET("/admin/experiments/:key", handlers.GetExperiment)
+		api.PUT("/admin/experiments/:key", handlers.UpdateExperiment)
+		api.GET("/admin/experiments/:key/report", handlers.GetExperimentReport)
 	}
 	return r
 }
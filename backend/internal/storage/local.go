@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LocalStore 保存在本地目录的文件存储，适合单机部署
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore 创建本地文件存储，目录不存在时自动创建
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("未配置本地存储目录")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// Name 存储类型
+func (s *LocalStore) Name() string {
+	return "local"
+}
+
+// Put 先写入临时文件，计算摘要后移动到最终位置
+func (s *LocalStore) Put(r io.Reader, contentType string) (Blob, error) {
+	f, blob, err := spool(r, filepath.Join(s.dir, "tmp"))
+	if err != nil {
+		return Blob{}, fmt.Errorf("写入临时文件失败: %v", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	path := s.path(blob.Key)
+	if _, err := os.Stat(path); err == nil {
+		return blob, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Blob{}, err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return Blob{}, fmt.Errorf("保存文件失败: %v", err)
+	}
+	return blob, nil
+}
+
+// Open 打开文件
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete 删除文件
+func (s *LocalStore) Delete(key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL 本地存储没有可直接访问的地址，由下载接口读取文件
+func (s *LocalStore) SignedURL(key, fileName, contentType string, ttl time.Duration) (string, error) {
+	return "", nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
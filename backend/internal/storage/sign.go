@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/logger"
+)
+
+var (
+	signingKeyOnce sync.Once
+	signingKey     []byte
+)
+
+// key 返回下载链接的签名密钥。未配置时使用随机密钥，重启或多副本部署时已发出的链接会失效
+func key() []byte {
+	signingKeyOnce.Do(func() {
+		if config.C.StorageSigningKey != "" {
+			signingKey = []byte(config.C.StorageSigningKey)
+			return
+		}
+		signingKey = make([]byte, 32)
+		_, _ = rand.Read(signingKey)
+		logger.Warn("未配置 STORAGE_SIGNING_KEY，使用随机密钥签名下载链接，重启后链接失效")
+	})
+	return signingKey
+}
+
+// SignPath 为接口路径添加有效期和签名参数，如 /api/documents/1/download?expires=...&signature=...
+func SignPath(path string, ttl time.Duration) (string, time.Time) {
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return path + "?expires=" + exp + "&signature=" + sign(path, exp), expires
+}
+
+// VerifyPath 校验签名是否有效且未过期
+func VerifyPath(path, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(sign(path, expires)))
+}
+
+func sign(path, expires string) string {
+	mac := hmac.New(sha256.New, key())
+	mac.Write([]byte(path + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ContentDisposition 生成下载时使用原始文件名的 Content-Disposition，兼容中文文件名
+func ContentDisposition(fileName string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, fileName)
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback, url.PathEscape(fileName))
+}
@@ -0,0 +1,124 @@
+// Package storage 保存用户上传的文件
+//
+// 文件按内容的 SHA-256 寻址（键为 sha256/<前两位>/<摘要>），相同内容只保存一份，同一秒上传的
+// 同名文件也不会互相覆盖。支持本地目录和 S3 兼容的对象存储（如 MinIO），多副本部署时应使用
+// 对象存储。下载链接由服务端签名，带有效期，不依赖登录状态。
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/logger"
+)
+
+// ErrNotFound 文件不存在
+var ErrNotFound = errors.New("文件不存在")
+
+var keyRegex = regexp.MustCompile(`^sha256/[0-9a-f]{2}/[0-9a-f]{64}$`)
+
+// Blob 保存后的文件
+type Blob struct {
+	Key    string `json:"key"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// BlobStore 文件存储
+type BlobStore interface {
+	// Put 保存内容并返回按内容寻址的键，内容已存在时不重复写入
+	Put(r io.Reader, contentType string) (Blob, error)
+	// Open 以流的方式读取内容，不存在时返回 ErrNotFound
+	Open(key string) (io.ReadCloser, error)
+	// Delete 删除内容，不存在时不报错
+	Delete(key string) error
+	// SignedURL 返回可直接从存储下载的临时链接，不支持时返回空字符串
+	SignedURL(key, fileName, contentType string, ttl time.Duration) (string, error)
+	// Name 存储类型，用于日志
+	Name() string
+}
+
+var (
+	defaultStore BlobStore
+	defaultOnce  sync.Once
+)
+
+// Default 返回按配置创建的文件存储，创建失败时返回 nil
+func Default() BlobStore {
+	defaultOnce.Do(func() {
+		var err error
+		switch config.C.StorageBackend {
+		case "", "local":
+			defaultStore, err = NewLocalStore(config.C.StorageLocalDir)
+		case "s3":
+			defaultStore, err = NewS3Store(S3Config{
+				Endpoint:  config.C.StorageS3Endpoint,
+				Region:    config.C.StorageS3Region,
+				Bucket:    config.C.StorageS3Bucket,
+				AccessKey: config.C.StorageS3AccessKey,
+				SecretKey: config.C.StorageS3SecretKey,
+				PathStyle: config.C.StorageS3PathStyle,
+			})
+		default:
+			err = fmt.Errorf("未知的文件存储类型: %s", config.C.StorageBackend)
+		}
+		if err != nil {
+			logger.Error("创建文件存储失败: %v", err)
+			defaultStore = nil
+			return
+		}
+		logger.Info("文件存储已启用: Backend=%s", defaultStore.Name())
+	})
+	return defaultStore
+}
+
+// ReadAll 读取全部内容
+func ReadAll(s BlobStore, key string) ([]byte, error) {
+	rc, err := s.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// keyFor 按内容摘要生成键
+func keyFor(sum string) string {
+	return "sha256/" + sum[:2] + "/" + sum
+}
+
+// validKey 只接受按内容寻址的键，防止拼出存储目录以外的路径
+func validKey(key string) error {
+	if !keyRegex.MatchString(key) {
+		return fmt.Errorf("无效的文件键: %s", key)
+	}
+	return nil
+}
+
+// spool 把内容写入 dir 下的临时文件并计算摘要，返回已回到开头的临时文件，调用方负责删除
+func spool(r io.Reader, dir string) (*os.File, Blob, error) {
+	f, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, Blob{}, err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, Blob{}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	return f, Blob{Key: keyFor(sum), SHA256: sum, Size: n}, nil
+}
@@ -0,0 +1,301 @@
+// Package summary 为长会话维护滚动摘要
+//
+// 会话中尚未摘要的对话超过 THREAD_SUMMARY_TURNS 轮后，除最近 THREAD_RECENT_TURNS 轮外的消息
+// 由模型合并进已有摘要。生成回复时以摘要加尚未摘要的原始消息作为上下文，不再发送完整历史。
+// 摘要沿对话分支生成，切换到不包含已摘要消息的分支后会重新生成。
+package summary
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/db"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+	"ai-career-buddy/internal/prompts"
+
+	"gorm.io/gorm"
+)
+
+// 上下文和摘要输入中单条消息的最大长度
+const maxMessageRunes = 1000
+
+// threadLock 会话摘要锁，refs 为持有或等待该锁的调用数
+type threadLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// 同一会话的摘要串行更新，没有调用等待时删除锁，避免随会话数增长
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*threadLock{}
+)
+
+// lockThread 锁定用户的会话，返回解锁函数
+func lockThread(userID, threadID string) func() {
+	key := userID + "\x00" + threadID
+	locksMu.Lock()
+	l := locks[key]
+	if l == nil {
+		l = &threadLock{}
+		locks[key] = l
+	}
+	l.refs++
+	locksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		locksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(locks, key)
+		}
+		locksMu.Unlock()
+	}
+}
+
+// Context 返回回复用户消息时附加的会话上下文：已有摘要和之后的原始消息
+func Context(msg *models.Message) string {
+	if !config.C.ThreadSummaryEnabled || msg.ParentID == nil {
+		return ""
+	}
+	path, err := branchPath(msg.UserID, msg.ThreadID, *msg.ParentID)
+	if err != nil {
+		logger.Warn("加载会话历史失败: ThreadID=%s, 错误=%v", msg.ThreadID, err)
+		return ""
+	}
+
+	var previous string
+	var s models.ThreadSummary
+	if err := db.Conn.Where("user_id = ? AND thread_id = ?", msg.UserID, msg.ThreadID).First(&s).Error; err == nil {
+		if i := indexOf(path, s.CoveredMessageID); i >= 0 {
+			previous = s.Summary
+			path = path[i+1:]
+		}
+	}
+	// 摘要未及时更新时也不超过 THREAD_SUMMARY_TURNS 轮原文
+	path = lastTurns(path, config.C.ThreadSummaryTurns)
+
+	var sb strings.Builder
+	if previous != "" {
+		sb.WriteString("\n\n【会话摘要】以下是本次会话较早内容的摘要：\n")
+		sb.WriteString(previous)
+	}
+	if len(path) > 0 {
+		sb.WriteString("\n\n【最近对话】\n")
+		sb.WriteString(transcript(path))
+	}
+	return sb.String()
+}
+
+// Get 返回用户会话已保存的摘要，没有时返回 nil
+func Get(userID, threadID string) (*models.ThreadSummary, error) {
+	var s models.ThreadSummary
+	err := db.Conn.Where("user_id = ? AND thread_id = ?", userID, threadID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Pending 返回以 leafID 结尾的分支上尚未并入摘要的对话轮数
+func Pending(s *models.ThreadSummary, userID, threadID string, leafID uint) (int, error) {
+	path, err := branchPath(userID, threadID, leafID)
+	if err != nil {
+		return 0, err
+	}
+	if s != nil {
+		if i := indexOf(path, s.CoveredMessageID); i >= 0 {
+			path = path[i+1:]
+		}
+	}
+	return countTurns(path), nil
+}
+
+// RefreshAsync 在回复保存后异步更新摘要
+func RefreshAsync(userID, threadID string, leafID uint) {
+	if !config.C.ThreadSummaryEnabled {
+		return
+	}
+	go func() {
+		if _, err := Refresh(userID, threadID, leafID, false); err != nil {
+			logger.Warn("更新会话摘要失败: UserID=%s, ThreadID=%s, 错误=%v", userID, threadID, err)
+		}
+	}()
+}
+
+// Refresh 把以 leafID 结尾的分支上较早的消息并入摘要；force 为 true 时不等待达到
+// THREAD_SUMMARY_TURNS 轮，除最近 THREAD_RECENT_TURNS 轮外全部并入。返回最新的摘要，没有摘要时返回 nil
+func Refresh(userID, threadID string, leafID uint, force bool) (*models.ThreadSummary, error) {
+	defer lockThread(userID, threadID)()
+
+	s, err := Get(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	path, err := branchPath(userID, threadID, leafID)
+	if err != nil {
+		return s, err
+	}
+
+	// 已有摘要不在当前分支上时从头生成
+	pending := path
+	var previous string
+	var coveredTurns int
+	if s != nil {
+		if i := indexOf(path, s.CoveredMessageID); i >= 0 {
+			pending = path[i+1:]
+			previous = s.Summary
+			coveredTurns = s.CoveredTurns
+		}
+	}
+	if !force && countTurns(pending) <= config.C.ThreadSummaryTurns {
+		return s, nil
+	}
+	recent := lastTurns(pending, config.C.ThreadRecentTurns)
+	fold := pending[:len(pending)-len(recent)]
+	if len(fold) == 0 {
+		return s, nil
+	}
+
+	modelID := config.C.ThreadSummaryModel
+	text, ref, err := summarize(previous, fold, modelID)
+	if err != nil {
+		return s, err
+	}
+
+	if s == nil {
+		s = &models.ThreadSummary{UserID: userID, ThreadID: threadID}
+	}
+	s.Summary = text
+	s.CoveredMessageID = fold[len(fold)-1].ID
+	s.CoveredTurns = coveredTurns + countTurns(fold)
+	s.ModelID = modelID
+	s.PromptVersion = ref.String()
+	if err := db.Conn.Save(s).Error; err != nil {
+		return nil, err
+	}
+	logger.Info("会话摘要已更新: UserID=%s, ThreadID=%s, 覆盖轮数=%d, 覆盖到消息=%d", userID, threadID, s.CoveredTurns, s.CoveredMessageID)
+	return s, nil
+}
+
+// Setup 删除早期只按会话名称建立的唯一索引，摘要改为按用户和会话区分
+func Setup() {
+	m := db.Conn.Migrator()
+	if m.HasIndex(&models.ThreadSummary{}, "idx_thread_summaries_thread_id") {
+		if err := m.DropIndex(&models.ThreadSummary{}, "idx_thread_summaries_thread_id"); err != nil {
+			logger.Warn("删除会话摘要旧索引失败: %v", err)
+		}
+	}
+}
+
+// summarize 调用模型把对话合并进已有摘要
+func summarize(previous string, msgs []models.Message, modelID string) (string, prompts.Ref, error) {
+	vars := prompts.SummaryVars{Previous: previous, Transcript: transcript(msgs)}
+	prompt, ref, err := prompts.Render(prompts.KeyChatSummary, prompts.Selector{ModelID: modelID}, vars)
+	if err != nil {
+		return "", ref, err
+	}
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, nil)
+	if err != nil {
+		return "", ref, err
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return "", ref, fmt.Errorf("模型未返回摘要")
+	}
+	return strings.TrimSpace(response.Choices[0].Message.Content), ref, nil
+}
+
+// branchPath 返回该用户会话中从根消息到 leafID 的消息，按对话顺序排列
+func branchPath(userID, threadID string, leafID uint) ([]models.Message, error) {
+	var msgs []models.Message
+	if err := db.Conn.Where("user_id = ? AND thread_id = ?", userID, threadID).Order("id ASC").Find(&msgs).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Message, len(msgs))
+	for i := range msgs {
+		byID[msgs[i].ID] = &msgs[i]
+	}
+
+	var path []models.Message
+	for m := byID[leafID]; m != nil; {
+		path = append(path, *m)
+		if m.ParentID == nil || len(path) > len(msgs) {
+			break
+		}
+		m = byID[*m.ParentID]
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// transcript 把消息整理为对话文本
+func transcript(msgs []models.Message) string {
+	lines := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		role := "用户"
+		if m.Role == "assistant" {
+			role = "助手"
+		}
+		lines = append(lines, role+": "+plainContent(m.Content))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainContent 去掉回复末尾的模型标注并截断过长的内容
+func plainContent(content string) string {
+	if i := strings.LastIndex(content, "\n\n[使用模型:"); i >= 0 {
+		content = content[:i]
+	}
+	content = strings.TrimSpace(content)
+	if r := []rune(content); len(r) > maxMessageRunes {
+		content = string(r[:maxMessageRunes]) + "…"
+	}
+	return content
+}
+
+// lastTurns 返回最近 n 轮对话，一轮从一条用户消息开始
+func lastTurns(msgs []models.Message, n int) []models.Message {
+	if n <= 0 {
+		return nil
+	}
+	turns := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			turns++
+			if turns == n {
+				return msgs[i:]
+			}
+		}
+	}
+	return msgs
+}
+
+func countTurns(msgs []models.Message) int {
+	n := 0
+	for _, m := range msgs {
+		if m.Role == "user" {
+			n++
+		}
+	}
+	return n
+}
+
+func indexOf(msgs []models.Message, id uint) int {
+	for i, m := range msgs {
+		if m.ID == id {
+			return i
+		}
+	}
+	return -1
+}
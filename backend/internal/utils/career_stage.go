@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/models"
+)
+
+// 职业发展路线
+const (
+	TrackGeneral    = "general"
+	TrackTechnical  = "technical"
+	TrackManagement = "management"
+)
+
+// stageSeed 默认阶段配置
+type stageSeed struct {
+	stage, description, skills, goals, duration string
+	minExp, maxExp, requiredGoals               int
+	keywords                                    []string
+}
+
+var defaultStageSeeds = map[string][]stageSeed{
+	TrackGeneral: {
+		{"职场新人", "刚进入职场，学习基础技能", "基础技能、沟通能力、学习能力", "快速适应工作环境，掌握基本技能", "1-2年", 0, 2, 1, []string{"实习", "助理", "初级", "应届", "管培生"}},
+		{"技能提升", "专业技能快速提升期", "专业技能、项目管理、团队协作", "成为团队核心成员，承担重要项目", "2-3年", 2, 5, 2, []string{"中级", "专员"}},
+		{"专业专家", "在专业领域有一定影响力", "深度专业技能、领导力、行业洞察", "成为行业专家，指导他人", "3-5年", 5, 8, 2, []string{"高级", "资深", "专家", "senior"}},
+		{"管理转型", "从专业向管理转型", "管理技能、战略思维、人员管理", "带领团队，制定战略", "5-8年", 8, 12, 3, []string{"主管", "组长", "经理", "leader", "manager"}},
+		{"高级管理", "高级管理层", "高级管理、战略规划、商业洞察", "制定公司战略，影响行业发展", "8年以上", 12, 0, 3, []string{"总监", "副总裁", "总裁", "vp", "director", "ceo", "cto", "coo", "cfo"}},
+	},
+	TrackTechnical: {
+		{"职场新人", "刚进入职场，夯实技术基础", "编程基础、工程规范、学习能力", "独立完成模块开发", "1-2年", 0, 2, 1, []string{"实习", "助理", "初级", "应届", "junior"}},
+		{"技能提升", "成为能独当一面的工程师", "系统设计、代码质量、项目交付", "负责完整功能或子系统", "2-3年", 2, 4, 2, []string{"中级"}},
+		{"专业专家", "在技术领域形成专长", "架构设计、性能优化、技术影响力", "主导复杂项目，指导他人", "3-5年", 4, 8, 2, []string{"高级", "资深", "senior"}},
+		{"技术专家", "跨团队的技术带头人", "技术战略、架构治理、技术布道", "制定技术方向，解决关键难题", "4-6年", 8, 12, 3, []string{"专家", "架构师", "staff", "principal", "技术负责人"}},
+		{"首席专家", "公司级技术决策者", "技术愿景、行业影响力、组织建设", "影响公司技术战略和行业发展", "8年以上", 12, 0, 3, []string{"首席", "fellow", "cto", "chief"}},
+	},
+	TrackManagement: {
+		{"职场新人", "刚进入职场，学习基础技能", "基础技能、沟通能力、学习能力", "快速适应工作环境，掌握基本技能", "1-2年", 0, 2, 1, []string{"实习", "助理", "初级", "应届", "管培生"}},
+		{"技能提升", "专业能力与协作能力并重", "专业技能、项目管理、跨部门协作", "成为团队骨干，承担项目负责人", "2-3年", 2, 4, 2, []string{"中级", "专员", "项目负责人"}},
+		{"管理转型", "从个人贡献者转为带团队", "团队管理、目标拆解、绩效辅导", "带领小团队达成业务目标", "3-4年", 4, 7, 2, []string{"主管", "组长", "leader", "team lead"}},
+		{"中层管理", "负责部门或业务线", "组织建设、资源协调、战略落地", "打造高绩效团队，承担业务结果", "3-5年", 7, 10, 3, []string{"经理", "manager", "总监", "director", "负责人"}},
+		{"高级管理", "高级管理层", "高级管理、战略规划、商业洞察", "制定公司战略，影响行业发展", "8年以上", 10, 0, 3, []string{"副总裁", "总裁", "vp", "ceo", "coo", "cfo", "cto", "合伙人"}},
+	},
+}
+
+// DefaultCareerStageDefinitions 返回内置的通用阶段配置（行业为空）
+func DefaultCareerStageDefinitions() []models.CareerStageDefinition {
+	var definitions []models.CareerStageDefinition
+	for _, track := range []string{TrackGeneral, TrackTechnical, TrackManagement} {
+		for i, seed := range defaultStageSeeds[track] {
+			definition := models.CareerStageDefinition{
+				Track:         track,
+				StageOrder:    i,
+				Stage:         seed.stage,
+				Description:   seed.description,
+				Skills:        seed.skills,
+				Goals:         seed.goals,
+				Duration:      seed.duration,
+				MinExperience: seed.minExp,
+				MaxExperience: seed.maxExp,
+				RequiredGoals: seed.requiredGoals,
+			}
+			definition.SetPositionKeywords(seed.keywords)
+			definitions = append(definitions, definition)
+		}
+	}
+	return definitions
+}
+
+// managementKeywords 用于从职位推断管理路线
+var managementKeywords = []string{"主管", "组长", "经理", "总监", "副总裁", "总裁", "负责人", "manager", "director", "vp", "head", "lead", "leader", "ceo", "coo"}
+
+// technicalKeywords 用于从职位推断技术路线，其他职位（销售、人事、专员等）使用通用路线
+var technicalKeywords = []string{"工程师", "开发", "研发", "程序员", "架构", "算法", "技术", "前端", "后端", "测试", "运维", "数据",
+	"engineer", "developer", "programmer", "architect", "devops", "sre", "qa", "dev", "cto"}
+
+// InferCareerTrack 根据职位名称推断发展路线
+func InferCareerTrack(position string) string {
+	lower := strings.ToLower(position)
+	for _, keyword := range managementKeywords {
+		if MatchPositionKeyword(lower, keyword) {
+			return TrackManagement
+		}
+	}
+	for _, keyword := range technicalKeywords {
+		if MatchPositionKeyword(lower, keyword) {
+			return TrackTechnical
+		}
+	}
+	return TrackGeneral
+}
+
+// MatchPositionKeyword 判断职位是否包含关键词（不区分大小写）。英文关键词按单词匹配，
+// 避免 director 命中 cto、leadership 命中 lead；中文关键词按子串匹配
+func MatchPositionKeyword(position, keyword string) bool {
+	position, keyword = strings.ToLower(position), strings.ToLower(strings.TrimSpace(keyword))
+	if keyword == "" {
+		return false
+	}
+	if !isASCIIWord(keyword) {
+		return strings.Contains(position, keyword)
+	}
+	for start := 0; ; {
+		i := strings.Index(position[start:], keyword)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(keyword)
+		if (i == 0 || !isASCIIAlnum(position[i-1])) && (end == len(position) || !isASCIIAlnum(position[end])) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func isASCIIWord(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIAlnum(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+// CareerStageSignals 评估职业阶段使用的用户信号
+type CareerStageSignals struct {
+	ExperienceYears     float64 `json:"experienceYears"`     // 工作年限
+	ExperienceSource    string  `json:"experienceSource"`    // 年限来源: profile, resume
+	Position            string  `json:"position"`            // 当前职位
+	WorkExperienceCount int     `json:"workExperienceCount"` // 简历中的工作段数
+	ResumeCount         int     `json:"resumeCount"`         // 已分析的简历份数
+	CompletedGoals      int     `json:"completedGoals"`      // 已完成的职业目标数
+}
+
+// CareerStageAssessment 用户职业阶段评估结果
+type CareerStageAssessment struct {
+	Track        string               `json:"track"`
+	Industry     string               `json:"industry"`
+	CurrentStage string               `json:"currentStage"`
+	CurrentIndex int                  `json:"currentIndex"`
+	Stages       []models.CareerStage `json:"stages"`
+	Signals      CareerStageSignals   `json:"signals"`
+	Reasons      []string             `json:"reasons"`
+}
+
+// AssessCareerStage 根据阶段配置和用户信号计算当前阶段及各阶段进度
+//
+// 当前阶段优先由职位关键词确定，工作年限最多只能把阶段向上修正一级；
+// 没有匹配到职位关键词时按工作年限确定。当前阶段内的进度由年限(60%)、
+// 已完成目标(30%)和履历丰富度(10%)加权得出，上限95，100留给已跨越的阶段。
+func AssessCareerStage(definitions []models.CareerStageDefinition, signals CareerStageSignals) *CareerStageAssessment {
+	assessment := &CareerStageAssessment{Signals: signals, CurrentIndex: -1}
+	if len(definitions) == 0 {
+		return assessment
+	}
+	assessment.Track = definitions[0].Track
+	assessment.Industry = definitions[0].Industry
+
+	expIndex := 0
+	for i, d := range definitions {
+		if signals.ExperienceYears >= float64(d.MinExperience) {
+			expIndex = i
+		}
+	}
+	assessment.Reasons = append(assessment.Reasons,
+		fmt.Sprintf("工作年限%.1f年，对应阶段「%s」", signals.ExperienceYears, definitions[expIndex].Stage))
+
+	posIndex := -1
+	if signals.Position != "" {
+		for i, d := range definitions {
+			for _, keyword := range d.GetPositionKeywords() {
+				if MatchPositionKeyword(signals.Position, keyword) {
+					posIndex = i
+				}
+			}
+		}
+	}
+
+	current := expIndex
+	if posIndex >= 0 {
+		assessment.Reasons = append(assessment.Reasons,
+			fmt.Sprintf("职位「%s」匹配阶段「%s」", signals.Position, definitions[posIndex].Stage))
+		current = posIndex
+		if expIndex > posIndex {
+			current = posIndex + 1
+		}
+	}
+	assessment.CurrentIndex = current
+	assessment.CurrentStage = definitions[current].Stage
+
+	d := definitions[current]
+	span := float64(d.MaxExperience - d.MinExperience)
+	if d.MaxExperience == 0 || span <= 0 {
+		span = 4
+	}
+	expProgress := clamp01((signals.ExperienceYears - float64(d.MinExperience)) / span)
+
+	goalProgress := 0.0
+	if d.RequiredGoals > 0 {
+		goalProgress = clamp01(float64(signals.CompletedGoals) / float64(d.RequiredGoals))
+	}
+	historyProgress := clamp01(float64(signals.WorkExperienceCount) / 3)
+
+	currentProgress := int(math.Round((expProgress*0.6 + goalProgress*0.3 + historyProgress*0.1) * 100))
+	if currentProgress > 95 {
+		currentProgress = 95
+	}
+	if signals.CompletedGoals > 0 {
+		assessment.Reasons = append(assessment.Reasons, fmt.Sprintf("已完成%d个职业目标", signals.CompletedGoals))
+	}
+
+	for i, definition := range definitions {
+		stage := models.CareerStage{
+			Stage:       definition.Stage,
+			Description: definition.Description,
+			Skills:      definition.Skills,
+			Goals:       definition.Goals,
+			Duration:    definition.Duration,
+			Track:       definition.Track,
+		}
+		switch {
+		case i < current:
+			stage.Progress = 100
+		case i == current:
+			stage.Progress = currentProgress
+			stage.Current = true
+		}
+		assessment.Stages = append(assessment.Stages, stage)
+	}
+
+	return assessment
+}
+
+var (
+	yearMonthPattern = regexp.MustCompile(`((?:19|20)\d{2})(?:\s*[年./\-]\s*(\d{1,2})\b)?`)
+	yearCountPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*年`)
+	ongoingPattern   = regexp.MustCompile(`(?i)至今|现在|目前|present|now`)
+)
+
+// ParseDurationYears 解析简历中的工作时间，如"2019.03 - 2022.06"、"2020年3月-至今"、"3年"
+func ParseDurationYears(duration string, now time.Time) float64 {
+	matches := yearMonthPattern.FindAllStringSubmatch(duration, -1)
+	if len(matches) == 0 {
+		if m := yearCountPattern.FindStringSubmatch(duration); m != nil {
+			years, _ := strconv.ParseFloat(m[1], 64)
+			return years
+		}
+		return 0
+	}
+
+	toMonths := func(m []string) int {
+		year, _ := strconv.Atoi(m[1])
+		month := 1
+		if m[2] != "" {
+			month, _ = strconv.Atoi(m[2])
+			if month < 1 || month > 12 {
+				month = 1
+			}
+		}
+		return year*12 + month - 1
+	}
+
+	start := toMonths(matches[0])
+	end := start
+	if len(matches) > 1 {
+		end = toMonths(matches[1])
+	} else if ongoingPattern.MatchString(duration) {
+		end = now.Year()*12 + int(now.Month()) - 1
+	}
+	if end < start {
+		return 0
+	}
+	return float64(end-start) / 12
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
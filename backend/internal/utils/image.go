@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	_ "image/png" // 注册PNG解码器
+)
+
+// 依次尝试的JPEG质量，仍超过大小上限时把图片缩小一半后重试
+var jpegQualities = []int{85, 70, 55}
+
+// PrepareImage 把图片调整为适合发送给视觉模型的大小：最长边超过 maxSide 时等比缩小，
+// 编码后超过 maxBytes 时降低质量或继续缩小。尺寸和大小都在限制内的JPEG/PNG原样返回，
+// 其他情况转为JPEG（透明背景填充为白色）。返回图片数据和MIME类型。
+func PrepareImage(data []byte, maxSide, maxBytes int) ([]byte, string, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("无法解析图片: %v", err)
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil, "", fmt.Errorf("图片尺寸无效")
+	}
+	if max(w, h) <= maxSide && len(data) <= maxBytes && (format == "jpeg" || format == "png") {
+		return data, "image/" + format, nil
+	}
+
+	// 转为白底RGBA，之后的缩放直接读写像素
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	if side := max(w, h); side > maxSide {
+		w, h = max(1, w*maxSide/side), max(1, h*maxSide/side)
+	}
+	for attempt := 0; attempt < 4; attempt++ {
+		scaled := src
+		if w != src.Bounds().Dx() || h != src.Bounds().Dy() {
+			scaled = resizeRGBA(src, w, h)
+		}
+		var buf bytes.Buffer
+		for _, q := range jpegQualities {
+			buf.Reset()
+			if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: q}); err != nil {
+				return nil, "", fmt.Errorf("图片编码失败: %v", err)
+			}
+			if buf.Len() <= maxBytes {
+				return buf.Bytes(), "image/jpeg", nil
+			}
+		}
+		w, h = max(1, w/2), max(1, h/2)
+	}
+	return nil, "", fmt.Errorf("图片压缩后仍超过 %d 字节", maxBytes)
+}
+
+// resizeRGBA 按区域平均缩小图片，每个目标像素取对应源区域的平均颜色
+func resizeRGBA(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					bl += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j], dst.Pix[j+1], dst.Pix[j+2], dst.Pix[j+3] = uint8(r/n), uint8(g/n), uint8(bl/n), uint8(a/n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// 小于该尺寸的图片多为图标、印章或logo，不是扫描页面
+const minPageImageSide = 300
+
+// 页面图片的尺寸上限（600dpi 的 A3 约 7000×9900 像素），超过时不解码，避免压缩炸弹耗尽内存
+const (
+	maxPageImageSide   = 10000
+	maxPageImagePixels = 40_000_000
+)
+
+var (
+	pdfImageRegex  = regexp.MustCompile(`/Subtype\s*/Image`)
+	pdfObjRegex    = regexp.MustCompile(`\d+\s+\d+\s+obj`)
+	pdfIntRegex    = regexp.MustCompile(`/(Width|Height|BitsPerComponent|Length|Predictor|Colors)\s+(\d+)(\s+\d+\s+R)?`)
+	pdfFilterRegex = regexp.MustCompile(`/(FlateDecode|DCTDecode|LZWDecode|CCITTFaxDecode|JBIG2Decode|JPXDecode|RunLengthDecode)`)
+)
+
+// ExtractPDFPageImages 取出PDF中的整页图片，按出现顺序返回JPEG数据，最多 limit 张。
+//
+// 扫描版PDF每页通常就是一张整页图片，文本提取失败时用它代替页面渲染交给视觉模型识别。
+// 支持 DCTDecode（JPEG）和 8 位灰度/RGB 的 FlateDecode 图片，其他编码的图片跳过。
+func ExtractPDFPageImages(data []byte, limit int) ([][]byte, error) {
+	var pages [][]byte
+	for _, loc := range pdfImageRegex.FindAllIndex(data, -1) {
+		if len(pages) >= limit {
+			break
+		}
+		page, err := extractPDFImage(data, loc[0])
+		if err != nil || page == nil {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("PDF中没有可识别的页面图片")
+	}
+	return pages, nil
+}
+
+// pdfImageObject 定位 pos 所在图片对象的字典和数据流范围
+func pdfImageObject(data []byte, pos int) (dict []byte, start, end int, err error) {
+	objs := pdfObjRegex.FindAllIndex(data[:pos], -1)
+	if len(objs) == 0 {
+		return nil, 0, 0, fmt.Errorf("找不到图片对象")
+	}
+	streamAt := bytes.Index(data[pos:], []byte("stream"))
+	if streamAt < 0 {
+		return nil, 0, 0, fmt.Errorf("图片对象没有数据流")
+	}
+	streamAt += pos
+	dict = data[objs[len(objs)-1][1]:streamAt]
+
+	// 数据流从 stream 后的换行开始，长度未知时以 endstream 为界
+	start = streamAt + len("stream")
+	if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+		start += 2
+	} else if bytes.HasPrefix(data[start:], []byte("\n")) {
+		start++
+	}
+	if n, ok := pdfInts(dict)["Length"]; ok && start+n <= len(data) {
+		return dict, start, start + n, nil
+	}
+	if i := bytes.Index(data[start:], []byte("endstream")); i >= 0 {
+		return dict, start, start + len(bytes.TrimRight(data[start:start+i], "\r\n")), nil
+	}
+	return nil, 0, 0, fmt.Errorf("图片数据流不完整")
+}
+
+// pdfInts 读取字典中直接给出的整数值，间接引用的数值无法直接读取
+func pdfInts(dict []byte) map[string]int {
+	ints := map[string]int{}
+	for _, m := range pdfIntRegex.FindAllSubmatch(dict, -1) {
+		if len(m[3]) == 0 {
+			ints[string(m[1])], _ = strconv.Atoi(string(m[2]))
+		}
+	}
+	return ints
+}
+
+// StripPDFImages 去掉PDF中图片对象的数据流，避免按文本解析时把图片数据当作文字
+func StripPDFImages(data []byte) []byte {
+	var out []byte
+	last := 0
+	for _, loc := range pdfImageRegex.FindAllIndex(data, -1) {
+		if loc[0] < last {
+			continue
+		}
+		_, start, end, err := pdfImageObject(data, loc[0])
+		if err != nil {
+			continue
+		}
+		out = append(out, data[last:start]...)
+		last = end
+	}
+	return append(out, data[last:]...)
+}
+
+// extractPDFImage 解析 pos 所在的图片对象，图片太小时返回 nil
+func extractPDFImage(data []byte, pos int) ([]byte, error) {
+	dict, start, end, err := pdfImageObject(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	ints := pdfInts(dict)
+	w, h := ints["Width"], ints["Height"]
+	if min(w, h) < minPageImageSide {
+		return nil, nil
+	}
+	if max(w, h) > maxPageImageSide || w*h > maxPageImagePixels {
+		return nil, fmt.Errorf("图片尺寸过大: %dx%d", w, h)
+	}
+	body := data[start:end]
+
+	var filters []string
+	for _, m := range pdfFilterRegex.FindAllSubmatch(dict, -1) {
+		filters = append(filters, string(m[1]))
+	}
+	if len(filters) > 0 && filters[0] == "FlateDecode" {
+		r, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// 解压后最多为每像素3字节加每行1字节的预测器标记，多读1字节用于判断是否超出
+		limit := int64(w*h*3 + h)
+		if body, err = io.ReadAll(io.LimitReader(r, limit+1)); err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > limit {
+			return nil, fmt.Errorf("图片解压后的数据超出尺寸")
+		}
+		filters = filters[1:]
+		if len(filters) == 0 {
+			return encodeRawImage(body, w, h, ints)
+		}
+	}
+	if len(filters) == 1 && filters[0] == "DCTDecode" {
+		return body, nil
+	}
+	return nil, fmt.Errorf("不支持的图片编码: %v", filters)
+}
+
+// encodeRawImage 把解压后的像素数据编码为JPEG，支持PNG预测器
+func encodeRawImage(raw []byte, w, h int, ints map[string]int) ([]byte, error) {
+	if bpc, ok := ints["BitsPerComponent"]; ok && bpc != 8 {
+		return nil, fmt.Errorf("不支持 %d 位图片", bpc)
+	}
+	rowPrefix := 0
+	if ints["Predictor"] >= 10 {
+		rowPrefix = 1
+	}
+	if len(raw) < h || len(raw)%h != 0 {
+		return nil, fmt.Errorf("图片数据长度无效")
+	}
+	comps := (len(raw)/h - rowPrefix) / w
+	if comps != 1 && comps != 3 {
+		return nil, fmt.Errorf("不支持的颜色空间")
+	}
+	stride := w * comps
+	if rowPrefix == 1 {
+		raw = unpredictPNG(raw, stride, comps)
+	}
+
+	var img image.Image
+	if comps == 1 {
+		img = &image.Gray{Pix: raw[:stride*h], Stride: stride, Rect: image.Rect(0, 0, w, h)}
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+		for i, j := 0, 0; i < stride*h; i, j = i+3, j+4 {
+			rgba.Pix[j], rgba.Pix[j+1], rgba.Pix[j+2], rgba.Pix[j+3] = raw[i], raw[i+1], raw[i+2], 0xff
+		}
+		img = rgba
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unpredictPNG 还原PNG预测器处理过的行数据，返回去掉行首过滤类型后的像素
+func unpredictPNG(raw []byte, stride, bpp int) []byte {
+	rows := len(raw) / (stride + 1)
+	out := make([]byte, rows*stride)
+	prev := make([]byte, stride)
+	for y := 0; y < rows; y++ {
+		filter := raw[y*(stride+1)]
+		line := raw[y*(stride+1)+1 : (y+1)*(stride+1)]
+		cur := out[y*stride : (y+1)*stride]
+		for i := 0; i < stride; i++ {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = cur[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 1:
+				cur[i] = line[i] + left
+			case 2:
+				cur[i] = line[i] + up
+			case 3:
+				cur[i] = line[i] + byte((int(left)+int(up))/2)
+			case 4:
+				cur[i] = line[i] + paeth(left, up, upLeft)
+			default:
+				cur[i] = line[i]
+			}
+		}
+		prev = cur
+	}
+	return out
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-career-buddy/internal/api"
+	"ai-career-buddy/internal/logger"
+	"ai-career-buddy/internal/models"
+)
+
+// PlanGenerator 基于AI的目标拆解器
+type PlanGenerator struct{}
+
+// NewPlanGenerator 创建目标拆解器
+func NewPlanGenerator() *PlanGenerator {
+	return &PlanGenerator{}
+}
+
+// PlanInput 生成计划所需的上下文
+type PlanInput struct {
+	Goal    *models.Goal
+	Profile *models.UserProfile           // 可为空
+	Resume  *models.DocumentExtractedInfo // 可为空
+	Weeks   int
+	ModelID string
+}
+
+// GeneratedTask AI生成的单个任务
+type GeneratedTask struct {
+	Week        int    `json:"week"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	LinkedSkill string `json:"linkedSkill"`
+}
+
+// GeneratePlan 将目标拆解为按周排列的任务
+func (pg *PlanGenerator) GeneratePlan(input PlanInput) ([]GeneratedTask, error) {
+	if input.Goal == nil {
+		return nil, fmt.Errorf("目标不能为空")
+	}
+	if input.Weeks <= 0 {
+		input.Weeks = 4
+	}
+	modelID := input.ModelID
+	if modelID == "" {
+		modelID = "bailian/qwen-plus"
+	}
+
+	prompt := pg.buildPrompt(input)
+	response, err := api.ProviderFor(modelID).SendMessage(modelID, prompt, []string{})
+	if err != nil {
+		logger.Error("AI生成目标计划失败: GoalID=%d, 错误=%v", input.Goal.ID, err)
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("AI响应为空")
+	}
+
+	content := response.Choices[0].Message.Content
+	logger.Info("AI返回的目标计划内容: GoalID=%d, 长度=%d", input.Goal.ID, len(content))
+
+	var result struct {
+		Tasks []GeneratedTask `json:"tasks"`
+	}
+	cleanedContent := CleanJSONContent(content)
+	if err := json.Unmarshal([]byte(cleanedContent), &result); err != nil {
+		logger.Error("解析目标计划失败: %v, 内容: %s", err, cleanedContent)
+		return nil, fmt.Errorf("解析目标计划失败: %v", err)
+	}
+
+	// 过滤无效任务，并把周数限制在计划范围内
+	var tasks []GeneratedTask
+	for _, task := range result.Tasks {
+		task.Title = strings.TrimSpace(task.Title)
+		if task.Title == "" {
+			continue
+		}
+		if task.Week < 1 {
+			task.Week = 1
+		}
+		if task.Week > input.Weeks {
+			task.Week = input.Weeks
+		}
+		if task.LinkedSkill == "" {
+			task.LinkedSkill = input.Goal.LinkedSkill
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("AI未生成有效任务")
+	}
+
+	return tasks, nil
+}
+
+// buildPrompt 构建目标拆解提示词
+func (pg *PlanGenerator) buildPrompt(input PlanInput) string {
+	goal := input.Goal
+
+	var background strings.Builder
+	if input.Profile != nil {
+		p := input.Profile
+		background.WriteString(fmt.Sprintf("- 职业阶段: %s\n", valueOrUnknown(p.CareerStage)))
+		background.WriteString(fmt.Sprintf("- 行业: %s\n", valueOrUnknown(p.Industry)))
+		background.WriteString(fmt.Sprintf("- 当前职位: %s\n", valueOrUnknown(p.Position)))
+		background.WriteString(fmt.Sprintf("- 工作年限: %d年\n", p.Experience))
+	} else {
+		background.WriteString("- 用户未填写档案\n")
+	}
+
+	if input.Resume != nil {
+		r := input.Resume
+		if len(r.WorkExperience) > 0 {
+			background.WriteString("- 工作经历:\n")
+			for _, exp := range r.WorkExperience {
+				background.WriteString(fmt.Sprintf("  - %s @ %s (%s)\n", exp.Position, exp.Company, exp.Duration))
+			}
+		}
+		if len(r.Skills.Technical) > 0 {
+			background.WriteString(fmt.Sprintf("- 技术技能: %s\n", strings.Join(r.Skills.Technical, "、")))
+		}
+		if len(r.Skills.Soft) > 0 {
+			background.WriteString(fmt.Sprintf("- 软技能: %s\n", strings.Join(r.Skills.Soft, "、")))
+		}
+		if len(r.Skills.Certifications) > 0 {
+			background.WriteString(fmt.Sprintf("- 证书: %s\n", strings.Join(r.Skills.Certifications, "、")))
+		}
+	}
+
+	targetDate := "未设定"
+	if goal.TargetDate != nil {
+		targetDate = goal.TargetDate.Format("2006-01-02")
+	}
+
+	return fmt.Sprintf(`
+你是一位资深的职业发展教练，请把用户的职业目标拆解为按周执行的具体任务，并以JSON格式返回。
+
+用户背景：
+%s
+职业目标：
+- 标题: %s
+- 描述: %s
+- 关联技能: %s
+- 目标日期: %s
+- 计划周期: %d周
+
+拆解要求：
+1. 每周安排1-3个任务，任务需具体、可执行、可验证
+2. 任务要结合用户已有的技能和经历，避免重复学习已掌握的内容
+3. 越靠前的任务越偏向基础和准备，越靠后的任务越偏向实践和产出
+4. 每个任务写明完成后可以留存的证明材料（如项目链接、证书、笔记）
+
+请严格按照以下JSON格式返回，不要输出其他内容：
+{
+  "tasks": [
+    {
+      "week": 1,
+      "title": "任务标题",
+      "description": "任务说明及验收标准",
+      "linkedSkill": "关联技能"
+    }
+  ]
+}
+`, background.String(), goal.Title, valueOrUnknown(goal.Description), valueOrUnknown(goal.LinkedSkill), targetDate, input.Weeks)
+}
+
+// PlanWeeks 根据目标日期计算计划周数，限制在1-12周之间
+func PlanWeeks(targetDate *time.Time, now time.Time) int {
+	if targetDate == nil {
+		return 4
+	}
+	weeks := int(targetDate.Sub(now).Hours()/24/7 + 0.5)
+	if weeks < 1 {
+		return 1
+	}
+	if weeks > 12 {
+		return 12
+	}
+	return weeks
+}
+
+// valueOrUnknown 空值替换为"未提供"
+func valueOrUnknown(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "未提供"
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SalaryRange 解析后的薪资，金额单位为人民币元
+type SalaryRange struct {
+	MonthlyMin float64 `json:"monthlyMin"`
+	MonthlyMax float64 `json:"monthlyMax"`
+	Months     int     `json:"months"` // 每年发放月数，如 13、16 薪；未写明按12
+	AnnualMin  float64 `json:"annualMin"`
+	AnnualMax  float64 `json:"annualMax"`
+}
+
+// MonthlyMid 月薪区间中值
+func (s *SalaryRange) MonthlyMid() float64 {
+	return (s.MonthlyMin + s.MonthlyMax) / 2
+}
+
+// AnnualMid 年薪区间中值
+func (s *SalaryRange) AnnualMid() float64 {
+	return (s.AnnualMin + s.AnnualMax) / 2
+}
+
+var (
+	salaryMonthsPattern = regexp.MustCompile(`(?:[*xX×·]\s*(\d{2})\s*薪?)|(?:(\d{2})\s*薪)`)
+	salaryRangePattern  = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([kKwW万千]?)\s*[-~～至到]\s*(\d+(?:\.\d+)?)\s*([kKwW万千]?)`)
+	salaryNumberPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([kKwW万千]?)`)
+	salaryAnnualPattern = regexp.MustCompile(`年薪|年包|/年|每年|per\s*year|annual|/y`)
+)
+
+// 月薪合理范围，超出时认为解析有误
+const (
+	minMonthlySalary = 1000
+	maxMonthlySalary = 1000000
+)
+
+// ParseSalary 解析Offer或合同中的薪资描述
+//
+// 支持的写法如"25k-35k"、"25-35K·16薪"、"月薪3万"、"年薪50万"、"30,000元/月"、
+// "40w/年"、"¥25000 * 14"。区间只写了一个单位时两端共用该单位；没有单位且
+// 数值小于1000时按千元理解。无法解析时返回 nil。
+func ParseSalary(text string) *SalaryRange {
+	s := strings.ReplaceAll(text, ",", "")
+	s = strings.ReplaceAll(s, "，", "")
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+
+	months := 12
+	if m := salaryMonthsPattern.FindStringSubmatch(s); m != nil {
+		v := m[1]
+		if v == "" {
+			v = m[2]
+		}
+		if n, err := strconv.Atoi(v); err == nil && n >= 12 && n <= 24 {
+			months = n
+		}
+		s = salaryMonthsPattern.ReplaceAllString(s, " ")
+	}
+	annual := salaryAnnualPattern.MatchString(strings.ToLower(s))
+
+	var low, high float64
+	if m := salaryRangePattern.FindStringSubmatch(s); m != nil {
+		lowUnit, highUnit := m[2], m[4]
+		if lowUnit == "" {
+			lowUnit = highUnit
+		}
+		if highUnit == "" {
+			highUnit = lowUnit
+		}
+		low = salaryAmount(m[1], lowUnit)
+		high = salaryAmount(m[3], highUnit)
+	} else if m := salaryNumberPattern.FindStringSubmatch(s); m != nil {
+		low = salaryAmount(m[1], m[2])
+		high = low
+	} else {
+		return nil
+	}
+	if low > high {
+		low, high = high, low
+	}
+
+	result := &SalaryRange{Months: months}
+	if annual {
+		result.AnnualMin, result.AnnualMax = low, high
+		result.MonthlyMin, result.MonthlyMax = low/float64(months), high/float64(months)
+	} else {
+		result.MonthlyMin, result.MonthlyMax = low, high
+		result.AnnualMin, result.AnnualMax = low*float64(months), high*float64(months)
+	}
+
+	if result.MonthlyMin < minMonthlySalary || result.MonthlyMax > maxMonthlySalary {
+		return nil
+	}
+	return result
+}
+
+func salaryAmount(number, unit string) float64 {
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "k", "K", "千":
+		return v * 1000
+	case "w", "W", "万":
+		return v * 10000
+	}
+	if v < 1000 {
+		return v * 1000
+	}
+	return v
+}
@@ -26,8 +26,8 @@ func (e *SimplePDFExtractor) ExtractTextFromBase64PDF(base64Data string) (string
 		return "", fmt.Errorf("failed to decode base64: %v", err)
 	}
 
-	// 转换为字符串进行文本提取
-	pdfContent := string(pdfData)
+	// 转换为字符串进行文本提取，图片数据不参与解析
+	pdfContent := string(StripPDFImages(pdfData))
 
 	// 提取文本
 	text := e.extractTextFromPDF(pdfContent)
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenize 切分检索词：英文和数字按单词切分，中文按相邻二元组切分
+//
+// 不依赖分词词典，"竞业限制补偿"会切成 竞业/业限/限制/制补/补偿，
+// 单个汉字构成的片段保留原字。
+func Tokenize(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	var han []rune
+
+	flushWord := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	flushHan := func() {
+		switch {
+		case len(han) == 1:
+			tokens = append(tokens, string(han))
+		case len(han) > 1:
+			for i := 0; i+1 < len(han); i++ {
+				tokens = append(tokens, string(han[i:i+2]))
+			}
+		}
+		han = han[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word.WriteRune(r)
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return tokens
+}
@@ -0,0 +1,54 @@
+package websearch
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BuildPrompt 将检索结果整理为带引用编号的提示词片段
+func BuildPrompt(results []Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("【检索资料】以下是与用户问题相关的检索结果。引用时在句末用方括号标注编号，如[1]；" +
+		"只能引用这里列出的资料，不要编造其他来源、数据或链接：\n")
+	for _, r := range results {
+		sb.WriteString(fmt.Sprintf("\n[%d] %s", r.ID, r.Title))
+		if meta := sourceMeta(r); meta != "" {
+			sb.WriteString("（" + meta + "）")
+		}
+		sb.WriteString("\n")
+		if r.URL != "" {
+			sb.WriteString("链接: " + r.URL + "\n")
+		}
+		sb.WriteString("摘要: " + r.Snippet + "\n")
+	}
+	return sb.String()
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d{1,2})\]`)
+
+// CitedIDs 找出回复中以 [n] 形式引用的编号
+func CitedIDs(answer string) map[int]bool {
+	cited := map[int]bool{}
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		if id, err := strconv.Atoi(m[1]); err == nil {
+			cited[id] = true
+		}
+	}
+	return cited
+}
+
+func sourceMeta(r Result) string {
+	var parts []string
+	if r.Source != "" {
+		parts = append(parts, r.Source)
+	}
+	if r.PublishedAt != "" {
+		parts = append(parts, r.PublishedAt)
+	}
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,152 @@
+package websearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// HTTPProvider 调用外部搜索API的检索服务
+//
+// 以 GET {url}?q=查询&count=条数 请求，API Key 通过 Authorization: Bearer 传递。
+// 兼容两种常见的返回格式：
+//
+//	{"results": [{"title": "", "url": "", "snippet": "", "source": "", "publishedAt": ""}]}
+//	{"webPages": {"value": [{"name": "", "url": "", "snippet": "", "siteName": "", "datePublished": ""}]}}
+//
+// 后者也可以包在 data 字段中。
+type HTTPProvider struct {
+	apiURL string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPProvider 创建HTTP检索服务
+func NewHTTPProvider(apiURL, apiKey string, timeout time.Duration) (*HTTPProvider, error) {
+	if apiURL == "" {
+		return nil, errors.New("SEARCH_API_URL 未配置")
+	}
+	if _, err := url.ParseRequestURI(apiURL); err != nil {
+		return nil, fmt.Errorf("SEARCH_API_URL 无效: %v", err)
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &HTTPProvider{apiURL: apiURL, apiKey: apiKey, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (p *HTTPProvider) Name() string {
+	return "http"
+}
+
+type webPage struct {
+	Name          string `json:"name"`
+	URL           string `json:"url"`
+	Snippet       string `json:"snippet"`
+	Summary       string `json:"summary"`
+	SiteName      string `json:"siteName"`
+	DatePublished string `json:"datePublished"`
+}
+
+type webPages struct {
+	Value []webPage `json:"value"`
+}
+
+type httpSearchResponse struct {
+	Results []struct {
+		Title       string  `json:"title"`
+		URL         string  `json:"url"`
+		Snippet     string  `json:"snippet"`
+		Content     string  `json:"content"`
+		Source      string  `json:"source"`
+		PublishedAt string  `json:"publishedAt"`
+		Score       float64 `json:"score"`
+	} `json:"results"`
+	WebPages *webPages `json:"webPages"`
+	Data     *struct {
+		WebPages *webPages `json:"webPages"`
+	} `json:"data"`
+}
+
+func (p *HTTPProvider) Search(query string, limit int) ([]Result, error) {
+	u, _ := url.Parse(p.apiURL)
+	params := u.Query()
+	params.Set("q", query)
+	params.Set("count", strconv.Itoa(limit))
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求搜索API失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取搜索API响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("搜索API返回状态码 %d: %s", resp.StatusCode, truncateRunes(string(body), 200))
+	}
+
+	var parsed httpSearchResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("解析搜索API响应失败: %v", err)
+	}
+
+	var results []Result
+	for i, r := range parsed.Results {
+		snippet := r.Snippet
+		if snippet == "" {
+			snippet = r.Content
+		}
+		score := r.Score
+		if score == 0 {
+			score = 1 / float64(i+1) // 没有分数时保留API返回的顺序
+		}
+		results = append(results, Result{
+			Title:       r.Title,
+			URL:         r.URL,
+			Snippet:     truncateRunes(snippet, snippetRunes),
+			Source:      r.Source,
+			PublishedAt: r.PublishedAt,
+			Score:       score,
+		})
+	}
+
+	pages := parsed.WebPages
+	if pages == nil && parsed.Data != nil {
+		pages = parsed.Data.WebPages
+	}
+	if pages != nil {
+		for i, page := range pages.Value {
+			snippet := page.Summary
+			if snippet == "" {
+				snippet = page.Snippet
+			}
+			results = append(results, Result{
+				Title:       page.Name,
+				URL:         page.URL,
+				Snippet:     truncateRunes(snippet, snippetRunes),
+				Source:      page.SiteName,
+				PublishedAt: page.DatePublished,
+				Score:       1 / float64(i+1),
+			})
+		}
+	}
+	return results, nil
+}
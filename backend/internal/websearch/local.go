@@ -0,0 +1,326 @@
+package websearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"ai-career-buddy/internal/utils"
+)
+
+// BM25 参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	chunkRunes   = 300 // 每个段落块的目标长度
+	snippetRunes = 200
+
+	// 得分低于最高分该比例的结果视为弱相关，不返回
+	minRelativeScore = 0.3
+)
+
+// Article 本地资料库中的一篇文章
+type Article struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Source      string `json:"source"`
+	PublishedAt string `json:"publishedAt"`
+	Content     string `json:"content"`
+}
+
+type chunk struct {
+	article *Article
+	text    string
+	terms   map[string]int
+	length  int
+}
+
+// LocalProvider 基于本地资料库的检索服务，用于离线开发和测试
+//
+// 目录下每篇文章一个文件，支持 JSON（单篇对象或数组）和带头信息的 Markdown：
+//
+//	---
+//	title: 劳动合同法竞业限制条款解读
+//	url: https://example.com/non-compete
+//	source: 示例资料库
+//	publishedAt: 2025-03-01
+//	---
+//	正文……
+//
+// 文章按段落切块后建立 BM25 索引，标题参与每个块的打分。
+type LocalProvider struct {
+	chunks    []*chunk
+	docFreq   map[string]int
+	avgLength float64
+}
+
+// NewLocalProvider 加载资料库目录并建立索引
+func NewLocalProvider(dir string) (*LocalProvider, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取检索资料库目录失败: %v", err)
+	}
+
+	var articles []*Article
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		var loaded []*Article
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json":
+			loaded, err = loadJSONArticles(path)
+		case ".md", ".markdown", ".txt":
+			loaded, err = loadMarkdownArticle(path)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("加载检索资料 %s 失败: %v", entry.Name(), err)
+		}
+		articles = append(articles, loaded...)
+	}
+	return NewLocalProviderFromArticles(articles), nil
+}
+
+// NewLocalProviderFromArticles 基于给定文章建立索引
+func NewLocalProviderFromArticles(articles []*Article) *LocalProvider {
+	p := &LocalProvider{docFreq: map[string]int{}}
+	total := 0
+	for _, article := range articles {
+		titleTokens := utils.Tokenize(article.Title)
+		for _, text := range splitChunks(article.Content) {
+			c := &chunk{article: article, text: text, terms: map[string]int{}}
+			for _, tokens := range [][]string{titleTokens, utils.Tokenize(text)} {
+				for _, t := range tokens {
+					c.terms[t]++
+					c.length++
+				}
+			}
+			for t := range c.terms {
+				p.docFreq[t]++
+			}
+			total += c.length
+			p.chunks = append(p.chunks, c)
+		}
+	}
+	if len(p.chunks) > 0 {
+		p.avgLength = float64(total) / float64(len(p.chunks))
+	}
+	return p
+}
+
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Search 按 BM25 为段落块打分，每篇文章只取得分最高的块
+func (p *LocalProvider) Search(query string, limit int) ([]Result, error) {
+	queryTerms := map[string]bool{}
+	for _, t := range utils.Tokenize(query) {
+		queryTerms[t] = true
+	}
+	if len(queryTerms) == 0 || len(p.chunks) == 0 {
+		return nil, nil
+	}
+
+	n := float64(len(p.chunks))
+	best := map[*Article]*Result{}
+	for _, c := range p.chunks {
+		score := 0.0
+		for t := range queryTerms {
+			tf := float64(c.terms[t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(p.docFreq[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(c.length)/p.avgLength))
+		}
+		if score <= 0 {
+			continue
+		}
+		if r, ok := best[c.article]; ok && r.Score >= score {
+			continue
+		}
+		best[c.article] = &Result{
+			Title:       c.article.Title,
+			URL:         c.article.URL,
+			Snippet:     bestSnippet(c.text, queryTerms),
+			Source:      c.article.Source,
+			PublishedAt: c.article.PublishedAt,
+			Score:       math.Round(score*1000) / 1000,
+		}
+	}
+
+	top := 0.0
+	for _, r := range best {
+		top = max(top, r.Score)
+	}
+	results := make([]Result, 0, len(best))
+	for _, r := range best {
+		if r.Score >= top*minRelativeScore {
+			results = append(results, *r)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Title < results[j].Title
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func loadJSONArticles(path string) ([]*Article, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var articles []*Article
+		if err := json.Unmarshal(data, &articles); err != nil {
+			return nil, err
+		}
+		return articles, nil
+	}
+	var article Article
+	if err := json.Unmarshal(data, &article); err != nil {
+		return nil, err
+	}
+	return []*Article{&article}, nil
+}
+
+func loadMarkdownArticle(path string) ([]*Article, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	article := &Article{}
+	var body strings.Builder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	inHeader := false
+	for line := 0; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if line == 0 && strings.TrimSpace(text) == "---" {
+			inHeader = true
+			continue
+		}
+		if inHeader {
+			if strings.TrimSpace(text) == "---" {
+				inHeader = false
+				continue
+			}
+			if key, value, ok := strings.Cut(text, ":"); ok {
+				value = strings.TrimSpace(value)
+				switch strings.TrimSpace(key) {
+				case "title":
+					article.Title = value
+				case "url":
+					article.URL = value
+				case "source":
+					article.Source = value
+				case "publishedAt", "date":
+					article.PublishedAt = value
+				}
+			}
+			continue
+		}
+		// 没有头信息时用一级标题作为文章标题
+		if article.Title == "" && strings.HasPrefix(text, "# ") {
+			article.Title = strings.TrimSpace(strings.TrimPrefix(text, "# "))
+			continue
+		}
+		body.WriteString(text)
+		body.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if article.Title == "" {
+		article.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	article.Content = body.String()
+	return []*Article{article}, nil
+}
+
+// splitChunks 按空行切分段落，过短的相邻段落合并到约 chunkRunes 字
+func splitChunks(content string) []string {
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	for _, para := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		length := len([]rune(para))
+		if currentLen > 0 && currentLen+length > chunkRunes {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			currentLen = 0
+		}
+		if currentLen > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(para)
+		currentLen += length
+	}
+	if currentLen > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// bestSnippet 从命中查询词最多的句子开始截取摘要，而不是总取段落开头
+func bestSnippet(text string, queryTerms map[string]bool) string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '。' || r == '；' || r == '！' || r == '？' || r == '\n' {
+			sentences = append(sentences, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+
+	best, bestHits := 0, 0
+	for i, sentence := range sentences {
+		hits := 0
+		for _, t := range utils.Tokenize(sentence) {
+			if queryTerms[t] {
+				hits++
+			}
+		}
+		if hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+	return truncateRunes(strings.Join(sentences[best:], ""), snippetRunes)
+}
+
+func truncateRunes(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
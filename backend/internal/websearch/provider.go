@@ -0,0 +1,128 @@
+// Package websearch 为对话的联网搜索模式提供检索能力
+//
+// 检索结果会带上引用编号注入提示词，模型回答时用 [1]、[2] 标注来源，
+// 回复中同时返回结构化的来源列表，避免模型凭空编造数据出处。
+package websearch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-career-buddy/internal/config"
+	"ai-career-buddy/internal/logger"
+)
+
+// Result 一条检索结果
+type Result struct {
+	ID          int     `json:"id"` // 引用编号，从1开始
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Snippet     string  `json:"snippet"`
+	Source      string  `json:"source"` // 发布机构或站点
+	PublishedAt string  `json:"publishedAt,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// SearchProvider 检索服务
+type SearchProvider interface {
+	// Name 检索服务名称，用于日志
+	Name() string
+	// Search 返回与查询最相关的至多 limit 条结果
+	Search(query string, limit int) ([]Result, error)
+}
+
+// ProviderFactory 根据配置创建检索服务
+type ProviderFactory func() (SearchProvider, error)
+
+var providerFactories = map[string]ProviderFactory{}
+
+// RegisterProvider 注册检索服务，name 对应 SEARCH_PROVIDER 配置
+func RegisterProvider(name string, factory ProviderFactory) {
+	providerFactories[name] = factory
+}
+
+func init() {
+	RegisterProvider("local", func() (SearchProvider, error) {
+		return NewLocalProvider(config.C.SearchCorpusDir)
+	})
+	RegisterProvider("http", func() (SearchProvider, error) {
+		return NewHTTPProvider(config.C.SearchAPIURL, config.C.SearchAPIKey, config.C.SearchTimeout)
+	})
+}
+
+// NewProvider 按名称创建检索服务
+func NewProvider(name string) (SearchProvider, error) {
+	factory, ok := providerFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的检索服务: %s", name)
+	}
+	return factory()
+}
+
+var (
+	defaultProvider SearchProvider
+	defaultOnce     sync.Once
+)
+
+// Default 返回按配置创建的检索服务，未启用或创建失败时返回 nil
+func Default() SearchProvider {
+	defaultOnce.Do(func() {
+		name := strings.TrimSpace(config.C.SearchProvider)
+		if name == "" || name == "none" {
+			logger.Info("联网检索未启用")
+			return
+		}
+		provider, err := NewProvider(name)
+		if err != nil {
+			logger.Error("创建检索服务失败: Provider=%s, 错误=%v", name, err)
+			return
+		}
+		defaultProvider = provider
+		logger.Info("检索服务已启用: Provider=%s", provider.Name())
+	})
+	return defaultProvider
+}
+
+// Retrieve 使用默认检索服务检索，并按顺序分配引用编号
+func Retrieve(query string) []Result {
+	provider := Default()
+	if provider == nil || strings.TrimSpace(query) == "" {
+		return nil
+	}
+	return RetrieveWith(provider, query, config.C.SearchTopK)
+}
+
+// RetrieveWith 使用指定检索服务检索，去掉重复链接后分配引用编号
+func RetrieveWith(provider SearchProvider, query string, limit int) []Result {
+	if limit <= 0 {
+		limit = 5
+	}
+	results, err := provider.Search(query, limit)
+	if err != nil {
+		logger.Warn("检索失败: Provider=%s, 错误=%v", provider.Name(), err)
+		return nil
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	seen := map[string]bool{}
+	var out []Result
+	for _, r := range results {
+		key := r.URL
+		if key == "" {
+			key = r.Title
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r.ID = len(out) + 1
+		out = append(out, r)
+		if len(out) >= limit {
+			break
+		}
+	}
+	logger.Info("检索完成: Provider=%s, 结果数=%d", provider.Name(), len(out))
+	return out
+}
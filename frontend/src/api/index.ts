@@ -43,30 +43,60 @@ http.interceptors.response.use(
   }
 );
 
-export type Message = { id?: number; role: string; content: string; threadId?: string; createdAt?: string; attachments?: string };
-export type Note = { id?: number; title: string; content: string; updatedAt?: string };
+export type MessageSource = { id: number; title: string; url?: string; snippet: string; source?: string; publishedAt?: string; cited: boolean };
+export type Message = { id?: number; role: string; content: string; threadId?: string; createdAt?: string; attachments?: string; sources?: MessageSource[]; promptVersion?: string; isBookmarked?: boolean; bookmarkedAt?: string; parentId?: number; siblingIds?: number[] };
+export type MessageNode = Message & { children: MessageNode[] };
+export type MessageFeedback = { id?: number; messageId: number; thumbs?: 'up' | 'down' | ''; rating?: number; reasons?: string[]; comment?: string; updatedAt?: string };
+export type Bookmark = { message: Message; question: string; feedback?: MessageFeedback };
+export type ThreadSummary = { threadId: string; summary: string; coveredMessageId: number; coveredTurns: number; modelId?: string; updatedAt?: string };
+export type Intent = { category: string; subIntent?: string; confidence: number; source: 'llm' | 'bayes' | 'thread' };
+export type TagCount = { tag: string; count: number };
+export type Note = { id?: number; userId?: string; title: string; content: string; updatedAt?: string };
 
 export const api = {
   health: () => http.get('/health').then(r => r.data),
-  sendMessage: (p: { userId: string; threadId?: string; content: string; attachments?: string[]; modelId?: string; deepThinking?: boolean; networkSearch?: boolean }) => http.post('/api/messages', p).then(r => r.data),
-  streamMessage: (p: { userId: string; threadId?: string; content: string; attachments?: string[]; modelId?: string; deepThinking?: boolean; networkSearch?: boolean }) => http.post('/api/messages/stream', p, { responseType: 'text' }),
+  sendMessage: (p: { userId: string; threadId?: string; content: string; attachments?: string[]; modelId?: string; deepThinking?: boolean; networkSearch?: boolean; parentId?: number }) => http.post('/api/messages', p).then(r => r.data),
+  streamMessage: (p: { userId: string; threadId?: string; content: string; attachments?: string[]; modelId?: string; deepThinking?: boolean; networkSearch?: boolean; parentId?: number }) => http.post('/api/messages/stream', p, { responseType: 'text' }),
   listMessages: (threadId?: string) => http.get('/api/messages', { params: { threadId } }).then(r => r.data as Message[]),
+  getBranch: (userId: string, threadId: string, leafId?: number) => http.get('/api/messages', { params: { userId, threadId, view: 'branch', leafId } }).then(r => r.data as Message[]),
+  getMessageTree: (userId: string, threadId: string) => http.get('/api/messages', { params: { userId, threadId, view: 'tree' } }).then(r => r.data as MessageNode[]),
+  regenerateMessage: (messageId: number, userId: string, p: { modelId?: string; deepThinking?: boolean; networkSearch?: boolean } = {}) =>
+    http.post(`/api/messages/${messageId}/regenerate`, { ...p, userId }).then(r => r.data as Message),
+  editMessage: (messageId: number, userId: string, p: { content: string; modelId?: string; deepThinking?: boolean; networkSearch?: boolean }) =>
+    http.post(`/api/messages/${messageId}/edit`, { ...p, userId }).then(r => r.data as Message[]),
+  classifyIntent: (content: string, threadId?: string) =>
+    http.post('/api/intent/classify', { content, threadId }).then(r => r.data as { intent: Intent; subIntentName: string }),
+  getThreadSummary: (userId: string, threadId: string, refresh = false) =>
+    http.get(`/api/users/${userId}/threads/${threadId}/summary`, { params: { refresh: refresh || undefined } }).then(r => r.data as { threadId: string; summary: ThreadSummary | null; pendingTurns: number }),
   extractPDFText: (base64Data: string) => http.post('/api/pdf/extract', { base64Data }).then(r => r.data),
   listNotes: () => http.get('/api/notes').then(r => r.data as Note[]),
   createNote: (n: Partial<Note>) => http.post('/api/notes', n).then(r => r.data as Note),
   updateNote: (id: number, n: Partial<Note>) => http.put(`/api/notes/${id}`, n).then(r => r.data as Note),
   deleteNote: (id: number) => http.delete(`/api/notes/${id}`).then(r => r.data),
-  getCareerHistory: (userId: string, category?: string) => http.get(`/api/users/${userId}/career-history`, { params: { category } }).then(r => r.data),
+  getCareerHistory: (userId: string, category?: string, tag?: string) => http.get(`/api/users/${userId}/career-history`, { params: { category, tag } }).then(r => r.data),
+  getUserTags: (userId: string, category?: string, limit?: number) =>
+    http.get(`/api/users/${userId}/tags`, { params: { category, limit } }).then(r => r.data.tags as TagCount[]),
+
+  // 回复反馈与收藏
+  submitFeedback: (messageId: number, f: { userId: string; thumbs?: 'up' | 'down'; rating?: number; reasons?: string[]; comment?: string }) =>
+    http.post(`/api/messages/${messageId}/feedback`, f).then(r => r.data as MessageFeedback),
+  getFeedback: (messageId: number, userId: string) => http.get(`/api/messages/${messageId}/feedback`, { params: { userId } }).then(r => r.data.feedback as MessageFeedback | null),
+  bookmarkMessage: (messageId: number, userId: string) => http.post(`/api/messages/${messageId}/bookmark`, null, { params: { userId } }).then(r => r.data as Message),
+  unbookmarkMessage: (messageId: number, userId: string) => http.delete(`/api/messages/${messageId}/bookmark`, { params: { userId } }).then(r => r.data as Message),
+  getBookmarks: (userId: string, page = 1, pageSize = 20) =>
+    http.get(`/api/users/${userId}/bookmarks`, { params: { page, pageSize } }).then(r => r.data as { total: number; page: number; pageSize: number; bookmarks: Bookmark[] }),
   
   // 用户模型偏好相关
   getUserDefaultModel: (userId: string) => http.get(`/api/users/${userId}/default-model`).then(r => r.data),
   updateUserDefaultModel: (userId: string, defaultModel: string) => http.put(`/api/users/${userId}/default-model`, { defaultModel }).then(r => r.data),
   
   // 文档管理相关
-  uploadDocument: (userId: string, file: File, documentType: string) => {
+  // versionOf 指定后作为该文档的新版本上传；内容完全相同的文件返回已有文档（duplicate: true）
+  uploadDocument: (userId: string, file: File, documentType: string, versionOf?: string) => {
     const formData = new FormData();
     formData.append('file', file);
     formData.append('documentType', documentType);
+    if (versionOf) formData.append('versionOf', versionOf);
     return http.post(`/api/users/${userId}/documents`, formData, {
       headers: { 'Content-Type': 'multipart/form-data' }
     }).then(r => r.data);
@@ -75,6 +105,52 @@ export const api = {
     http.get(`/api/users/${userId}/documents/${documentId}/extracted-info`).then(r => r.data),
   processDocument: (userId: string, documentId: string) => 
     http.post(`/api/users/${userId}/documents/${documentId}/process`).then(r => r.data),
+  // 对话中上传的图片和PDF保存在文档库，消息附件中以 document:ID 引用
+  documentFileUrl: (userId: string, documentId: string) => `/api/users/${userId}/documents/${documentId}/file`,
+  // 对比两份合同/Offer：按条款对齐的原文差异、字段变化，以及每项变化对员工是否有利
+  diffDocuments: (userId: string, oldDocumentId: number, newDocumentId: number, commentary = true) =>
+    http.post(`/api/users/${userId}/documents/diff`, { oldDocumentId, newDocumentId, commentary }).then(r => r.data),
+  // 文档的全部版本及相邻版本提取信息的变化；传 from/to 时只比较这两个版本
+  getDocumentVersions: (userId: string, documentId: string, params?: { from?: string; to?: string }) =>
+    http.get(`/api/users/${userId}/documents/${documentId}/versions`, { params }).then(r => r.data),
+  // 带有效期的签名下载链接，可直接用于 <a href> 或分享
+  getDocumentDownloadUrl: (userId: string, documentId: string) =>
+    http.get(`/api/users/${userId}/documents/${documentId}/download-url`).then(r => r.data as { url: string; expiresAt: string }),
+  // 简历模板和默认章节顺序
+  getResumeTemplates: () => http.get('/api/resumes/templates').then(r => r.data),
+  // 按模板生成简历（PDF/HTML/Markdown），保存为新文档并返回下载链接；asNewVersion 时作为 documentId 的新版本
+  renderResume: (userId: string, payload: {
+    documentId?: number;
+    data?: Record<string, unknown>;
+    template?: string;
+    sections?: string[];
+    format?: 'pdf' | 'html' | 'markdown';
+    fileName?: string;
+    asNewVersion?: boolean;
+  }) => http.post(`/api/users/${userId}/resumes/render`, payload).then(r => r.data),
+  // 针对目标岗位生成简历优化建议：逐条要点改写、建议的章节顺序和关键词覆盖
+  tailorResume: (userId: string, documentId: number, payload: { targetPosition: string; jobDescription?: string; modelId?: string }) =>
+    http.post(`/api/users/${userId}/resumes/${documentId}/tailor`, payload).then(r => r.data),
+  getResumeTailoring: (userId: string, tailoringId: number) =>
+    http.get(`/api/users/${userId}/resume-tailorings/${tailoringId}`).then(r => r.data),
+  // 采纳（可修改改写内容）或拒绝一条建议，返回更新后的关键词覆盖
+  updateResumeSuggestion: (userId: string, tailoringId: number, suggestionId: number, payload: { status: 'pending' | 'accepted' | 'rejected'; rewrite?: string }) =>
+    http.put(`/api/users/${userId}/resume-tailorings/${tailoringId}/suggestions/${suggestionId}`, payload).then(r => r.data),
+  // 应用已采纳的建议，生成原简历的新版本
+  applyResumeTailoring: (userId: string, tailoringId: number, payload: { template?: string; format?: 'pdf' | 'html' | 'markdown'; fileName?: string; applySectionOrder?: boolean } = {}) =>
+    http.post(`/api/users/${userId}/resume-tailorings/${tailoringId}/apply`, payload).then(r => r.data),
+  // 文书草稿：还价邮件、接受/婉拒Offer、辞职信、求职信
+  getDraftOptions: () => http.get('/api/drafts/options').then(r => r.data),
+  generateDraft: (userId: string, payload: { kind: string; offerDocumentId?: number; tone?: string; length?: string; recipient?: string; recipientName?: string; company?: string; position?: string; targetSalary?: string; lastDay?: string; notes?: string; useModel?: boolean; modelId?: string }) =>
+    http.post(`/api/users/${userId}/drafts/generate`, payload).then(r => r.data),
+  getDrafts: (userId: string, kind?: string) =>
+    http.get(`/api/users/${userId}/drafts`, { params: { kind } }).then(r => r.data),
+  getDraft: (userId: string, draftId: number) => http.get(`/api/users/${userId}/drafts/${draftId}`).then(r => r.data),
+  updateDraft: (userId: string, draftId: number, payload: { title?: string; subject?: string; body?: string; recipient?: string; recipientName?: string }) =>
+    http.put(`/api/users/${userId}/drafts/${draftId}`, payload).then(r => r.data),
+  deleteDraft: (userId: string, draftId: number) => http.delete(`/api/users/${userId}/drafts/${draftId}`).then(r => r.data),
+  draftExportUrl: (userId: string, draftId: number, format: 'markdown' | 'eml' = 'markdown') =>
+    `/api/users/${userId}/drafts/${draftId}/export?format=${format}`,
 };
 
 
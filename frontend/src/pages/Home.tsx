@@ -67,6 +67,11 @@ const MODEL_CONFIGS: ModelConfig[] = [
   { id: 'bailian/deepseek-v3', name: 'DeepSeek V3 (百炼)', provider: '百炼', type: 'chat', description: '🧠 推理专家 | 外部API | 需注意数据安全', isPrivate: false },
   { id: 'bailian/deepseek-r1', name: 'DeepSeek R1 (百炼)', provider: '百炼', type: 'chat', description: '🎯 逻辑推理 | 外部API | 需注意数据安全', isPrivate: false },
   { id: 'bailian/deepseek-v3.1', name: 'DeepSeek V3.1 (百炼)', provider: '百炼', type: 'chat', description: '🚀 最新版本 | 外部API | 需注意数据安全', isPrivate: false },
+
+  // 离线模拟模型 - 回复由后端脚本决定，只在开发环境显示
+  ...(import.meta.env.DEV
+    ? [{ id: 'fake/default', name: '模拟模型', provider: '离线', type: 'chat' as const, description: '🧪 脚本回复 | 无需网络 | 仅供测试', isPrivate: true }]
+    : []),
 ];
 
 const TAB_CONFIGS = {
@@ -767,7 +772,7 @@ export default function Home() {
       console.log('发送消息到API', { threadId: session.id, content: userMessage.content });
       
       // 检查是否使用支持流式响应的模型
-      const isStreamModel = selectedModel.startsWith('bailian/') || selectedModel === 'nbg-v3-33b' || selectedModel.includes('deepseek') || selectedModel.includes('qwen');
+      const isStreamModel = selectedModel.startsWith('bailian/') || selectedModel.startsWith('fake/') || selectedModel === 'nbg-v3-33b' || selectedModel.includes('deepseek') || selectedModel.includes('qwen');
       
       if (isStreamModel) {
         // 创建AI回复消息占位符
@@ -1528,6 +1533,17 @@ export default function Home() {
                               </div>
                             );
                           }
+                          // 保存到文档库的附件（图片或PDF）
+                          if (attachment.startsWith('document:')) {
+                            const fileUrl = api.documentFileUrl(currentUserId, attachment.replace('document:', ''));
+                            return (
+                              <div key={index} className="attachment-image">
+                                <a href={fileUrl} target="_blank" rel="noreferrer">
+                                  <img src={fileUrl} alt={`附件 ${index + 1}`} onError={e => { e.currentTarget.replaceWith(`📎 附件 ${index + 1}`); }} />
+                                </a>
+                              </div>
+                            );
+                          }
                           return null;
                         })}
                       </div>